@@ -0,0 +1,191 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package controller runs reconcile loops over the objects of a type.
+//
+// A controller watches a type through an informer and queues the id of
+// every object that is added, updated, deleted or resynced. Workers take
+// the ids from the queue and reconcile them. An id is never reconciled
+// by two workers at once, and ids queued several times before they are
+// reconciled are reconciled once. Failed reconciles are retried with a
+// delay that doubles for every failure.
+package controller
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/shenrytech/shdb"
+)
+
+const (
+	defaultBaseDelay = 10 * time.Millisecond
+	defaultMaxDelay  = 5 * time.Minute
+)
+
+// Result tells the controller what to do after a successful reconcile.
+type Result struct {
+	// Requeue reconciles the object again after the rate limited delay.
+	Requeue bool
+	// RequeueAfter reconciles the object again after a fixed delay.
+	RequeueAfter time.Duration
+}
+
+// Reconciler brings the world in line with the object with id tid. The
+// object may have been deleted. Returning an error reconciles it again
+// after the rate limited delay.
+type Reconciler func(ctx context.Context, tid shdb.TypeId) (Result, error)
+
+// Options configures a Controller.
+type Options struct {
+	// Selector is a label selector for the objects to reconcile, see
+	// shdb.ParseSelector.
+	Selector string
+	// Workers is the number of objects reconciled in parallel. Defaults
+	// to one.
+	Workers int
+	// ResyncPeriod is how often all objects are reconciled even if they
+	// have not changed. Zero disables resyncs.
+	ResyncPeriod time.Duration
+	// BaseDelay is the delay before an object is reconciled again after
+	// the first failure. Defaults to 10ms.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay after repeated failures. Defaults to five
+	// minutes.
+	MaxDelay time.Duration
+	// GracePeriod is how long running reconciles may go on after Run is
+	// stopped before their context is cancelled.
+	GracePeriod time.Duration
+	// Indexes are field paths the informer cache is indexed on.
+	Indexes []string
+}
+
+// Controller reconciles the objects of one type in a source, which is
+// either an embedded shdb.DB or a shdb.Client.
+type Controller struct {
+	reconcile Reconciler
+	opts      Options
+	informer  *shdb.Informer
+	queue     *queue
+}
+
+// New returns a controller that calls reconcile for the objects of type
+// tk in src. Nothing happens until Run is called.
+func New(src shdb.Source, tk shdb.TypeKey, reconcile Reconciler, opts Options) (*Controller, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = defaultBaseDelay
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = defaultMaxDelay
+	}
+	informer, err := shdb.NewInformer(src, tk, shdb.InformerOptions{
+		Selector:     opts.Selector,
+		ResyncPeriod: opts.ResyncPeriod,
+		Indexes:      opts.Indexes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c := &Controller{
+		reconcile: reconcile,
+		opts:      opts,
+		informer:  informer,
+		queue:     newQueue(opts.BaseDelay, opts.MaxDelay),
+	}
+	enqueue := func(obj shdb.IObject) { c.queue.add(*shdb.GetTypeId(obj)) }
+	informer.AddHandler(shdb.InformerHandler{
+		OnAdd:    enqueue,
+		OnUpdate: func(old, obj shdb.IObject) { enqueue(obj) },
+		OnDelete: enqueue,
+	})
+	return c, nil
+}
+
+// Informer returns the informer of the controller, whose cache can be
+// read by the reconciler.
+func (c *Controller) Informer() *shdb.Informer {
+	return c.informer
+}
+
+// Enqueue queues an object to be reconciled, for example when an object
+// of another type it depends on has changed.
+func (c *Controller) Enqueue(tid shdb.TypeId) {
+	c.queue.add(tid)
+}
+
+// Run reconciles objects until ctx is done. Reconciling starts when the
+// informer has synced. When ctx is done no new reconciles are started,
+// and Run returns when the running ones have returned.
+func (c *Controller) Run(ctx context.Context) {
+	informerCtx, stopInformer := context.WithCancel(context.Background())
+	informerDone := make(chan struct{})
+	go func() {
+		defer close(informerDone)
+		c.informer.Run(informerCtx)
+	}()
+	defer func() {
+		stopInformer()
+		<-informerDone
+	}()
+
+	// Reconciles get their own context to finish during the grace period
+	workCtx, cancelWork := context.WithCancel(context.Background())
+	defer cancelWork()
+	wg := sync.WaitGroup{}
+	if c.informer.WaitForSync(ctx) == nil {
+		for k := 0; k < c.opts.Workers; k++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for c.processNext(workCtx) {
+				}
+			}()
+		}
+	}
+
+	<-ctx.Done()
+	c.queue.shutdown()
+	grace := time.AfterFunc(c.opts.GracePeriod, cancelWork)
+	defer grace.Stop()
+	wg.Wait()
+}
+
+// processNext reconciles the next object in the queue. It returns false
+// when the queue is shut down.
+func (c *Controller) processNext(ctx context.Context) bool {
+	tid, ok := c.queue.get()
+	if !ok {
+		return false
+	}
+	defer c.queue.done(tid)
+	res, err := c.reconcile(ctx, tid)
+	switch {
+	case err != nil:
+		log.Printf("reconcile of %s failed, err=[%v]\n", tid.String(), err)
+		c.queue.addRateLimited(tid)
+	case res.RequeueAfter > 0:
+		c.queue.forget(tid)
+		c.queue.addAfter(tid, res.RequeueAfter)
+	case res.Requeue:
+		c.queue.addRateLimited(tid)
+	default:
+		c.queue.forget(tid)
+	}
+	return true
+}
@@ -0,0 +1,160 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shenrytech/shdb"
+)
+
+var tObj = shdb.TypeKeyOf("shdb.v1.TObject")
+
+func recvTid(t *testing.T, ch chan shdb.TypeId) shdb.TypeId {
+	select {
+	case tid := <-ch:
+		return tid
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for reconcile")
+	}
+	return shdb.TypeId{}
+}
+
+func TestController(t *testing.T) {
+	db, err := shdb.OpenMemory()
+	if err != nil {
+		t.FailNow()
+	}
+	defer db.Close()
+	list := []*shdb.TObject{}
+	for k := 0; k < 3; k++ {
+		list = append(list, shdb.MustNew[*shdb.TObject](db, tObj))
+	}
+	if err := shdb.Put(db, list...); err != nil {
+		t.FailNow()
+	}
+	failing := list[0].GetMetadata().TypeId()
+
+	mux := sync.Mutex{}
+	count := map[shdb.TypeId]int{}
+	reconciled := make(chan shdb.TypeId, 100)
+	var c *Controller
+	c, err = New(db, tObj, func(ctx context.Context, tid shdb.TypeId) (Result, error) {
+		mux.Lock()
+		count[tid]++
+		n := count[tid]
+		mux.Unlock()
+		if tid == failing && n < 3 {
+			return Result{}, errors.New("try again")
+		}
+		if _, err := c.Informer().Get(tid); errors.Is(err, shdb.ErrNotFound) {
+			// Deleted
+			reconciled <- shdb.TypeId{}
+			return Result{}, nil
+		}
+		reconciled <- tid
+		return Result{}, nil
+	}, Options{Workers: 2, BaseDelay: time.Millisecond})
+	if err != nil {
+		t.FailNow()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Run(ctx)
+	}()
+
+	seen := map[shdb.TypeId]bool{}
+	for k := 0; k < 3; k++ {
+		seen[recvTid(t, reconciled)] = true
+	}
+	mux.Lock()
+	if len(seen) != 3 || count[failing] != 3 {
+		t.Fail()
+	}
+	mux.Unlock()
+
+	if _, err := shdb.Update(db, list[1].GetMetadata().TypeId(), func(obj *shdb.TObject) (*shdb.TObject, error) {
+		obj.MyString = "duck"
+		return obj, nil
+	}); err != nil {
+		t.FailNow()
+	}
+	if tid := recvTid(t, reconciled); tid != list[1].GetMetadata().TypeId() {
+		t.Fail()
+	}
+	if _, err := shdb.Delete[*shdb.TObject](db, list[2].GetMetadata().TypeId()); err != nil {
+		t.FailNow()
+	}
+	if tid := recvTid(t, reconciled); tid != (shdb.TypeId{}) {
+		t.Fail()
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("controller did not stop")
+	}
+}
+
+func TestControllerGracePeriod(t *testing.T) {
+	db, err := shdb.OpenMemory()
+	if err != nil {
+		t.FailNow()
+	}
+	defer db.Close()
+	if err := shdb.Put(db, shdb.MustNew[*shdb.TObject](db, tObj)); err != nil {
+		t.FailNow()
+	}
+	started := make(chan struct{})
+	stopped := make(chan error, 1)
+	c, err := New(db, tObj, func(ctx context.Context, tid shdb.TypeId) (Result, error) {
+		close(started)
+		<-ctx.Done()
+		stopped <- ctx.Err()
+		return Result{}, nil
+	}, Options{GracePeriod: 10 * time.Millisecond})
+	if err != nil {
+		t.FailNow()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Run(ctx)
+	}()
+	<-started
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("controller did not stop")
+	}
+	// Run waited for the reconcile
+	select {
+	case err := <-stopped:
+		if !errors.Is(err, context.Canceled) {
+			t.Fail()
+		}
+	default:
+		t.Fail()
+	}
+}
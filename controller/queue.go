@@ -0,0 +1,149 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shenrytech/shdb"
+)
+
+// queue is a work queue of object ids. An id is only queued once, and is
+// not handed out again while it is being processed. Ids added while they
+// are processed are queued again when done.
+type queue struct {
+	baseDelay time.Duration
+	maxDelay  time.Duration
+
+	mux        sync.Mutex
+	cond       *sync.Cond
+	items      []shdb.TypeId
+	dirty      map[shdb.TypeId]bool
+	processing map[shdb.TypeId]bool
+	failures   map[shdb.TypeId]int
+	shutDown   bool
+}
+
+func newQueue(baseDelay, maxDelay time.Duration) *queue {
+	q := &queue{
+		baseDelay:  baseDelay,
+		maxDelay:   maxDelay,
+		dirty:      map[shdb.TypeId]bool{},
+		processing: map[shdb.TypeId]bool{},
+		failures:   map[shdb.TypeId]int{},
+	}
+	q.cond = sync.NewCond(&q.mux)
+	return q
+}
+
+// add queues tid unless it is queued already.
+func (q *queue) add(tid shdb.TypeId) {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	if q.shutDown || q.dirty[tid] {
+		return
+	}
+	q.dirty[tid] = true
+	if !q.processing[tid] {
+		q.items = append(q.items, tid)
+		q.cond.Signal()
+	}
+}
+
+// addAfter queues tid after a delay.
+func (q *queue) addAfter(tid shdb.TypeId, delay time.Duration) {
+	if delay <= 0 {
+		q.add(tid)
+		return
+	}
+	time.AfterFunc(delay, func() { q.add(tid) })
+}
+
+// addRateLimited queues tid after a delay that doubles with every
+// failure since it was last forgotten.
+func (q *queue) addRateLimited(tid shdb.TypeId) {
+	q.mux.Lock()
+	n := q.failures[tid]
+	q.failures[tid] = n + 1
+	q.mux.Unlock()
+	delay := q.baseDelay
+	for ; n > 0 && delay < q.maxDelay; n-- {
+		delay *= 2
+	}
+	if delay > q.maxDelay {
+		delay = q.maxDelay
+	}
+	q.addAfter(tid, delay)
+}
+
+// forget resets the failures of tid.
+func (q *queue) forget(tid shdb.TypeId) {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	delete(q.failures, tid)
+}
+
+// requeues returns the number of failures of tid.
+func (q *queue) requeues(tid shdb.TypeId) int {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	return q.failures[tid]
+}
+
+// get waits for an id to process. It returns false once the queue is
+// shut down. The caller must call done when the id is processed.
+func (q *queue) get() (shdb.TypeId, bool) {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	for len(q.items) == 0 && !q.shutDown {
+		q.cond.Wait()
+	}
+	if q.shutDown {
+		return shdb.TypeId{}, false
+	}
+	tid := q.items[0]
+	q.items = q.items[1:]
+	delete(q.dirty, tid)
+	q.processing[tid] = true
+	return tid, true
+}
+
+// done marks tid as processed and queues it again if it was added while
+// processed.
+func (q *queue) done(tid shdb.TypeId) {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	delete(q.processing, tid)
+	if q.dirty[tid] {
+		q.items = append(q.items, tid)
+		q.cond.Signal()
+	}
+}
+
+// len returns the number of queued ids.
+func (q *queue) len() int {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	return len(q.items)
+}
+
+// shutdown stops handing out ids and drops new ones.
+func (q *queue) shutdown() {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	q.shutDown = true
+	q.cond.Broadcast()
+}
@@ -0,0 +1,81 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shenrytech/shdb"
+)
+
+func testTid() shdb.TypeId {
+	id := uuid.New()
+	return *shdb.NewTypeId(shdb.TypeKeyOf("shdb.v1.TObject"), id[:])
+}
+
+func TestQueue(t *testing.T) {
+	q := newQueue(time.Millisecond, time.Second)
+	a, b := testTid(), testTid()
+	q.add(a)
+	q.add(b)
+	q.add(a)
+	if q.len() != 2 {
+		t.FailNow()
+	}
+	if tid, ok := q.get(); !ok || tid != a {
+		t.FailNow()
+	}
+	// Not handed out again while processed
+	q.add(a)
+	if q.len() != 1 {
+		t.Fail()
+	}
+	if tid, ok := q.get(); !ok || tid != b {
+		t.FailNow()
+	}
+	q.done(b)
+	q.done(a)
+	if tid, ok := q.get(); !ok || tid != a {
+		t.FailNow()
+	}
+	q.done(a)
+
+	q.shutdown()
+	q.add(a)
+	if _, ok := q.get(); ok || q.len() != 0 {
+		t.Fail()
+	}
+}
+
+func TestQueueRateLimited(t *testing.T) {
+	q := newQueue(time.Millisecond, 4*time.Millisecond)
+	a := testTid()
+	for k := 0; k < 5; k++ {
+		q.addRateLimited(a)
+		if tid, ok := q.get(); !ok || tid != a {
+			t.FailNow()
+		}
+		q.done(a)
+	}
+	if q.requeues(a) != 5 {
+		t.Fail()
+	}
+	q.forget(a)
+	if q.requeues(a) != 0 {
+		t.Fail()
+	}
+}
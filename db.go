@@ -15,47 +15,230 @@
 package shdb
 
 import (
-	"errors"
-	"log"
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	"go.etcd.io/bbolt"
+	"github.com/google/uuid"
 )
 
 var (
-	bucket_obj    = []byte("obj")
-	bucket_schema = []byte("schema")
-	db            *bbolt.DB
-	typeRegistry  *TypeRegistry
+	bucket_obj       = []byte("obj")
+	bucket_schema    = []byte("schema")
+	bucket_meta      = []byte("meta")
+	bucket_index     = []byte("index")
+	bucket_history   = []byte("history")
+	bucket_ttl       = []byte("ttl")
+	bucket_expiry    = []byte("expiry")
+	bucket_deleted   = []byte("deleted")
+	bucket_refs      = []byte("refs")
+	bucket_types     = []byte("types")
+	bucket_renames   = []byte("renames")
+	bucket_changelog = []byte("changelog")
+	bucket_consumers = []byte("consumers")
+
+	// buckets are all buckets of a database
+	buckets = [][]byte{bucket_obj, bucket_schema, bucket_meta, bucket_index, bucket_history, bucket_ttl, bucket_expiry, bucket_deleted, bucket_refs, bucket_types, bucket_renames, bucket_changelog, bucket_consumers}
 )
 
-// Init initializes the backing database.
-func Init(dbFile string) {
-	var err error
-	db, err = bbolt.Open(dbFile, 0600, nil)
-	if err != nil {
-		panic(err)
-	}
-	db.Update(func(tx *bbolt.Tx) error {
-		tx.CreateBucketIfNotExists(bucket_obj)
-		tx.CreateBucketIfNotExists(bucket_schema)
-		return nil
-	})
-	typeRegistry = NewTypeRegistry()
-	err = typeRegistry.LoadSchema()
+// Options controls how a database is opened. The zero value is valid
+// and opens the database read-write with default settings. Timeout,
+// NoSync and FileMode only apply to file based storage.
+type Options struct {
+	// Timeout is the amount of time to wait for the file lock.
+	// When zero it waits indefinitely.
+	Timeout time.Duration
+	// ReadOnly opens the database in read-only mode.
+	ReadOnly bool
+	// NoSync skips fsync after each commit. Faster, but data may
+	// be lost if the machine crashes.
+	NoSync bool
+	// FileMode is used when the database file is created. Defaults to 0600.
+	FileMode os.FileMode
+	// ReapInterval is how often expired objects are deleted in the
+	// background. When zero there is no background reaper and Reap
+	// has to be called.
+	ReapInterval time.Duration
+	// ReapBatchSize is the maximum number of objects the reaper deletes
+	// in one transaction. Defaults to 1000.
+	ReapBatchSize int
+	// Migrations upgrade objects written with an older version of their
+	// type. See Migration.
+	Migrations []Migration
+	// MigrateEager migrates all objects when the version of a type has
+	// changed. Otherwise objects are migrated when they are read.
+	MigrateEager bool
+	// MigrateBatchSize is the maximum number of objects migrated in one
+	// transaction. Defaults to 1000.
+	MigrateBatchSize int
+	// Renames maps old to new fullnames of renamed types. Their objects
+	// are moved with RenameType when the database is opened.
+	Renames map[string]string
+	// ChangelogMaxAge and ChangelogMaxCount limit how long and how many
+	// changes are kept in the changelog. They are applied by the reaper.
+	// When zero changes are kept until TruncateChangelog is called.
+	ChangelogMaxAge   time.Duration
+	ChangelogMaxCount uint64
+}
+
+// DB is a handle to an open database. It carries its own backing store,
+// type registry, watch dispatcher and paging sessions, so several
+// databases can be open in the same process.
+type DB struct {
+	st  Storage
+	reg *TypeRegistry
+	// opts are the options the database was opened with.
+	opts Options
+
+	watchCmdCh chan watchCtrlReq
+	watchEvCh  chan *EventInfo
+	watchCtx   context.Context
+	watchStop  context.CancelFunc
+	commitMux  sync.Mutex
+	// watchQueues are the queues of the watchers by watcherId, so that
+	// RemoveWatcher and Close can close a queue the dispatcher is
+	// blocked on.
+	watchQueuesMux sync.Mutex
+	watchQueues    map[string]*watchQueue
+
+	reapStop context.CancelFunc
+	reapDone chan struct{}
+
+	changelogMaxAge   time.Duration
+	changelogMaxCount uint64
+
+	schemaVersion atomic.Uint32
+	schemaMux     sync.Mutex
+	schemaRegs    map[uint32]*TypeRegistry
+
+	streamsMux             sync.Mutex
+	activeStreams          map[uuid.UUID]*activeStream
+	activeSearchStreams    map[uuid.UUID]*activeSearchStream
+	activeSearchRefStreams map[uuid.UUID]*activeSearchRefStream
+}
+
+// Open opens the database stored in the bbolt file at path, creating it
+// if it does not exist.
+func Open(path string, opts Options) (*DB, error) {
+	st, err := OpenBoltStorage(path, opts)
 	if err != nil {
-		if errors.Is(err, ErrNotFound) {
-			err = typeRegistry.refresh()
-			log.Println("loaded schema from runtime")
-			if err != nil {
-				panic(err)
+		return nil, err
+	}
+	return OpenStorage(st, opts)
+}
+
+// OpenMemory opens a new empty database that is kept in memory.
+func OpenMemory() (*DB, error) {
+	return OpenStorage(NewMemoryStorage(), Options{})
+}
+
+// OpenStorage opens a database on top of st. The storage is closed
+// when the database is closed, or if opening fails.
+func OpenStorage(st Storage, opts Options) (*DB, error) {
+	if !opts.ReadOnly {
+		err := update(st, func(tx StorageTx) error {
+			for _, b := range buckets {
+				if err := tx.CreateBucketIfNotExists(b); err != nil {
+					return err
+				}
 			}
+			return nil
+		})
+		if err != nil {
+			st.Close()
+			return nil, err
 		}
-	} else {
-		log.Println("loaded schema from database")
 	}
+
+	reg, err := NewTypeRegistry()
+	if err != nil {
+		st.Close()
+		return nil, err
+	}
+	db := &DB{
+		st:                     st,
+		reg:                    reg,
+		opts:                   opts,
+		schemaRegs:             map[uint32]*TypeRegistry{},
+		changelogMaxAge:        opts.ChangelogMaxAge,
+		changelogMaxCount:      opts.ChangelogMaxCount,
+		activeStreams:          map[uuid.UUID]*activeStream{},
+		activeSearchStreams:    map[uuid.UUID]*activeSearchStream{},
+		activeSearchRefStreams: map[uuid.UUID]*activeSearchRefStream{},
+	}
+	for _, m := range opts.Migrations {
+		db.reg.addMigration(m)
+	}
+	db.startWatch()
+	if err := db.openSchema(opts); err != nil {
+		db.watchStop()
+		st.Close()
+		return nil, err
+	}
+	if !opts.ReadOnly && opts.ReapInterval > 0 {
+		db.startReaper(opts.ReapInterval, opts.ReapBatchSize)
+	}
+	return db, nil
+}
+
+// Init opens the database in dbFile with default options.
+func Init(dbFile string) (*DB, error) {
+	return Open(dbFile, Options{})
+}
+
+// Storage returns the storage backing the database.
+func (db *DB) Storage() Storage {
+	return db.st
+}
+
+// TypeRegistry returns the type registry used by the database.
+func (db *DB) TypeRegistry() *TypeRegistry {
+	return db.reg
+}
+
+// GetMessageInfo returns the MessageInfo of the type tk.
+func (db *DB) GetMessageInfo(tk TypeKey) (MessageInfo, error) {
+	return db.reg.GetMessageInfo(tk)
 }
 
-// Close the backing database
-func Close() error {
-	return db.Close()
+// Close the backing database. All watchers are removed and their
+// event channels are closed, and all paging sessions are ended.
+func (db *DB) Close() error {
+	db.stopReaper()
+	db.watchStop()
+	db.closeWatchQueues()
+	db.streamsMux.Lock()
+	for id, s := range db.activeStreams {
+		delete(db.activeStreams, id)
+		close(s.doneCh)
+	}
+	for id, s := range db.activeSearchStreams {
+		delete(db.activeSearchStreams, id)
+		close(s.doneCh)
+	}
+	for id, s := range db.activeSearchRefStreams {
+		delete(db.activeSearchRefStreams, id)
+		close(s.doneCh)
+	}
+	db.streamsMux.Unlock()
+	return db.st.Close()
+}
+
+// closeStream ends a paging session and releases its read transaction.
+// It is a no-op if the session has already been ended by Close.
+func (db *DB) closeStream(id uuid.UUID, doneCh chan struct{}) {
+	db.streamsMux.Lock()
+	defer db.streamsMux.Unlock()
+	_, a := db.activeStreams[id]
+	_, b := db.activeSearchStreams[id]
+	_, c := db.activeSearchRefStreams[id]
+	if !a && !b && !c {
+		return
+	}
+	delete(db.activeStreams, id)
+	delete(db.activeSearchStreams, id)
+	delete(db.activeSearchRefStreams, id)
+	close(doneCh)
 }
@@ -0,0 +1,146 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// BackupOptions selects what a backup contains. The zero value makes a
+// full uncompressed backup.
+type BackupOptions struct {
+	// Gzip compresses the backup.
+	Gzip bool
+	// SinceRevision makes an incremental backup of the changes made
+	// after the revision.
+	SinceRevision uint64
+	// Since makes an incremental backup of the changes made after the
+	// time. It is ignored if SinceRevision is set.
+	Since time.Time
+}
+
+func (o BackupOptions) incremental() bool {
+	return o.SinceRevision > 0 || !o.Since.IsZero()
+}
+
+// Backup writes a consistent snapshot of the database to w and returns
+// its header. A full backup is a bbolt database file that can be opened
+// with Open. An incremental backup is a stream of length-delimited
+// BackupRecords, starting with the header, with the changes made since
+// SinceRevision or Since as found in the changelog. It fails with
+// ErrChangelogTruncated if some of them have been truncated.
+func (db *DB) Backup(w io.Writer, opts BackupOptions) (*BackupHeader, error) {
+	var zw *gzip.Writer
+	if opts.Gzip {
+		zw = gzip.NewWriter(w)
+		w = zw
+	}
+	var header *BackupHeader
+	err := db.View(func(tx *Tx) error {
+		header = &BackupHeader{
+			Revision:      tx.Revision(),
+			CreatedAt:     timestamppb.Now(),
+			SchemaVersion: tx.currentSchemaVersion(),
+		}
+		if !opts.incremental() {
+			st, ok := tx.st.(Snapshotter)
+			if !ok {
+				return fmt.Errorf("%w: the storage cannot be backed up", ErrNotSupported)
+			}
+			_, err := st.WriteTo(w)
+			return err
+		}
+		header.SinceRevision = opts.SinceRevision
+		if !opts.Since.IsZero() {
+			header.Since = timestamppb.New(opts.Since)
+		}
+		if sv, err := tx.schemaVersion(header.SchemaVersion); err == nil {
+			header.Schema = sv.Schema
+		}
+		return tx.backupChanges(w, header, opts)
+	})
+	if err == nil && zw != nil {
+		err = zw.Close()
+	}
+	return header, err
+}
+
+// backupChanges writes the records of an incremental backup. The records
+// are read from the changelog, in the order the changes were made.
+func (tx *Tx) backupChanges(w io.Writer, header *BackupHeader, opts BackupOptions) error {
+	var from uint64
+	var err error
+	if opts.SinceRevision > 0 {
+		from, err = tx.seqAfterRevision(opts.SinceRevision)
+	} else {
+		from, err = tx.seqAfterTime(opts.Since)
+	}
+	if err != nil {
+		return err
+	}
+	write := func(rec *BackupRecord) error {
+		_, err := protodelim.MarshalTo(w, rec)
+		return err
+	}
+	if err := write(&BackupRecord{Record: &BackupRecord_Header{Header: header}}); err != nil {
+		return err
+	}
+
+	c := tx.st.Cursor(bucket_changelog, nil)
+	for k, v := c.Seek(encodeSeq(from)); k != nil; k, v = c.Next() {
+		change := &Change{}
+		if err := proto.Unmarshal(v, change); err != nil {
+			return err
+		}
+		rec := &BackupRecord{Time: change.Time}
+		switch {
+		case change.Item == nil:
+			continue
+		case change.Kind != EventDeleted:
+			rec.Record = &BackupRecord_Object{Object: change.Item}
+		case tx.isTombstone(change.Item.Key, change.Revision):
+			tomb := tx.st.Get(bucket_deleted, change.Item.Key)
+			rec.Record = &BackupRecord_Tombstone{Tombstone: &BinaryObject{Key: change.Item.Key, Value: tomb}}
+		default:
+			rec.Record = &BackupRecord_Deleted{Deleted: &BackupRecord_Deletion{
+				Key:       change.Item.Key,
+				DeletedAt: change.Time,
+				Revision:  change.Revision,
+			}}
+		}
+		if err := write(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isTombstone returns true if the soft deleted object key was deleted at
+// revision rev.
+func (tx *Tx) isTombstone(key []byte, rev uint64) bool {
+	data := tx.st.Get(bucket_deleted, key)
+	if data == nil {
+		return false
+	}
+	obj, err := tx.db.reg.decode(key, data)
+	return err == nil && obj.GetMetadata().Revision == rev
+}
@@ -0,0 +1,169 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protodelim"
+)
+
+func TestBackup(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "shdb_test")
+	if err != nil {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+
+	src, err := Open(path.Join(tmpDir, "src.db"), Options{})
+	if err != nil {
+		t.FailNow()
+	}
+	defer src.Close()
+	list := PutTestData(src, 10)
+
+	for k, db := range []*DB{src, CreateTestDb()} {
+		if k == 1 {
+			PutTestData(db, 10)
+			defer CloseTestDb(db)
+		}
+		buf := &bytes.Buffer{}
+		header, err := db.Backup(buf, BackupOptions{Gzip: true})
+		if err != nil || header.Revision != 10 {
+			t.FailNow()
+		}
+		zr, err := gzip.NewReader(buf)
+		if err != nil {
+			t.FailNow()
+		}
+		dbFile := path.Join(tmpDir, "backup.db")
+		f, err := os.Create(dbFile)
+		if err != nil {
+			t.FailNow()
+		}
+		if _, err := io.Copy(f, zr); err != nil {
+			t.FailNow()
+		}
+		f.Close()
+
+		restored, err := Open(dbFile, Options{})
+		if err != nil {
+			t.FailNow()
+		}
+		if res, _ := GetAll[*TObject](restored, TObj); len(res) != 10 {
+			t.Fail()
+		}
+		if rev, _ := restored.Revision(); rev != 10 {
+			t.Fail()
+		}
+		restored.Close()
+		os.Remove(dbFile)
+	}
+
+	// Incremental backups have the objects written since the revision
+	list[0].MyString = "changed"
+	if err := Put(src, list[0], MustNew[*TObject](src, TObj)); err != nil {
+		t.FailNow()
+	}
+	buf := &bytes.Buffer{}
+	header, err := src.Backup(buf, BackupOptions{SinceRevision: 10})
+	if err != nil || header.Revision != 12 || header.Schema == nil {
+		t.FailNow()
+	}
+	r := bufio.NewReader(buf)
+	objects := 0
+	for {
+		rec := &BackupRecord{}
+		if err := protodelim.UnmarshalFrom(r, rec); err == io.EOF {
+			break
+		} else if err != nil {
+			t.FailNow()
+		}
+		if rec.GetObject() != nil {
+			objects++
+		}
+	}
+	if objects != 2 {
+		t.Fail()
+	}
+}
+
+func TestBackupDeleted(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+	fd := testFileWithTypes([]string{"Soft", "Hist", "Plain"}, []*Shdb_Message_Options{
+		{SoftDelete: &Shdb_Soft_Delete_Options{}},
+		{History: &Shdb_History_Options{}},
+		{},
+	})
+	if err := db.TypeRegistry().AddFileFromProtoFileDescriptor(fd); err != nil {
+		t.FailNow()
+	}
+	soft := MustNew[IObject](db, TypeKeyOf("shdb.test.volatile.Soft"))
+	hist := MustNew[IObject](db, TypeKeyOf("shdb.test.volatile.Hist"))
+	hard := MustNew[IObject](db, TypeKeyOf("shdb.test.volatile.Plain"))
+	if err := Put(db, soft, hist, hard); err != nil {
+		t.FailNow()
+	}
+	rev, _ := db.Revision()
+	for _, obj := range []IObject{soft, hist, hard} {
+		if _, err := Delete[IObject](db, obj.GetMetadata().TypeId()); err != nil {
+			t.FailNow()
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if _, err := db.Backup(buf, BackupOptions{SinceRevision: rev}); err != nil {
+		t.FailNow()
+	}
+	r := bufio.NewReader(buf)
+	tombstones, deleted := 0, 0
+	for {
+		rec := &BackupRecord{}
+		if err := protodelim.UnmarshalFrom(r, rec); err == io.EOF {
+			break
+		} else if err != nil {
+			t.FailNow()
+		}
+		if rec.GetTombstone() != nil {
+			tombstones++
+		}
+		if rec.GetDeleted() != nil {
+			deleted++
+		}
+	}
+	if tombstones != 1 || deleted != 2 {
+		t.Fail()
+	}
+
+	// Deletes no longer in the changelog cannot be backed up
+	seq, _ := db.LastSeq()
+	if _, err := db.TruncateChangelog(seq); err != nil {
+		t.FailNow()
+	}
+	if _, err := db.Backup(&bytes.Buffer{}, BackupOptions{SinceRevision: rev}); !errors.Is(err, ErrChangelogTruncated) {
+		t.Fail()
+	}
+	if _, err := db.Backup(&bytes.Buffer{}, BackupOptions{SinceRevision: rev + 2}); err != nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,658 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Every committed change is appended to bucket_changelog, in the
+// transaction making it, as a Change keyed by its sequence number:
+//
+//	[b0 .. b7]		Sequence number
+//
+// Sequence numbers start at 1 and increase by one for every change. The
+// last one assigned is kept in bucket_meta under changelogSeqKey, and
+// the first one not truncated under changelogStartKey. bucket_consumers
+// maps the names of durable consumers to the sequence number of the
+// last change they have processed.
+
+var (
+	changelogSeqKey   = []byte("changelog_seq")
+	changelogStartKey = []byte("changelog_start")
+)
+
+// changelogBatchSize is the number of changes a subscription reads
+// from the changelog in one transaction.
+const changelogBatchSize = 1000
+
+func encodeSeq(seq uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, seq)
+}
+
+func (tx *Tx) metaSeq(key []byte) uint64 {
+	if data := tx.st.Get(bucket_meta, key); len(data) == 8 {
+		return binary.BigEndian.Uint64(data)
+	}
+	return 0
+}
+
+// LastSeq returns the sequence number of the last change in the
+// changelog as seen by the transaction.
+func (tx *Tx) LastSeq() uint64 {
+	return tx.metaSeq(changelogSeqKey)
+}
+
+// firstSeq returns the sequence number of the first change that has
+// not been truncated.
+func (tx *Tx) firstSeq() uint64 {
+	if seq := tx.metaSeq(changelogStartKey); seq > 0 {
+		return seq
+	}
+	return 1
+}
+
+// LastSeq returns the sequence number of the last change in the changelog.
+func (db *DB) LastSeq() (seq uint64, err error) {
+	err = db.View(func(tx *Tx) error {
+		seq = tx.LastSeq()
+		return nil
+	})
+	return
+}
+
+// ListSeq returns the objects of a type whose labels match the selector,
+// and the sequence number of the last change they include.
+func (db *DB) ListSeq(tk TypeKey, selector string) (objs []IObject, seq uint64, err error) {
+	sel, err := ParseSelector(selector)
+	if err != nil {
+		return nil, 0, err
+	}
+	err = db.View(func(tx *Tx) error {
+		seq = tx.LastSeq()
+		return tx.forEachSelected(tk, sel, func(obj IObject) error {
+			objs = append(objs, obj)
+			return nil
+		})
+	})
+	return
+}
+
+// logChanges assigns sequence numbers to the events of the transaction
+// and appends them to the changelog. It is called right before commit.
+func (tx *Tx) logChanges() error {
+	if len(tx.events) == 0 {
+		return nil
+	}
+	seq := tx.LastSeq()
+	now := time.Now()
+	for _, ev := range tx.events {
+		seq++
+		ev.Seq = seq
+		ev.Time = now
+		change, err := changeOf(ev)
+		if err != nil {
+			return err
+		}
+		data, err := proto.Marshal(change)
+		if err != nil {
+			return err
+		}
+		if err := tx.st.Put(bucket_changelog, encodeSeq(seq), data); err != nil {
+			return err
+		}
+	}
+	return tx.st.Put(bucket_meta, changelogSeqKey, encodeSeq(seq))
+}
+
+// changeOf returns the stored form of ev.
+func changeOf(ev *EventInfo) (*Change, error) {
+	change := &Change{Seq: ev.Seq, Revision: ev.Revision, Kind: int32(ev.Kind), Time: timestamppb.New(ev.Time)}
+	var err error
+	if change.Item, err = binaryObjectOf(ev.Object); err != nil {
+		return nil, err
+	}
+	if change.Previous, err = binaryObjectOf(ev.Previous); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// binaryObjectOf returns the stored form of obj, or nil if obj is nil.
+func binaryObjectOf(obj IObject) (*BinaryObject, error) {
+	if obj == nil {
+		return nil, nil
+	}
+	kvs, err := Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	return &BinaryObject{Key: kvs[0].Key(), Value: kvs[0].Value}, nil
+}
+
+// decodeChange returns the event of a Change.
+func decodeChange(reg *TypeRegistry, change *Change) (*EventInfo, error) {
+	obj, err := reg.Unmarshal(change.Item.GetKey(), change.Item.GetValue())
+	if err != nil {
+		return nil, err
+	}
+	ev := &EventInfo{
+		Kind:     int(change.Kind),
+		Tid:      obj.GetMetadata().TypeId(),
+		Object:   obj,
+		Seq:      change.Seq,
+		Revision: change.Revision,
+		Time:     change.Time.AsTime(),
+	}
+	if change.Previous != nil {
+		if ev.Previous, err = reg.Unmarshal(change.Previous.Key, change.Previous.Value); err != nil {
+			return nil, err
+		}
+	}
+	return ev, nil
+}
+
+// Changes calls fn for up to limit changes, starting with the change
+// with sequence number from, in sequence order. It fails with
+// ErrChangelogTruncated if from has been truncated.
+func (tx *Tx) Changes(from uint64, limit int, fn func(ev *EventInfo) error) error {
+	if from < tx.firstSeq() {
+		return ErrChangelogTruncated
+	}
+	c := tx.st.Cursor(bucket_changelog, nil)
+	n := 0
+	for k, v := c.Seek(encodeSeq(from)); k != nil && n < limit; k, v = c.Next() {
+		change := &Change{}
+		if err := proto.Unmarshal(v, change); err != nil {
+			return err
+		}
+		ev, err := decodeChange(tx.db.reg, change)
+		if err != nil {
+			return err
+		}
+		if err := fn(ev); err != nil {
+			return err
+		}
+		n++
+	}
+	return nil
+}
+
+// change returns the change with sequence number seq.
+func (tx *Tx) change(seq uint64) (*Change, error) {
+	data := tx.st.Get(bucket_changelog, encodeSeq(seq))
+	if data == nil {
+		return nil, ErrChangelogTruncated
+	}
+	change := &Change{}
+	if err := proto.Unmarshal(data, change); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// searchChangelog returns the sequence number of the first change in the
+// changelog for which after returns true, or the next sequence number if
+// there is none. after must be false for the changes before it and true
+// for the rest, as for their revisions and times.
+func (tx *Tx) searchChangelog(after func(change *Change) bool) (uint64, error) {
+	lo, hi := tx.firstSeq(), tx.LastSeq()+1
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		change, err := tx.change(mid)
+		if err != nil {
+			return 0, err
+		}
+		if after(change) {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo, nil
+}
+
+// seqAfterRevision returns the sequence number of the first change made
+// after revision rev of the database, or the next sequence number if
+// there is none yet. It fails with ErrChangelogTruncated if changes made
+// after rev have been truncated.
+func (tx *Tx) seqAfterRevision(rev uint64) (uint64, error) {
+	seq, err := tx.searchChangelog(func(change *Change) bool { return change.Revision > rev })
+	if err != nil {
+		return 0, err
+	}
+	if first := tx.firstSeq(); seq == first && first > 1 {
+		next := tx.Revision()
+		if seq <= tx.LastSeq() {
+			change, err := tx.change(seq)
+			if err != nil {
+				return 0, err
+			}
+			next = change.Revision - 1
+		}
+		if next > rev {
+			return 0, ErrChangelogTruncated
+		}
+	}
+	return seq, nil
+}
+
+// seqAfterTime returns the sequence number of the first change made
+// after t, or the next sequence number if there is none yet. It fails
+// with ErrChangelogTruncated if changes that may have been made after t
+// have been truncated.
+func (tx *Tx) seqAfterTime(t time.Time) (uint64, error) {
+	seq, err := tx.searchChangelog(func(change *Change) bool { return change.Time.AsTime().After(t) })
+	if err != nil {
+		return 0, err
+	}
+	if first := tx.firstSeq(); seq == first && first > 1 {
+		return 0, ErrChangelogTruncated
+	}
+	return seq, nil
+}
+
+// truncateChangelog removes up to limit changes from the start of the
+// changelog that have a sequence number below before or are older than
+// olderThan, unless it is zero. It returns the number of removed changes.
+func (tx *Tx) truncateChangelog(before uint64, olderThan time.Time, limit int) (int, error) {
+	keep := tx.consumedSeq()
+	keys := [][]byte{}
+	c := tx.st.Cursor(bucket_changelog, nil)
+	for k, v := c.First(); k != nil && len(keys) < limit; k, v = c.Next() {
+		if binary.BigEndian.Uint64(k) > keep {
+			break
+		}
+		if binary.BigEndian.Uint64(k) >= before {
+			if olderThan.IsZero() {
+				break
+			}
+			change := &Change{}
+			if err := proto.Unmarshal(v, change); err != nil {
+				return 0, err
+			}
+			if !change.Time.AsTime().Before(olderThan) {
+				break
+			}
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	start := binary.BigEndian.Uint64(keys[len(keys)-1]) + 1
+	for _, k := range keys {
+		if err := tx.st.Delete(bucket_changelog, k); err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), tx.st.Put(bucket_meta, changelogStartKey, encodeSeq(start))
+}
+
+// consumedSeq returns the lowest offset committed by a durable consumer,
+// or the largest sequence number if there are none. Later changes are
+// kept in the changelog until the consumers have processed them.
+func (tx *Tx) consumedSeq() uint64 {
+	seq := uint64(math.MaxUint64)
+	c := tx.st.Cursor(bucket_consumers, nil)
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if len(v) == 8 && binary.BigEndian.Uint64(v) < seq {
+			seq = binary.BigEndian.Uint64(v)
+		}
+	}
+	return seq
+}
+
+// reapChangelog truncates up to limit changes beyond the retention
+// limits of the changelog.
+func (tx *Tx) reapChangelog(now time.Time, limit int) (int, error) {
+	db := tx.db
+	if db.changelogMaxAge == 0 && db.changelogMaxCount == 0 {
+		return 0, nil
+	}
+	var before uint64
+	if last := tx.LastSeq(); db.changelogMaxCount > 0 && last > db.changelogMaxCount {
+		before = last - db.changelogMaxCount + 1
+	}
+	var olderThan time.Time
+	if db.changelogMaxAge > 0 {
+		olderThan = now.Add(-db.changelogMaxAge)
+	}
+	return tx.truncateChangelog(before, olderThan, limit)
+}
+
+// TruncateChangelog removes all changes with a sequence number below
+// before from the changelog, and returns the number of removed changes.
+// Changes after the offset of a durable consumer are kept, here and when
+// the changelog is reaped, until the consumer has committed them or is
+// deleted with DeleteConsumer.
+func (db *DB) TruncateChangelog(before uint64) (n int, err error) {
+	err = db.Txn(func(tx *Tx) error {
+		n, err = tx.truncateChangelog(before, time.Time{}, math.MaxInt)
+		return err
+	})
+	return
+}
+
+// ChangeFilter selects the changes of a subscription by the type or the
+// TypeId of the changed object, and by its labels and Expr before or
+// after the change. When Fields are given, updates are only selected if
+// one of those fields has changed. An empty filter selects all changes.
+type ChangeFilter struct {
+	Types    []TypeKey
+	TypeIds  []TypeId
+	Selector Selector
+	Expr     *Expr
+	Fields   []string
+}
+
+func (f ChangeFilter) matches(ev *EventInfo) bool {
+	if !f.Selector.Empty() && !f.Selector.MatchesObject(ev.Object) && !f.Selector.MatchesObject(ev.Previous) {
+		return false
+	}
+	if f.Expr != nil && !f.Expr.matches(ev.Object) && !f.Expr.matches(ev.Previous) {
+		return false
+	}
+	if len(f.Fields) > 0 && ev.Kind == EventUpdated && !fieldsChanged(ev.Previous, ev.Object, f.Fields) {
+		return false
+	}
+	if len(f.Types) == 0 && len(f.TypeIds) == 0 {
+		return true
+	}
+	if containsTypeKey(f.Types, ev.Tid.TypeKey()) {
+		return true
+	}
+	for _, tid := range f.TypeIds {
+		if tid == ev.Tid {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscription delivers the changes selected by a subscription on C, in
+// sequence order. C is closed when the subscription is closed, when the
+// database is closed or when the subscription fails, see Err.
+type Subscription struct {
+	C <-chan *EventInfo
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+// Close ends the subscription and returns the error it failed with,
+// if any.
+func (s *Subscription) Close() error {
+	s.cancel()
+	<-s.done
+	return s.err
+}
+
+// Err returns the error the subscription failed with once C is closed.
+func (s *Subscription) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// WatchOptions selects the changes sent by Watch. Without types and ids
+// all changes are sent.
+type WatchOptions struct {
+	Types   []TypeKey
+	TypeIds []TypeId
+	// Selector is a label selector, see ParseSelector.
+	Selector string
+	// Predicate is an expression the objects must match before or after
+	// the change, see ParseExpr.
+	Predicate string
+	// Fields are dotted field paths. When given, updates are only sent if
+	// one of them has changed.
+	Fields []string
+	// FromSeq is the sequence number in the changelog of the first change
+	// to send, as in EventInfo.Seq. It is not an object revision. When
+	// zero only new changes are sent, unless FromRevision is set.
+	FromSeq uint64
+	// FromRevision sends the changes made after this revision of the
+	// database, as in Metadata.Revision. Used when FromSeq is zero.
+	FromRevision uint64
+}
+
+// filter parses the selector and predicate of opts and checks them
+// against the types.
+func (opts WatchOptions) filter(reg *TypeRegistry) (ChangeFilter, error) {
+	filter := ChangeFilter{Types: opts.Types, TypeIds: opts.TypeIds, Fields: opts.Fields}
+	sel, err := ParseSelector(opts.Selector)
+	if err != nil {
+		return ChangeFilter{}, err
+	}
+	filter.Selector = sel
+	if opts.Predicate != "" {
+		if filter.Expr, err = ParseExpr(opts.Predicate); err != nil {
+			return ChangeFilter{}, err
+		}
+	}
+	for _, tk := range opts.Types {
+		mi, err := reg.GetMessageInfo(tk)
+		if err != nil {
+			return ChangeFilter{}, fmt.Errorf("type %s: %w", tk, err)
+		}
+		md := mi.MessageType.Descriptor()
+		if filter.Expr != nil {
+			if err := filter.Expr.Check(md); err != nil {
+				return ChangeFilter{}, err
+			}
+		}
+		if err := checkFieldPaths(md, opts.Fields); err != nil {
+			return ChangeFilter{}, err
+		}
+	}
+	return filter, nil
+}
+
+// Watch subscribes to the changes selected by opts, see Subscribe. The
+// subscription must be closed by the caller.
+func (db *DB) Watch(opts WatchOptions) (*Subscription, error) {
+	filter, err := opts.filter(db.reg)
+	if err != nil {
+		return nil, err
+	}
+	from, err := db.watchFrom(opts)
+	if err != nil {
+		return nil, err
+	}
+	return db.Subscribe(context.Background(), from, filter)
+}
+
+// watchFrom returns the sequence number of the first change to send to
+// a watch with opts.
+func (db *DB) watchFrom(opts WatchOptions) (from uint64, err error) {
+	if opts.FromSeq != 0 {
+		return opts.FromSeq, nil
+	}
+	err = db.View(func(tx *Tx) error {
+		if opts.FromRevision != 0 {
+			from, err = tx.seqAfterRevision(opts.FromRevision)
+			return err
+		}
+		from = tx.LastSeq() + 1
+		return nil
+	})
+	return from, err
+}
+
+// Subscribe replays the changes from the changelog, starting with the
+// change with sequence number fromSeq, and then delivers new changes as
+// they are committed. With fromSeq zero it starts with the oldest change
+// in the changelog. It fails with ErrChangelogTruncated if fromSeq has
+// been truncated, and with ErrInvalidSeq if it is after the next change.
+// The subscription ends when ctx is done or Close is called.
+func (db *DB) Subscribe(ctx context.Context, fromSeq uint64, filter ChangeFilter) (*Subscription, error) {
+	err := db.View(func(tx *Tx) error {
+		if fromSeq == 0 {
+			fromSeq = tx.firstSeq()
+		} else if fromSeq < tx.firstSeq() {
+			return ErrChangelogTruncated
+		} else if fromSeq > tx.LastSeq()+1 {
+			return fmt.Errorf("%w: %d is after the last change %d", ErrInvalidSeq, fromSeq, tx.LastSeq())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Watch before replaying, so no change is missed in between. Changes
+	// dropped from the queue are read from the changelog.
+	live := make(chan *EventInfo)
+	watcherId, err := db.watchAll(live, WatcherOptions{Overflow: OverflowDropOldest})
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan *EventInfo)
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Subscription{C: out, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		defer close(out)
+		defer db.RemoveWatcher(watcherId)
+		err := db.runSubscription(ctx, fromSeq, filter, live, out)
+		if ctx.Err() == nil {
+			s.err = err
+		}
+	}()
+	return s, nil
+}
+
+// runSubscription sends the changes from next onwards to out. Changes
+// are read from the changelog until it has been replayed, and then
+// taken from live. Changes missing from live, for instance while
+// replaying, are read from the changelog.
+func (db *DB) runSubscription(ctx context.Context, next uint64, filter ChangeFilter, live chan *EventInfo, out chan *EventInfo) error {
+	send := func(ev *EventInfo) error {
+		next = ev.Seq + 1
+		if !filter.matches(ev) {
+			return nil
+		}
+		select {
+		case out <- ev:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	replay := func(until uint64) error {
+		for next < until {
+			evs := []*EventInfo{}
+			err := db.View(func(tx *Tx) error {
+				return tx.Changes(next, changelogBatchSize, func(ev *EventInfo) error {
+					if ev.Seq < until {
+						evs = append(evs, ev)
+					}
+					return nil
+				})
+			})
+			if err != nil {
+				return err
+			}
+			if len(evs) == 0 {
+				return nil
+			}
+			for _, ev := range evs {
+				if err := send(ev); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := replay(^uint64(0)); err != nil {
+		return err
+	}
+	for {
+		select {
+		case ev, ok := <-live:
+			if !ok {
+				return nil
+			}
+			if ev.Kind == EventError {
+				return ev.Err
+			}
+			if ev.Seq < next {
+				continue
+			}
+			if err := replay(ev.Seq); err != nil {
+				return err
+			}
+			if err := send(ev); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// ConsumerOffset returns the sequence number of the last change
+// processed by a durable consumer, or zero for unknown consumers.
+func (db *DB) ConsumerOffset(consumer string) (seq uint64, err error) {
+	err = db.View(func(tx *Tx) error {
+		if data := tx.st.Get(bucket_consumers, []byte(consumer)); len(data) == 8 {
+			seq = binary.BigEndian.Uint64(data)
+		}
+		return nil
+	})
+	return
+}
+
+// CommitOffset records that a durable consumer has processed all
+// changes up to and including seq.
+func (db *DB) CommitOffset(consumer string, seq uint64) error {
+	return db.Txn(func(tx *Tx) error {
+		return tx.st.Put(bucket_consumers, []byte(consumer), encodeSeq(seq))
+	})
+}
+
+// DeleteConsumer forgets the offset of a durable consumer.
+func (db *DB) DeleteConsumer(consumer string) error {
+	return db.Txn(func(tx *Tx) error {
+		return tx.st.Delete(bucket_consumers, []byte(consumer))
+	})
+}
+
+// SubscribeConsumer subscribes a durable consumer from the change after
+// its committed offset, see CommitOffset. New consumers start with the
+// oldest change in the changelog.
+func (db *DB) SubscribeConsumer(ctx context.Context, consumer string, filter ChangeFilter) (*Subscription, error) {
+	offset, err := db.ConsumerOffset(consumer)
+	if err != nil {
+		return nil, err
+	}
+	from := uint64(0)
+	if offset > 0 {
+		from = offset + 1
+	}
+	return db.Subscribe(ctx, from, filter)
+}
@@ -0,0 +1,292 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func recvChange(t *testing.T, sub *Subscription) *EventInfo {
+	select {
+	case ev, ok := <-sub.C:
+		if !ok {
+			t.Fatalf("subscription closed, err=%v", sub.Err())
+		}
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for change")
+	}
+	return nil
+}
+
+func TestChangelog(t *testing.T) {
+	db, list := GenerateTestData(10)
+	defer RemoveTestData(db)
+	if _, err := Delete[*TObject](db, list[0].GetMetadata().TypeId()); err != nil {
+		t.FailNow()
+	}
+	if seq, err := db.LastSeq(); err != nil || seq != 11 {
+		t.FailNow()
+	}
+
+	sub, err := db.Subscribe(context.Background(), 0, ChangeFilter{Types: []TypeKey{TObj}})
+	if err != nil {
+		t.FailNow()
+	}
+	for k := 0; k < 10; k++ {
+		ev := recvChange(t, sub)
+		if ev.Seq != uint64(k+1) || ev.Kind != EventCreated || ev.Object.(*TObject).MyInt != uint64(k) {
+			t.Fail()
+		}
+	}
+	if ev := recvChange(t, sub); ev.Seq != 11 || ev.Kind != EventDeleted || ev.Tid != list[0].GetMetadata().TypeId() {
+		t.Fail()
+	}
+
+	// Switches to live changes once replayed
+	if _, err := Update(db, list[1].GetMetadata().TypeId(), func(obj *TObject) (*TObject, error) {
+		obj.MyString = "live"
+		return obj, nil
+	}); err != nil {
+		t.FailNow()
+	}
+	ev := recvChange(t, sub)
+	if ev.Seq != 12 || ev.Kind != EventUpdated || ev.Object.(*TObject).MyString != "live" || ev.Previous == nil {
+		t.Fail()
+	}
+	if err := sub.Close(); err != nil {
+		t.Fail()
+	}
+	if _, ok := <-sub.C; ok {
+		t.Fail()
+	}
+
+	// Replays from the middle
+	sub, err = db.Subscribe(context.Background(), 11, ChangeFilter{TypeIds: []TypeId{list[1].GetMetadata().TypeId()}})
+	if err != nil {
+		t.FailNow()
+	}
+	if ev := recvChange(t, sub); ev.Seq != 12 {
+		t.Fail()
+	}
+	sub.Close()
+}
+
+func TestChangelogTruncate(t *testing.T) {
+	db, _ := GenerateTestData(10)
+	defer RemoveTestData(db)
+	if n, err := db.TruncateChangelog(5); err != nil || n != 4 {
+		t.FailNow()
+	}
+	if _, err := db.Subscribe(context.Background(), 3, ChangeFilter{}); !errors.Is(err, ErrChangelogTruncated) {
+		t.Fail()
+	}
+	// Only the next change may follow the last one
+	if _, err := db.Subscribe(context.Background(), 12, ChangeFilter{}); !errors.Is(err, ErrInvalidSeq) {
+		t.Fail()
+	}
+	sub, err := db.Subscribe(context.Background(), 11, ChangeFilter{})
+	if err != nil {
+		t.FailNow()
+	}
+	sub.Close()
+	if sub, err = db.Subscribe(context.Background(), 0, ChangeFilter{}); err != nil {
+		t.FailNow()
+	}
+	if ev := recvChange(t, sub); ev.Seq != 5 {
+		t.Fail()
+	}
+	sub.Close()
+
+	db2, err := OpenStorage(NewMemoryStorage(), Options{ChangelogMaxCount: 3})
+	if err != nil {
+		t.FailNow()
+	}
+	defer db2.Close()
+	PutTestData(db2, 10)
+	if n, err := db2.Reap(0); err != nil || n != 7 {
+		t.Fail()
+	}
+	err = db2.View(func(tx *Tx) error {
+		if err := tx.Changes(7, 10, func(ev *EventInfo) error { return nil }); !errors.Is(err, ErrChangelogTruncated) {
+			t.Fail()
+		}
+		seqs := []uint64{}
+		err := tx.Changes(8, 10, func(ev *EventInfo) error {
+			seqs = append(seqs, ev.Seq)
+			return nil
+		})
+		if len(seqs) != 3 || seqs[0] != 8 {
+			t.Fail()
+		}
+		return err
+	})
+	if err != nil {
+		t.Fail()
+	}
+}
+
+func TestWatchFromRevision(t *testing.T) {
+	db, list := GenerateTestData(5)
+	defer RemoveTestData(db)
+	rev := list[2].GetMetadata().Revision
+	sub, err := db.Watch(WatchOptions{FromRevision: rev})
+	if err != nil {
+		t.FailNow()
+	}
+	if ev := recvChange(t, sub); ev.Revision != rev+1 || ev.Object.(*TObject).MyInt != 3 {
+		t.Fail()
+	}
+	sub.Close()
+
+	if n, err := db.TruncateChangelog(4); err != nil || n != 3 {
+		t.FailNow()
+	}
+	if _, err := db.Watch(WatchOptions{FromRevision: rev - 1}); !errors.Is(err, ErrChangelogTruncated) {
+		t.Fail()
+	}
+	if sub, err = db.Watch(WatchOptions{FromRevision: rev}); err != nil {
+		t.FailNow()
+	}
+	if ev := recvChange(t, sub); ev.Seq != 4 || ev.Revision != rev+1 {
+		t.Fail()
+	}
+	sub.Close()
+
+	// A revision not made yet waits for new changes
+	if sub, err = db.Watch(WatchOptions{FromRevision: rev + 10}); err != nil {
+		t.FailNow()
+	}
+	defer sub.Close()
+	if _, err := Update(db, list[0].GetMetadata().TypeId(), func(obj *TObject) (*TObject, error) {
+		obj.MyString = "live"
+		return obj, nil
+	}); err != nil {
+		t.FailNow()
+	}
+	if ev := recvChange(t, sub); ev.Seq != 6 || ev.Object.(*TObject).MyString != "live" {
+		t.Fail()
+	}
+}
+
+func TestSubscribeConsumer(t *testing.T) {
+	db, _ := GenerateTestData(5)
+	defer RemoveTestData(db)
+	sub, err := db.SubscribeConsumer(context.Background(), "c1", ChangeFilter{})
+	if err != nil {
+		t.FailNow()
+	}
+	for k := 0; k < 3; k++ {
+		ev := recvChange(t, sub)
+		if err := db.CommitOffset("c1", ev.Seq); err != nil {
+			t.Fail()
+		}
+	}
+	sub.Close()
+
+	// Picks up after the committed offset
+	sub, err = db.SubscribeConsumer(context.Background(), "c1", ChangeFilter{})
+	if err != nil {
+		t.FailNow()
+	}
+	if ev := recvChange(t, sub); ev.Seq != 4 {
+		t.Fail()
+	}
+	sub.Close()
+	if err := db.DeleteConsumer("c1"); err != nil {
+		t.Fail()
+	}
+	if seq, err := db.ConsumerOffset("c1"); err != nil || seq != 0 {
+		t.Fail()
+	}
+}
+
+func TestTruncateConsumed(t *testing.T) {
+	db, err := OpenStorage(NewMemoryStorage(), Options{ChangelogMaxCount: 2})
+	if err != nil {
+		t.FailNow()
+	}
+	defer db.Close()
+	PutTestData(db, 3)
+	if err := db.CommitOffset("c1", 1); err != nil {
+		t.FailNow()
+	}
+	PutTestData(db, 7)
+
+	// Only the changes committed by the consumer are truncated
+	if n, err := db.Reap(0); err != nil || n != 1 {
+		t.Fail()
+	}
+	if n, err := db.TruncateChangelog(10); err != nil || n != 0 {
+		t.Fail()
+	}
+	if err := db.CommitOffset("c1", 5); err != nil {
+		t.FailNow()
+	}
+	if n, err := db.TruncateChangelog(10); err != nil || n != 4 {
+		t.Fail()
+	}
+	sub, err := db.SubscribeConsumer(context.Background(), "c1", ChangeFilter{})
+	if err != nil {
+		t.FailNow()
+	}
+	if ev := recvChange(t, sub); ev.Seq != 6 {
+		t.Fail()
+	}
+	sub.Close()
+
+	if err := db.DeleteConsumer("c1"); err != nil {
+		t.FailNow()
+	}
+	if n, err := db.Reap(0); err != nil || n != 3 {
+		t.Fail()
+	}
+}
+
+func TestSubscribeSelector(t *testing.T) {
+	db := CreateTestDb()
+	defer RemoveTestData(db)
+	sel, err := ParseSelector("env=prod")
+	if err != nil {
+		t.FailNow()
+	}
+	sub, err := db.Subscribe(context.Background(), 0, ChangeFilter{Types: []TypeKey{TObj}, Selector: sel})
+	if err != nil {
+		t.FailNow()
+	}
+	defer sub.Close()
+	obj := MustNew[*TObject](db, TObj)
+	obj.Metadata.Labels = []string{"env=prod"}
+	other := MustNew[*TObject](db, TObj)
+	if err := Put(db, other, obj); err != nil {
+		t.FailNow()
+	}
+	// Leaving the selection is also a change of it
+	if _, err := Update(db, obj.GetMetadata().TypeId(), func(obj *TObject) (*TObject, error) {
+		obj.Metadata.Labels = nil
+		return obj, nil
+	}); err != nil {
+		t.FailNow()
+	}
+	if ev := recvChange(t, sub); ev.Seq != 2 || ev.Kind != EventCreated {
+		t.Fail()
+	}
+	if ev := recvChange(t, sub); ev.Seq != 3 || ev.Kind != EventUpdated {
+		t.Fail()
+	}
+}
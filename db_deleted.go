@@ -0,0 +1,206 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Objects of types with the soft_delete option are moved to
+// bucket_deleted when deleted, keyed by their TypeId and with
+// Metadata.DeletedAt set. Until they are purged they can be undeleted.
+
+// delete deletes obj, keeping a tombstone if its type has soft delete.
+// The on_delete options of the objects referencing obj are applied.
+func (tx *Tx) delete(obj IObject) error {
+	tid := obj.GetMetadata().TypeId()
+	refs, err := tx.deletableRefs(tid)
+	if err != nil {
+		return err
+	}
+	if err := tx.remove(obj); err != nil {
+		return err
+	}
+	if err := tx.tombstone(obj); err != nil {
+		return err
+	}
+	return tx.deleteRefs(tid, refs)
+}
+
+// tombstone keeps a copy of the deleted obj if its type has soft delete.
+// The tombstone has the revision of the delete.
+func (tx *Tx) tombstone(obj IObject) error {
+	tid := obj.GetMetadata().TypeId()
+	mi, err := tx.db.reg.GetMessageInfo(tid.TypeKey())
+	if err != nil || mi.SoftDelete == nil {
+		return nil
+	}
+	tomb := cloneObject(obj)
+	md := tomb.GetMetadata()
+	md.Revision = tx.Revision()
+	md.DeletedAt = timestamppb.Now()
+	if err := setMetadata(tomb, md); err != nil {
+		return err
+	}
+	kvs, err := Marshal(tomb)
+	if err != nil {
+		return err
+	}
+	return tx.st.Put(bucket_deleted, tid.Key(), kvs[0].Value)
+}
+
+// GetDeleted returns a soft deleted object.
+func GetDeleted[T IObject](h Handle, tid TypeId) (T, error) {
+	var t T
+	err := h.read(func(tx *Tx) error {
+		data := tx.st.Get(bucket_deleted, tid.Key())
+		if data == nil {
+			return ErrNotFound
+		}
+		obj, err := Unmarshal[T](tx.db, KeyVal{TypeId: tid, Value: data})
+		t = obj
+		return err
+	})
+	return t, err
+}
+
+// forEachDeleted calls fn for the soft deleted objects of a type whose
+// labels match sel.
+func (tx *Tx) forEachDeleted(typ TypeKey, sel Selector, fn func(obj IObject) error) error {
+	c := tx.st.Cursor(bucket_deleted, typ[:])
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		obj, err := tx.db.reg.Unmarshal(k, v)
+		if err != nil {
+			return err
+		}
+		if !sel.MatchesObject(obj) {
+			continue
+		}
+		if err := fn(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetAllDeleted returns all soft deleted objects of a specific type.
+func GetAllDeleted[T IObject](h Handle, typ TypeKey) ([]T, error) {
+	res := []T{}
+	err := h.read(func(tx *Tx) error {
+		return tx.forEachDeleted(typ, Selector{}, func(obj IObject) error {
+			t, ok := obj.(T)
+			if !ok {
+				return ErrInvalidType
+			}
+			res = append(res, t)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Undelete restores a soft deleted object. It fails with a *ConflictError
+// if an object with the same TypeId has been stored since.
+func Undelete[T IObject](h Handle, tid TypeId) (T, error) {
+	var t T
+	err := h.write(func(tx *Tx) error {
+		data := tx.st.Get(bucket_deleted, tid.Key())
+		if data == nil {
+			return ErrNotFound
+		}
+		obj, err := Unmarshal[T](tx.db, KeyVal{TypeId: tid, Value: data})
+		if err != nil {
+			return err
+		}
+		prev, err := tx.load(tid)
+		if err != nil {
+			return err
+		}
+		if prev != nil {
+			return &ConflictError{TypeId: tid, Actual: prev.GetMetadata().Revision}
+		}
+		md := obj.GetMetadata()
+		md.DeletedAt = nil
+		if err := setMetadata(obj, md); err != nil {
+			return err
+		}
+		if err := tx.st.Delete(bucket_deleted, tid.Key()); err != nil {
+			return err
+		}
+		t = obj
+		return tx.store(obj, nil)
+	})
+	return t, err
+}
+
+// Purge permanently removes a soft deleted object.
+func Purge(h Handle, tid TypeId) error {
+	return h.write(func(tx *Tx) error {
+		if tx.st.Get(bucket_deleted, tid.Key()) == nil {
+			return ErrNotFound
+		}
+		return tx.st.Delete(bucket_deleted, tid.Key())
+	})
+}
+
+// PurgeDeleted permanently removes the soft deleted objects of a type
+// that were deleted before the time before. A zero before purges all.
+// It returns the number of purged objects.
+func (tx *Tx) PurgeDeleted(tk TypeKey, before time.Time) (int, error) {
+	return tx.purgeDeleted(tk, before, -1)
+}
+
+// PurgeDeleted permanently removes the soft deleted objects of a type
+// that were deleted before the time before. A zero before purges all.
+func (db *DB) PurgeDeleted(tk TypeKey, before time.Time) (n int, err error) {
+	err = db.Txn(func(tx *Tx) error {
+		n, err = tx.PurgeDeleted(tk, before)
+		return err
+	})
+	return
+}
+
+// purgeDeleted purges at most limit tombstones, or all if limit is negative.
+func (tx *Tx) purgeDeleted(tk TypeKey, before time.Time, limit int) (int, error) {
+	if !tx.st.Writable() {
+		return 0, ErrTxReadOnly
+	}
+	keys := [][]byte{}
+	err := tx.forEachDeleted(tk, Selector{}, func(obj IObject) error {
+		if limit >= 0 && len(keys) >= limit {
+			return nil
+		}
+		md := obj.GetMetadata()
+		if before.IsZero() || md.DeletedAt.AsTime().Before(before) {
+			tid := md.TypeId()
+			keys = append(keys, tid.Key())
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, k := range keys {
+		if err := tx.st.Delete(bucket_deleted, k); err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), nil
+}
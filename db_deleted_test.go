@@ -0,0 +1,142 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestSoftDelete(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+	tk := addTestType(db, &Shdb_Message_Options{SoftDelete: &Shdb_Soft_Delete_Options{}})
+
+	obj := MustNew[IObject](db, tk)
+	tid := obj.GetMetadata().TypeId()
+	if err := Put(db, obj, MustNew[IObject](db, tk)); err != nil {
+		t.FailNow()
+	}
+	if _, err := Delete[IObject](db, tid); err != nil {
+		t.FailNow()
+	}
+	if _, err := Get[IObject](db, tid); !errors.Is(err, ErrNotFound) {
+		t.Fail()
+	}
+	deleted, err := GetDeleted[IObject](db, tid)
+	if err != nil || deleted.GetMetadata().DeletedAt == nil {
+		t.FailNow()
+	}
+
+	// Soft deleted objects are listed after the others
+	res, _, err := QueryWith(context.Background(), db, tk, QueryOptions{IncludeDeleted: true},
+		func(IObject) (bool, error) { return true, nil }, 100, "")
+	if err != nil || len(res) != 2 || res[1].GetMetadata().DeletedAt == nil {
+		t.Fail()
+	}
+	if res, _ := GetAll[IObject](db, tk); len(res) != 1 {
+		t.Fail()
+	}
+
+	restored, err := Undelete[IObject](db, tid)
+	if err != nil || restored.GetMetadata().DeletedAt != nil {
+		t.FailNow()
+	}
+	if _, err := Get[IObject](db, tid); err != nil {
+		t.Fail()
+	}
+	if _, err := GetDeleted[IObject](db, tid); !errors.Is(err, ErrNotFound) {
+		t.Fail()
+	}
+	if _, err := Undelete[IObject](db, tid); !errors.Is(err, ErrNotFound) {
+		t.Fail()
+	}
+}
+
+func TestSoftDeleteAll(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+	tk := addTestType(db, &Shdb_Message_Options{SoftDelete: &Shdb_Soft_Delete_Options{}})
+
+	for k := 0; k < 5; k++ {
+		if err := Put(db, MustNew[IObject](db, tk)); err != nil {
+			t.FailNow()
+		}
+	}
+	if err := db.DeleteAll(tk); err != nil {
+		t.FailNow()
+	}
+	deleted, err := GetAllDeleted[IObject](db, tk)
+	if err != nil || len(deleted) != 5 {
+		t.FailNow()
+	}
+
+	// An object stored again with the same id prevents the undelete
+	if err := Put(db, deleted[0]); err != nil {
+		t.FailNow()
+	}
+	if _, err := Undelete[IObject](db, deleted[0].GetMetadata().TypeId()); !errors.Is(err, ErrConflict) {
+		t.Fail()
+	}
+
+	if err := Purge(db, deleted[1].GetMetadata().TypeId()); err != nil {
+		t.Fail()
+	}
+	if n, err := db.PurgeDeleted(tk, time.Time{}); err != nil || n != 4 {
+		t.Fail()
+	}
+	if deleted, _ := GetAllDeleted[IObject](db, tk); len(deleted) != 0 {
+		t.Fail()
+	}
+}
+
+func TestSoftDeletePurgeAfter(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+	tk := addTestType(db, &Shdb_Message_Options{
+		SoftDelete: &Shdb_Soft_Delete_Options{PurgeAfter: durationpb.New(time.Millisecond)},
+	})
+
+	obj := MustNew[IObject](db, tk)
+	if err := Put(db, obj); err != nil {
+		t.FailNow()
+	}
+	if _, err := Delete[IObject](db, obj.GetMetadata().TypeId()); err != nil {
+		t.FailNow()
+	}
+	time.Sleep(5 * time.Millisecond)
+	if n, err := db.Reap(0); err != nil || n != 1 {
+		t.Fail()
+	}
+	if deleted, _ := GetAllDeleted[IObject](db, tk); len(deleted) != 0 {
+		t.Fail()
+	}
+}
+
+func TestHardDelete(t *testing.T) {
+	db, list := GenerateTestData(2)
+	defer RemoveTestData(db)
+
+	if _, err := Delete[*TObject](db, list[0].Metadata.TypeId()); err != nil {
+		t.FailNow()
+	}
+	if _, err := GetDeleted[*TObject](db, list[0].Metadata.TypeId()); !errors.Is(err, ErrNotFound) {
+		t.Fail()
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"log"
+	"time"
+)
+
+// Objects expire at Metadata.ExpiresAt, or when they are older than the
+// max_age of the retention rules of their type. Expiring objects are
+// tracked in two buckets. bucket_ttl maps the TypeId of an object to its
+// encoded expiry time, and bucket_expiry has keys with a memory layout
+// like:
+//
+//	[b0 .. b11]		Expiry time
+//	[b12 .. b31]	TypeId
+//
+// so the reaper finds expired objects in expiry order.
+
+// createdIndex is the index the reaper uses for types with a max_count.
+const createdIndex = "metadata.created_at"
+
+const defaultReapBatchSize = 1000
+
+func encodeTimestamp(t time.Time) []byte {
+	return binary.BigEndian.AppendUint32(encodeInt(t.Unix()), uint32(t.Nanosecond()))
+}
+
+func decodeTimestamp(data []byte) time.Time {
+	sec := int64(binary.BigEndian.Uint64(data) ^ (1 << 63))
+	return time.Unix(sec, int64(binary.BigEndian.Uint32(data[8:])))
+}
+
+// expiryOf returns when obj expires, or nil if it does not.
+func (tx *Tx) expiryOf(obj IObject) *time.Time {
+	md := obj.GetMetadata()
+	var exp *time.Time
+	if md.ExpiresAt != nil {
+		t := md.ExpiresAt.AsTime()
+		exp = &t
+	}
+	mi, err := tx.db.reg.GetMessageInfo(md.TypeId().TypeKey())
+	if err == nil && mi.Retention != nil && mi.Retention.MaxAge != nil && md.CreatedAt != nil {
+		t := md.CreatedAt.AsTime().Add(mi.Retention.MaxAge.AsDuration())
+		if exp == nil || t.Before(*exp) {
+			exp = &t
+		}
+	}
+	return exp
+}
+
+// updateExpiry replaces the expiry entries of prev with those of obj.
+// Either may be nil.
+func (tx *Tx) updateExpiry(obj, prev IObject) error {
+	if prev != nil {
+		tid := prev.GetMetadata().TypeId()
+		if old := tx.st.Get(bucket_ttl, tid.Key()); old != nil {
+			if err := tx.st.Delete(bucket_expiry, append(bytes.Clone(old), tid.Key()...)); err != nil {
+				return err
+			}
+			if err := tx.st.Delete(bucket_ttl, tid.Key()); err != nil {
+				return err
+			}
+		}
+	}
+	if obj == nil {
+		return nil
+	}
+	exp := tx.expiryOf(obj)
+	if exp == nil {
+		return nil
+	}
+	tid := obj.GetMetadata().TypeId()
+	data := encodeTimestamp(*exp)
+	if err := tx.st.Put(bucket_ttl, tid.Key(), data); err != nil {
+		return err
+	}
+	return tx.st.Put(bucket_expiry, append(bytes.Clone(data), tid.Key()...), []byte{})
+}
+
+// expired returns true if the object has expired but not yet been reaped.
+func (tx *Tx) expired(tid TypeId) bool {
+	data := tx.st.Get(bucket_ttl, tid.Key())
+	if len(data) != 12 {
+		return false
+	}
+	return !time.Now().Before(decodeTimestamp(data))
+}
+
+// reap deletes up to batchSize objects that have expired at now or that
+// exceed the max_count of their type, and purges soft deleted objects
+// past the purge_after of their type. Changes beyond the retention of
+// the changelog are truncated.
+func (tx *Tx) reap(batchSize int, now time.Time) (int, error) {
+	tids := []TypeId{}
+	limit := encodeTimestamp(now)
+	c := tx.st.Cursor(bucket_expiry, nil)
+	for k, _ := c.First(); k != nil && len(tids) < batchSize; k, _ = c.Next() {
+		if len(k) != 32 {
+			return 0, ErrDatabaseCorrupt
+		}
+		if bytes.Compare(k[:12], limit) > 0 {
+			break
+		}
+		tids = append(tids, *MarshalTypeId(k[12:]))
+	}
+	for _, mi := range tx.db.reg.messageInfos() {
+		if len(tids) >= batchSize {
+			break
+		}
+		if mi.Retention == nil || mi.Retention.MaxCount == 0 {
+			continue
+		}
+		// Oldest first
+		all, err := tx.scanIndex(mi.TypeKey, createdIndex, nil, nil, map[TypeId]bool{})
+		if err != nil {
+			return 0, err
+		}
+		for k := 0; k < len(all)-int(mi.Retention.MaxCount) && len(tids) < batchSize; k++ {
+			tids = append(tids, all[k])
+		}
+	}
+
+	n := 0
+	for _, mi := range tx.db.reg.messageInfos() {
+		if len(tids)+n >= batchSize {
+			break
+		}
+		if mi.SoftDelete == nil || mi.SoftDelete.PurgeAfter == nil {
+			continue
+		}
+		purged, err := tx.purgeDeleted(mi.TypeKey, now.Add(-mi.SoftDelete.PurgeAfter.AsDuration()), batchSize-len(tids)-n)
+		if err != nil {
+			return 0, err
+		}
+		n += purged
+	}
+	for _, tid := range tids {
+		kv, err := tx.rawKV(tid)
+		if errors.Is(err, ErrNotFound) {
+			// Both expired and exceeding the max count
+			continue
+		}
+		obj, err := tx.db.reg.Unmarshal(kv.Key(), kv.Value)
+		if err != nil {
+			return n, err
+		}
+		// A restricted object is left for a later reap rather than
+		// failing the batch.
+		if _, err := tx.deletableRefs(tid); errors.Is(err, ErrReferenced) {
+			log.Printf("not reaping %s, err=[%v]\n", tid.Uuid(), err)
+			continue
+		}
+		if err := tx.delete(obj); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if n < batchSize {
+		truncated, err := tx.reapChangelog(now, batchSize-n)
+		if err != nil {
+			return n, err
+		}
+		n += truncated
+	}
+	return n, nil
+}
+
+// Reap deletes up to batchSize objects that have expired or exceed the
+// max_count of their type, in one transaction. Watchers are notified and
+// the on_delete options of referrers are applied as for any other delete;
+// objects a referrer restricts are left in place. Soft deleted objects past the purge_after of
+// their type are purged, and changes beyond the retention of the
+// changelog are truncated. It returns the number of deleted and purged
+// objects and truncated changes.
+func (db *DB) Reap(batchSize int) (n int, err error) {
+	if batchSize <= 0 {
+		batchSize = defaultReapBatchSize
+	}
+	err = db.Txn(func(tx *Tx) error {
+		n, err = tx.reap(batchSize, time.Now())
+		return err
+	})
+	return
+}
+
+// startReaper runs Reap every interval until the database is closed.
+func (db *DB) startReaper(interval time.Duration, batchSize int) {
+	if batchSize <= 0 {
+		batchSize = defaultReapBatchSize
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	db.reapStop = cancel
+	db.reapDone = make(chan struct{})
+	go func() {
+		defer close(db.reapDone)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			// Keep going while there are full batches
+			for ctx.Err() == nil {
+				n, err := db.Reap(batchSize)
+				if err != nil {
+					log.Printf("reaper failed, err=[%v]\n", err)
+					break
+				}
+				if n < batchSize {
+					break
+				}
+			}
+		}
+	}()
+}
+
+// stopReaper stops the background reaper, if any, and waits for it.
+func (db *DB) stopReaper() {
+	if db.reapStop != nil {
+		db.reapStop()
+		<-db.reapDone
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestExpiry(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+
+	ch := make(chan *EventInfo, 10)
+	watchId, err := db.WatchType("", ch, TObj)
+	if err != nil {
+		t.FailNow()
+	}
+	defer db.RemoveWatcher(watchId)
+
+	list := PutTestData(db, 3)
+	_, err = Update(db, list[0].Metadata.TypeId(), func(obj *TObject) (*TObject, error) {
+		obj.Metadata.ExpiresAt = timestamppb.New(time.Now().Add(-time.Second))
+		return obj, nil
+	})
+	if err != nil {
+		t.FailNow()
+	}
+	tid := list[0].Metadata.TypeId()
+
+	// Expired objects are invisible before being reaped
+	if _, err := Get[*TObject](db, tid); !errors.Is(err, ErrNotFound) {
+		t.Fail()
+	}
+	if res, _ := GetAll[*TObject](db, TObj); len(res) != 2 {
+		t.Fail()
+	}
+	if res, _ := Lookup[*TObject](db, TObj, "my_int", 0); len(res) != 0 {
+		t.Fail()
+	}
+
+	n, err := db.Reap(0)
+	if err != nil || n != 1 {
+		t.FailNow()
+	}
+	if n, _ := db.Reap(0); n != 0 {
+		t.Fail()
+	}
+	for _, kind := range []int{EventCreated, EventCreated, EventCreated, EventUpdated, EventDeleted} {
+		if ev := <-ch; ev.Kind != kind {
+			t.Fail()
+		}
+	}
+}
+
+func TestExpiryOverwrite(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+
+	obj := MustNew[*TObject](db, TObj)
+	obj.Metadata.ExpiresAt = timestamppb.New(time.Now().Add(-time.Second))
+	obj.MyInt = 42
+	if err := Put(db, obj); err != nil {
+		t.FailNow()
+	}
+	// The expired object is replaced by a new one
+	obj.Metadata.ExpiresAt = nil
+	if err := Create(db, obj); err != nil {
+		t.FailNow()
+	}
+	if res, _ := Lookup[*TObject](db, TObj, "my_int", 42); len(res) != 1 {
+		t.Fail()
+	}
+	if n, _ := db.Reap(0); n != 0 {
+		t.Fail()
+	}
+}
+
+// addTestType registers a copy of TObject, TObj2, with other options.
+func addTestType(db *DB, shdbOpts *Shdb_Message_Options) TypeKey {
+	fd := CreateTestFileDescriptor()
+	opts := &descriptorpb.MessageOptions{}
+	proto.SetExtension(opts, E_ShdbOptions, shdbOpts)
+	fd.MessageType[0].Options = opts
+	if err := db.TypeRegistry().AddFileFromProtoFileDescriptor(fd); err != nil {
+		panic(err)
+	}
+	return TypeKeyOf("shdb.test.volatile.TObj2")
+}
+
+func TestRetentionMaxAge(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+	tk := addTestType(db, &Shdb_Message_Options{
+		Retention: &Shdb_Retention_Options{MaxAge: durationpb.New(time.Hour)},
+	})
+
+	old := MustNew[IObject](db, tk)
+	md := old.GetMetadata()
+	md.CreatedAt = timestamppb.New(time.Now().Add(-2 * time.Hour))
+	if err := setMetadata(old, md); err != nil {
+		t.FailNow()
+	}
+	young := MustNew[IObject](db, tk)
+	if err := Put(db, old, young); err != nil {
+		t.FailNow()
+	}
+	if res, _ := GetAll[IObject](db, tk); len(res) != 1 {
+		t.Fail()
+	}
+	if n, err := db.Reap(0); err != nil || n != 1 {
+		t.Fail()
+	}
+}
+
+func TestRetentionMaxCount(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+	tk := addTestType(db, &Shdb_Message_Options{
+		Retention: &Shdb_Retention_Options{MaxCount: 3},
+	})
+
+	tids := []TypeId{}
+	for k := 0; k < 5; k++ {
+		obj := MustNew[IObject](db, tk)
+		md := obj.GetMetadata()
+		md.CreatedAt = timestamppb.New(time.Unix(int64(1000+k), 0))
+		if err := setMetadata(obj, md); err != nil {
+			t.FailNow()
+		}
+		if err := Put(db, obj); err != nil {
+			t.FailNow()
+		}
+		tids = append(tids, md.TypeId())
+	}
+	// Batches are respected
+	if n, err := db.Reap(1); err != nil || n != 1 {
+		t.Fail()
+	}
+	if n, err := db.Reap(0); err != nil || n != 1 {
+		t.Fail()
+	}
+	for k, tid := range tids {
+		_, err := Get[IObject](db, tid)
+		if (k < 2) != errors.Is(err, ErrNotFound) {
+			t.Fail()
+		}
+	}
+}
+
+func TestReaper(t *testing.T) {
+	db, err := OpenStorage(NewMemoryStorage(), Options{ReapInterval: 5 * time.Millisecond})
+	if err != nil {
+		t.FailNow()
+	}
+	defer db.Close()
+
+	ch := make(chan *EventInfo, 10)
+	watchId, err := db.WatchType("", ch, TObj)
+	if err != nil {
+		t.FailNow()
+	}
+	defer db.RemoveWatcher(watchId)
+
+	obj := MustNew[*TObject](db, TObj)
+	obj.Metadata.ExpiresAt = timestamppb.New(time.Now().Add(20 * time.Millisecond))
+	if err := Put(db, obj); err != nil {
+		t.FailNow()
+	}
+	if ev := <-ch; ev.Kind != EventCreated {
+		t.Fail()
+	}
+	select {
+	case ev := <-ch:
+		if ev.Kind != EventDeleted || ev.Tid != obj.Metadata.TypeId() {
+			t.Fail()
+		}
+	case <-time.After(5 * time.Second):
+		t.Fail()
+	}
+}
+
+func TestReapRefs(t *testing.T) {
+	db, list := GenerateTestData(2)
+	defer RemoveTestData(db)
+
+	root := newTestNode(db, "root", list[0])
+	child := newTestNode(db, "child", list[0])
+	child.Parent = root.Metadata.Ref()
+	keeper := newTestNode(db, "keeper", list[1])
+	root.Metadata.ExpiresAt = timestamppb.New(time.Now().Add(-time.Second))
+	if err := Put(db, root, child, keeper); err != nil {
+		t.FailNow()
+	}
+	// The owner of keeper expires first but is restricted
+	_, err := Update(db, list[1].Metadata.TypeId(), func(obj *TObject) (*TObject, error) {
+		obj.Metadata.ExpiresAt = timestamppb.New(time.Now().Add(-time.Minute))
+		return obj, nil
+	})
+	if err != nil {
+		t.FailNow()
+	}
+
+	if n, err := db.Reap(0); err != nil || n != 1 {
+		t.FailNow()
+	}
+	if res, _ := GetAll[*TNode](db, TNodeKey); len(res) != 1 || res[0].Name != "keeper" {
+		t.Fail()
+	}
+	err = db.View(func(tx *Tx) error {
+		_, err := tx.rawKV(list[1].Metadata.TypeId())
+		return err
+	})
+	if err != nil {
+		t.Fail()
+	}
+	if n, err := db.Reap(0); err != nil || n != 0 {
+		t.Fail()
+	}
+}
+
+func TestReapSoftDelete(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+	tk := addTestType(db, &Shdb_Message_Options{SoftDelete: &Shdb_Soft_Delete_Options{}})
+
+	obj := MustNew[IObject](db, tk)
+	md := obj.GetMetadata()
+	md.ExpiresAt = timestamppb.New(time.Now().Add(-time.Second))
+	if err := setMetadata(obj, md); err != nil {
+		t.FailNow()
+	}
+	if err := Put(db, obj); err != nil {
+		t.FailNow()
+	}
+	if n, err := db.Reap(0); err != nil || n != 1 {
+		t.FailNow()
+	}
+	if deleted, err := GetDeleted[IObject](db, md.TypeId()); err != nil || deleted.GetMetadata().DeletedAt == nil {
+		t.Fail()
+	}
+}
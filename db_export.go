@@ -0,0 +1,346 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"sigs.k8s.io/yaml"
+)
+
+// An export has one record per object with the fullname of its type and
+// the object as protojson, one record per line for NDJSON and one per
+// document for YAML. UUIDs are written as text and the TypeKeys of
+// ObjRefs as type names, so an export can be imported into a database
+// where the types have other keys. Metadata.Type is left out.
+
+// ExportFormat is the encoding of an export.
+type ExportFormat string
+
+const (
+	NDJSON ExportFormat = "ndjson"
+	YAML   ExportFormat = "yaml"
+)
+
+var errDryRun = errors.New("dry run")
+
+// ExportOptions selects what Export writes.
+type ExportOptions struct {
+	// Format defaults to NDJSON.
+	Format ExportFormat
+	// Selector only exports objects whose labels match.
+	Selector Selector
+}
+
+// ImportOptions controls how Import reads an export.
+type ImportOptions struct {
+	// Format defaults to NDJSON.
+	Format ExportFormat
+	// DryRun checks the export without storing anything.
+	DryRun bool
+}
+
+type exportRecord struct {
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+const (
+	metadataName protoreflect.FullName = "shdb.v1.Metadata"
+	objRefName   protoreflect.FullName = "shdb.v1.ObjRef"
+)
+
+// Export writes the objects of the types typeKeys, or of all types, to w
+// in a single read transaction. Objects written with an older version of
+// their type are migrated. It returns the number of exported objects.
+func (db *DB) Export(w io.Writer, opts ExportOptions, typeKeys ...TypeKey) (int, error) {
+	if len(typeKeys) == 0 {
+		mis := db.reg.messageInfos()
+		sort.Slice(mis, func(i, j int) bool { return mis[i].Fullname < mis[j].Fullname })
+		for _, mi := range mis {
+			typeKeys = append(typeKeys, mi.TypeKey)
+		}
+	}
+	bw := bufio.NewWriter(w)
+	n := 0
+	err := db.View(func(tx *Tx) error {
+		for _, tk := range typeKeys {
+			mi, err := db.reg.GetMessageInfo(tk)
+			if err != nil {
+				return fmt.Errorf("%w: type key %x", err, tk)
+			}
+			err = tx.forEachSelected(tk, opts.Selector, func(obj IObject) error {
+				n++
+				return db.writeRecord(bw, opts.Format, mi, obj)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return n, err
+	}
+	return n, bw.Flush()
+}
+
+// writeRecord writes the export record of obj.
+func (db *DB) writeRecord(w io.Writer, format ExportFormat, mi MessageInfo, obj IObject) error {
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	m := map[string]any{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	convertRefs(obj.ProtoReflect().Descriptor(), m, func(name protoreflect.FullName, m map[string]any) {
+		if id, ok := m["uuid"].(string); ok {
+			if data, err := base64.StdEncoding.DecodeString(id); err == nil {
+				if u, err := uuid.FromBytes(data); err == nil {
+					m["uuid"] = u.String()
+				}
+			}
+		}
+		typ, ok := m["type"].(string)
+		if !ok {
+			return
+		}
+		if name == metadataName {
+			delete(m, "type")
+			return
+		}
+		if data, err := base64.StdEncoding.DecodeString(typ); err == nil && len(data) == 4 {
+			if mi, err := db.reg.GetMessageInfo(TypeKey(data)); err == nil {
+				m["type"] = mi.Fullname
+			}
+		}
+	})
+	object, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	data, err = json.Marshal(exportRecord{Type: mi.Fullname, Object: object})
+	if err != nil {
+		return err
+	}
+	if format == YAML {
+		if data, err = yaml.JSONToYAML(data); err != nil {
+			return err
+		}
+		data = append([]byte("---\n"), data...)
+	} else {
+		data = append(data, '\n')
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// convertRefs calls fn for the JSON object of every Metadata and ObjRef
+// in m, the JSON object of a message of type md.
+func convertRefs(md protoreflect.MessageDescriptor, m map[string]any, fn func(name protoreflect.FullName, m map[string]any)) {
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		v, ok := m[string(fd.Name())]
+		if !ok {
+			v, ok = m[fd.JSONName()]
+		}
+		if !ok {
+			continue
+		}
+		var items []any
+		sub := fd.Message()
+		switch {
+		case fd.IsMap():
+			sub = fd.MapValue().Message()
+			values, _ := v.(map[string]any)
+			for _, item := range values {
+				items = append(items, item)
+			}
+		case fd.IsList():
+			items, _ = v.([]any)
+		default:
+			items = []any{v}
+		}
+		if sub == nil {
+			continue
+		}
+		for _, item := range items {
+			im, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			if sub.FullName() == metadataName || sub.FullName() == objRefName {
+				fn(sub.FullName(), im)
+			} else {
+				convertRefs(sub, im, fn)
+			}
+		}
+	}
+}
+
+// Import stores the objects of an export made by Export in a single
+// transaction, replacing existing objects with the same TypeId. The
+// types are looked up by fullname or alias, and objects of an older
+// version of their type are migrated. References are checked once all
+// objects are stored. It returns the number of imported objects.
+func (db *DB) Import(r io.Reader, opts ImportOptions) (int, error) {
+	n := 0
+	err := db.Txn(func(tx *Tx) error {
+		objs := []IObject{}
+		err := readRecords(r, opts.Format, func(rec *exportRecord) error {
+			obj, err := db.readRecord(rec)
+			if err != nil {
+				return fmt.Errorf("record %d: %w", n+1, err)
+			}
+			prev, err := tx.load(obj.GetMetadata().TypeId())
+			if err != nil {
+				return err
+			}
+			if err := tx.storeUnchecked(obj, prev); err != nil {
+				return err
+			}
+			objs = append(objs, obj)
+			n++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, obj := range objs {
+			if err := tx.checkRefs(obj); err != nil {
+				return err
+			}
+		}
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		err = nil
+	}
+	return n, err
+}
+
+// readRecords calls fn for every record read from r.
+func readRecords(r io.Reader, format ExportFormat, fn func(rec *exportRecord) error) error {
+	if format != YAML {
+		dec := json.NewDecoder(r)
+		for {
+			rec := &exportRecord{}
+			if err := dec.Decode(rec); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if err := fn(rec); err != nil {
+				return err
+			}
+		}
+	}
+	doc := &bytes.Buffer{}
+	flush := func() error {
+		if len(bytes.TrimSpace(doc.Bytes())) == 0 {
+			return nil
+		}
+		data, err := yaml.YAMLToJSON(doc.Bytes())
+		doc.Reset()
+		if err != nil {
+			return err
+		}
+		rec := &exportRecord{}
+		if err := json.Unmarshal(data, rec); err != nil {
+			return err
+		}
+		return fn(rec)
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		if bytes.Equal(bytes.TrimRight(scanner.Bytes(), " \t\r"), []byte("---")) {
+			if err := flush(); err != nil {
+				return err
+			}
+			continue
+		}
+		doc.Write(scanner.Bytes())
+		doc.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// readRecord decodes the object of an export record.
+func (db *DB) readRecord(rec *exportRecord) (IObject, error) {
+	tk, err := db.reg.GetTypeKeyFromToA(rec.Type)
+	if err != nil {
+		return nil, fmt.Errorf("%w: type %s", err, rec.Type)
+	}
+	mi, err := db.reg.GetMessageInfo(tk)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]any{}
+	if err := json.Unmarshal(rec.Object, &m); err != nil {
+		return nil, err
+	}
+	convertRefs(mi.MessageType.Descriptor(), m, func(name protoreflect.FullName, m map[string]any) {
+		if id, ok := m["uuid"].(string); ok {
+			if u, err := uuid.Parse(id); err == nil {
+				m["uuid"] = base64.StdEncoding.EncodeToString(u[:])
+			}
+		}
+		if typ, ok := m["type"].(string); ok && name == objRefName {
+			if tk, err := db.reg.GetTypeKeyFromToA(typ); err == nil {
+				m["type"] = base64.StdEncoding.EncodeToString(tk[:])
+			}
+		}
+	})
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	obj := mi.newObject()
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, obj); err != nil {
+		return nil, err
+	}
+	md := obj.GetMetadata()
+	if md == nil {
+		md = &Metadata{}
+	}
+	md.Type = bytes.Clone(tk[:])
+	if err := md.Fill(); err != nil {
+		return nil, err
+	}
+	if err := setMetadata(obj, md); err != nil {
+		return nil, err
+	}
+	return obj, db.reg.migrate(obj)
+}
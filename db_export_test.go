@@ -0,0 +1,103 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestExportImport(t *testing.T) {
+	for _, format := range []ExportFormat{NDJSON, YAML} {
+		src, list := GenerateTestData(5)
+		list[0].Metadata.SetLabel("env", "prod")
+		node := newTestNode(src, "node", list[0])
+		if err := Put[IObject](src, list[0], node); err != nil {
+			t.FailNow()
+		}
+
+		buf := &bytes.Buffer{}
+		n, err := src.Export(buf, ExportOptions{Format: format}, TObj, TNodeKey)
+		if err != nil || n != 6 {
+			t.FailNow()
+		}
+		id, _ := list[0].Metadata.GetUuidAsString()
+		if !strings.Contains(buf.String(), id) || !strings.Contains(buf.String(), "shdb.v1.TObject") {
+			t.Fail()
+		}
+
+		db := CreateTestDb()
+		if n, err := db.Import(bytes.NewReader(buf.Bytes()), ImportOptions{Format: format, DryRun: true}); err != nil || n != 6 {
+			t.FailNow()
+		}
+		if res, _ := GetAll[*TObject](db, TObj); len(res) != 0 {
+			t.Fail()
+		}
+		if n, err := db.Import(buf, ImportOptions{Format: format}); err != nil || n != 6 {
+			t.FailNow()
+		}
+		obj, err := Get[*TObject](db, list[0].Metadata.TypeId())
+		if err != nil || obj.MyInt != list[0].MyInt || !proto.Equal(obj.Metadata.CreatedAt, list[0].Metadata.CreatedAt) {
+			t.Fail()
+		}
+		if imported, err := Get[*TNode](db, node.Metadata.TypeId()); err != nil || !proto.Equal(imported.Owner, node.Owner) {
+			t.Fail()
+		}
+
+		// Label filter
+		buf.Reset()
+		if n, err := src.Export(buf, ExportOptions{Format: format, Selector: MustParseSelector("env=prod")}); err != nil || n != 1 {
+			t.Fail()
+		}
+		RemoveTestData(src)
+		CloseTestDb(db)
+	}
+}
+
+func TestImportDynamic(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+	tk := addTestType(db, &Shdb_Message_Options{Indexes: []string{"my_string"}})
+	obj := MustNew[IObject](db, tk)
+	m := obj.ProtoReflect()
+	m.Set(m.Descriptor().Fields().ByName("my_string"), protoreflect.ValueOfString("dynamic"))
+	if err := Put(db, obj); err != nil {
+		t.FailNow()
+	}
+	buf := &bytes.Buffer{}
+	if n, err := db.Export(buf, ExportOptions{}, tk); err != nil || n != 1 {
+		t.FailNow()
+	}
+	if err := db.DeleteAll(tk); err != nil {
+		t.FailNow()
+	}
+	if n, err := db.Import(buf, ImportOptions{}); err != nil || n != 1 {
+		t.FailNow()
+	}
+	if res, err := Lookup[IObject](db, tk, "my_string", "dynamic"); err != nil || len(res) != 1 {
+		t.Fail()
+	}
+
+	// Dangling references fail the import
+	rec := `{"type":"tnode","object":{"metadata":{"uuid":"` + "0b6b7d64-6a0e-4c3e-9f53-8c1b5f2d7e11" + `"},"name":"x"}}`
+	if _, err := db.Import(strings.NewReader(rec), ImportOptions{}); !errors.Is(err, ErrDanglingRef) {
+		t.Fail()
+	}
+}
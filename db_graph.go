@@ -0,0 +1,172 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Edge is a reference from one object to another.
+type Edge struct {
+	From TypeId
+	// Field is the path of the ObjRef field holding the reference.
+	Field string
+	To    TypeId
+}
+
+// Graph is an object and the objects it references, directly or
+// indirectly, as returned by Expand.
+type Graph struct {
+	Root IObject
+	// Objects are the referenced objects, the root not included unless
+	// it is referenced.
+	Objects map[TypeId]IObject
+	// Edges are the followed references in breadth first order. Edges to
+	// objects that do not exist, or whose type is not registered, are
+	// included but their targets are not in Objects.
+	Edges []Edge
+}
+
+// Expand returns the object tid together with the objects it references
+// through its ObjRef fields, up to depth references away. A negative
+// depth has no limit. If fields is not empty only the ObjRef fields with
+// these paths are followed. Every object is read once, which also ends
+// the expansion of cycles.
+func Expand(h Handle, tid TypeId, depth int, fields []string) (g *Graph, err error) {
+	err = h.read(func(tx *Tx) error {
+		g, err = tx.expand(tid, depth, fields)
+		return err
+	})
+	return
+}
+
+func (tx *Tx) expand(tid TypeId, depth int, fields []string) (*Graph, error) {
+	root, err := Get[IObject](tx, tid)
+	if err != nil {
+		return nil, err
+	}
+	g := &Graph{Root: root, Objects: map[TypeId]IObject{}, Edges: []Edge{}}
+	seen := map[TypeId]bool{tid: true}
+	level := []IObject{root}
+	for d := 0; len(level) > 0 && (depth < 0 || d < depth); d++ {
+		next := []IObject{}
+		for _, obj := range level {
+			from := obj.GetMetadata().TypeId()
+			mi, err := tx.db.reg.GetMessageInfo(from.TypeKey())
+			if err != nil {
+				return nil, err
+			}
+			for _, rf := range mi.Refs {
+				if len(fields) > 0 && !containsString(fields, rf.Path) {
+					continue
+				}
+				for _, ref := range refValues(obj.ProtoReflect(), rf.Path) {
+					to, ok := refTypeId(ref)
+					if !ok {
+						continue
+					}
+					g.Edges = append(g.Edges, Edge{From: from, Field: rf.Path, To: to})
+					if seen[to] {
+						if to == tid {
+							g.Objects[to] = root
+						}
+						continue
+					}
+					seen[to] = true
+					if _, err := tx.db.reg.GetMessageInfo(to.TypeKey()); err != nil {
+						// Cannot be decoded
+						continue
+					}
+					target, err := Get[IObject](tx, to)
+					if errors.Is(err, ErrNotFound) {
+						continue
+					} else if err != nil {
+						return nil, err
+					}
+					g.Objects[to] = target
+					next = append(next, target)
+				}
+			}
+		}
+		level = next
+	}
+	return g, nil
+}
+
+// HasCycle returns true if the references of the graph form a cycle.
+func (g *Graph) HasCycle() bool {
+	adj := map[TypeId][]TypeId{}
+	for _, e := range g.Edges {
+		adj[e.From] = append(adj[e.From], e.To)
+	}
+	// 1 while on the path from the root, 2 when done
+	state := map[TypeId]int{}
+	var visit func(tid TypeId) bool
+	visit = func(tid TypeId) bool {
+		state[tid] = 1
+		for _, to := range adj[tid] {
+			if state[to] == 1 || (state[to] == 0 && visit(to)) {
+				return true
+			}
+		}
+		state[tid] = 2
+		return false
+	}
+	for _, e := range g.Edges {
+		if state[e.From] == 0 && visit(e.From) {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteDot writes the graph in the DOT language of Graphviz. Objects that
+// do not exist are drawn dashed.
+func (g *Graph) WriteDot(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "digraph shdb {"); err != nil {
+		return err
+	}
+	node := func(tid TypeId, obj IObject) error {
+		if obj == nil {
+			_, err := fmt.Fprintf(w, "  %q [label=%q, style=dashed];\n", tid.Uuid().String(), tid.Uuid().String())
+			return err
+		}
+		label := fmt.Sprintf("%s\n%s", obj.ProtoReflect().Descriptor().Name(), tid.Uuid())
+		_, err := fmt.Fprintf(w, "  %q [label=%q];\n", tid.Uuid().String(), label)
+		return err
+	}
+	rootId := g.Root.GetMetadata().TypeId()
+	if err := node(rootId, g.Root); err != nil {
+		return err
+	}
+	written := map[TypeId]bool{rootId: true}
+	for _, e := range g.Edges {
+		if !written[e.To] {
+			written[e.To] = true
+			if err := node(e.To, g.Objects[e.To]); err != nil {
+				return err
+			}
+		}
+	}
+	for _, e := range g.Edges {
+		if _, err := fmt.Fprintf(w, "  %q -> %q [label=%q];\n", e.From.Uuid().String(), e.To.Uuid().String(), e.Field); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
@@ -0,0 +1,112 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	db, list := GenerateTestData(1)
+	defer RemoveTestData(db)
+
+	a := newTestNode(db, "a", list[0])
+	b := newTestNode(db, "b", list[0])
+	c := newTestNode(db, "c", list[0])
+	a.Links = []*ObjRef{b.Metadata.Ref(), c.Metadata.Ref()}
+	b.Links = []*ObjRef{c.Metadata.Ref()}
+	if err := Put(db, b, c, a); err != nil {
+		t.FailNow()
+	}
+
+	g, err := Expand(db, a.Metadata.TypeId(), 0, nil)
+	if err != nil || g.Root.GetMetadata().TypeId() != a.Metadata.TypeId() || len(g.Objects) != 0 {
+		t.FailNow()
+	}
+
+	// a -> b, c, owner; b -> c, owner; c -> owner
+	g, err = Expand(db, a.Metadata.TypeId(), 1, nil)
+	if err != nil || len(g.Objects) != 3 || len(g.Edges) != 3 {
+		t.FailNow()
+	}
+	g, err = Expand(db, a.Metadata.TypeId(), -1, nil)
+	if err != nil || len(g.Objects) != 3 || len(g.Edges) != 6 || g.HasCycle() {
+		t.FailNow()
+	}
+	g, err = Expand(db, a.Metadata.TypeId(), -1, []string{"links"})
+	if err != nil || len(g.Objects) != 2 || len(g.Edges) != 3 {
+		t.FailNow()
+	}
+	if _, ok := g.Objects[list[0].Metadata.TypeId()]; ok {
+		t.Fail()
+	}
+}
+
+func TestExpandUnknownType(t *testing.T) {
+	db, list := GenerateTestData(1)
+	defer RemoveTestData(db)
+
+	// An object of a type that is no longer registered
+	unknown := MustParseTypeId(TypeKeyOf("shdb.v1.Gone"), "6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	err := db.Txn(func(tx *Tx) error {
+		return tx.st.Put(bucket_obj, unknown.Key(), []byte{})
+	})
+	if err != nil {
+		t.FailNow()
+	}
+	a := newTestNode(db, "a", list[0])
+	ref, _ := UnmarshalObjRef(unknown.Key())
+	a.Links = []*ObjRef{ref}
+	if err := Put(db, a); err != nil {
+		t.FailNow()
+	}
+	g, err := Expand(db, a.Metadata.TypeId(), -1, []string{"links"})
+	if err != nil || len(g.Edges) != 1 || len(g.Objects) != 0 {
+		t.Fail()
+	}
+}
+
+func TestExpandCycle(t *testing.T) {
+	db, list := GenerateTestData(1)
+	defer RemoveTestData(db)
+
+	a := newTestNode(db, "a", list[0])
+	b := newTestNode(db, "b", list[0])
+	a.Parent = b.Metadata.Ref()
+	b.Parent = a.Metadata.Ref()
+	// A reference to an object that does not exist
+	b.Links = []*ObjRef{MustNew[*TNode](db, TNodeKey).Metadata.Ref()}
+	if err := Put(db, a, b); err != nil {
+		t.FailNow()
+	}
+
+	g, err := Expand(db, a.Metadata.TypeId(), -1, []string{"parent", "links"})
+	if err != nil || !g.HasCycle() || len(g.Edges) != 3 || len(g.Objects) != 2 {
+		t.FailNow()
+	}
+	if _, ok := g.Objects[a.Metadata.TypeId()]; !ok {
+		t.Fail()
+	}
+
+	sb := &strings.Builder{}
+	if err := g.WriteDot(sb); err != nil {
+		t.FailNow()
+	}
+	dot := sb.String()
+	if !strings.HasPrefix(dot, "digraph") || strings.Count(dot, "->") != 3 || !strings.Contains(dot, "dashed") {
+		t.Fail()
+	}
+}
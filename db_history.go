@@ -0,0 +1,304 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The history of types with the history option is kept in bucket_history.
+// Every replaced or deleted revision of an object is stored as a
+// HistoryEntry with a key that has a memory layout like:
+//
+//	[b0 .. b19]		TypeId
+//	[b20 .. b27]	Revision
+
+// Version is a revision of an object.
+type Version struct {
+	Revision uint64
+	Object   IObject
+	// ReplacedAt is when the revision was replaced or deleted. It is the
+	// zero time for the current revision.
+	ReplacedAt time.Time
+	// Deleted is true if the object was deleted at ReplacedAt.
+	Deleted bool
+}
+
+func historyKey(tid TypeId, rev uint64) []byte {
+	return binary.BigEndian.AppendUint64(bytes.Clone(tid.Key()), rev)
+}
+
+// historyOptions returns the history options of a type or nil if the
+// type does not keep a history.
+func (tx *Tx) historyOptions(tk TypeKey) *Shdb_History_Options {
+	mi, err := tx.db.reg.GetMessageInfo(tk)
+	if err != nil {
+		return nil
+	}
+	return mi.History
+}
+
+// recordHistory keeps prev, which was replaced or deleted at replacedAt,
+// if its type has the history option.
+func (tx *Tx) recordHistory(prev IObject, replacedAt *timestamppb.Timestamp, deleted bool) error {
+	tid := prev.GetMetadata().TypeId()
+	opts := tx.historyOptions(tid.TypeKey())
+	if opts == nil {
+		return nil
+	}
+	kvs, err := Marshal(prev)
+	if err != nil {
+		return err
+	}
+	entry := &HistoryEntry{
+		Revision:         prev.GetMetadata().Revision,
+		Item:             &BinaryObject{Key: kvs[0].Key(), Value: kvs[0].Value},
+		ReplacedAt:       replacedAt,
+		Deleted:          deleted,
+		ReplacedRevision: tx.Revision(),
+	}
+	data, err := proto.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := tx.st.Put(bucket_history, historyKey(tid, entry.Revision), data); err != nil {
+		return err
+	}
+	return tx.pruneHistory(tid, opts, replacedAt.AsTime())
+}
+
+// historyEntries returns the stored history entries of an object, oldest
+// first.
+func (tx *Tx) historyEntries(tid TypeId) ([]*HistoryEntry, error) {
+	res := []*HistoryEntry{}
+	c := tx.st.Cursor(bucket_history, tid.Key())
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		entry := &HistoryEntry{}
+		if err := proto.Unmarshal(v, entry); err != nil {
+			return nil, err
+		}
+		res = append(res, entry)
+	}
+	return res, nil
+}
+
+// history returns the history entries of an object followed by an entry
+// for the current revision if the object exists.
+func (tx *Tx) history(tid TypeId) ([]*HistoryEntry, error) {
+	entries, err := tx.historyEntries(tid)
+	if err != nil {
+		return nil, err
+	}
+	kv, err := tx.getKV(tid)
+	if err == nil {
+		obj, err := tx.db.reg.Unmarshal(kv.Key(), kv.Value)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, &HistoryEntry{
+			Revision: obj.GetMetadata().Revision,
+			Item:     &BinaryObject{Key: bytes.Clone(kv.Key()), Value: bytes.Clone(kv.Value)},
+		})
+	}
+	if len(entries) == 0 {
+		return nil, ErrNotFound
+	}
+	return entries, nil
+}
+
+// pruneHistory removes the history entries of an object that exceed the
+// limits of opts.
+func (tx *Tx) pruneHistory(tid TypeId, opts *Shdb_History_Options, now time.Time) error {
+	entries, err := tx.historyEntries(tid)
+	if err != nil {
+		return err
+	}
+	drop := 0
+	if opts.MaxRevisions > 0 && len(entries) > int(opts.MaxRevisions) {
+		drop = len(entries) - int(opts.MaxRevisions)
+	}
+	if opts.MaxAge != nil {
+		for drop < len(entries) && now.Sub(entries[drop].ReplacedAt.AsTime()) > opts.MaxAge.AsDuration() {
+			drop++
+		}
+	}
+	for _, entry := range entries[:drop] {
+		if err := tx.st.Delete(bucket_history, historyKey(tid, entry.Revision)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PruneHistory removes the history entries of all objects of a type that
+// exceed its history limits. Limits are otherwise only applied when an
+// object is written, so entries of objects that are no longer written
+// stay until this is called. All entries are removed if the type no
+// longer has the history option.
+func (tx *Tx) PruneHistory(tk TypeKey) error {
+	if !tx.st.Writable() {
+		return ErrTxReadOnly
+	}
+	opts := tx.historyOptions(tk)
+	tids := []TypeId{}
+	c := tx.st.Cursor(bucket_history, tk[:])
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		tid := *MarshalTypeId(k)
+		if len(tids) == 0 || tids[len(tids)-1] != tid {
+			tids = append(tids, tid)
+		}
+	}
+	for _, tid := range tids {
+		if opts == nil {
+			if err := tx.dropHistory(tid); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := tx.pruneHistory(tid, opts, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PruneHistory removes the history entries of all objects of a type that
+// exceed its history limits.
+func (db *DB) PruneHistory(tk TypeKey) error {
+	return db.Txn(func(tx *Tx) error {
+		return tx.PruneHistory(tk)
+	})
+}
+
+// dropHistory removes all history entries of an object.
+func (tx *Tx) dropHistory(tid TypeId) error {
+	entries, err := tx.historyEntries(tid)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := tx.st.Delete(bucket_history, historyKey(tid, entry.Revision)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// versions converts history entries to Versions.
+func (tx *Tx) versions(entries []*HistoryEntry) ([]Version, error) {
+	res := []Version{}
+	for _, entry := range entries {
+		obj, err := tx.db.reg.Unmarshal(entry.Item.Key, entry.Item.Value)
+		if err != nil {
+			return nil, err
+		}
+		v := Version{Revision: entry.Revision, Object: obj, Deleted: entry.Deleted}
+		if entry.ReplacedAt != nil {
+			v.ReplacedAt = entry.ReplacedAt.AsTime()
+		}
+		res = append(res, v)
+	}
+	return res, nil
+}
+
+// History returns the kept revisions of an object, oldest first, followed
+// by the current revision if the object exists.
+func History(h Handle, tid TypeId) ([]Version, error) {
+	var res []Version
+	err := h.read(func(tx *Tx) error {
+		entries, err := tx.history(tid)
+		if err != nil {
+			return err
+		}
+		res, err = tx.versions(entries)
+		return err
+	})
+	return res, err
+}
+
+// GetAt returns an object as it was at a point in time. ErrNotFound is
+// returned if the object did not exist then or if that revision is no
+// longer kept.
+func GetAt[T IObject](h Handle, tid TypeId, at time.Time) (t T, err error) {
+	err = h.read(func(tx *Tx) error {
+		entries, err := tx.history(tid)
+		if err != nil {
+			return err
+		}
+		versions, err := tx.versions(entries)
+		if err != nil {
+			return err
+		}
+		for k := len(versions) - 1; k >= 0; k-- {
+			v := versions[k]
+			if at.Before(writtenAt(v.Object)) {
+				continue
+			}
+			if !v.ReplacedAt.IsZero() && !at.Before(v.ReplacedAt) {
+				// The object was deleted at the time
+				return ErrNotFound
+			}
+			obj, ok := v.Object.(T)
+			if !ok {
+				return ErrInvalidType
+			}
+			t = obj
+			return nil
+		}
+		return ErrNotFound
+	})
+	return
+}
+
+// writtenAt returns when obj was written.
+func writtenAt(obj IObject) time.Time {
+	md := obj.GetMetadata()
+	if md.UpdatedAt != nil {
+		return md.UpdatedAt.AsTime()
+	}
+	return md.CreatedAt.AsTime()
+}
+
+// Revert stores a kept revision of an object as its new revision. The
+// object is recreated if it has been deleted.
+func Revert[T IObject](h Handle, tid TypeId, revision uint64) (t T, err error) {
+	err = h.write(func(tx *Tx) error {
+		entries, err := tx.history(tid)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.Revision != revision {
+				continue
+			}
+			obj, err := Unmarshal[T](tx.db, KeyVal{TypeId: tid, Value: entry.Item.Value})
+			if err != nil {
+				return err
+			}
+			if err := tx.put(obj); err != nil {
+				return err
+			}
+			t = obj
+			return nil
+		}
+		return ErrNotFound
+	})
+	return
+}
@@ -0,0 +1,143 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+
+	obj := MustNew[*TObject](db, TObj)
+	if err := Put(db, obj); err != nil {
+		t.FailNow()
+	}
+	tid := obj.Metadata.TypeId()
+	times := []time.Time{}
+	for k := 1; k <= 3; k++ {
+		times = append(times, time.Now())
+		time.Sleep(time.Millisecond)
+		_, err := Update(db, tid, func(obj *TObject) (*TObject, error) {
+			obj.MyInt = uint64(k)
+			return obj, nil
+		})
+		if err != nil {
+			t.FailNow()
+		}
+	}
+
+	versions, err := History(db, tid)
+	if err != nil || len(versions) != 4 {
+		t.FailNow()
+	}
+	for k, v := range versions {
+		if v.Object.(*TObject).MyInt != uint64(k) || v.Deleted {
+			t.Fail()
+		}
+		if k > 0 && v.Revision <= versions[k-1].Revision {
+			t.Fail()
+		}
+	}
+	if !versions[3].ReplacedAt.IsZero() {
+		t.Fail()
+	}
+
+	for k, at := range times {
+		old, err := GetAt[*TObject](db, tid, at)
+		if err != nil || old.MyInt != uint64(k) {
+			t.Fail()
+		}
+	}
+	if _, err := GetAt[*TObject](db, tid, times[0].Add(-time.Hour)); !errors.Is(err, ErrNotFound) {
+		t.Fail()
+	}
+}
+
+func TestHistoryDeleteRevert(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+
+	obj := MustNew[*TObject](db, TObj)
+	obj.MyInt = 7
+	if err := Put(db, obj); err != nil {
+		t.FailNow()
+	}
+	tid := obj.Metadata.TypeId()
+	if _, err := Delete[*TObject](db, tid); err != nil {
+		t.FailNow()
+	}
+	versions, err := History(db, tid)
+	if err != nil || len(versions) != 1 || !versions[0].Deleted {
+		t.FailNow()
+	}
+	if _, err := GetAt[*TObject](db, tid, time.Now()); !errors.Is(err, ErrNotFound) {
+		t.Fail()
+	}
+
+	restored, err := Revert[*TObject](db, tid, versions[0].Revision)
+	if err != nil || restored.MyInt != 7 || restored.Metadata.Revision <= versions[0].Revision {
+		t.FailNow()
+	}
+	if cur, err := Get[*TObject](db, tid); err != nil || cur.MyInt != 7 {
+		t.Fail()
+	}
+	if _, err := Revert[*TObject](db, tid, 12345); !errors.Is(err, ErrNotFound) {
+		t.Fail()
+	}
+}
+
+func TestHistoryRetention(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+
+	obj := MustNew[*TObject](db, TObj)
+	if err := Put(db, obj); err != nil {
+		t.FailNow()
+	}
+	tid := obj.Metadata.TypeId()
+	for k := 1; k <= 20; k++ {
+		obj.MyInt = uint64(k)
+		if err := Put(db, obj); err != nil {
+			t.FailNow()
+		}
+	}
+	// TObject keeps at most 10 previous revisions
+	versions, err := History(db, tid)
+	if err != nil || len(versions) != 11 || versions[0].Object.(*TObject).MyInt != 10 {
+		t.Fail()
+	}
+}
+
+func TestHistoryDisabled(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+	fd := CreateTestFileDescriptor()
+	fd.MessageType[0].Options = nil
+	if err := db.TypeRegistry().AddFileFromProtoFileDescriptor(fd); err != nil {
+		t.FailNow()
+	}
+	obj := MustNew[IObject](db, TypeKeyOf("shdb.test.volatile.TObj2"))
+	if err := Put(db, obj, obj); err != nil {
+		t.FailNow()
+	}
+	versions, err := History(db, obj.GetMetadata().TypeId())
+	if err != nil || len(versions) != 1 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,482 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Secondary indexes are declared with the indexes field of
+// Shdb_Message_Options and kept in bucket_index. An index entry has
+// no value and a key with a memory layout like:
+//
+//	[b0 .. b3]		TypeKey
+//	[b4]			Length n of the field path
+//	[b5 .. b4+n]	Field path
+//	[...]			Encoded field value
+//	[last 16]		UUID of the object
+//
+// Field values are encoded so that their byte order is the same as their
+// value order, which turns range lookups into a cursor walk.
+
+const timestampFullname = "google.protobuf.Timestamp"
+
+// labelIndex is the index of Metadata.labels that all types have.
+const labelIndex = "metadata.labels"
+
+// indexFields resolves the dotted field path of an index. All but the
+// last field must be singular messages. The last field must be a
+// scalar, an enum or a Timestamp and may be repeated.
+func indexFields(md protoreflect.MessageDescriptor, path string) ([]protoreflect.FieldDescriptor, error) {
+	if len(path) == 0 || len(path) > math.MaxUint8 {
+		return nil, fmt.Errorf("%w: %q in %s", ErrInvalidIndex, path, md.FullName())
+	}
+	fds := []protoreflect.FieldDescriptor{}
+	names := strings.Split(path, ".")
+	msg := md
+	for i, name := range names {
+		fd := msg.Fields().ByName(protoreflect.Name(name))
+		if fd == nil || fd.IsMap() {
+			return nil, fmt.Errorf("%w: %q in %s", ErrInvalidIndex, path, md.FullName())
+		}
+		isTimestamp := fd.Message() != nil && fd.Message().FullName() == timestampFullname
+		if i < len(names)-1 {
+			if fd.Message() == nil || isTimestamp || fd.IsList() {
+				return nil, fmt.Errorf("%w: %q in %s", ErrInvalidIndex, path, md.FullName())
+			}
+			msg = fd.Message()
+		} else if fd.Message() != nil && !isTimestamp {
+			return nil, fmt.Errorf("%w: %q in %s", ErrInvalidIndex, path, md.FullName())
+		}
+		fds = append(fds, fd)
+	}
+	return fds, nil
+}
+
+// indexValues returns the encoded values of the field at the end of fds.
+// Nothing is returned if a message on the way is not set.
+func indexValues(m protoreflect.Message, fds []protoreflect.FieldDescriptor) [][]byte {
+	for _, fd := range fds[:len(fds)-1] {
+		if !m.Has(fd) {
+			return nil
+		}
+		m = m.Get(fd).Message()
+	}
+	fd := fds[len(fds)-1]
+	if fd.IsList() {
+		res := [][]byte{}
+		l := m.Get(fd).List()
+		for i := 0; i < l.Len(); i++ {
+			res = append(res, encodeIndexValue(fd, l.Get(i)))
+		}
+		return res
+	}
+	if fd.Message() != nil && !m.Has(fd) {
+		return nil
+	}
+	return [][]byte{encodeIndexValue(fd, m.Get(fd))}
+}
+
+// encodeIndexValue encodes a field value so that the byte order of the
+// encoded values is the order of the values.
+func encodeIndexValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) []byte {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		if v.Bool() {
+			return []byte{1}
+		}
+		return []byte{0}
+	case protoreflect.EnumKind:
+		return encodeInt(int64(v.Enum()))
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return encodeInt(v.Int())
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return binary.BigEndian.AppendUint64(nil, v.Uint())
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		bits := math.Float64bits(v.Float())
+		if bits&(1<<63) != 0 {
+			bits = ^bits
+		} else {
+			bits |= 1 << 63
+		}
+		return binary.BigEndian.AppendUint64(nil, bits)
+	case protoreflect.StringKind:
+		return append(escapeIndexBytes([]byte(v.String())), 0, 1)
+	case protoreflect.BytesKind:
+		return append(escapeIndexBytes(v.Bytes()), 0, 1)
+	case protoreflect.MessageKind:
+		// Timestamp
+		m := v.Message()
+		fields := m.Descriptor().Fields()
+		data := encodeInt(m.Get(fields.ByName("seconds")).Int())
+		return binary.BigEndian.AppendUint32(data, uint32(m.Get(fields.ByName("nanos")).Int()))
+	}
+	return nil
+}
+
+func encodeInt(v int64) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(v)^(1<<63))
+}
+
+// escapeIndexBytes escapes zero bytes so that a terminating {0, 1}
+// sorts shorter values before longer ones.
+func escapeIndexBytes(data []byte) []byte {
+	res := make([]byte, 0, len(data)+2)
+	for _, b := range data {
+		res = append(res, b)
+		if b == 0 {
+			res = append(res, 0xff)
+		}
+	}
+	return res
+}
+
+// indexValueOf converts a Go value, as given to the lookup functions,
+// to a value of the field fd.
+func indexValueOf(fd protoreflect.FieldDescriptor, v any) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		if s, ok := v.(string); ok {
+			return protoreflect.ValueOfString(s), nil
+		}
+	case protoreflect.BytesKind:
+		switch b := v.(type) {
+		case []byte:
+			return protoreflect.ValueOfBytes(b), nil
+		case string:
+			return protoreflect.ValueOfBytes([]byte(b)), nil
+		}
+	case protoreflect.MessageKind:
+		switch ts := v.(type) {
+		case time.Time:
+			return protoreflect.ValueOfMessage(timestamppb.New(ts).ProtoReflect()), nil
+		case *timestamppb.Timestamp:
+			return protoreflect.ValueOfMessage(ts.ProtoReflect()), nil
+		}
+	default:
+		rv := reflect.ValueOf(v)
+		switch {
+		case rv.Kind() == reflect.Bool && fd.Kind() == protoreflect.BoolKind:
+			return protoreflect.ValueOfBool(rv.Bool()), nil
+		case rv.CanInt():
+			return intIndexValue(fd, rv.Int())
+		case rv.CanUint() && rv.Uint() <= math.MaxInt64:
+			return intIndexValue(fd, int64(rv.Uint()))
+		case rv.CanFloat() && (fd.Kind() == protoreflect.FloatKind || fd.Kind() == protoreflect.DoubleKind):
+			return protoreflect.ValueOfFloat64(rv.Float()), nil
+		}
+	}
+	return protoreflect.Value{}, fmt.Errorf("%w: %T is not a valid value for %s", ErrInvalidIndex, v, fd.FullName())
+}
+
+func intIndexValue(fd protoreflect.FieldDescriptor, v int64) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind, protoreflect.StringKind, protoreflect.BytesKind, protoreflect.MessageKind:
+		return protoreflect.Value{}, fmt.Errorf("%w: integer is not a valid value for %s", ErrInvalidIndex, fd.FullName())
+	case protoreflect.EnumKind:
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(v)), nil
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return protoreflect.ValueOfFloat64(float64(v)), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if v < 0 {
+			return protoreflect.Value{}, fmt.Errorf("%w: negative value for %s", ErrInvalidIndex, fd.FullName())
+		}
+		return protoreflect.ValueOfUint64(uint64(v)), nil
+	}
+	return protoreflect.ValueOfInt64(v), nil
+}
+
+// indexPrefix returns the key prefix of all entries of an index.
+func indexPrefix(tk TypeKey, path string) []byte {
+	prefix := make([]byte, 0, 5+len(path))
+	prefix = append(prefix, tk[:]...)
+	prefix = append(prefix, byte(len(path)))
+	return append(prefix, path...)
+}
+
+// prefixEnd returns the smallest key that is greater than all keys
+// starting with prefix, or nil if there is none.
+func prefixEnd(prefix []byte) []byte {
+	end := bytes.Clone(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+// indexKeys returns the keys of all index entries of obj. It returns
+// no keys for a nil obj.
+func (tx *Tx) indexKeys(obj IObject) (map[string]struct{}, error) {
+	if obj == nil {
+		return map[string]struct{}{}, nil
+	}
+	mi, err := tx.db.reg.GetMessageInfo(obj.GetMetadata().TypeId().TypeKey())
+	if err != nil {
+		return map[string]struct{}{}, nil
+	}
+	return indexKeysOf(obj, mi)
+}
+
+// indexKeysOf returns the keys of the index entries of obj for the
+// indexes of mi.
+func indexKeysOf(obj IObject, mi MessageInfo) (map[string]struct{}, error) {
+	keys := map[string]struct{}{}
+	tid := obj.GetMetadata().TypeId()
+	m := obj.ProtoReflect()
+	for _, path := range indexPaths(mi) {
+		fds, err := indexFields(m.Descriptor(), path)
+		if err != nil {
+			return nil, err
+		}
+		prefix := indexPrefix(tid.TypeKey(), path)
+		for _, v := range indexValues(m, fds) {
+			key := append(append(bytes.Clone(prefix), v...), tid.UuidBytes()...)
+			keys[string(key)] = struct{}{}
+		}
+	}
+	return keys, nil
+}
+
+// updateIndexes replaces the index entries of prev with those of obj.
+// Either may be nil.
+func (tx *Tx) updateIndexes(obj, prev IObject) error {
+	oldKeys, err := tx.indexKeys(prev)
+	if err != nil {
+		return err
+	}
+	newKeys, err := tx.indexKeys(obj)
+	if err != nil {
+		return err
+	}
+	for k := range oldKeys {
+		if _, ok := newKeys[k]; !ok {
+			if err := tx.st.Delete(bucket_index, []byte(k)); err != nil {
+				return err
+			}
+		}
+	}
+	for k := range newKeys {
+		if _, ok := oldKeys[k]; !ok {
+			if err := tx.st.Put(bucket_index, []byte(k), []byte{}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Reindex rebuilds the secondary indexes and the reverse references of
+// a type. It is needed when an index is added to a type that already has
+// stored objects.
+func (tx *Tx) Reindex(tk TypeKey) error {
+	if !tx.st.Writable() {
+		return ErrTxReadOnly
+	}
+	stale := [][]byte{}
+	c := tx.st.Cursor(bucket_index, tk[:])
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		stale = append(stale, bytes.Clone(k))
+	}
+	for _, k := range stale {
+		if err := tx.st.Delete(bucket_index, k); err != nil {
+			return err
+		}
+	}
+	objs := []IObject{}
+	err := tx.forEachRaw(tk[:], func(kv KeyVal) error {
+		obj, err := tx.db.reg.Unmarshal(kv.Key(), kv.Value)
+		if err != nil {
+			return err
+		}
+		objs = append(objs, obj)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		if err := tx.updateIndexes(obj, nil); err != nil {
+			return err
+		}
+		if err := tx.updateExpiry(obj, obj); err != nil {
+			return err
+		}
+	}
+	return tx.reindexRefs(tk, objs)
+}
+
+// Reindex rebuilds the secondary indexes of a type.
+func (db *DB) Reindex(tk TypeKey) error {
+	return db.Txn(func(tx *Tx) error {
+		return tx.Reindex(tk)
+	})
+}
+
+// Lookup returns the objects of type typ whose indexed field equals value.
+// Values of repeated fields match if any element equals value. Timestamps
+// are given as time.Time or *timestamppb.Timestamp.
+func Lookup[T IObject](h Handle, typ TypeKey, index string, value any) ([]T, error) {
+	return lookupIndex[T](h, typ, index, func(fd protoreflect.FieldDescriptor) ([]byte, []byte, error) {
+		v, err := indexValueOf(fd, value)
+		if err != nil {
+			return nil, nil, err
+		}
+		from := encodeIndexValue(fd, v)
+		return from, prefixEnd(from), nil
+	})
+}
+
+// LookupPrefix returns the objects of type typ whose indexed string or
+// bytes field starts with prefix, in index order.
+func LookupPrefix[T IObject](h Handle, typ TypeKey, index string, prefix string) ([]T, error) {
+	return lookupIndex[T](h, typ, index, func(fd protoreflect.FieldDescriptor) ([]byte, []byte, error) {
+		if fd.Kind() != protoreflect.StringKind && fd.Kind() != protoreflect.BytesKind {
+			return nil, nil, fmt.Errorf("%w: prefix lookup on %s", ErrInvalidIndex, fd.FullName())
+		}
+		from := escapeIndexBytes([]byte(prefix))
+		return from, prefixEnd(from), nil
+	})
+}
+
+// LookupRange returns the objects of type typ whose indexed field is in
+// the range [from, to), in index order. A nil from or to leaves that end
+// of the range open.
+func LookupRange[T IObject](h Handle, typ TypeKey, index string, from, to any) ([]T, error) {
+	return lookupIndex[T](h, typ, index, func(fd protoreflect.FieldDescriptor) (start []byte, end []byte, err error) {
+		if from != nil {
+			v, err := indexValueOf(fd, from)
+			if err != nil {
+				return nil, nil, err
+			}
+			start = encodeIndexValue(fd, v)
+		}
+		if to != nil {
+			v, err := indexValueOf(fd, to)
+			if err != nil {
+				return nil, nil, err
+			}
+			end = encodeIndexValue(fd, v)
+		}
+		return start, end, nil
+	})
+}
+
+// lookupIndex returns the objects with index entries between the encoded
+// values from and to returned by bounds. A nil to means no upper bound.
+func lookupIndex[T IObject](h Handle, typ TypeKey, index string, bounds func(fd protoreflect.FieldDescriptor) (from, to []byte, err error)) ([]T, error) {
+	res := []T{}
+	err := h.read(func(tx *Tx) error {
+		mi, err := tx.db.reg.GetMessageInfo(typ)
+		if err != nil {
+			return err
+		}
+		if !hasIndex(mi, index) {
+			return fmt.Errorf("%w: %s has no index %q", ErrInvalidIndex, mi.Fullname, index)
+		}
+		fds, err := indexFields(mi.MessageType.Descriptor(), index)
+		if err != nil {
+			return err
+		}
+		from, to, err := bounds(fds[len(fds)-1])
+		if err != nil {
+			return err
+		}
+		tids, err := tx.scanIndex(typ, index, from, to, map[TypeId]bool{})
+		if err != nil {
+			return err
+		}
+		for _, tid := range tids {
+			kv, err := tx.getKV(tid)
+			if errors.Is(err, ErrNotFound) {
+				// Expired
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			obj, err := Unmarshal[T](tx.db, kv)
+			if err != nil {
+				return err
+			}
+			res = append(res, obj)
+		}
+		return nil
+	})
+	return res, err
+}
+
+// scanIndex returns the TypeIds of the entries of an index with encoded
+// values between from and to. A nil to means no upper bound. TypeIds in
+// seen are skipped and the returned ones are added to it.
+func (tx *Tx) scanIndex(typ TypeKey, index string, from, to []byte, seen map[TypeId]bool) ([]TypeId, error) {
+	prefix := indexPrefix(typ, index)
+	tids := []TypeId{}
+	c := tx.st.Cursor(bucket_index, prefix)
+	for k, _ := c.Seek(append(bytes.Clone(prefix), from...)); k != nil; k, _ = c.Next() {
+		entry := k[len(prefix):]
+		if to != nil && bytes.Compare(entry, to) >= 0 {
+			break
+		}
+		if len(entry) < 16 {
+			return nil, ErrDatabaseCorrupt
+		}
+		tid := NewTypeId(typ, entry[len(entry)-16:])
+		if !seen[*tid] {
+			seen[*tid] = true
+			tids = append(tids, *tid)
+		}
+	}
+	return tids, nil
+}
+
+// indexPaths returns the paths of all indexes of a type, including the
+// label index and the index the reaper needs for types with a max_count.
+func indexPaths(mi MessageInfo) []string {
+	paths := append([]string{}, mi.Indexes...)
+	if !containsString(mi.Indexes, labelIndex) {
+		paths = append(paths, labelIndex)
+	}
+	if mi.Retention != nil && mi.Retention.MaxCount > 0 && !containsString(mi.Indexes, createdIndex) {
+		paths = append(paths, createdIndex)
+	}
+	return paths
+}
+
+func hasIndex(mi MessageInfo, index string) bool {
+	if index == labelIndex {
+		return true
+	}
+	for _, v := range mi.Indexes {
+		if v == index {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestLookup(t *testing.T) {
+	db, list := GenerateTestData(20)
+	defer RemoveTestData(db)
+
+	res, err := Lookup[*TObject](db, TObj, "my_int", 7)
+	if err != nil || len(res) != 1 || res[0].MyInt != 7 {
+		t.FailNow()
+	}
+	res, err = LookupRange[*TObject](db, TObj, "my_int", 5, 10)
+	if err != nil || len(res) != 5 {
+		t.FailNow()
+	}
+	for k, v := range res {
+		if v.MyInt != uint64(5+k) {
+			t.Fail()
+		}
+	}
+	if res, _ := LookupRange[*TObject](db, TObj, "my_int", nil, 3); len(res) != 3 {
+		t.Fail()
+	}
+	if res, _ := LookupRange[*TObject](db, TObj, "my_int", 18, nil); len(res) != 2 {
+		t.Fail()
+	}
+
+	// All objects have the same empty string
+	if res, _ := Lookup[*TObject](db, TObj, "my_string", ""); len(res) != len(list) {
+		t.Fail()
+	}
+	if _, err := Lookup[*TObject](db, TObj, "description", ""); !errors.Is(err, ErrInvalidIndex) {
+		t.Fail()
+	}
+	if _, err := Lookup[*TObject](db, TObj, "my_int", "seven"); !errors.Is(err, ErrInvalidIndex) {
+		t.Fail()
+	}
+}
+
+func TestLookupMaintained(t *testing.T) {
+	db, list := GenerateTestData(10)
+	defer RemoveTestData(db)
+
+	for k, v := range list {
+		_, err := Update(db, v.Metadata.TypeId(), func(obj *TObject) (*TObject, error) {
+			obj.MyString = fmt.Sprintf("duck-%d", k)
+			obj.Timestamp = timestamppb.New(time.Unix(int64(1000+k), 0))
+			return obj, nil
+		})
+		if err != nil {
+			t.FailNow()
+		}
+	}
+	if res, _ := Lookup[*TObject](db, TObj, "my_string", ""); len(res) != 0 {
+		t.Fail()
+	}
+	if res, _ := LookupPrefix[*TObject](db, TObj, "my_string", "duck-"); len(res) != 10 {
+		t.Fail()
+	}
+	if res, _ := LookupPrefix[*TObject](db, TObj, "my_string", "duck-1"); len(res) != 1 {
+		t.Fail()
+	}
+	res, err := LookupRange[*TObject](db, TObj, "timestamp", time.Unix(1002, 0), time.Unix(1004, 0))
+	if err != nil || len(res) != 2 || res[0].MyInt != 2 || res[1].MyInt != 3 {
+		t.Fail()
+	}
+
+	if _, err := Delete[*TObject](db, list[3].Metadata.TypeId()); err != nil {
+		t.FailNow()
+	}
+	if res, _ := Lookup[*TObject](db, TObj, "my_int", 3); len(res) != 0 {
+		t.Fail()
+	}
+	if err := db.DeleteAll(TObj); err != nil {
+		t.FailNow()
+	}
+	if res, _ := LookupRange[*TObject](db, TObj, "my_int", nil, nil); len(res) != 0 {
+		t.Fail()
+	}
+}
+
+func TestReindex(t *testing.T) {
+	db, list := GenerateTestData(10)
+	defer RemoveTestData(db)
+
+	// Drop the index entries as if the index had been added later
+	err := db.Txn(func(tx *Tx) error {
+		for _, v := range list {
+			if err := tx.updateIndexes(nil, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.FailNow()
+	}
+	if res, _ := Lookup[*TObject](db, TObj, "my_int", 4); len(res) != 0 {
+		t.Fail()
+	}
+	if err := db.Reindex(TObj); err != nil {
+		t.FailNow()
+	}
+	if res, _ := Lookup[*TObject](db, TObj, "my_int", 4); len(res) != 1 {
+		t.Fail()
+	}
+}
+
+func TestLookupDynamic(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+	if err := db.TypeRegistry().AddFileFromProtoFileDescriptor(CreateTestFileDescriptor()); err != nil {
+		t.FailNow()
+	}
+	TObj2 := TypeKeyOf("shdb.test.volatile.TObj2")
+	obj := MustNew[IObject](db, TObj2)
+	m := obj.ProtoReflect()
+	m.Set(m.Descriptor().Fields().ByName("my_string"), protoreflect.ValueOfString("dynamic"))
+	if err := Put(db, obj); err != nil {
+		t.FailNow()
+	}
+	res, err := Lookup[IObject](db, TObj2, "my_string", "dynamic")
+	if err != nil || len(res) != 1 {
+		t.Fail()
+	}
+}
+
+func TestIndexOrder(t *testing.T) {
+	ints := []int64{-1 << 40, -2, -1, 0, 1, 300, 1 << 40}
+	for k := 1; k < len(ints); k++ {
+		if string(encodeInt(ints[k-1])) >= string(encodeInt(ints[k])) {
+			t.Fail()
+		}
+	}
+	strs := []string{"", "\x00", "\x00\x00", "\x01", "a", "a\x00", "ab", "b"}
+	for k := 1; k < len(strs); k++ {
+		a := append(escapeIndexBytes([]byte(strs[k-1])), 0, 1)
+		b := append(escapeIndexBytes([]byte(strs[k])), 0, 1)
+		if string(a) >= string(b) {
+			t.Fail()
+		}
+	}
+}
@@ -18,238 +18,254 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 
 	"github.com/google/uuid"
-	"go.etcd.io/bbolt"
 	"google.golang.org/protobuf/proto"
-	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // Put creates objects in the database. If the object already existed
-// it will be overwritten.
-func Put[T IObject](val ...T) error {
+// it will be overwritten. The objects may be of different types.
+func Put[T IObject](h Handle, val ...T) error {
 	if len(val) == 0 {
 		return nil
 	}
-	for _, v := range val {
-		v.GetMetadata().UpdatedAt = timestamppb.Now()
-	}
-	kv, err := Marshal(val...)
-	if err != nil {
-		return err
-	}
-
-	err = db.Update(func(tx *bbolt.Tx) error {
-		b := tx.Bucket(bucket_obj)
-		for _, v := range kv {
-			err = b.Put(v.Key(), v.Value)
-			if err != nil {
+	return h.write(func(tx *Tx) error {
+		for _, v := range val {
+			if err := tx.put(v); err != nil {
 				return err
 			}
 		}
 		return nil
 	})
-	if err != nil {
-		return err
-	}
-	for _, v := range val {
-		notifyCreate(v)
-	}
-	return nil
 }
 
-func get(tid TypeId) (*KeyVal, error) {
+func (db *DB) get(tid TypeId) (*KeyVal, error) {
 	kv := &KeyVal{TypeId: tid}
-	err := db.View(func(tx *bbolt.Tx) error {
-		b := tx.Bucket(bucket_obj)
-		kv.Value = b.Get(kv.Key())
-		if kv.Value == nil {
-			return ErrNotFound
+	err := db.View(func(tx *Tx) error {
+		stored, err := tx.getKV(tid)
+		if err != nil {
+			return err
 		}
+		kv.Value = bytes.Clone(stored.Value)
 		return nil
 	})
 	return kv, err
 }
 
 // Get an object from the database based on the type and id of the object.
-func Get[T IObject](tid TypeId) (T, error) {
+func Get[T IObject](h Handle, tid TypeId) (T, error) {
 	var t T
-	kv := KeyVal{TypeId: tid}
-	err := db.View(func(tx *bbolt.Tx) error {
-		b := tx.Bucket(bucket_obj)
-		kv.Value = b.Get(kv.Key())
-		if kv.Value == nil {
-			return ErrNotFound
+	err := h.read(func(tx *Tx) error {
+		kv, err := tx.getKV(tid)
+		if err != nil {
+			return err
 		}
-		var err error
-		t, err = Unmarshal[T](kv)
+		t, err = Unmarshal[T](tx.db, kv)
 		return err
 	})
 	return t, err
 }
 
 // GetRef returns an object from the database based on an ObjRef
-func GetRef[T IObject](ref *ObjRef) (T, error) {
-	var t T
-	kv := KeyVal{TypeId: *ref.TypeId()}
-	err := db.View(func(tx *bbolt.Tx) error {
-		b := tx.Bucket(bucket_obj)
-		kv.Value = b.Get(kv.Key())
-		if kv.Value == nil {
-			return ErrNotFound
-		}
-		var err error
-		t, err = Unmarshal[T](kv)
-		return err
-	})
-	return t, err
+func GetRef[T IObject](h Handle, ref *ObjRef) (T, error) {
+	return Get[T](h, *ref.TypeId())
 }
 
 // GetOne returns one of the objects in the database with the specified type that
 // matches the selector function.
 // The selector should return true for a match and false otherwise when presented
 // with an object.
-func GetFirst[T IObject](typeKey TypeKey, selector func(obj T) bool) (T, error) {
+func GetFirst[T IObject](h Handle, typeKey TypeKey, selector func(obj T) bool) (T, error) {
 	var t T
-	err := db.View(func(tx *bbolt.Tx) error {
-		c := tx.Bucket(bucket_obj).Cursor()
-		for k, v := c.Seek(typeKey[:]); k != nil && bytes.HasPrefix(k, typeKey[:]); k, v = c.Next() {
-			kv := KeyVal{TypeId: *MarshalTypeId(k), Value: v}
-			obj, err := Unmarshal[T](kv)
+	found := errors.New("found")
+	err := h.read(func(tx *Tx) error {
+		err := tx.forEach(typeKey[:], func(kv KeyVal) error {
+			obj, err := Unmarshal[T](tx.db, kv)
 			if err != nil {
 				return err
 			}
 			if selector(obj) {
 				t = obj
-				return nil
+				return found
 			}
+			return nil
+		})
+		if err == found {
+			return nil
+		}
+		if err == nil {
+			return ErrNotFound
 		}
-		return ErrNotFound
+		return err
 	})
 	return t, err
 }
 
 // Update an object in the database by using an updater function. The updated
 // object is returned.
-func Update[T IObject](tid TypeId, updater func(obj T) (T, error)) (t T, err error) {
-	var (
-		prev T
-		obj  T
-	)
-
-	err = db.Update(func(tx *bbolt.Tx) error {
-		b := tx.Bucket(bucket_obj)
-		kv := KeyVal{TypeId: tid}
-		kv.Value = b.Get(kv.Key())
-		if kv.Value == nil {
-			return ErrNotFound
+func Update[T IObject](h Handle, tid TypeId, updater func(obj T) (T, error)) (t T, err error) {
+	var obj T
+	err = h.write(func(tx *Tx) error {
+		kv, err := tx.getKV(tid)
+		if err != nil {
+			return err
 		}
-		var err error
-		prev, err = Unmarshal[T](kv)
+		prev, err := Unmarshal[T](tx.db, kv)
 		if err != nil {
 			return err
 		}
-		obj, err = updater(proto.Clone(prev).(T))
+		obj, err = updater(cloneObject(prev).(T))
 		if err != nil {
 			return err
 		}
-		obj.GetMetadata().UpdatedAt = timestamppb.Now()
-		kvs, err := Marshal(obj)
-		b.Put(kvs[0].Key(), kvs[0].Value)
-		return err
+		return tx.store(obj, prev)
 	})
-	if err == nil {
-		notifyUpdate(obj, prev)
-	}
 	return obj, err
 }
 
 // Delete an object from the database based on the type and id.
-// The old value is returned
-func Delete[T IObject](tid TypeId) (T, error) {
-	obj, err := Get[T](tid)
-	if err != nil {
-		return obj, err
-	}
-	err = db.Update(func(tx *bbolt.Tx) error {
-		b := tx.Bucket(bucket_obj)
-		return b.Delete(tid.Key())
+// The old value is returned. Objects of types with soft delete are
+// kept as tombstones, see Undelete.
+func Delete[T IObject](h Handle, tid TypeId) (T, error) {
+	var obj T
+	err := h.write(func(tx *Tx) error {
+		kv, err := tx.getKV(tid)
+		if err != nil {
+			return err
+		}
+		obj, err = Unmarshal[T](tx.db, kv)
+		if err != nil {
+			return err
+		}
+		return tx.delete(obj)
 	})
-	if err == nil {
-		notifyDelete(obj)
-	}
 	return obj, err
-
 }
 
 // Delete all objects of a specific type from the database.
-func DeleteAll(tk TypeKey) error {
-	deleted := []IObject{}
-	err := db.Update(func(tx *bbolt.Tx) error {
-		c := tx.Bucket(bucket_obj).Cursor()
-		for k, v := c.Seek(tk[:]); k != nil && bytes.HasPrefix(k, tk[:]); k, v = c.Next() {
-			kv := KeyVal{TypeId: *MarshalTypeId(k), Value: v}
-			t, err := Unmarshal[IObject](kv)
-			if err != nil {
-				deleted = append(deleted, t)
-			}
-		}
-		return nil
+func (db *DB) DeleteAll(tk TypeKey) error {
+	return db.Txn(func(tx *Tx) error {
+		return tx.DeleteAll(tk)
 	})
-	for _, d := range deleted {
-		notifyDelete(d)
-	}
-	return err
-
 }
 
 // GetAllKV returns all KeyVals of the database.
-func GetAllKV(typeKey TypeKey) ([]KeyVal, error) {
+func (db *DB) GetAllKV(typeKey TypeKey) ([]KeyVal, error) {
 	allKvs := []KeyVal{}
-	err := db.View(func(tx *bbolt.Tx) error {
-		c := tx.Bucket(bucket_obj).Cursor()
-		for k, v := c.Seek(typeKey[:]); k != nil && bytes.HasPrefix(k, typeKey[:]); k, v = c.Next() {
-			kv := KeyVal{TypeId: *MarshalTypeId(k), Value: v}
+	err := db.View(func(tx *Tx) error {
+		return tx.forEach(typeKey[:], func(kv KeyVal) error {
+			kv.Value = bytes.Clone(kv.Value)
 			allKvs = append(allKvs, kv)
-		}
-		return nil
+			return nil
+		})
 	})
 	if err != nil {
 		return nil, err
 	}
-	// sort.SliceStable(allKvs, func(i, j int) bool {
-	// 	return bytes.Compare(allKvs[i].Key(), allKvs[j].Key()) < 0
-	// })
 	return allKvs, nil
 }
 
 // GetAll returns all objects in database of a specific type
-func GetAll[T IObject](typeKey TypeKey) ([]T, error) {
-	allKvs, err := GetAllKV(typeKey)
+func GetAll[T IObject](h Handle, typeKey TypeKey) ([]T, error) {
+	res := []T{}
+	err := h.read(func(tx *Tx) error {
+		return tx.forEach(typeKey[:], func(kv KeyVal) error {
+			obj, err := Unmarshal[T](tx.db, kv)
+			if err != nil {
+				return err
+			}
+			res = append(res, obj)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Select returns all objects of a specific type matching a selector function.
+// It is the transactional counterpart of Query: all results are collected in
+// the transaction of the handle instead of being paged.
+func Select[T IObject](h Handle, typ TypeKey, selectFn func(obj T) (bool, error)) ([]T, error) {
+	res := []T{}
+	err := h.read(func(tx *Tx) error {
+		err := tx.forEach(typ[:], func(kv KeyVal) error {
+			obj, err := Unmarshal[T](tx.db, kv)
+			if err != nil {
+				return err
+			}
+			selected, err := selectFn(obj)
+			if selected {
+				res = append(res, obj)
+			}
+			return err
+		})
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	return UnmarshalMany[T](allKvs)
+	return res, nil
 }
 
-func queryStream(typ TypeKey, doneCh chan struct{}) (ch chan proto.Message) {
+func (db *DB) queryStream(typ TypeKey, opts QueryOptions, doneCh chan struct{}) (ch chan proto.Message, seq uint64, err error) {
+	if opts.Where != nil {
+		mi, err := db.reg.GetMessageInfo(typ)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := opts.Where.Check(mi.MessageType.Descriptor()); err != nil {
+			return nil, 0, err
+		}
+	}
+	// The transaction is started here to know which changes it includes
+	stx, err := db.st.Begin(false)
+	if err != nil {
+		return nil, 0, err
+	}
+	tx := &Tx{db: db, st: stx}
+	seq = tx.LastSeq()
 	ch = make(chan proto.Message, 10)
 	go func() {
 		defer close(ch)
-		err := db.View(func(tx *bbolt.Tx) error {
+		defer stx.Rollback()
+		err := func() error {
+			send := func(obj IObject) error {
+				if opts.Where != nil && !opts.Where.matches(obj) {
+					return nil
+				}
+				select {
+				case ch <- obj:
+					return nil
+				case <-doneCh:
+					return io.EOF
+				}
+			}
+			if !opts.Selector.Empty() || opts.IncludeDeleted {
+				if err := tx.forEachSelected(typ, opts.Selector, send); err != nil {
+					return err
+				}
+				if opts.IncludeDeleted {
+					return tx.forEachDeleted(typ, opts.Selector, send)
+				}
+				return nil
+			}
 			cnt := 1
-			c := tx.Bucket(bucket_obj).Cursor()
-			for k, v := c.Seek(typ[:]); k != nil && bytes.HasPrefix(k, typ[:]); k, v = c.Next() {
-				kv := KeyVal{TypeId: *MarshalTypeId(k), Value: v}
+			return tx.forEach(typ[:], func(kv KeyVal) error {
 				if kv.Value == nil {
 					log.Printf("empty value in database kv=[%s]\n", kv.String())
 				}
-				t, err := unmarshal(kv)
+				t, err := db.unmarshal(kv)
 				if err != nil {
 					log.Printf("failed to parse value in database kv=[%s], err=[%v]\n", kv.String(), err)
-				} else {
+				} else if opts.Where == nil || opts.Where.matches(t) {
 					select {
 					case ch <- t:
 						cnt++
@@ -257,9 +273,9 @@ func queryStream(typ TypeKey, doneCh chan struct{}) (ch chan proto.Message) {
 						return io.EOF
 					}
 				}
-			}
-			return nil
-		})
+				return nil
+			})
+		}()
 		if err != nil {
 			log.Printf("queryStream failed, err=[%v]\n", err)
 		}
@@ -270,16 +286,41 @@ func queryStream(typ TypeKey, doneCh chan struct{}) (ch chan proto.Message) {
 type activeStream struct {
 	inCh   chan proto.Message
 	doneCh chan struct{}
+	seq    uint64
 }
 
-var activeStreams = map[uuid.UUID]*activeStream{}
-
 // Query returns all objects of a specific type matching a selector function.
 // Paging of the results is implemented using a pageSize and a token. If there are more
 // results available after pageSize items have been returned a non-empty nextPageToken is returned
 // that can be used to retrieve a new page of results.
 // If nextPageToken is the empty string, no more results are available.
-func Query[T IObject](ctx context.Context, typ TypeKey, selectFn func(obj T) (bool, error), pageSize int32, pageToken string) (result []T, nextPageToken string, err error) {
+func Query[T IObject](ctx context.Context, db *DB, typ TypeKey, selectFn func(obj T) (bool, error), pageSize int32, pageToken string) (result []T, nextPageToken string, err error) {
+	return QueryWith(ctx, db, typ, QueryOptions{}, selectFn, pageSize, pageToken)
+}
+
+// QueryOptions narrows down or widens the objects considered by QueryWith.
+type QueryOptions struct {
+	// Selector only considers objects whose labels match.
+	Selector Selector
+	// IncludeDeleted also considers soft deleted objects, after the
+	// others. Their Metadata.DeletedAt is set.
+	IncludeDeleted bool
+	// Where only considers objects matching the expression. It is
+	// checked against the type before the query starts.
+	Where *Expr
+}
+
+// QueryWith is like Query with options for the objects considered.
+func QueryWith[T IObject](ctx context.Context, db *DB, typ TypeKey, opts QueryOptions, selectFn func(obj T) (bool, error), pageSize int32, pageToken string) (result []T, nextPageToken string, err error) {
+	result, nextPageToken, _, err = QuerySeq(ctx, db, typ, opts, selectFn, pageSize, pageToken)
+	return
+}
+
+// QuerySeq is like QueryWith but also returns the sequence number of the
+// last change in the changelog when the query was started. All pages
+// of a query see the database as of that change, so subscribing from
+// the next sequence number misses no change of the queried objects.
+func QuerySeq[T IObject](ctx context.Context, db *DB, typ TypeKey, opts QueryOptions, selectFn func(obj T) (bool, error), pageSize int32, pageToken string) (result []T, nextPageToken string, seq uint64, err error) {
 
 	var (
 		streamId uuid.UUID
@@ -294,17 +335,23 @@ func Query[T IObject](ctx context.Context, typ TypeKey, selectFn func(obj T) (bo
 			return
 		}
 		doneCh := make(chan struct{})
-		inCh := queryStream(typ, doneCh)
-		stream = &activeStream{inCh: inCh, doneCh: doneCh}
-		activeStreams[streamId] = stream
-	} else {
-		streamId, err = uuid.Parse(pageToken)
-		if err != nil {
+		var inCh chan proto.Message
+		if inCh, seq, err = db.queryStream(typ, opts, doneCh); err != nil {
 			return
 		}
-		stream, ok = activeStreams[streamId]
+		stream = &activeStream{inCh: inCh, doneCh: doneCh, seq: seq}
+		db.streamsMux.Lock()
+		db.activeStreams[streamId] = stream
+		db.streamsMux.Unlock()
+	} else {
+		if streamId, err = uuid.Parse(pageToken); err != nil {
+			return nil, "", 0, fmt.Errorf("%w: invalid page token", ErrSessionInvalid)
+		}
+		db.streamsMux.Lock()
+		stream, ok = db.activeStreams[streamId]
+		db.streamsMux.Unlock()
 		if !ok {
-			return nil, "", ErrSessionInvalid
+			return nil, "", 0, ErrSessionInvalid
 		}
 	}
 
@@ -314,9 +361,8 @@ func Query[T IObject](ctx context.Context, typ TypeKey, selectFn func(obj T) (bo
 		select {
 		case obj, ok := <-stream.inCh:
 			if !ok {
-				close(stream.doneCh)
-				delete(activeStreams, streamId)
-				return res, "", nil
+				db.closeStream(streamId, stream.doneCh)
+				return res, "", stream.seq, nil
 			}
 			t := obj.(T)
 			selected, err := selectFn(t)
@@ -324,24 +370,22 @@ func Query[T IObject](ctx context.Context, typ TypeKey, selectFn func(obj T) (bo
 				res = append(res, obj.(T))
 			}
 			if errors.Is(err, io.EOF) {
-				close(stream.doneCh)
-				delete(activeStreams, streamId)
-				return res, "", nil
+				db.closeStream(streamId, stream.doneCh)
+				return res, "", stream.seq, nil
 			}
 		case <-ctx.Done():
-			close(stream.doneCh)
-			delete(activeStreams, streamId)
-			return res, "", ErrContextCancelled
+			db.closeStream(streamId, stream.doneCh)
+			return res, "", stream.seq, ErrContextCancelled
 		}
 	}
 
-	return res, streamId.String(), nil
+	return res, streamId.String(), stream.seq, nil
 }
 
 // List all objects pertaining to a specific type. For arguments and paging see `Query` method.
-func List[T IObject](ctx context.Context, typ TypeKey, pageSize int32, pageToken string) (result []T, nextPageToken string, err error) {
+func List[T IObject](ctx context.Context, db *DB, typ TypeKey, pageSize int32, pageToken string) (result []T, nextPageToken string, err error) {
 	identityFn := func(a T) (bool, error) {
 		return true, nil
 	}
-	return Query(ctx, typ, identityFn, pageSize, pageToken)
+	return Query(ctx, db, typ, identityFn, pageSize, pageToken)
 }
@@ -0,0 +1,170 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+)
+
+// labelCandidates returns the TypeIds of the objects of type typ that may
+// match sel according to the label index. ok is false if no requirement
+// of sel can be answered by the index, and all objects have to be checked.
+func (tx *Tx) labelCandidates(typ TypeKey, sel Selector) (tids []TypeId, ok bool, err error) {
+	for _, req := range sel.reqs {
+		if req.op != opEquals && req.op != opIn && req.op != opExists {
+			continue
+		}
+		seen := map[TypeId]bool{}
+		scan := func(from, to []byte) error {
+			res, err := tx.scanIndex(typ, labelIndex, from, to, seen)
+			tids = append(tids, res...)
+			return err
+		}
+		exact := func(label string) error {
+			from := append(escapeIndexBytes([]byte(label)), 0, 1)
+			return scan(from, prefixEnd(from))
+		}
+		// A label without '=' is a key with an empty value
+		if req.op == opExists {
+			from := escapeIndexBytes([]byte(req.key + "="))
+			if err := scan(from, prefixEnd(from)); err != nil {
+				return nil, false, err
+			}
+			if err := exact(req.key); err != nil {
+				return nil, false, err
+			}
+		}
+		for _, v := range req.values {
+			if err := exact(req.key + "=" + v); err != nil {
+				return nil, false, err
+			}
+			if v == "" {
+				if err := exact(req.key); err != nil {
+					return nil, false, err
+				}
+			}
+		}
+		sort.Slice(tids, func(i, j int) bool {
+			return bytes.Compare(tids[i].Key(), tids[j].Key()) < 0
+		})
+		return tids, true, nil
+	}
+	return nil, false, nil
+}
+
+// forEachSelected calls fn for all objects of type typ whose labels
+// match sel. The label index is used when possible.
+func (tx *Tx) forEachSelected(typ TypeKey, sel Selector, fn func(obj IObject) error) error {
+	check := func(kv KeyVal) error {
+		obj, err := tx.db.reg.Unmarshal(kv.Key(), kv.Value)
+		if err != nil {
+			return err
+		}
+		if !sel.MatchesObject(obj) {
+			return nil
+		}
+		return fn(obj)
+	}
+	tids, ok, err := tx.labelCandidates(typ, sel)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return tx.forEach(typ[:], check)
+	}
+	for _, tid := range tids {
+		kv, err := tx.getKV(tid)
+		if errors.Is(err, ErrNotFound) {
+			// Expired
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := check(kv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SelectLabels returns all objects of a specific type whose labels match sel.
+func SelectLabels[T IObject](h Handle, typ TypeKey, sel Selector) ([]T, error) {
+	res := []T{}
+	err := h.read(func(tx *Tx) error {
+		return tx.forEachSelected(typ, sel, func(obj IObject) error {
+			t, ok := obj.(T)
+			if !ok {
+				return ErrInvalidType
+			}
+			res = append(res, t)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// DeleteSelector deletes all objects of a specific type whose labels
+// match sel and returns the number of deleted objects.
+func (tx *Tx) DeleteSelector(tk TypeKey, sel Selector) (int, error) {
+	if !tx.st.Writable() {
+		return 0, ErrTxReadOnly
+	}
+	objs := []IObject{}
+	err := tx.forEachSelected(tk, sel, func(obj IObject) error {
+		objs = append(objs, obj)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, obj := range objs {
+		if err := tx.delete(obj); err != nil {
+			return 0, err
+		}
+	}
+	return len(objs), nil
+}
+
+// DeleteSelector deletes all objects of a specific type whose labels
+// match sel and returns the number of deleted objects.
+func (db *DB) DeleteSelector(tk TypeKey, sel Selector) (n int, err error) {
+	err = db.Txn(func(tx *Tx) error {
+		n, err = tx.DeleteSelector(tk, sel)
+		return err
+	})
+	return
+}
+
+// QuerySelector is like Query but only considers objects whose labels
+// match sel.
+func QuerySelector[T IObject](ctx context.Context, db *DB, typ TypeKey, sel Selector, selectFn func(obj T) (bool, error), pageSize int32, pageToken string) (result []T, nextPageToken string, err error) {
+	return QueryWith(ctx, db, typ, QueryOptions{Selector: sel}, selectFn, pageSize, pageToken)
+}
+
+// ListSelector lists the objects of a specific type whose labels match
+// sel. For arguments and paging see `Query` method.
+func ListSelector[T IObject](ctx context.Context, db *DB, typ TypeKey, sel Selector, pageSize int32, pageToken string) (result []T, nextPageToken string, err error) {
+	identityFn := func(a T) (bool, error) {
+		return true, nil
+	}
+	return QueryWith(ctx, db, typ, QueryOptions{Selector: sel}, identityFn, pageSize, pageToken)
+}
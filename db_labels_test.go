@@ -0,0 +1,134 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"context"
+	"testing"
+)
+
+// putLabeledTestData stores count objects with env set to prod for the even
+// and dev for the odd ones. Every third object has a team label.
+func putLabeledTestData(db *DB, count int) []*TObject {
+	list := []*TObject{}
+	for k := 0; k < count; k++ {
+		obj := MustNew[*TObject](db, TObj)
+		obj.MyInt = uint64(k)
+		if k%2 == 0 {
+			obj.Metadata.SetLabel("env", "prod")
+		} else {
+			obj.Metadata.SetLabel("env", "dev")
+		}
+		if k%3 == 0 {
+			obj.Metadata.SetLabel("team", "a")
+		}
+		list = append(list, obj)
+	}
+	if err := Put(db, list...); err != nil {
+		panic(err)
+	}
+	return list
+}
+
+func TestSelectLabels(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+	putLabeledTestData(db, 12)
+
+	tests := map[string]int{
+		"":                        12,
+		"env=prod":                6,
+		"env in (prod,dev)":       12,
+		"team":                    4,
+		"!team":                   8,
+		"env=prod,team":           2,
+		"env!=prod":               6,
+		"env=prod,team notin (a)": 4,
+	}
+	for s, count := range tests {
+		res, err := SelectLabels[*TObject](db, TObj, MustParseSelector(s))
+		if err != nil || len(res) != count {
+			t.Errorf("%q: got %d, expected %d", s, len(res), count)
+		}
+	}
+
+	res, token, err := ListSelector[*TObject](context.Background(), db, TObj, MustParseSelector("env=dev"), 100, "")
+	if err != nil || token != "" || len(res) != 6 {
+		t.Fail()
+	}
+	for _, v := range res {
+		if v.MyInt%2 != 1 {
+			t.Fail()
+		}
+	}
+}
+
+func TestSelectLabelsUpdated(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+	list := putLabeledTestData(db, 4)
+
+	_, err := Update(db, list[0].Metadata.TypeId(), func(obj *TObject) (*TObject, error) {
+		obj.Metadata.SetLabel("env", "dev")
+		return obj, nil
+	})
+	if err != nil {
+		t.FailNow()
+	}
+	if res, _ := SelectLabels[*TObject](db, TObj, MustParseSelector("env=prod")); len(res) != 1 {
+		t.Fail()
+	}
+	n, err := db.DeleteSelector(TObj, MustParseSelector("env=dev"))
+	if err != nil || n != 3 {
+		t.Fail()
+	}
+	if res, _ := GetAll[*TObject](db, TObj); len(res) != 1 {
+		t.Fail()
+	}
+}
+
+func TestWatchSelector(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+
+	ch := make(chan *EventInfo, 10)
+	watchId, err := db.WatchSelector("", ch, TObj, MustParseSelector("env=prod"))
+	if err != nil {
+		t.FailNow()
+	}
+	defer db.RemoveWatcher(watchId)
+
+	list := putLabeledTestData(db, 2)
+	// The object leaving the selection is reported
+	_, err = Update(db, list[0].Metadata.TypeId(), func(obj *TObject) (*TObject, error) {
+		obj.Metadata.SetLabel("env", "dev")
+		return obj, nil
+	})
+	if err != nil {
+		t.FailNow()
+	}
+	if _, err := Delete[*TObject](db, list[0].Metadata.TypeId()); err != nil {
+		t.FailNow()
+	}
+	if ev := <-ch; ev.Kind != EventCreated || ev.Tid != list[0].Metadata.TypeId() {
+		t.Fail()
+	}
+	if ev := <-ch; ev.Kind != EventUpdated || ev.Tid != list[0].Metadata.TypeId() {
+		t.Fail()
+	}
+	if len(ch) != 0 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,536 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// When a database is opened the stored schema is compared with the
+// runtime schema. Files that are only in the stored schema, such as
+// those of dynamic types, are kept. Incompatible changes fail the open,
+// otherwise the runtime schema is stored.
+//
+// The version option of a type is the version of its schema. Objects
+// record the version they were written with, and objects with an older
+// version are migrated when they are read, or all at once by Migrate.
+
+const defaultMigrateBatchSize = 1000
+
+// Migration upgrades an object of type Type to Version from an older
+// version. Migrate changes the object in place.
+type Migration struct {
+	// Type is the fullname of the type.
+	Type    string
+	Version uint32
+	Migrate func(obj IObject) error
+}
+
+// ChangeKind is the kind of a SchemaChange.
+type ChangeKind int
+
+const (
+	TypeAdded ChangeKind = iota
+	TypeRemoved
+	TypeVersionChanged
+	FieldAdded
+	FieldRemoved
+	FieldRenamed
+	FieldNumberReused
+	FieldTypeChanged
+)
+
+var changeKindNames = []string{"type added", "type removed", "type version changed",
+	"field added", "field removed", "field renamed", "field number reused", "field type changed"}
+
+func (k ChangeKind) String() string {
+	if int(k) < len(changeKindNames) {
+		return changeKindNames[k]
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// SchemaChange is a difference between two versions of a schema.
+type SchemaChange struct {
+	Kind ChangeKind
+	// Type is the fullname of the changed message.
+	Type string
+	// Field is the name of the changed field in the new schema, or in
+	// the old schema if it was removed.
+	Field string
+	// Incompatible is set if stored objects cannot be read with the new
+	// schema.
+	Incompatible bool
+}
+
+func (c SchemaChange) String() string {
+	if c.Field == "" {
+		return fmt.Sprintf("%s: %s", c.Type, c.Kind)
+	}
+	return fmt.Sprintf("%s.%s: %s", c.Type, c.Field, c.Kind)
+}
+
+// SchemaError is returned when the stored and the runtime schema are
+// incompatible. It matches ErrIncompatibleSchema with errors.Is.
+type SchemaError struct {
+	// Changes are the incompatible changes.
+	Changes []SchemaChange
+}
+
+func (e *SchemaError) Error() string {
+	msg := "incompatible schema"
+	for i, c := range e.Changes {
+		if i == 0 {
+			msg += ": "
+		} else {
+			msg += ", "
+		}
+		msg += c.String()
+	}
+	return msg
+}
+
+func (e *SchemaError) Unwrap() error {
+	return ErrIncompatibleSchema
+}
+
+// DiffSchema returns the changes of the messages in the files of before that
+// are also in after, and the messages added in after. Fields are compared by
+// number.
+func DiffSchema(before, after *descriptorpb.FileDescriptorSet) ([]SchemaChange, error) {
+	beforeFiles, err := protodesc.NewFiles(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFiles, err := protodesc.NewFiles(after)
+	if err != nil {
+		return nil, err
+	}
+	changes := []SchemaChange{}
+	beforeFiles.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		if _, err := afterFiles.FindFileByPath(fd.Path()); err != nil {
+			return true
+		}
+		rangeMessages(fd.Messages(), func(md protoreflect.MessageDescriptor) {
+			d, err := afterFiles.FindDescriptorByName(md.FullName())
+			if nmd, ok := d.(protoreflect.MessageDescriptor); err == nil && ok {
+				changes = append(changes, diffMessage(md, nmd)...)
+			} else {
+				changes = append(changes, SchemaChange{Kind: TypeRemoved, Type: string(md.FullName())})
+			}
+		})
+		return true
+	})
+	afterFiles.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		rangeMessages(fd.Messages(), func(md protoreflect.MessageDescriptor) {
+			if _, err := beforeFiles.FindDescriptorByName(md.FullName()); err != nil {
+				changes = append(changes, SchemaChange{Kind: TypeAdded, Type: string(md.FullName())})
+			}
+		})
+		return true
+	})
+	return changes, nil
+}
+
+// rangeMessages calls fn for all messages, including nested ones.
+func rangeMessages(mds protoreflect.MessageDescriptors, fn func(md protoreflect.MessageDescriptor)) {
+	for i := 0; i < mds.Len(); i++ {
+		md := mds.Get(i)
+		if md.IsMapEntry() {
+			continue
+		}
+		fn(md)
+		rangeMessages(md.Messages(), fn)
+	}
+}
+
+func diffMessage(before, after protoreflect.MessageDescriptor) []SchemaChange {
+	changes := []SchemaChange{}
+	typ := string(after.FullName())
+	if typeVersion(before) != typeVersion(after) {
+		changes = append(changes, SchemaChange{Kind: TypeVersionChanged, Type: typ})
+	}
+	for i := 0; i < before.Fields().Len(); i++ {
+		of := before.Fields().Get(i)
+		nf := after.Fields().ByNumber(of.Number())
+		switch {
+		case nf == nil:
+			changes = append(changes, SchemaChange{Kind: FieldRemoved, Type: typ, Field: string(of.Name())})
+		case !sameFieldType(of, nf) && of.Name() != nf.Name():
+			changes = append(changes, SchemaChange{Kind: FieldNumberReused, Type: typ, Field: string(nf.Name()), Incompatible: true})
+		case !sameFieldType(of, nf):
+			changes = append(changes, SchemaChange{Kind: FieldTypeChanged, Type: typ, Field: string(nf.Name()), Incompatible: true})
+		case of.Name() != nf.Name():
+			changes = append(changes, SchemaChange{Kind: FieldRenamed, Type: typ, Field: string(nf.Name())})
+		}
+	}
+	for i := 0; i < after.Fields().Len(); i++ {
+		nf := after.Fields().Get(i)
+		if before.Fields().ByNumber(nf.Number()) == nil {
+			changes = append(changes, SchemaChange{Kind: FieldAdded, Type: typ, Field: string(nf.Name())})
+		}
+	}
+	return changes
+}
+
+func sameFieldType(a, b protoreflect.FieldDescriptor) bool {
+	if a.Kind() != b.Kind() || a.Cardinality() != b.Cardinality() || a.IsMap() != b.IsMap() {
+		return false
+	}
+	switch {
+	case a.IsMap():
+		return sameFieldType(a.MapKey(), b.MapKey()) && sameFieldType(a.MapValue(), b.MapValue())
+	case a.Message() != nil:
+		return a.Message().FullName() == b.Message().FullName()
+	case a.Enum() != nil:
+		return a.Enum().FullName() == b.Enum().FullName()
+	}
+	return true
+}
+
+// typeVersion returns the version option of a message.
+func typeVersion(md protoreflect.MessageDescriptor) uint32 {
+	if !proto.HasExtension(md.Options(), E_ShdbOptions) {
+		return 0
+	}
+	return proto.GetExtension(md.Options(), E_ShdbOptions).(*Shdb_Message_Options).GetVersion()
+}
+
+// mergeSchema returns the files of runtime followed by the files of
+// stored that are not in runtime.
+func mergeSchema(runtime, stored *descriptorpb.FileDescriptorSet) *descriptorpb.FileDescriptorSet {
+	res := &descriptorpb.FileDescriptorSet{File: append([]*descriptorpb.FileDescriptorProto{}, runtime.File...)}
+	paths := map[string]bool{}
+	for _, f := range runtime.File {
+		paths[f.GetName()] = true
+	}
+	for _, f := range stored.File {
+		if !paths[f.GetName()] {
+			res.File = append(res.File, f)
+		}
+	}
+	return res
+}
+
+// openSchema compares the stored schema with the runtime schema when the
+// database is opened, and stores the runtime schema as a new version if
+// they are compatible.
+func (db *DB) openSchema(opts Options) error {
+	assigned, err := db.loadTypeKeys()
+	if err != nil {
+		return err
+	}
+	renamed, err := db.loadRenames()
+	if err != nil {
+		return err
+	}
+	if err := db.reg.useTypeKeys(assigned, renamed); err != nil {
+		return err
+	}
+	if !opts.ReadOnly {
+		for old, name := range opts.Renames {
+			n, err := db.RenameType(old, name, opts.MigrateBatchSize)
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				log.Printf("renamed %d objects from %s to %s", n, old, name)
+			}
+		}
+		if assigned, err = db.loadTypeKeys(); err != nil {
+			return err
+		}
+	}
+	stored, err := db.LoadSchema()
+	if errors.Is(err, ErrNotFound) {
+		log.Println("loaded schema from runtime")
+		if opts.ReadOnly {
+			return nil
+		}
+		return db.reg.StoreSchema(db)
+	} else if err != nil {
+		return err
+	}
+
+	merged, changes, err := db.checkSchema(stored, assigned, db.hasObjects)
+	if err != nil {
+		return err
+	}
+	migrate := false
+	for _, c := range changes {
+		migrate = migrate || c.Kind == TypeVersionChanged
+	}
+	files, err := protodesc.NewFiles(merged)
+	if err != nil {
+		return err
+	}
+	if err := db.reg.useFiles(files); err != nil {
+		return err
+	}
+	log.Println("loaded schema from database")
+	if opts.ReadOnly {
+		return nil
+	}
+	if migrate && opts.MigrateEager {
+		n, err := db.Migrate(opts.MigrateBatchSize)
+		if err != nil {
+			return err
+		}
+		log.Printf("migrated %d objects", n)
+	}
+	return db.reg.StoreSchema(db)
+}
+
+// reloadSchema does what openSchema does with the schema stored by tx,
+// after a restore has replaced its data. Renames and migrations are done
+// in tx, so no other writer sees the restored data with the old schema.
+func (tx *Tx) reloadSchema(opts Options) error {
+	db := tx.db
+	db.schemaMux.Lock()
+	db.schemaRegs = map[uint32]*TypeRegistry{}
+	db.schemaMux.Unlock()
+	assigned, err := tx.typeKeys()
+	if err != nil {
+		return err
+	}
+	if err := db.reg.useTypeKeys(assigned, tx.renames()); err != nil {
+		return err
+	}
+	for old, name := range opts.Renames {
+		mi, err := db.reg.messageInfo(name)
+		if err != nil {
+			return err
+		}
+		from, ok := assigned[old]
+		if !ok {
+			from = TypeKeyOf(old)
+		}
+		n, _, err := tx.renameBatch(old, from, mi, 0)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			log.Printf("renamed %d objects from %s to %s", n, old, name)
+		}
+	}
+	if assigned, err = tx.typeKeys(); err != nil {
+		return err
+	}
+	if err := db.reg.useTypeKeys(assigned, tx.renames()); err != nil {
+		return err
+	}
+	stored, _, err := tx.loadSchema()
+	if errors.Is(err, ErrNotFound) {
+		return tx.storeSchema(db.reg.copyFiles())
+	} else if err != nil {
+		return err
+	}
+
+	hasObjects := func(tk TypeKey) bool {
+		k, _ := tx.st.Cursor(bucket_obj, tk[:]).First()
+		return k != nil
+	}
+	merged, changes, err := db.checkSchema(stored, assigned, hasObjects)
+	if err != nil {
+		return err
+	}
+	files, err := protodesc.NewFiles(merged)
+	if err != nil {
+		return err
+	}
+	if err := db.reg.useFiles(files); err != nil {
+		return err
+	}
+	migrate := false
+	for _, c := range changes {
+		migrate = migrate || c.Kind == TypeVersionChanged
+	}
+	if migrate && opts.MigrateEager {
+		total := 0
+		for _, mi := range db.reg.messageInfos() {
+			if mi.Version == 0 {
+				continue
+			}
+			for after := mi.TypeKey[:]; after != nil; {
+				var n int
+				if n, after, err = tx.migrateBatch(mi, after, defaultMigrateBatchSize); err != nil {
+					return err
+				}
+				total += n
+			}
+		}
+		log.Printf("migrated %d objects", total)
+	}
+	return tx.storeSchema(db.reg.copyFiles())
+}
+
+// checkSchema compares a stored schema with the runtime schema merged
+// into it. assigned are the stored TypeKeys. A removed type is only
+// incompatible if it has objects and its key is not taken over by a
+// registered type. A *SchemaError is returned for incompatible changes.
+func (db *DB) checkSchema(stored *descriptorpb.FileDescriptorSet, assigned map[string]TypeKey, hasObjects func(tk TypeKey) bool) (*descriptorpb.FileDescriptorSet, []SchemaChange, error) {
+	merged := mergeSchema(db.reg.GetFileDescriptorSet(), stored)
+	changes, err := DiffSchema(stored, merged)
+	if err != nil {
+		return nil, nil, err
+	}
+	incompatible := []SchemaChange{}
+	for k, c := range changes {
+		if c.Kind == TypeRemoved {
+			// A renamed type keeps the key of the removed one
+			tk, ok := assigned[c.Type]
+			if !ok {
+				tk = TypeKeyOf(c.Type)
+			}
+			if _, err := db.reg.GetMessageInfo(tk); err != nil && hasObjects(tk) {
+				changes[k].Incompatible = true
+			}
+		}
+		if changes[k].Incompatible {
+			incompatible = append(incompatible, changes[k])
+		}
+	}
+	if len(incompatible) > 0 {
+		return nil, nil, &SchemaError{Changes: incompatible}
+	}
+	return merged, changes, nil
+}
+
+// hasObjects returns true if objects of the type are stored.
+func (db *DB) hasObjects(tk TypeKey) bool {
+	found := false
+	view(db.st, func(tx StorageTx) error {
+		k, _ := tx.Cursor(bucket_obj, tk[:]).First()
+		found = k != nil
+		return nil
+	})
+	return found
+}
+
+// useFiles replaces the files known to the registry.
+func (r *TypeRegistry) useFiles(files *protoregistry.Files) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.files = files
+	r.clear()
+	return r.refresh()
+}
+
+func (r *TypeRegistry) addMigration(m Migration) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	list := append(r.migrations[m.Type], m)
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	r.migrations[m.Type] = list
+}
+
+// migrate applies the migrations of the type of obj that are newer than
+// the version obj was written with.
+func (r *TypeRegistry) migrate(obj IObject) error {
+	md := obj.GetMetadata()
+	if len(md.GetType()) != 4 {
+		return nil
+	}
+	r.mux.RLock()
+	mi, ok := r.fromTypeKey[TypeKey(md.Type)]
+	var migrations []Migration
+	if ok {
+		migrations = r.migrations[mi.Fullname]
+	}
+	r.mux.RUnlock()
+	if !ok || md.TypeVersion >= mi.Version {
+		return nil
+	}
+	for _, m := range migrations {
+		if m.Version <= md.TypeVersion || m.Version > mi.Version {
+			continue
+		}
+		if err := m.Migrate(obj); err != nil {
+			return fmt.Errorf("migrating %s %s to version %d: %w", mi.Fullname, md.TypeId().Uuid(), m.Version, err)
+		}
+	}
+	md = obj.GetMetadata()
+	md.TypeVersion = mi.Version
+	return setMetadata(obj, md)
+}
+
+// Migrate rewrites the objects that were written with an older version of
+// their type, in transactions of at most batchSize objects. It returns
+// the number of migrated objects. A batchSize of zero or less means 1000.
+func (db *DB) Migrate(batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = defaultMigrateBatchSize
+	}
+	total := 0
+	for _, mi := range db.reg.messageInfos() {
+		if mi.Version == 0 {
+			continue
+		}
+		after := mi.TypeKey[:]
+		for after != nil {
+			var n int
+			err := db.Txn(func(tx *Tx) (err error) {
+				n, after, err = tx.migrateBatch(mi, after, batchSize)
+				return err
+			})
+			if err != nil {
+				return total, err
+			}
+			total += n
+		}
+	}
+	return total, nil
+}
+
+// migrateBatch migrates up to batchSize objects of a type, starting with
+// the key after. It returns the key to continue with, or nil when done.
+func (tx *Tx) migrateBatch(mi MessageInfo, after []byte, batchSize int) (int, []byte, error) {
+	stale := []IObject{}
+	var next []byte
+	c := tx.st.Cursor(bucket_obj, mi.TypeKey[:])
+	for k, v := c.Seek(after); k != nil; k, v = c.Next() {
+		if len(stale) == batchSize {
+			next = bytes.Clone(k)
+			break
+		}
+		obj, err := tx.db.reg.decode(k, v)
+		if err != nil {
+			return 0, nil, err
+		}
+		if obj.GetMetadata().TypeVersion < mi.Version {
+			stale = append(stale, obj)
+		}
+	}
+	for _, prev := range stale {
+		obj := cloneObject(prev)
+		if err := tx.db.reg.migrate(obj); err != nil {
+			return 0, nil, err
+		}
+		if err := tx.store(obj, prev); err != nil {
+			return 0, nil, err
+		}
+	}
+	return len(stale), next, nil
+}
@@ -0,0 +1,253 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"errors"
+	"os"
+	"path"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func findChange(changes []SchemaChange, kind ChangeKind, field string) *SchemaChange {
+	for _, c := range changes {
+		if c.Kind == kind && c.Field == field {
+			return &c
+		}
+	}
+	return nil
+}
+
+func TestDiffSchema(t *testing.T) {
+	r, err := NewTypeRegistry()
+	if err != nil {
+		t.FailNow()
+	}
+	before := r.GetFileDescriptorSet()
+	before.File = append(before.File, CreateTestFileDescriptor())
+	after := proto.Clone(before).(*descriptorpb.FileDescriptorSet)
+	fd := after.File[len(after.File)-1]
+	fields := fd.MessageType[0].Field
+	// metadata, my_int, my_string, timestamp, any
+	fields[1].Type = descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+	fields[2].Name = proto.String("renamed")
+	fields[2].JsonName = proto.String("renamed")
+	fields[3].Name = proto.String("reused")
+	fields[3].JsonName = proto.String("reused")
+	fields[3].Type = descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum()
+	fields[3].TypeName = nil
+	added := proto.Clone(fields[1]).(*descriptorpb.FieldDescriptorProto)
+	added.Name = proto.String("added")
+	added.JsonName = proto.String("added")
+	added.Number = proto.Int32(10)
+	fd.MessageType[0].Field = append(fields[:4], added)
+
+	changes, err := DiffSchema(before, after)
+	if err != nil {
+		t.FailNow()
+	}
+	if c := findChange(changes, FieldTypeChanged, "my_int"); c == nil || !c.Incompatible {
+		t.Fail()
+	}
+	if c := findChange(changes, FieldRenamed, "renamed"); c == nil || c.Incompatible {
+		t.Fail()
+	}
+	if c := findChange(changes, FieldNumberReused, "reused"); c == nil || !c.Incompatible {
+		t.Fail()
+	}
+	if c := findChange(changes, FieldRemoved, "any"); c == nil || c.Incompatible {
+		t.Fail()
+	}
+	if c := findChange(changes, FieldAdded, "added"); c == nil || c.Incompatible {
+		t.Fail()
+	}
+	if len(changes) != 5 {
+		t.Fail()
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	calls := 0
+	setString := func(value string) func(obj IObject) error {
+		return func(obj IObject) error {
+			calls++
+			m := obj.ProtoReflect()
+			m.Set(m.Descriptor().Fields().ByName("my_string"), protoreflect.ValueOfString(value))
+			return nil
+		}
+	}
+	db, err := OpenStorage(NewMemoryStorage(), Options{Migrations: []Migration{
+		{Type: "shdb.test.volatile.TObj2", Version: 2, Migrate: setString("v2")},
+		{Type: "shdb.test.volatile.TObj2", Version: 1, Migrate: setString("v1")},
+	}})
+	if err != nil {
+		t.FailNow()
+	}
+	defer db.Close()
+	tk := addTestType(db, &Shdb_Message_Options{Version: 2})
+
+	// Store an object as if it was written with version 1
+	obj := MustNew[IObject](db, tk)
+	tid := obj.GetMetadata().TypeId()
+	md := obj.GetMetadata()
+	md.TypeVersion = 1
+	setMetadata(obj, md)
+	kvs, _ := Marshal(obj)
+	err = db.Txn(func(tx *Tx) error {
+		return tx.st.Put(bucket_obj, tid.Key(), kvs[0].Value)
+	})
+	if err != nil {
+		t.FailNow()
+	}
+
+	res, err := Get[IObject](db, tid)
+	if err != nil || res.GetMetadata().TypeVersion != 2 || calls != 1 {
+		t.FailNow()
+	}
+	m := res.ProtoReflect()
+	if m.Get(m.Descriptor().Fields().ByName("my_string")).String() != "v2" {
+		t.Fail()
+	}
+
+	if n, err := db.Migrate(0); err != nil || n != 1 {
+		t.Fail()
+	}
+	if n, err := db.Migrate(0); err != nil || n != 0 {
+		t.Fail()
+	}
+	calls = 0
+	if _, err := Get[IObject](db, tid); err != nil || calls != 0 {
+		t.Fail()
+	}
+
+	// New objects are written with the current version
+	if err := Put(db, MustNew[IObject](db, tk)); err != nil {
+		t.FailNow()
+	}
+	if n, _ := db.Migrate(0); n != 0 {
+		t.Fail()
+	}
+}
+
+func TestLazyMigrateIndexes(t *testing.T) {
+	db, err := OpenStorage(NewMemoryStorage(), Options{Migrations: []Migration{
+		{Type: "shdb.test.volatile.TObj2", Version: 2, Migrate: func(obj IObject) error {
+			m := obj.ProtoReflect()
+			m.Set(m.Descriptor().Fields().ByName("my_string"), protoreflect.ValueOfString("new"))
+			return nil
+		}},
+	}})
+	if err != nil {
+		t.FailNow()
+	}
+	defer db.Close()
+	tk := addTestType(db, &Shdb_Message_Options{Version: 2, Indexes: []string{"my_string"}})
+
+	// Store an object indexed as "old" as if it was written with version 1
+	obj := MustNew[IObject](db, tk)
+	tid := obj.GetMetadata().TypeId()
+	m := obj.ProtoReflect()
+	m.Set(m.Descriptor().Fields().ByName("my_string"), protoreflect.ValueOfString("old"))
+	if err := Put(db, obj); err != nil {
+		t.FailNow()
+	}
+	md := obj.GetMetadata()
+	md.TypeVersion = 1
+	setMetadata(obj, md)
+	kvs, _ := Marshal(obj)
+	err = db.Txn(func(tx *Tx) error {
+		return tx.st.Put(bucket_obj, tid.Key(), kvs[0].Value)
+	})
+	if err != nil {
+		t.FailNow()
+	}
+
+	// Writing the lazily migrated object replaces the stored index entry
+	if _, err := Update(db, tid, func(obj IObject) (IObject, error) { return obj, nil }); err != nil {
+		t.FailNow()
+	}
+	if res, err := Lookup[IObject](db, tk, "my_string", "old"); err != nil || len(res) != 0 {
+		t.Fail()
+	}
+	if res, err := Lookup[IObject](db, tk, "my_string", "new"); err != nil || len(res) != 1 {
+		t.Fail()
+	}
+	if _, err := Delete[IObject](db, tid); err != nil {
+		t.FailNow()
+	}
+	err = db.View(func(tx *Tx) error {
+		c := tx.st.Cursor(bucket_index, indexPrefix(tk, "my_string"))
+		if k, _ := c.First(); k != nil {
+			t.Error("index entry left after delete")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fail()
+	}
+}
+
+func TestOpenIncompatibleSchema(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "shdb_test")
+	if err != nil {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+	dbFile := path.Join(tmpDir, "test.db")
+
+	db, err := Open(dbFile, Options{})
+	if err != nil {
+		t.FailNow()
+	}
+	// Store a schema with a type that is not in the runtime schema, and
+	// an object of it.
+	fds := db.TypeRegistry().GetFileDescriptorSet()
+	for _, f := range fds.File {
+		if f.GetName() == File_pb_shdb_v1_test_proto.Path() {
+			gone := proto.Clone(f.MessageType[0]).(*descriptorpb.DescriptorProto)
+			gone.Name = proto.String("TGone")
+			gone.Options = nil
+			f.MessageType = append(f.MessageType, gone)
+		}
+	}
+	files, err := protodesc.NewFiles(fds)
+	if err != nil {
+		t.FailNow()
+	}
+	if err := db.StoreSchema(files); err != nil {
+		t.FailNow()
+	}
+	tid := MustParseTypeId(TypeKeyOf("shdb.v1.TGone"), "5e4f4d2c-8ab1-4b1d-9d43-0b4f1c2b3a10")
+	err = db.Txn(func(tx *Tx) error {
+		return tx.st.Put(bucket_obj, tid.Key(), []byte{})
+	})
+	if err != nil {
+		t.FailNow()
+	}
+	db.Close()
+
+	var se *SchemaError
+	if _, err = Open(dbFile, Options{}); !errors.Is(err, ErrIncompatibleSchema) || !errors.As(err, &se) {
+		t.FailNow()
+	}
+	if len(se.Changes) != 1 || se.Changes[0].Kind != TypeRemoved || se.Changes[0].Type != "shdb.v1.TGone" {
+		t.Fail()
+	}
+}
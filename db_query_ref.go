@@ -21,20 +21,18 @@ import (
 	"log"
 
 	"github.com/google/uuid"
-	"go.etcd.io/bbolt"
 )
 
-func searchRefStream(selector func(ref *ObjRef) bool, doneCh chan struct{}) (ch chan *ObjRef) {
+func (db *DB) searchRefStream(selector func(ref *ObjRef) bool, doneCh chan struct{}) (ch chan *ObjRef) {
 	ch = make(chan *ObjRef, 10)
 	go func() {
 		defer func() {
 			close(ch)
 		}()
 
-		err := db.View(func(tx *bbolt.Tx) error {
-			b := tx.Bucket(bucket_obj)
-			err := b.ForEach(func(k, v []byte) error {
-				ref, err := UnmarshalObjRef(k)
+		err := db.View(func(tx *Tx) error {
+			return tx.forEach(nil, func(kv KeyVal) error {
+				ref, err := UnmarshalObjRef(kv.Key())
 				if err != nil {
 					return err
 				}
@@ -47,7 +45,6 @@ func searchRefStream(selector func(ref *ObjRef) bool, doneCh chan struct{}) (ch
 				}
 				return nil
 			})
-			return err
 		})
 
 		if err != nil {
@@ -62,11 +59,9 @@ type activeSearchRefStream struct {
 	doneCh chan struct{}
 }
 
-var activeSearchRefStreams = map[uuid.UUID]*activeSearchRefStream{}
-
 // SearchRef searches the Ref of objects
 // For paging functionality see `Query` method.
-func SearchRef(ctx context.Context,
+func (db *DB) SearchRef(ctx context.Context,
 	selector func(*ObjRef) bool,
 	pageSize int32,
 	pageToken string) (result []*ObjRef, nextPageToken string, err error) {
@@ -84,15 +79,19 @@ func SearchRef(ctx context.Context,
 			return
 		}
 		doneCh := make(chan struct{})
-		inCh := searchRefStream(selector, doneCh)
+		inCh := db.searchRefStream(selector, doneCh)
 		stream = &activeSearchRefStream{inCh: inCh, doneCh: doneCh}
-		activeSearchRefStreams[streamId] = stream
+		db.streamsMux.Lock()
+		db.activeSearchRefStreams[streamId] = stream
+		db.streamsMux.Unlock()
 	} else {
 		streamId, err = uuid.Parse(pageToken)
 		if err != nil {
 			return
 		}
-		stream, ok = activeSearchRefStreams[streamId]
+		db.streamsMux.Lock()
+		stream, ok = db.activeSearchRefStreams[streamId]
+		db.streamsMux.Unlock()
 		if !ok {
 			return nil, "", ErrSessionInvalid
 		}
@@ -104,19 +103,16 @@ func SearchRef(ctx context.Context,
 		select {
 		case obj, ok := <-stream.inCh:
 			if !ok {
-				close(stream.doneCh)
-				delete(activeStreams, streamId)
+				db.closeStream(streamId, stream.doneCh)
 				return res, "", nil
 			}
 			res = append(res, obj)
 			if errors.Is(err, io.EOF) {
-				close(stream.doneCh)
-				delete(activeStreams, streamId)
+				db.closeStream(streamId, stream.doneCh)
 				return res, "", nil
 			}
 		case <-ctx.Done():
-			close(stream.doneCh)
-			delete(activeStreams, streamId)
+			db.closeStream(streamId, stream.doneCh)
 			return res, "", ErrContextCancelled
 		}
 	}
@@ -16,6 +16,7 @@ package shdb
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
 	"path"
@@ -26,8 +27,8 @@ import (
 func TestQuery(t *testing.T) {
 	count := 10
 	pageSize := 10
-	list, testDir := GenerateTestData(count)
-	defer RemoveTestData(testDir)
+	db, list := GenerateTestData(count)
+	defer RemoveTestData(db)
 
 	var (
 		nextPageToken string = ""
@@ -41,7 +42,7 @@ func TestQuery(t *testing.T) {
 	}
 	list4 := []*TObject{}
 	for {
-		list3, nextPageToken, err = Query(ctx, TObj, identityFn, int32(count/pageSize), nextPageToken)
+		list3, nextPageToken, err = Query(ctx, db, TObj, identityFn, int32(count/pageSize), nextPageToken)
 		if err != nil {
 			t.Fail()
 		}
@@ -59,8 +60,8 @@ func TestQuery(t *testing.T) {
 func TestQueryPageToken(t *testing.T) {
 	count := 100
 	pageSize := 10
-	list, testDir := GenerateTestData(count)
-	defer RemoveTestData(testDir)
+	db, list := GenerateTestData(count)
+	defer RemoveTestData(db)
 
 	var (
 		nextPageToken string = ""
@@ -74,7 +75,7 @@ func TestQueryPageToken(t *testing.T) {
 	}
 	list4 := []*TObject{}
 	for {
-		list3, nextPageToken, err = Query(ctx, TObj, identityFn, int32(count/pageSize), nextPageToken)
+		list3, nextPageToken, err = Query(ctx, db, TObj, identityFn, int32(count/pageSize), nextPageToken)
 		if err != nil {
 			t.Fail()
 		}
@@ -92,8 +93,8 @@ func TestQueryPageToken(t *testing.T) {
 func TestQueryFilter(t *testing.T) {
 	count := 100
 	pageSize := 10
-	list, testDir := GenerateTestData(count)
-	defer RemoveTestData(testDir)
+	db, list := GenerateTestData(count)
+	defer RemoveTestData(db)
 
 	var (
 		nextPageToken string = ""
@@ -107,7 +108,7 @@ func TestQueryFilter(t *testing.T) {
 	}
 	list4 := []*TObject{}
 	for {
-		list3, nextPageToken, err = Query(ctx, TObj, evenFn, int32(count/pageSize), nextPageToken)
+		list3, nextPageToken, err = Query(ctx, db, TObj, evenFn, int32(count/pageSize), nextPageToken)
 		if err != nil {
 			t.Fail()
 		}
@@ -129,30 +130,59 @@ func TestQueryFilter(t *testing.T) {
 	}
 }
 
+func TestQueryWhere(t *testing.T) {
+	db, list := GenerateTestData(10)
+	defer RemoveTestData(db)
+	list[3].Metadata.Labels = []string{"prod"}
+	if err := Put(db, list[3]); err != nil {
+		t.FailNow()
+	}
+	identityFn := func(obj *TObject) (bool, error) {
+		return true, nil
+	}
+	opts := QueryOptions{Where: MustParseExpr("my_int >= 5 || metadata.labels has \"prod\"")}
+	res, next, err := QueryWith(context.Background(), db, TObj, opts, identityFn, 100, "")
+	if err != nil || next != "" || len(res) != 6 {
+		t.FailNow()
+	}
+	for _, obj := range res {
+		if obj.MyInt < 5 && obj.MyInt != 3 {
+			t.Fail()
+		}
+	}
+
+	opts = QueryOptions{Where: MustParseExpr("my_int == \"5\"")}
+	if _, _, err := QueryWith(context.Background(), db, TObj, opts, identityFn, 100, ""); !errors.Is(err, ErrInvalidExpr) {
+		t.Fail()
+	}
+}
+
 func ExampleQuery() {
-	Init(path.Join(os.TempDir(), "example_query.db"))
+	db, err := Init(path.Join(os.TempDir(), "example_query.db"))
+	if err != nil {
+		panic(err)
+	}
 	count := 100
 	pageSize := 10
 
 	list := []*TObject{}
 	for k := 0; k < count; k++ {
-		tObj := MustNew[*TObject](TObj)
+		tObj := MustNew[*TObject](db, TObj)
 		tObj.MyInt = uint64(k)
 		list = append(list, tObj)
 	}
-	if err := Put(list...); err != nil {
+	if err := Put(db, list...); err != nil {
 		panic(err)
 	}
 
 	defer func() {
-		Close()
+		db.Close()
 		os.Remove(path.Join(os.TempDir(), "example_query.db"))
 	}()
 
 	var (
 		nextPageToken string = ""
 		partList      []*TObject
-		err           error
 	)
 	ctx := context.Background()
 
@@ -161,7 +191,7 @@ func ExampleQuery() {
 	}
 	collected := []*TObject{}
 	for {
-		partList, nextPageToken, err = Query(ctx, TObj, selectorFn, int32(count/pageSize), nextPageToken)
+		partList, nextPageToken, err = Query(ctx, db, TObj, selectorFn, int32(count/pageSize), nextPageToken)
 		if err != nil {
 			panic(err)
 		}
@@ -0,0 +1,369 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// The ObjRef fields of a type are found through its descriptor. For
+// every reference held by a stored object bucket_refs has an entry with
+// no value and a key with a memory layout like:
+//
+//	[b0 .. b19]		TypeId of the referenced object
+//	[b20 .. b39]	TypeId of the referencing object
+//	[b40 ..]		Path of the ObjRef field
+//
+// so the referrers of an object are found with a prefix scan.
+
+const objRefFullname = "shdb.v1.ObjRef"
+
+// RefField is an ObjRef field of a type.
+type RefField struct {
+	// Path is the dotted path of the field, for instance "parent".
+	Path string
+	// Repeated is set for repeated ObjRef fields.
+	Repeated bool
+	// Options are the ref options of the field, never nil.
+	Options *Shdb_Ref_Options
+}
+
+// Referrer is an object that references another object.
+type Referrer struct {
+	TypeId TypeId
+	// Field is the path of the ObjRef field holding the reference.
+	Field string
+}
+
+// refFields returns the ObjRef fields of md, including those of nested
+// singular messages. ObjRefs inside repeated messages and maps are not
+// tracked.
+func refFields(md protoreflect.MessageDescriptor) ([]RefField, error) {
+	res := []RefField{}
+	seen := map[protoreflect.FullName]bool{}
+	var walk func(msg protoreflect.MessageDescriptor, prefix string) error
+	walk = func(msg protoreflect.MessageDescriptor, prefix string) error {
+		seen[msg.FullName()] = true
+		defer delete(seen, msg.FullName())
+		fields := msg.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			if fd.Message() == nil || fd.IsMap() {
+				continue
+			}
+			path := prefix + string(fd.Name())
+			name := fd.Message().FullName()
+			if name == objRefFullname {
+				rf := RefField{Path: path, Repeated: fd.IsList(), Options: &Shdb_Ref_Options{}}
+				if proto.HasExtension(fd.Options(), E_ShdbFieldOptions) {
+					ext := proto.GetExtension(fd.Options(), E_ShdbFieldOptions).(*Shdb_Field_Options)
+					if ext.GetRef() != nil {
+						rf.Options = ext.Ref
+					}
+				}
+				if rf.Options.Required && !rf.Repeated && rf.Options.OnDelete == Shdb_Ref_Options_NULLIFY {
+					return fmt.Errorf("%w: required field %s of %s cannot be nullified", ErrInvalidType, path, md.FullName())
+				}
+				res = append(res, rf)
+				continue
+			}
+			if fd.IsList() || seen[name] || strings.HasPrefix(string(name), "google.protobuf.") {
+				continue
+			}
+			if err := walk(fd.Message(), path+"."); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(md, ""); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// refParent returns the message holding the field at path and the field.
+// The message is nil if a message on the way is not set.
+func refParent(m protoreflect.Message, path string) (protoreflect.Message, protoreflect.FieldDescriptor) {
+	names := strings.Split(path, ".")
+	for _, name := range names[:len(names)-1] {
+		fd := m.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if fd == nil || !m.Has(fd) {
+			return nil, nil
+		}
+		m = m.Mutable(fd).Message()
+	}
+	fd := m.Descriptor().Fields().ByName(protoreflect.Name(names[len(names)-1]))
+	if fd == nil {
+		return nil, nil
+	}
+	return m, fd
+}
+
+// refValues returns the ObjRef messages set in the field at path.
+func refValues(m protoreflect.Message, path string) []protoreflect.Message {
+	m, fd := refParent(m, path)
+	if m == nil || !m.Has(fd) {
+		return nil
+	}
+	if fd.IsList() {
+		res := []protoreflect.Message{}
+		l := m.Get(fd).List()
+		for i := 0; i < l.Len(); i++ {
+			res = append(res, l.Get(i).Message())
+		}
+		return res
+	}
+	return []protoreflect.Message{m.Get(fd).Message()}
+}
+
+// refTypeId returns the TypeId an ObjRef message references. It works
+// for both *ObjRef and dynamic messages.
+func refTypeId(ref protoreflect.Message) (TypeId, bool) {
+	fields := ref.Descriptor().Fields()
+	typ := ref.Get(fields.ByName("type")).Bytes()
+	id := ref.Get(fields.ByName("uuid")).Bytes()
+	if len(typ) != 4 || len(id) != 16 {
+		return TypeId{}, false
+	}
+	return *NewTypeId(TypeKey(typ), id), true
+}
+
+func refKey(target, source TypeId, path string) []byte {
+	key := make([]byte, 0, 40+len(path))
+	key = append(key, target.data[:]...)
+	key = append(key, source.data[:]...)
+	return append(key, path...)
+}
+
+// refKeys returns the keys of the reverse index entries of obj. It
+// returns no keys for a nil obj.
+func (tx *Tx) refKeys(obj IObject) map[string]struct{} {
+	if obj == nil {
+		return map[string]struct{}{}
+	}
+	mi, err := tx.db.reg.GetMessageInfo(obj.GetMetadata().TypeId().TypeKey())
+	if err != nil {
+		return map[string]struct{}{}
+	}
+	return refKeysOf(obj, mi)
+}
+
+// refKeysOf returns the keys of the reverse index entries of obj for the
+// ObjRef fields of mi.
+func refKeysOf(obj IObject, mi MessageInfo) map[string]struct{} {
+	keys := map[string]struct{}{}
+	tid := obj.GetMetadata().TypeId()
+	m := obj.ProtoReflect()
+	for _, rf := range mi.Refs {
+		for _, ref := range refValues(m, rf.Path) {
+			if target, ok := refTypeId(ref); ok {
+				keys[string(refKey(target, tid, rf.Path))] = struct{}{}
+			}
+		}
+	}
+	return keys
+}
+
+// updateRefs replaces the reverse index entries of prev with those of
+// obj. Either may be nil.
+func (tx *Tx) updateRefs(obj, prev IObject) error {
+	oldKeys := tx.refKeys(prev)
+	newKeys := tx.refKeys(obj)
+	for k := range oldKeys {
+		if _, ok := newKeys[k]; !ok {
+			if err := tx.st.Delete(bucket_refs, []byte(k)); err != nil {
+				return err
+			}
+		}
+	}
+	for k := range newKeys {
+		if _, ok := oldKeys[k]; !ok {
+			if err := tx.st.Put(bucket_refs, []byte(k), []byte{}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkRefs returns a RefError if a required ObjRef field of obj is not
+// set or references an object that does not exist. An object may
+// reference itself.
+func (tx *Tx) checkRefs(obj IObject) error {
+	tid := obj.GetMetadata().TypeId()
+	mi, err := tx.db.reg.GetMessageInfo(tid.TypeKey())
+	if err != nil {
+		return nil
+	}
+	m := obj.ProtoReflect()
+	for _, rf := range mi.Refs {
+		if !rf.Options.Required {
+			continue
+		}
+		refs := refValues(m, rf.Path)
+		if len(refs) == 0 && !rf.Repeated {
+			return &RefError{TypeId: tid, Field: rf.Path, err: ErrDanglingRef}
+		}
+		for _, ref := range refs {
+			target, ok := refTypeId(ref)
+			if !ok {
+				return &RefError{TypeId: tid, Field: rf.Path, err: ErrDanglingRef}
+			}
+			if target == tid {
+				continue
+			}
+			if _, err := tx.getKV(target); errors.Is(err, ErrNotFound) {
+				return &RefError{TypeId: tid, Field: rf.Path, Ref: target, err: ErrDanglingRef}
+			} else if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// referrers returns the objects that reference tid, ordered by TypeId.
+func (tx *Tx) referrers(tid TypeId) ([]Referrer, error) {
+	res := []Referrer{}
+	c := tx.st.Cursor(bucket_refs, tid.Key())
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		if len(k) < 40 {
+			return nil, ErrDatabaseCorrupt
+		}
+		src := *MarshalTypeId(k[20:40])
+		if tx.expired(src) {
+			continue
+		}
+		res = append(res, Referrer{TypeId: src, Field: string(k[40:])})
+	}
+	return res, nil
+}
+
+// Referrers returns the objects that reference tid through an ObjRef
+// field. An object referencing tid through several fields is returned
+// once per field.
+func Referrers(h Handle, tid TypeId) (res []Referrer, err error) {
+	err = h.read(func(tx *Tx) error {
+		res, err = tx.referrers(tid)
+		return err
+	})
+	return
+}
+
+// onDelete returns what happens to the referrer r when the object it
+// references is deleted.
+func (tx *Tx) onDelete(r Referrer) Shdb_Ref_Options_OnDelete {
+	mi, err := tx.db.reg.GetMessageInfo(r.TypeId.TypeKey())
+	if err != nil {
+		return Shdb_Ref_Options_NONE
+	}
+	for _, rf := range mi.Refs {
+		if rf.Path == r.Field {
+			return rf.Options.OnDelete
+		}
+	}
+	return Shdb_Ref_Options_NONE
+}
+
+// deletableRefs returns the referrers of tid before it is deleted, or a
+// RefError if one of them restricts the delete.
+func (tx *Tx) deletableRefs(tid TypeId) ([]Referrer, error) {
+	refs, err := tx.referrers(tid)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range refs {
+		if r.TypeId != tid && tx.onDelete(r) == Shdb_Ref_Options_RESTRICT {
+			return nil, &RefError{TypeId: r.TypeId, Field: r.Field, Ref: tid, err: ErrReferenced}
+		}
+	}
+	return refs, nil
+}
+
+// deleteRefs applies the cascade and nullify options of the referrers
+// of the deleted object tid.
+func (tx *Tx) deleteRefs(tid TypeId, refs []Referrer) error {
+	for _, r := range refs {
+		action := tx.onDelete(r)
+		if action != Shdb_Ref_Options_CASCADE && action != Shdb_Ref_Options_NULLIFY {
+			continue
+		}
+		// The referrer may already be gone through an earlier cascade.
+		src, err := tx.load(r.TypeId)
+		if err != nil {
+			return err
+		}
+		if src == nil {
+			continue
+		}
+		if action == Shdb_Ref_Options_CASCADE {
+			if err := tx.delete(src); err != nil {
+				return err
+			}
+			continue
+		}
+		next := cloneObject(src)
+		m, fd := refParent(next.ProtoReflect(), r.Field)
+		if m == nil || !m.Has(fd) {
+			continue
+		}
+		if fd.IsList() {
+			l := m.Get(fd).List()
+			kept := m.NewField(fd).List()
+			for i := 0; i < l.Len(); i++ {
+				if target, ok := refTypeId(l.Get(i).Message()); !ok || target != tid {
+					kept.Append(l.Get(i))
+				}
+			}
+			m.Set(fd, protoreflect.ValueOfList(kept))
+		} else {
+			m.Clear(fd)
+		}
+		if err := tx.store(next, src); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reindexRefs rebuilds the reverse index entries of the objects of a
+// type.
+func (tx *Tx) reindexRefs(tk TypeKey, objs []IObject) error {
+	stale := [][]byte{}
+	c := tx.st.Cursor(bucket_refs, nil)
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		if len(k) >= 40 && bytes.Equal(k[20:24], tk[:]) {
+			stale = append(stale, bytes.Clone(k))
+		}
+	}
+	for _, k := range stale {
+		if err := tx.st.Delete(bucket_refs, k); err != nil {
+			return err
+		}
+	}
+	for _, obj := range objs {
+		if err := tx.updateRefs(obj, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
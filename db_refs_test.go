@@ -0,0 +1,158 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"errors"
+	"testing"
+)
+
+var TNodeKey = TypeKeyOf("shdb.v1.TNode")
+
+func newTestNode(db *DB, name string, owner IObject) *TNode {
+	node := MustNew[*TNode](db, TNodeKey)
+	node.Name = name
+	if owner != nil {
+		node.Owner = owner.GetMetadata().Ref()
+	}
+	return node
+}
+
+func TestRequiredRef(t *testing.T) {
+	db, list := GenerateTestData(2)
+	defer RemoveTestData(db)
+
+	node := newTestNode(db, "a", nil)
+	if err := Put(db, node); !errors.Is(err, ErrDanglingRef) {
+		t.Fail()
+	}
+	node.Owner = MustNew[*TObject](db, TObj).Metadata.Ref()
+	if err := Put(db, node); !errors.Is(err, ErrDanglingRef) {
+		t.Fail()
+	}
+	node.Owner = list[0].Metadata.Ref()
+	if err := Put(db, node); err != nil {
+		t.FailNow()
+	}
+
+	refs, err := Referrers(db, list[0].Metadata.TypeId())
+	if err != nil || len(refs) != 1 || refs[0].TypeId != node.Metadata.TypeId() || refs[0].Field != "owner" {
+		t.Fail()
+	}
+
+	// Moving the reference updates the reverse index
+	if _, err := Update(db, node.Metadata.TypeId(), func(n *TNode) (*TNode, error) {
+		n.Owner = list[1].Metadata.Ref()
+		return n, nil
+	}); err != nil {
+		t.FailNow()
+	}
+	if refs, _ := Referrers(db, list[0].Metadata.TypeId()); len(refs) != 0 {
+		t.Fail()
+	}
+	if refs, _ := Referrers(db, list[1].Metadata.TypeId()); len(refs) != 1 {
+		t.Fail()
+	}
+
+	// The owner is restricted from being deleted
+	var re *RefError
+	if _, err := Delete[*TObject](db, list[1].Metadata.TypeId()); !errors.Is(err, ErrReferenced) || !errors.As(err, &re) {
+		t.FailNow()
+	}
+	if re.Field != "owner" || re.Ref != list[1].Metadata.TypeId() {
+		t.Fail()
+	}
+	if _, err := Delete[*TNode](db, node.Metadata.TypeId()); err != nil {
+		t.FailNow()
+	}
+	if _, err := Delete[*TObject](db, list[1].Metadata.TypeId()); err != nil {
+		t.Fail()
+	}
+}
+
+func TestCascadeRef(t *testing.T) {
+	db, list := GenerateTestData(1)
+	defer RemoveTestData(db)
+
+	root := newTestNode(db, "root", list[0])
+	child := newTestNode(db, "child", list[0])
+	child.Parent = root.Metadata.Ref()
+	grandchild := newTestNode(db, "grandchild", list[0])
+	grandchild.Parent = child.Metadata.Ref()
+	// A cycle back to the root
+	root.Parent = grandchild.Metadata.Ref()
+	if err := Put(db, root, child, grandchild); err != nil {
+		t.FailNow()
+	}
+
+	if _, err := Delete[*TNode](db, child.Metadata.TypeId()); err != nil {
+		t.FailNow()
+	}
+	if res, _ := GetAll[*TNode](db, TNodeKey); len(res) != 0 {
+		t.Fail()
+	}
+	if refs, _ := Referrers(db, list[0].Metadata.TypeId()); len(refs) != 0 {
+		t.Fail()
+	}
+}
+
+func TestNullifyRef(t *testing.T) {
+	db, list := GenerateTestData(1)
+	defer RemoveTestData(db)
+
+	a := newTestNode(db, "a", list[0])
+	b := newTestNode(db, "b", list[0])
+	c := newTestNode(db, "c", list[0])
+	a.Links = []*ObjRef{b.Metadata.Ref(), c.Metadata.Ref(), b.Metadata.Ref()}
+	if err := Put(db, b, c, a); err != nil {
+		t.FailNow()
+	}
+	if refs, _ := Referrers(db, b.Metadata.TypeId()); len(refs) != 1 || refs[0].Field != "links" {
+		t.Fail()
+	}
+
+	if _, err := Delete[*TNode](db, b.Metadata.TypeId()); err != nil {
+		t.FailNow()
+	}
+	a2, err := Get[*TNode](db, a.Metadata.TypeId())
+	if err != nil || len(a2.Links) != 1 || !a2.Links[0].Equal(c.Metadata.Ref()) {
+		t.Fail()
+	}
+	if refs, _ := Referrers(db, b.Metadata.TypeId()); len(refs) != 0 {
+		t.Fail()
+	}
+}
+
+func TestReindexRefs(t *testing.T) {
+	db, list := GenerateTestData(1)
+	defer RemoveTestData(db)
+
+	node := newTestNode(db, "a", list[0])
+	if err := Put(db, node); err != nil {
+		t.FailNow()
+	}
+	err := db.Txn(func(tx *Tx) error {
+		return tx.st.Delete(bucket_refs, refKey(list[0].Metadata.TypeId(), node.Metadata.TypeId(), "owner"))
+	})
+	if err != nil {
+		t.FailNow()
+	}
+	if err := db.Reindex(TNodeKey); err != nil {
+		t.FailNow()
+	}
+	if refs, _ := Referrers(db, list[0].Metadata.TypeId()); len(refs) != 1 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"bytes"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// A type is renamed by moving its objects, tombstones and history from
+// the TypeKey of the old fullname to the TypeKey of the new type. When
+// all of them are moved the rename is recorded in bucket_renames and the
+// old fullname is kept in bucket_types with the new key. The old type is
+// then left out of the registry and its fullname becomes an alias of the
+// new type.
+
+// RenameType moves the objects of the type oldFullname to the registered
+// type newFullname and rewrites their Metadata.Type, indexes, references
+// and history. References from other objects are updated. The objects
+// are moved in transactions of at most batchSize objects, or in a single
+// transaction if batchSize is not positive. An interrupted rename is
+// resumed by calling RenameType again. It returns the number of moved
+// objects, tombstones included.
+func (db *DB) RenameType(oldFullname, newFullname string, batchSize int) (int, error) {
+	if oldFullname == newFullname {
+		return 0, fmt.Errorf("%w: %s is renamed to itself", ErrInvalidType, oldFullname)
+	}
+	mi, err := db.reg.messageInfo(newFullname)
+	if err != nil {
+		return 0, err
+	}
+	assigned, err := db.loadTypeKeys()
+	if err != nil {
+		return 0, err
+	}
+	from, ok := assigned[oldFullname]
+	if !ok {
+		from = TypeKeyOf(oldFullname)
+	}
+	total := 0
+	for done := false; !done; {
+		err := db.Txn(func(tx *Tx) error {
+			var (
+				n   int
+				err error
+			)
+			n, done, err = tx.renameBatch(oldFullname, from, mi, batchSize)
+			total += n
+			return err
+		})
+		if err != nil {
+			return total, err
+		}
+	}
+	if assigned, err = db.loadTypeKeys(); err != nil {
+		return total, err
+	}
+	renamed, err := db.loadRenames()
+	if err != nil {
+		return total, err
+	}
+	return total, db.reg.useTypeKeys(assigned, renamed)
+}
+
+// renameBatch moves at most limit objects, tombstones and history
+// entries from the TypeKey from to the type mi, or all of them if limit
+// is not positive. The rename is recorded when nothing is left to move.
+func (tx *Tx) renameBatch(oldFullname string, from TypeKey, mi MessageInfo, limit int) (n int, done bool, err error) {
+	if from != mi.TypeKey {
+		if n, err = tx.renameObjects(from, mi, limit); err != nil || (limit > 0 && n == limit) {
+			return n, false, err
+		}
+		m, err := tx.renameTombstones(from, mi, limit-n)
+		n += m
+		if err != nil || (limit > 0 && n == limit) {
+			return n, false, err
+		}
+		h, err := tx.renameHistory(from, mi, limit-n)
+		if err != nil || (limit > 0 && h == limit-n) {
+			return n, false, err
+		}
+	}
+	if err := tx.st.Put(bucket_renames, []byte(oldFullname), []byte(mi.Fullname)); err != nil {
+		return n, false, err
+	}
+	for _, name := range []string{oldFullname, mi.Fullname} {
+		if err := tx.st.Put(bucket_types, []byte(name), bytes.Clone(mi.TypeKey[:])); err != nil {
+			return n, false, err
+		}
+	}
+	return n, true, nil
+}
+
+// renameCursor returns up to limit keys and values from a bucket with
+// the prefix of the TypeKey from, or all of them if limit is not
+// positive.
+func (tx *Tx) renameCursor(bucket []byte, from TypeKey, limit int) ([][]byte, [][]byte) {
+	keys, values := [][]byte{}, [][]byte{}
+	c := tx.st.Cursor(bucket, from[:])
+	for k, v := c.First(); k != nil && (limit <= 0 || len(keys) < limit); k, v = c.Next() {
+		keys = append(keys, bytes.Clone(k))
+		values = append(values, bytes.Clone(v))
+	}
+	return keys, values
+}
+
+// renameObjects moves stored objects from the TypeKey from to the type
+// mi.
+func (tx *Tx) renameObjects(from TypeKey, mi MessageInfo, limit int) (int, error) {
+	// The entries of the old objects are found with the old type if it
+	// is still registered.
+	old, err := tx.db.reg.GetMessageInfo(from)
+	if err != nil {
+		old = mi
+	}
+	keys, values := tx.renameCursor(bucket_obj, from, limit)
+	for i, key := range keys {
+		prev := old.newObject()
+		if err := proto.Unmarshal(values[i], prev); err != nil {
+			return 0, err
+		}
+		obj, err := rekey(values[i], from, mi)
+		if err != nil {
+			return 0, err
+		}
+		rev, err := tx.nextRevision()
+		if err != nil {
+			return 0, err
+		}
+		md := obj.GetMetadata()
+		md.Revision = rev
+		md.UpdatedAt = timestamppb.Now()
+		if err := setMetadata(obj, md); err != nil {
+			return 0, err
+		}
+
+		idx, err := indexKeysOf(prev, old)
+		if err != nil {
+			return 0, err
+		}
+		for k := range idx {
+			if err := tx.st.Delete(bucket_index, []byte(k)); err != nil {
+				return 0, err
+			}
+		}
+		for k := range refKeysOf(prev, old) {
+			if err := tx.st.Delete(bucket_refs, []byte(k)); err != nil {
+				return 0, err
+			}
+		}
+		if err := tx.updateExpiry(nil, prev); err != nil {
+			return 0, err
+		}
+		if err := tx.st.Delete(bucket_obj, key); err != nil {
+			return 0, err
+		}
+
+		kvs, err := Marshal(obj)
+		if err != nil {
+			return 0, err
+		}
+		if err := tx.st.Put(bucket_obj, kvs[0].Key(), kvs[0].Value); err != nil {
+			return 0, err
+		}
+		if err := tx.updateIndexes(obj, nil); err != nil {
+			return 0, err
+		}
+		if err := tx.updateRefs(obj, nil); err != nil {
+			return 0, err
+		}
+		if err := tx.updateExpiry(obj, nil); err != nil {
+			return 0, err
+		}
+		if err := tx.retargetReferrers(prev.GetMetadata().TypeId(), obj.GetMetadata().TypeId()); err != nil {
+			return 0, err
+		}
+		tx.notifyDelete(prev)
+		tx.notifyCreate(obj)
+	}
+	return len(keys), nil
+}
+
+// retargetReferrers points the references to the object from at the
+// object to. Objects of the type being renamed are skipped since their
+// references are rewritten when they are moved.
+func (tx *Tx) retargetReferrers(from, to TypeId) error {
+	refs, err := tx.referrers(from)
+	if err != nil {
+		return err
+	}
+	for _, r := range refs {
+		if r.TypeId.TypeKey() == from.TypeKey() {
+			continue
+		}
+		src, err := tx.load(r.TypeId)
+		if err != nil {
+			return err
+		}
+		mi, err := tx.db.reg.GetMessageInfo(r.TypeId.TypeKey())
+		if src == nil || err != nil {
+			continue
+		}
+		next := cloneObject(src)
+		if !retarget(next, mi, to.TypeKey(), func(tid TypeId) bool { return tid == from }) {
+			continue
+		}
+		if err := tx.store(next, src); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renameTombstones moves soft deleted objects from the TypeKey from to
+// the type mi.
+func (tx *Tx) renameTombstones(from TypeKey, mi MessageInfo, limit int) (int, error) {
+	keys, values := tx.renameCursor(bucket_deleted, from, limit)
+	for i, key := range keys {
+		obj, err := rekey(values[i], from, mi)
+		if err != nil {
+			return 0, err
+		}
+		kvs, err := Marshal(obj)
+		if err != nil {
+			return 0, err
+		}
+		if err := tx.st.Put(bucket_deleted, kvs[0].Key(), kvs[0].Value); err != nil {
+			return 0, err
+		}
+		if err := tx.st.Delete(bucket_deleted, key); err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), nil
+}
+
+// renameHistory moves history entries from the TypeKey from to the type
+// mi.
+func (tx *Tx) renameHistory(from TypeKey, mi MessageInfo, limit int) (int, error) {
+	keys, values := tx.renameCursor(bucket_history, from, limit)
+	for i, key := range keys {
+		entry := &HistoryEntry{}
+		if err := proto.Unmarshal(values[i], entry); err != nil {
+			return 0, err
+		}
+		obj, err := rekey(entry.Item.GetValue(), from, mi)
+		if err != nil {
+			return 0, err
+		}
+		kvs, err := Marshal(obj)
+		if err != nil {
+			return 0, err
+		}
+		entry.Item = &BinaryObject{Key: kvs[0].Key(), Value: kvs[0].Value}
+		data, err := proto.Marshal(entry)
+		if err != nil {
+			return 0, err
+		}
+		if err := tx.st.Put(bucket_history, historyKey(kvs[0].TypeId, entry.Revision), data); err != nil {
+			return 0, err
+		}
+		if err := tx.st.Delete(bucket_history, key); err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), nil
+}
+
+// rekey decodes value as an object of the type mi and moves it, and its
+// references to objects of the TypeKey from, to the TypeKey of mi.
+func rekey(value []byte, from TypeKey, mi MessageInfo) (IObject, error) {
+	obj := mi.newObject()
+	if err := proto.Unmarshal(value, obj); err != nil {
+		return nil, err
+	}
+	md := obj.GetMetadata()
+	md.Type = bytes.Clone(mi.TypeKey[:])
+	if err := setMetadata(obj, md); err != nil {
+		return nil, err
+	}
+	retarget(obj, mi, mi.TypeKey, func(tid TypeId) bool { return tid.TypeKey() == from })
+	return obj, nil
+}
+
+// retarget sets the TypeKey of the ObjRef fields of obj that reference
+// an object matched by fn to to. It returns true if a field was changed.
+func retarget(obj IObject, mi MessageInfo, to TypeKey, fn func(tid TypeId) bool) bool {
+	changed := false
+	for _, f := range mi.Refs {
+		for _, ref := range refValues(obj.ProtoReflect(), f.Path) {
+			if tid, ok := refTypeId(ref); ok && fn(tid) {
+				ref.Set(ref.Descriptor().Fields().ByName("type"), protoreflect.ValueOfBytes(bytes.Clone(to[:])))
+				changed = true
+			}
+		}
+	}
+	return changed
+}
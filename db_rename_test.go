@@ -0,0 +1,107 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestRenameType(t *testing.T) {
+	for _, batchSize := range []int{0, 2} {
+		db := CreateTestDb()
+		fd := testFileWithTypes([]string{"Old", "New"}, []*Shdb_Message_Options{
+			{Indexes: []string{"my_string"}, SoftDelete: &Shdb_Soft_Delete_Options{}, History: &Shdb_History_Options{}},
+			{Indexes: []string{"my_string"}, SoftDelete: &Shdb_Soft_Delete_Options{}, History: &Shdb_History_Options{}},
+		})
+		if err := db.TypeRegistry().AddFileFromProtoFileDescriptor(fd); err != nil {
+			t.FailNow()
+		}
+		oldKey := TypeKeyOf("shdb.test.volatile.Old")
+		newKey := TypeKeyOf("shdb.test.volatile.New")
+
+		objs := []IObject{}
+		for _, s := range []string{"a", "b", "c", "d"} {
+			obj := MustNew[IObject](db, oldKey)
+			m := obj.ProtoReflect()
+			m.Set(m.Descriptor().Fields().ByName("my_string"), protoreflect.ValueOfString(s))
+			objs = append(objs, obj)
+		}
+		if err := Put(db, objs...); err != nil {
+			t.FailNow()
+		}
+		if err := Put(db, objs[0]); err != nil {
+			t.FailNow()
+		}
+		if _, err := Delete[IObject](db, objs[3].GetMetadata().TypeId()); err != nil {
+			t.FailNow()
+		}
+		node := newTestNode(db, "n", objs[1])
+		if err := Put(db, node); err != nil {
+			t.FailNow()
+		}
+
+		n, err := db.RenameType("shdb.test.volatile.Old", "shdb.test.volatile.New", batchSize)
+		if err != nil || n != 4 {
+			t.FailNow()
+		}
+		if res, _ := GetAll[IObject](db, oldKey); len(res) != 0 {
+			t.Fail()
+		}
+		if res, _ := GetAll[IObject](db, newKey); len(res) != 3 {
+			t.Fail()
+		}
+		res, err := Lookup[IObject](db, newKey, "my_string", "b")
+		if err != nil || len(res) != 1 {
+			t.FailNow()
+		}
+		tid := res[0].GetMetadata().TypeId()
+		if tid.TypeKey() != newKey || tid.Uuid() != objs[1].GetMetadata().TypeId().Uuid() {
+			t.Fail()
+		}
+
+		// References follow the renamed object
+		node, err = Get[*TNode](db, node.Metadata.TypeId())
+		if err != nil || *node.Owner.TypeId() != tid {
+			t.Fail()
+		}
+		if refs, _ := Referrers(db, tid); len(refs) != 1 {
+			t.Fail()
+		}
+		if _, err := Delete[IObject](db, tid); !errors.Is(err, ErrReferenced) {
+			t.Fail()
+		}
+
+		// History and tombstones are moved along
+		first := NewTypeId(newKey, objs[0].GetMetadata().Uuid)
+		if versions, _ := History(db, *first); len(versions) != 2 {
+			t.Fail()
+		}
+		if _, err := GetDeleted[IObject](db, *NewTypeId(newKey, objs[3].GetMetadata().Uuid)); err != nil {
+			t.Fail()
+		}
+
+		// The old name is an alias of the new type
+		if tk, err := db.TypeRegistry().GetTypeKeyFromToA("shdb.test.volatile.Old"); err != nil || tk != newKey {
+			t.Fail()
+		}
+		if n, err := db.RenameType("shdb.test.volatile.Old", "shdb.test.volatile.New", batchSize); err != nil || n != 0 {
+			t.Fail()
+		}
+		CloseTestDb(db)
+	}
+}
@@ -0,0 +1,339 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"log"
+	"os"
+	"path"
+	"time"
+
+	"go.etcd.io/bbolt"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// boltMagic is found at offset 16 of a bbolt database file.
+const boltMagic = 0xED0CDAED
+
+// RestoreOptions controls how a backup is restored.
+type RestoreOptions struct {
+	// Until is the point in time to recover to. The changes of an
+	// incremental backup made after Until are skipped, so its objects are
+	// restored as they were at Until. A zero Until applies all.
+	Until time.Time
+}
+
+// Restore loads a backup made by Backup, gzip compressed or not, and
+// returns the number of restored objects. A full backup replaces all
+// data in the database. An incremental backup is applied on top of it,
+// so restoring a full backup followed by its incremental backups, in
+// order and with Until set, recovers the database to that point in time.
+// The schema of the backup must be compatible with the runtime schema.
+// A full restore renames and migrates types as set by the Options the
+// database was opened with, in the same transaction as the data.
+// Watchers are not notified of a full restore. Instead all watchers end
+// with ErrRestored, and the changelog is emptied without reusing
+// sequence numbers or revisions, so subscriptions end with ErrRestored
+// or ErrChangelogTruncated, and resuming from a change before the
+// restore fails with ErrChangelogTruncated.
+func (db *DB) Restore(r io.Reader, opts RestoreOptions) (int, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return 0, err
+		}
+		defer zr.Close()
+		br = bufio.NewReader(zr)
+	}
+	if head, _ := br.Peek(20); len(head) == 20 &&
+		(binary.LittleEndian.Uint32(head[16:]) == boltMagic || binary.BigEndian.Uint32(head[16:]) == boltMagic) {
+		return db.restoreSnapshot(br)
+	}
+	return db.restoreChanges(br, opts)
+}
+
+// restoreSnapshot replaces the data in the database with a bbolt
+// database file read from r.
+func (db *DB) restoreSnapshot(r io.Reader) (int, error) {
+	dir, err := os.MkdirTemp("", "shdb")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(dir)
+	f, err := os.Create(path.Join(dir, "snapshot.db"))
+	if err != nil {
+		return 0, err
+	}
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, err
+	}
+	bdb, err := bbolt.Open(f.Name(), 0600, &bbolt.Options{ReadOnly: true})
+	if err != nil {
+		return 0, err
+	}
+	defer bdb.Close()
+	snap := &BoltStorage{db: bdb}
+
+	n := 0
+	reloaded := false
+	err = view(snap, func(stx StorageTx) error {
+		src := &Tx{db: db, st: stx}
+		if err := db.checkSnapshot(src); err != nil {
+			return err
+		}
+		return db.Txn(func(tx *Tx) error {
+			rev, seq := tx.Revision(), tx.LastSeq()
+			if r := src.Revision(); r > rev {
+				rev = r
+			}
+			if s := src.LastSeq(); s > seq {
+				seq = s
+			}
+			for _, b := range buckets {
+				keys := [][]byte{}
+				c := tx.st.Cursor(b, nil)
+				for k, _ := c.First(); k != nil; k, _ = c.Next() {
+					keys = append(keys, bytes.Clone(k))
+				}
+				for _, k := range keys {
+					if err := tx.st.Delete(b, k); err != nil {
+						return err
+					}
+				}
+				if bytes.Equal(b, bucket_changelog) {
+					continue
+				}
+				c = src.st.Cursor(b, nil)
+				for k, v := c.First(); k != nil; k, v = c.Next() {
+					if err := tx.st.Put(b, bytes.Clone(k), bytes.Clone(v)); err != nil {
+						return err
+					}
+					if bytes.Equal(b, bucket_obj) {
+						n++
+					}
+				}
+			}
+			// The changes of the snapshot are not those of the database,
+			// so the changelog starts over after a gap that makes every
+			// earlier sequence number truncated.
+			if err := tx.st.Put(bucket_meta, revisionKey, binary.BigEndian.AppendUint64(nil, rev)); err != nil {
+				return err
+			}
+			if err := tx.st.Put(bucket_meta, changelogSeqKey, encodeSeq(seq+1)); err != nil {
+				return err
+			}
+			if err := tx.st.Put(bucket_meta, changelogStartKey, encodeSeq(seq+2)); err != nil {
+				return err
+			}
+			tx.rev = rev
+			tx.restored = true
+			// The restored database has its own schema versions and
+			// type keys
+			reloaded = true
+			return tx.reloadSchema(db.opts)
+		})
+	})
+	if err != nil && reloaded {
+		// The registry was changed by the restore that failed
+		if rerr := db.openSchema(Options{ReadOnly: true}); rerr != nil {
+			log.Printf("reloading schema failed, err=[%v]\n", rerr)
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// checkSnapshot returns a *SchemaError if the schema of the snapshot read
+// by src is not compatible with the runtime schema.
+func (db *DB) checkSnapshot(src *Tx) error {
+	stored, _, err := src.loadSchema()
+	if err != nil {
+		return err
+	}
+	assigned, err := src.typeKeys()
+	if err != nil {
+		return err
+	}
+	// Types renamed when the database was opened are renamed again once
+	// restored
+	for old, name := range db.opts.Renames {
+		if mi, err := db.reg.messageInfo(name); err == nil {
+			assigned[old] = mi.TypeKey
+		}
+	}
+	_, _, err = db.checkSchema(stored, assigned, func(tk TypeKey) bool {
+		k, _ := src.st.Cursor(bucket_obj, tk[:]).First()
+		return k != nil
+	})
+	return err
+}
+
+// restoreChanges applies the records of an incremental backup in a
+// single transaction.
+func (db *DB) restoreChanges(r *bufio.Reader, opts RestoreOptions) (int, error) {
+	header := &BackupRecord{}
+	if err := protodelim.UnmarshalFrom(r, header); err != nil {
+		return 0, err
+	}
+	if header.GetHeader() == nil {
+		return 0, ErrDatabaseCorrupt
+	}
+	if schema := header.GetHeader().Schema; schema != nil {
+		// Objects of unknown types fail when they are decoded
+		noObjects := func(TypeKey) bool { return false }
+		if _, _, err := db.checkSchema(schema, db.reg.TypeKeys(), noObjects); err != nil {
+			return 0, err
+		}
+	}
+	// until returns true if the change of rec was made by Until. Records
+	// without the time of their change use t.
+	until := func(rec *BackupRecord, t *timestamppb.Timestamp) bool {
+		if rec.Time != nil {
+			t = rec.Time
+		}
+		return opts.Until.IsZero() || !t.AsTime().After(opts.Until)
+	}
+	n := 0
+	err := db.Txn(func(tx *Tx) error {
+		for {
+			rec := &BackupRecord{}
+			if err := protodelim.UnmarshalFrom(r, rec); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			switch {
+			case rec.GetObject() != nil:
+				obj, err := tx.db.reg.decode(rec.GetObject().Key, rec.GetObject().Value)
+				if err != nil {
+					return err
+				}
+				if !until(rec, obj.GetMetadata().GetUpdatedAt()) {
+					continue
+				}
+				if err := tx.restoreObject(obj, rec.GetObject().Value); err != nil {
+					return err
+				}
+			case rec.GetTombstone() != nil:
+				obj, err := tx.db.reg.decode(rec.GetTombstone().Key, rec.GetTombstone().Value)
+				if err != nil {
+					return err
+				}
+				if !until(rec, obj.GetMetadata().GetDeletedAt()) {
+					continue
+				}
+				if err := tx.restoreDeleted(obj.GetMetadata().TypeId(), obj.GetMetadata().Revision); err != nil {
+					return err
+				}
+				tid := obj.GetMetadata().TypeId()
+				if err := tx.st.Put(bucket_deleted, tid.Key(), bytes.Clone(rec.GetTombstone().Value)); err != nil {
+					return err
+				}
+			case rec.GetDeleted() != nil:
+				del := rec.GetDeleted()
+				if len(del.Key) != len(TypeId{}.data) || !until(rec, del.GetDeletedAt()) {
+					continue
+				}
+				if err := tx.restoreDeleted(*MarshalTypeId(del.Key), del.Revision); err != nil {
+					return err
+				}
+			default:
+				continue
+			}
+			n++
+		}
+	})
+	return n, err
+}
+
+// restoreObject writes obj, whose stored form is value, keeping its
+// metadata.
+func (tx *Tx) restoreObject(obj IObject, value []byte) error {
+	md := obj.GetMetadata()
+	tid := md.TypeId()
+	prev, err := tx.load(tid)
+	if err != nil {
+		return err
+	}
+	old, err := tx.stored(tid)
+	if err != nil {
+		return err
+	}
+	if err := tx.st.Put(bucket_obj, tid.Key(), bytes.Clone(value)); err != nil {
+		return err
+	}
+	if err := tx.st.Delete(bucket_deleted, tid.Key()); err != nil {
+		return err
+	}
+	if err := tx.updateIndexes(obj, old); err != nil {
+		return err
+	}
+	if err := tx.updateRefs(obj, old); err != nil {
+		return err
+	}
+	if err := tx.updateExpiry(obj, old); err != nil {
+		return err
+	}
+	if err := tx.advanceRevision(md.Revision); err != nil {
+		return err
+	}
+	if prev == nil {
+		tx.notifyCreate(obj)
+	} else {
+		tx.notifyUpdate(obj, prev)
+	}
+	return nil
+}
+
+// restoreDeleted removes the object tid, which was deleted at revision
+// rev, if it exists.
+func (tx *Tx) restoreDeleted(tid TypeId, rev uint64) error {
+	prev, err := tx.load(tid)
+	if err != nil {
+		return err
+	}
+	if prev != nil {
+		if err := tx.remove(prev); err != nil {
+			return err
+		}
+	}
+	return tx.advanceRevision(rev)
+}
+
+// advanceRevision makes sure the revision of the database is at least
+// rev.
+func (tx *Tx) advanceRevision(rev uint64) error {
+	if rev <= tx.Revision() {
+		return nil
+	}
+	if err := tx.st.Put(bucket_meta, revisionKey, binary.BigEndian.AppendUint64(nil, rev)); err != nil {
+		return err
+	}
+	tx.rev = rev
+	return nil
+}
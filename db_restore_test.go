@@ -0,0 +1,219 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestRestore(t *testing.T) {
+	src, list := GenerateTestData(10)
+	defer RemoveTestData(src)
+	full := &bytes.Buffer{}
+	if _, err := src.Backup(full, BackupOptions{Gzip: true}); err != nil {
+		t.FailNow()
+	}
+
+	// A full backup replaces the existing objects
+	db, other := GenerateTestData(3)
+	defer RemoveTestData(db)
+	n, err := db.Restore(bytes.NewReader(full.Bytes()), RestoreOptions{})
+	if err != nil || n != 10 {
+		t.FailNow()
+	}
+	if _, err := Get[*TObject](db, other[0].Metadata.TypeId()); !errors.Is(err, ErrNotFound) {
+		t.Fail()
+	}
+	if res, err := Lookup[*TObject](db, TObj, "my_int", 3); err != nil || len(res) != 1 {
+		t.Fail()
+	}
+	if rev, _ := db.Revision(); rev != 10 {
+		t.Fail()
+	}
+
+	// Incremental backups are applied on top
+	list[0].MyString = "changed"
+	created := MustNew[*TObject](src, TObj)
+	if err := Put(src, list[0], created); err != nil {
+		t.FailNow()
+	}
+	if _, err := Delete[*TObject](src, list[1].Metadata.TypeId()); err != nil {
+		t.FailNow()
+	}
+	inc := &bytes.Buffer{}
+	if _, err := src.Backup(inc, BackupOptions{SinceRevision: 10}); err != nil {
+		t.FailNow()
+	}
+	if n, err := db.Restore(bytes.NewReader(inc.Bytes()), RestoreOptions{}); err != nil || n != 3 {
+		t.FailNow()
+	}
+	if obj, err := Get[*TObject](db, list[0].Metadata.TypeId()); err != nil || obj.MyString != "changed" {
+		t.Fail()
+	}
+	if _, err := Get[*TObject](db, list[1].Metadata.TypeId()); !errors.Is(err, ErrNotFound) {
+		t.Fail()
+	}
+	if res, _ := GetAll[*TObject](db, TObj); len(res) != 10 {
+		t.Fail()
+	}
+	srcRev, _ := src.Revision()
+	if rev, _ := db.Revision(); rev < srcRev {
+		t.Fail()
+	}
+}
+
+func TestRestoreRenamed(t *testing.T) {
+	opts := []*Shdb_Message_Options{{Indexes: []string{"my_string"}}}
+	src := CreateTestDb()
+	defer CloseTestDb(src)
+	if err := src.TypeRegistry().AddFileFromProtoFileDescriptor(testFileWithTypes([]string{"Old"}, opts)); err != nil {
+		t.FailNow()
+	}
+	if err := src.TypeRegistry().StoreSchema(src); err != nil {
+		t.FailNow()
+	}
+	oldKey := TypeKeyOf("shdb.test.volatile.Old")
+	newKey := TypeKeyOf("shdb.test.volatile.New")
+	for _, s := range []string{"a", "b"} {
+		obj := MustNew[IObject](src, oldKey)
+		m := obj.ProtoReflect()
+		m.Set(m.Descriptor().Fields().ByName("my_string"), protoreflect.ValueOfString(s))
+		if err := Put(src, obj); err != nil {
+			t.FailNow()
+		}
+	}
+	full := &bytes.Buffer{}
+	if _, err := src.Backup(full, BackupOptions{}); err != nil {
+		t.FailNow()
+	}
+
+	// The database was opened with Old renamed to New
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+	if err := db.TypeRegistry().AddFileFromProtoFileDescriptor(testFileWithTypes([]string{"New"}, opts)); err != nil {
+		t.FailNow()
+	}
+	db.opts.Renames = map[string]string{"shdb.test.volatile.Old": "shdb.test.volatile.New"}
+	if n, err := db.Restore(full, RestoreOptions{}); err != nil || n != 2 {
+		t.FailNow()
+	}
+	if res, err := GetAll[IObject](db, newKey); err != nil || len(res) != 2 {
+		t.Fail()
+	}
+	if res, err := Lookup[IObject](db, newKey, "my_string", "b"); err != nil || len(res) != 1 {
+		t.Fail()
+	}
+	if tk, err := db.TypeRegistry().GetTypeKeyFromToA("shdb.test.volatile.Old"); err != nil || tk != newKey {
+		t.Fail()
+	}
+}
+
+func TestRestoreLive(t *testing.T) {
+	src, _ := GenerateTestData(2)
+	defer RemoveTestData(src)
+	full := &bytes.Buffer{}
+	if _, err := src.Backup(full, BackupOptions{}); err != nil {
+		t.FailNow()
+	}
+	db, _ := GenerateTestData(5)
+	defer RemoveTestData(db)
+	sub, err := db.Watch(WatchOptions{})
+	if err != nil {
+		t.FailNow()
+	}
+	defer sub.Close()
+	if _, err := db.Restore(full, RestoreOptions{}); err != nil {
+		t.FailNow()
+	}
+
+	// Subscriptions end, and sequence numbers and revisions do not go back.
+	// A subscription still replaying the changelog finds it truncated.
+	for range sub.C {
+	}
+	if err := sub.Err(); !errors.Is(err, ErrRestored) && !errors.Is(err, ErrChangelogTruncated) {
+		t.Fail()
+	}
+	if rev, _ := db.Revision(); rev != 5 {
+		t.Fail()
+	}
+	if seq, _ := db.LastSeq(); seq <= 5 {
+		t.Fail()
+	}
+	if _, err := db.Watch(WatchOptions{FromSeq: 6}); !errors.Is(err, ErrChangelogTruncated) {
+		t.Fail()
+	}
+	if _, err := db.Watch(WatchOptions{FromRevision: 5}); err != nil {
+		t.Fail()
+	}
+}
+
+func TestRestoreUntil(t *testing.T) {
+	src, _ := GenerateTestData(1)
+	defer RemoveTestData(src)
+	full := &bytes.Buffer{}
+	header, err := src.Backup(full, BackupOptions{})
+	if err != nil {
+		t.FailNow()
+	}
+	before := MustNew[*TObject](src, TObj)
+	if err := Put(src, before); err != nil {
+		t.FailNow()
+	}
+	deleted := MustNew[*TObject](src, TObj)
+	if err := Put(src, deleted); err != nil {
+		t.FailNow()
+	}
+	until := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	after := MustNew[*TObject](src, TObj)
+	if err := Put(src, after); err != nil {
+		t.FailNow()
+	}
+	// Changes after until are not applied to the objects that existed
+	before.MyString = "after"
+	if err := Put(src, before); err != nil {
+		t.FailNow()
+	}
+	if _, err := Delete[*TObject](src, deleted.Metadata.TypeId()); err != nil {
+		t.FailNow()
+	}
+	inc := &bytes.Buffer{}
+	if _, err := src.Backup(inc, BackupOptions{SinceRevision: header.Revision, Gzip: true}); err != nil {
+		t.FailNow()
+	}
+
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+	if _, err := db.Restore(full, RestoreOptions{}); err != nil {
+		t.FailNow()
+	}
+	if _, err := db.Restore(inc, RestoreOptions{Until: until}); err != nil {
+		t.FailNow()
+	}
+	if obj, err := Get[*TObject](db, before.Metadata.TypeId()); err != nil || obj.MyString != "" {
+		t.Fail()
+	}
+	if _, err := Get[*TObject](db, deleted.Metadata.TypeId()); err != nil {
+		t.Fail()
+	}
+	if _, err := Get[*TObject](db, after.Metadata.TypeId()); !errors.Is(err, ErrNotFound) {
+		t.Fail()
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+// Conditional writes. Every write stores the new revision of the database
+// in Metadata.Revision, so a caller that read an object can write it back
+// only if nobody else has written it since.
+
+// Create stores new objects. It fails with a *ConflictError if any of the
+// objects already exists. When h is a *DB none of the objects are stored
+// in that case.
+func Create[T IObject](h Handle, val ...T) error {
+	return h.write(func(tx *Tx) error {
+		for _, v := range val {
+			tid := v.GetMetadata().TypeId()
+			prev, err := tx.load(tid)
+			if err != nil {
+				return err
+			}
+			if prev != nil {
+				return &ConflictError{TypeId: tid, Actual: prev.GetMetadata().Revision}
+			}
+			if err := tx.store(v, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PutIfRevision stores an object if the stored object has the revision rev.
+// A rev of zero means that the object must not exist. Otherwise a
+// *ConflictError is returned.
+func PutIfRevision[T IObject](h Handle, rev uint64, val T) error {
+	return h.write(func(tx *Tx) error {
+		tid := val.GetMetadata().TypeId()
+		prev, err := tx.load(tid)
+		if err != nil {
+			return err
+		}
+		if err := checkRevision(tid, rev, prev); err != nil {
+			return err
+		}
+		return tx.store(val, prev)
+	})
+}
+
+// UpdateIfRevision is like Update but fails with a *ConflictError unless
+// the stored object has the revision rev.
+func UpdateIfRevision[T IObject](h Handle, tid TypeId, rev uint64, updater func(obj T) (T, error)) (t T, err error) {
+	var obj T
+	err = h.write(func(tx *Tx) error {
+		kv, err := tx.getKV(tid)
+		if err != nil {
+			return err
+		}
+		prev, err := Unmarshal[T](tx.db, kv)
+		if err != nil {
+			return err
+		}
+		if err := checkRevision(tid, rev, prev); err != nil {
+			return err
+		}
+		obj, err = updater(cloneObject(prev).(T))
+		if err != nil {
+			return err
+		}
+		return tx.store(obj, prev)
+	})
+	return obj, err
+}
+
+// checkRevision returns a *ConflictError unless the stored object prev
+// has the revision rev. prev is nil if the object does not exist.
+func checkRevision(tid TypeId, rev uint64, prev IObject) error {
+	var actual uint64
+	if prev != nil {
+		actual = prev.GetMetadata().Revision
+	}
+	if (prev == nil) != (rev == 0) || actual != rev {
+		return &ConflictError{TypeId: tid, Expected: rev, Actual: actual}
+	}
+	return nil
+}
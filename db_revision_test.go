@@ -0,0 +1,129 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRevision(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+
+	obj := MustNew[*TObject](db, TObj)
+	if err := Put(db, obj); err != nil {
+		t.FailNow()
+	}
+	stored, err := Get[*TObject](db, obj.Metadata.TypeId())
+	if err != nil || stored.Metadata.Revision == 0 {
+		t.FailNow()
+	}
+	rev1 := stored.Metadata.Revision
+	updated, err := Update(db, obj.Metadata.TypeId(), func(obj *TObject) (*TObject, error) {
+		obj.MyInt = 1
+		return obj, nil
+	})
+	if err != nil || updated.Metadata.Revision <= rev1 {
+		t.Fail()
+	}
+	dbRev, err := db.Revision()
+	if err != nil || dbRev != updated.Metadata.Revision {
+		t.Fail()
+	}
+	if _, err := Delete[*TObject](db, obj.Metadata.TypeId()); err != nil {
+		t.FailNow()
+	}
+	if rev, _ := db.Revision(); rev <= dbRev {
+		t.Fail()
+	}
+}
+
+func TestCreate(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+
+	obj := MustNew[*TObject](db, TObj)
+	if err := Create(db, obj); err != nil {
+		t.FailNow()
+	}
+	other := MustNew[*TObject](db, TObj)
+	err := Create(db, other, obj)
+	var ce *ConflictError
+	if !errors.Is(err, ErrConflict) || !errors.As(err, &ce) || ce.Expected != 0 || ce.Actual == 0 {
+		t.Fail()
+	}
+	// Nothing is stored when one of the objects exists
+	if _, err := Get[*TObject](db, other.Metadata.TypeId()); !errors.Is(err, ErrNotFound) {
+		t.Fail()
+	}
+}
+
+func TestPutIfRevision(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+
+	obj := MustNew[*TObject](db, TObj)
+	if err := PutIfRevision(db, 0, obj); err != nil {
+		t.FailNow()
+	}
+	if err := PutIfRevision(db, 0, obj); !errors.Is(err, ErrConflict) {
+		t.Fail()
+	}
+	stored, err := Get[*TObject](db, obj.Metadata.TypeId())
+	if err != nil {
+		t.FailNow()
+	}
+	rev := stored.Metadata.Revision
+	stored.MyString = "first"
+	if err := PutIfRevision(db, rev, stored); err != nil {
+		t.FailNow()
+	}
+	stored.MyString = "second"
+	err = PutIfRevision(db, rev, stored)
+	var ce *ConflictError
+	if !errors.As(err, &ce) || ce.Expected != rev || ce.Actual <= rev {
+		t.Fail()
+	}
+	if stored, _ := Get[*TObject](db, obj.Metadata.TypeId()); stored.MyString != "first" {
+		t.Fail()
+	}
+}
+
+func TestUpdateIfRevision(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+
+	obj := MustNew[*TObject](db, TObj)
+	if err := Put(db, obj); err != nil {
+		t.FailNow()
+	}
+	setInt := func(obj *TObject) (*TObject, error) {
+		obj.MyInt++
+		return obj, nil
+	}
+	if _, err := UpdateIfRevision(db, obj.Metadata.TypeId(), 1000, setInt); !errors.Is(err, ErrConflict) {
+		t.Fail()
+	}
+	stored, _ := Get[*TObject](db, obj.Metadata.TypeId())
+	updated, err := UpdateIfRevision(db, obj.Metadata.TypeId(), stored.Metadata.Revision, setInt)
+	if err != nil || updated.MyInt != stored.MyInt+1 {
+		t.Fail()
+	}
+	missing := MustNew[*TObject](db, TObj)
+	if _, err := UpdateIfRevision(db, missing.Metadata.TypeId(), 1, setInt); !errors.Is(err, ErrNotFound) {
+		t.Fail()
+	}
+}
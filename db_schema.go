@@ -15,58 +15,295 @@
 package shdb
 
 import (
-	"go.etcd.io/bbolt"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"sort"
+
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protodesc"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/reflect/protoregistry"
 	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// Every schema stored in the schema bucket is kept as a SchemaVersion
+// under a key with a memory layout like:
+//
+//	[b0]		'v'
+//	[b1 .. b4]	Version
+//
+// The current version is kept in bucket_meta. Databases created before
+// schema versions have the schema under schemaKey only.
+//
+// The TypeKey of every type that has been part of the schema is kept in
+// bucket_types by fullname, so a type keeps its key when it is renamed
+// with the old name as an alias, and a new type cannot take over the
+// keyspace of an old one.
+
 var (
-	schemaKey = []byte{1, 2, 3}
+	schemaKey        = []byte{1, 2, 3}
+	schemaVersionKey = []byte("schema_version")
 )
 
-// StoreSchema stores the current state of a protoregistry.Files object in the
-// schema bucket.
-func StoreSchema(files protoregistry.Files) error {
+func schemaKeyOf(version uint32) []byte {
+	return binary.BigEndian.AppendUint32([]byte{'v'}, version)
+}
+
+// fileSetOf returns the files of a protoregistry.Files sorted by name.
+func fileSetOf(files *protoregistry.Files) *descriptorpb.FileDescriptorSet {
 	fileSet := &descriptorpb.FileDescriptorSet{}
 	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
 		fileSet.File = append(fileSet.File, protodesc.ToFileDescriptorProto(fd))
 		return true
 	})
+	sort.Slice(fileSet.File, func(i, j int) bool {
+		return fileSet.File[i].GetName() < fileSet.File[j].GetName()
+	})
+	return fileSet
+}
 
-	data, err := proto.Marshal(fileSet)
+// StoreSchema stores the current state of a protoregistry.Files object in the
+// schema bucket. A new version is stored unless the schema is the same as
+// the current version.
+func (db *DB) StoreSchema(files *protoregistry.Files) error {
+	return db.Txn(func(tx *Tx) error {
+		return tx.storeSchema(files)
+	})
+}
+
+func (tx *Tx) storeSchema(files *protoregistry.Files) error {
+	fileSet := fileSetOf(files)
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(fileSet)
 	if err != nil {
 		return err
 	}
+	hash := sha256.Sum256(data)
 
-	return db.Update(func(tx *bbolt.Tx) error {
-		b := tx.Bucket(bucket_schema)
-		b.Put(schemaKey, data)
+	current, err := tx.schemaVersion(tx.currentSchemaVersion())
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if err := tx.storeTypeKeys(); err != nil {
+		return err
+	}
+	if current != nil && bytes.Equal(current.Hash, hash[:]) {
+		tx.db.schemaVersion.Store(current.Version)
 		return nil
+	}
+	sv := &SchemaVersion{
+		Version:   tx.currentSchemaVersion() + 1,
+		CreatedAt: timestamppb.Now(),
+		Hash:      hash[:],
+		Schema:    fileSet,
+	}
+	if data, err = proto.Marshal(sv); err != nil {
+		return err
+	}
+	if err := tx.st.Put(bucket_schema, schemaKeyOf(sv.Version), data); err != nil {
+		return err
+	}
+	if err := tx.st.Put(bucket_meta, schemaVersionKey, binary.BigEndian.AppendUint32(nil, sv.Version)); err != nil {
+		return err
+	}
+	if err := tx.st.Delete(bucket_schema, schemaKey); err != nil {
+		return err
+	}
+	tx.db.schemaVersion.Store(sv.Version)
+	return nil
+}
+
+// LoadSchema returns the current schema stored in the schema bucket.
+func (db *DB) LoadSchema() (fds *descriptorpb.FileDescriptorSet, err error) {
+	err = db.View(func(tx *Tx) error {
+		var version uint32
+		if fds, version, err = tx.loadSchema(); err == nil && version != 0 {
+			db.schemaVersion.Store(version)
+		}
+		return err
 	})
+	return
 }
 
-func LoadSchema() (*descriptorpb.FileDescriptorSet, error) {
-	data := []byte{}
-	fds := &descriptorpb.FileDescriptorSet{}
-	err := db.View(func(tx *bbolt.Tx) error {
-		b := tx.Bucket(bucket_schema)
-		payload := b.Get(schemaKey)
+// loadSchema returns the current schema and its version, which is 0 for
+// a schema stored before schema versions.
+func (tx *Tx) loadSchema() (*descriptorpb.FileDescriptorSet, uint32, error) {
+	version := tx.currentSchemaVersion()
+	if version == 0 {
+		payload := tx.st.Get(bucket_schema, schemaKey)
 		if payload == nil {
-			return ErrNotFound
+			return nil, 0, ErrNotFound
+		}
+		fds := &descriptorpb.FileDescriptorSet{}
+		return fds, 0, proto.Unmarshal(payload, fds)
+	}
+	sv, err := tx.schemaVersion(version)
+	if err != nil {
+		return nil, 0, err
+	}
+	return sv.Schema, version, nil
+}
+
+// storeTypeKeys persists the TypeKeys of the types in the registry.
+func (tx *Tx) storeTypeKeys() error {
+	for name, tk := range tx.db.reg.TypeKeys() {
+		if tx.st.Get(bucket_types, []byte(name)) != nil {
+			continue
+		}
+		if err := tx.st.Put(bucket_types, []byte(name), bytes.Clone(tk[:])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadTypeKeys returns the persisted TypeKeys by fullname.
+func (db *DB) loadTypeKeys() (res map[string]TypeKey, err error) {
+	err = db.View(func(tx *Tx) error {
+		res, err = tx.typeKeys()
+		return err
+	})
+	return
+}
+
+func (tx *Tx) typeKeys() (map[string]TypeKey, error) {
+	res := map[string]TypeKey{}
+	c := tx.st.Cursor(bucket_types, nil)
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if len(v) != 4 {
+			return nil, ErrDatabaseCorrupt
 		}
-		data = make([]byte, len(payload))
-		copy(data, payload)
+		res[string(k)] = TypeKey(v)
+	}
+	return res, nil
+}
+
+// loadRenames returns the new fullnames of renamed types by old fullname.
+func (db *DB) loadRenames() (res map[string]string, err error) {
+	err = db.View(func(tx *Tx) error {
+		res = tx.renames()
 		return nil
 	})
+	return
+}
+
+func (tx *Tx) renames() map[string]string {
+	res := map[string]string{}
+	c := tx.st.Cursor(bucket_renames, nil)
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		res[string(k)] = string(v)
+	}
+	return res
+}
+
+// currentSchemaVersion returns the version of the current schema, 0 if
+// no version has been stored.
+func (tx *Tx) currentSchemaVersion() uint32 {
+	if data := tx.st.Get(bucket_meta, schemaVersionKey); len(data) == 4 {
+		return binary.BigEndian.Uint32(data)
+	}
+	return 0
+}
+
+func (tx *Tx) schemaVersion(version uint32) (*SchemaVersion, error) {
+	data := tx.st.Get(bucket_schema, schemaKeyOf(version))
+	if data == nil {
+		return nil, ErrNotFound
+	}
+	sv := &SchemaVersion{}
+	if err := proto.Unmarshal(data, sv); err != nil {
+		return nil, err
+	}
+	return sv, nil
+}
+
+// SchemaVersion returns the version of the current schema. Objects
+// record it in Metadata.SchemaVersion when they are written.
+func (db *DB) SchemaVersion() uint32 {
+	return db.schemaVersion.Load()
+}
+
+// SchemaVersions returns all stored versions of the schema, oldest first.
+// The schemas themselves are left out.
+func (db *DB) SchemaVersions() ([]*SchemaVersion, error) {
+	res := []*SchemaVersion{}
+	err := db.View(func(tx *Tx) error {
+		c := tx.st.Cursor(bucket_schema, []byte{'v'})
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			sv := &SchemaVersion{}
+			if err := proto.Unmarshal(v, sv); err != nil {
+				return err
+			}
+			sv.Schema = nil
+			res = append(res, sv)
+		}
+		return nil
+	})
+	return res, err
+}
+
+// GetSchemaVersion returns a stored version of the schema.
+func (db *DB) GetSchemaVersion(version uint32) (sv *SchemaVersion, err error) {
+	err = db.View(func(tx *Tx) error {
+		sv, err = tx.schemaVersion(version)
+		return err
+	})
+	return
+}
+
+// TypeRegistry returns a registry of the types of the schema version.
+// All its types are dynamic, so objects are decoded exactly as they were
+// written with the version.
+func (sv *SchemaVersion) TypeRegistry() (*TypeRegistry, error) {
+	files, err := protodesc.NewFiles(sv.Schema)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewTypeRegistry()
+	if err != nil {
+		return nil, err
+	}
+	r.types = &protoregistry.Types{}
+	return r, r.useFiles(files)
+}
+
+// UnmarshalWithSchema returns the object stored in kv decoded with the
+// version of the schema it was written with. Objects written with the
+// current schema, or before there were schema versions, are decoded like
+// Unmarshal does without migrating them.
+func (db *DB) UnmarshalWithSchema(kv KeyVal) (IObject, error) {
+	obj, err := db.reg.decode(kv.Key(), kv.Value)
+	if err != nil {
+		return nil, err
+	}
+	version := obj.GetMetadata().SchemaVersion
+	if version == 0 || version == db.SchemaVersion() {
+		return obj, nil
+	}
+	r, err := db.schemaRegistry(version)
+	if err != nil {
+		return nil, err
+	}
+	return r.decode(kv.Key(), kv.Value)
+}
+
+// schemaRegistry returns the cached registry of a schema version.
+func (db *DB) schemaRegistry(version uint32) (*TypeRegistry, error) {
+	db.schemaMux.Lock()
+	defer db.schemaMux.Unlock()
+	if r, ok := db.schemaRegs[version]; ok {
+		return r, nil
+	}
+	sv, err := db.GetSchemaVersion(version)
 	if err != nil {
 		return nil, err
 	}
-	err = proto.Unmarshal(data, fds)
+	r, err := sv.TypeRegistry()
 	if err != nil {
 		return nil, err
 	}
-	return fds, err
+	db.schemaRegs[version] = r
+	return r, nil
 }
@@ -15,6 +15,7 @@
 package shdb
 
 import (
+	"errors"
 	"testing"
 
 	"google.golang.org/protobuf/reflect/protodesc"
@@ -22,19 +23,22 @@ import (
 )
 
 func TestDbSchema(t *testing.T) {
-	tmpDir := CreateTestDb()
-	defer CloseTestDb(tmpDir)
+	db := CreateTestDb()
+	defer CloseTestDb(db)
 
-	r := NewTypeRegistry()
-	if err := r.refresh(); err != nil {
+	r, err := NewTypeRegistry()
+	if err != nil {
 		t.FailNow()
 	}
-	err := r.StoreSchema()
+	err = r.StoreSchema(db)
 	if err != nil {
 		t.FailNow()
 	}
-	r2 := NewTypeRegistry()
-	err = r2.LoadSchema()
+	r2, err := NewTypeRegistry()
+	if err != nil {
+		t.FailNow()
+	}
+	err = r2.LoadSchema(db)
 	if err != nil {
 		t.FailNow()
 	}
@@ -91,3 +95,62 @@ loop3:
 		t.FailNow() // Not same
 	}
 }
+
+func TestSchemaVersions(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+
+	if db.SchemaVersion() != 1 {
+		t.FailNow()
+	}
+	// Storing the same schema again keeps the version
+	if err := db.TypeRegistry().StoreSchema(db); err != nil || db.SchemaVersion() != 1 {
+		t.FailNow()
+	}
+	t1 := MustNew[*TObject](db, TObj)
+	t1.MyInt = 42
+	if err := Put(db, t1); err != nil || t1.Metadata.SchemaVersion != 1 {
+		t.FailNow()
+	}
+
+	tk := addTestType(db, &Shdb_Message_Options{})
+	if err := db.TypeRegistry().StoreSchema(db); err != nil || db.SchemaVersion() != 2 {
+		t.FailNow()
+	}
+	t2 := MustNew[IObject](db, tk)
+	if err := Put(db, t2); err != nil || t2.GetMetadata().SchemaVersion != 2 {
+		t.FailNow()
+	}
+
+	versions, err := db.SchemaVersions()
+	if err != nil || len(versions) != 2 || versions[1].Version != 2 || len(versions[1].Hash) != 32 || versions[1].Schema != nil {
+		t.FailNow()
+	}
+	sv, err := db.GetSchemaVersion(1)
+	if err != nil || sv.Schema == nil {
+		t.FailNow()
+	}
+	if _, err := db.GetSchemaVersion(3); !errors.Is(err, ErrNotFound) {
+		t.Fail()
+	}
+
+	// t1 is decoded with version 1 of the schema
+	kv, err := db.get(t1.Metadata.TypeId())
+	if err != nil {
+		t.FailNow()
+	}
+	obj, err := db.UnmarshalWithSchema(*kv)
+	if err != nil {
+		t.FailNow()
+	}
+	dyn, ok := obj.(*DynObject)
+	if !ok {
+		t.FailNow()
+	}
+	if dyn.Get(dyn.Descriptor().Fields().ByName("my_int")).Uint() != 42 {
+		t.Fail()
+	}
+	if !dyn.GetMetadata().Ref().Equal(t1.Metadata.Ref()) {
+		t.Fail()
+	}
+}
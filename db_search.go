@@ -15,7 +15,6 @@
 package shdb
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"io"
@@ -23,7 +22,6 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/shenrytech/shdb/jsonsearch"
-	"go.etcd.io/bbolt"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 )
@@ -48,23 +46,21 @@ func SearchProto(m proto.Message, query func(s string) bool) (hits []string, err
 	return p.FieldPaths, err
 }
 
-func searchStream(typ TypeKey, selector func(s string) bool, doneCh chan struct{}) (ch chan *SearchHit) {
+func (db *DB) searchStream(typ TypeKey, selector func(s string) bool, doneCh chan struct{}) (ch chan *SearchHit) {
 	ch = make(chan *SearchHit, 10)
 	go func() {
 		defer func() {
 			close(ch)
 		}()
 
-		err := db.View(func(tx *bbolt.Tx) error {
+		err := db.View(func(tx *Tx) error {
 			cnt := 1
-			c := tx.Bucket(bucket_obj).Cursor()
-			for k, v := c.Seek(typ[:]); k != nil && bytes.HasPrefix(k, typ[:]); k, v = c.Next() {
-				kv := KeyVal{TypeId: *MarshalTypeId(k), Value: v}
+			return tx.forEach(typ[:], func(kv KeyVal) error {
 				if kv.Value == nil {
 					log.Printf("empty value in database kv=[%s]\n", kv.String())
-					continue
+					return nil
 				}
-				t, err := Unmarshal[IObject](kv)
+				t, err := Unmarshal[IObject](db, kv)
 				if err != nil {
 					log.Printf("failed to parse value in database kv=[%s], err=[%v]\n", kv.String(), err)
 				} else {
@@ -81,8 +77,8 @@ func searchStream(typ TypeKey, selector func(s string) bool, doneCh chan struct{
 						}
 					}
 				}
-			}
-			return nil
+				return nil
+			})
 		})
 		if err != nil {
 			log.Printf("searchStream failed, err=[%v]\n", err)
@@ -96,12 +92,10 @@ type activeSearchStream struct {
 	doneCh chan struct{}
 }
 
-var activeSearchStreams = map[uuid.UUID]*activeSearchStream{}
-
 // Search searches the values of the fields of objects pertaining to a type by calling
 // a selector function for each field in all objects.
 // For paging functionality see `Query` method.
-func Search(ctx context.Context,
+func (db *DB) Search(ctx context.Context,
 	typ TypeKey,
 	selector func(string) bool,
 	pageSize int32,
@@ -120,15 +114,19 @@ func Search(ctx context.Context,
 			return
 		}
 		doneCh := make(chan struct{})
-		inCh := searchStream(typ, selector, doneCh)
+		inCh := db.searchStream(typ, selector, doneCh)
 		stream = &activeSearchStream{inCh: inCh, doneCh: doneCh}
-		activeSearchStreams[streamId] = stream
+		db.streamsMux.Lock()
+		db.activeSearchStreams[streamId] = stream
+		db.streamsMux.Unlock()
 	} else {
 		streamId, err = uuid.Parse(pageToken)
 		if err != nil {
 			return
 		}
-		stream, ok = activeSearchStreams[streamId]
+		db.streamsMux.Lock()
+		stream, ok = db.activeSearchStreams[streamId]
+		db.streamsMux.Unlock()
 		if !ok {
 			return nil, "", ErrSessionInvalid
 		}
@@ -142,19 +140,16 @@ func Search(ctx context.Context,
 		select {
 		case obj, ok := <-stream.inCh:
 			if !ok {
-				close(stream.doneCh)
-				delete(activeStreams, streamId)
+				db.closeStream(streamId, stream.doneCh)
 				return res, "", nil
 			}
 			res.Hits = append(res.Hits, obj)
 			if errors.Is(err, io.EOF) {
-				close(stream.doneCh)
-				delete(activeStreams, streamId)
+				db.closeStream(streamId, stream.doneCh)
 				return res, "", nil
 			}
 		case <-ctx.Done():
-			close(stream.doneCh)
-			delete(activeStreams, streamId)
+			db.closeStream(streamId, stream.doneCh)
 			return res, "", ErrContextCancelled
 		}
 	}
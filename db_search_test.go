@@ -21,7 +21,8 @@ import (
 )
 
 func TestSearch(t *testing.T) {
-	GenerateTestData(1)
+	db, _ := GenerateTestData(1)
+	defer RemoveTestData(db)
 
 	ctx := context.Background()
 
@@ -32,7 +33,7 @@ func TestSearch(t *testing.T) {
 	)
 	allRes := &SearchResult{Hits: []*SearchHit{}}
 	for {
-		res, nextPageToken, err = Search(ctx, TObj, func(s string) bool {
+		res, nextPageToken, err = db.Search(ctx, TObj, func(s string) bool {
 			return strings.Contains(s, "Staffan Olsson")
 		}, 10, "")
 
@@ -53,7 +54,8 @@ func TestSearch(t *testing.T) {
 func TestSearch2(t *testing.T) {
 	count := 50
 	pageSize := 10
-	GenerateTestData(count)
+	db, _ := GenerateTestData(count)
+	defer RemoveTestData(db)
 
 	ctx := context.Background()
 
@@ -64,7 +66,7 @@ func TestSearch2(t *testing.T) {
 	)
 	allRes := &SearchResult{Hits: []*SearchHit{}}
 	for {
-		res, nextPageToken, err = Search(ctx, TObj, func(s string) bool {
+		res, nextPageToken, err = db.Search(ctx, TObj, func(s string) bool {
 			return strings.Contains(s, "Staffan Olsson")
 		}, int32(count/pageSize), nextPageToken)
 
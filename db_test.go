@@ -20,7 +20,9 @@ import (
 	"path"
 	"sort"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -28,43 +30,39 @@ var (
 	TObj = TypeKeyOf("shdb.v1.TObject")
 )
 
-func CreateTestDb() string {
-	tmpDir, err := os.MkdirTemp("", "shdb_test")
+func CreateTestDb() *DB {
+	db, err := OpenMemory()
 	if err != nil {
 		panic(err)
 	}
-	Init(path.Join(tmpDir, "test.db"))
-	return tmpDir
+	return db
 }
 
-func CloseTestDb(tmpDir string) {
-	Close()
-	os.RemoveAll(tmpDir)
+func CloseTestDb(db *DB) {
+	db.Close()
 }
 
-func GenerateTestData(count int) ([]*TObject, string) {
-	tmpDir, err := os.MkdirTemp("", "shdb_test")
-	if err != nil {
-		panic(err)
-	}
-	Init(path.Join(tmpDir, "test.db"))
-
+func PutTestData(db *DB, count int) []*TObject {
 	list := []*TObject{}
 	for k := 0; k < count; k++ {
-		tObj := MustNew[*TObject](TObj)
+		tObj := MustNew[*TObject](db, TObj)
 		tObj.GetMetadata().Description = "Staffan Olsson was here"
 		tObj.MyInt = uint64(k)
 		list = append(list, tObj)
 	}
-	if err := Put(list...); err != nil {
+	if err := Put(db, list...); err != nil {
 		panic(err)
 	}
-	return list, tmpDir
+	return list
+}
+
+func GenerateTestData(count int) (*DB, []*TObject) {
+	db := CreateTestDb()
+	return db, PutTestData(db, count)
 }
 
-func RemoveTestData(tmpDir string) {
-	Close()
-	os.RemoveAll(tmpDir)
+func RemoveTestData(db *DB) {
+	CloseTestDb(db)
 }
 
 func CompareSame(a, b []*TObject) bool {
@@ -87,23 +85,26 @@ func CompareSame(a, b []*TObject) bool {
 
 func TestDB(t *testing.T) {
 	dbFile := path.Join(os.TempDir(), "db_test.db")
-	Init(dbFile)
+	db, err := Init(dbFile)
+	if err != nil {
+		t.FailNow()
+	}
 	defer func() {
-		Close()
+		db.Close()
 		os.Remove(dbFile)
 	}()
 
-	t1 := MustNew[*TObject](TObj)
+	t1 := MustNew[*TObject](db, TObj)
 
 	if t1.GetMetadata().CreatedAt.Seconds == 0 {
 		t.Fail()
 	}
 
-	if err := Put(t1); err != nil {
+	if err := Put(db, t1); err != nil {
 		t.Fail()
 	}
 
-	t2, err := Get[*TObject](t1.Metadata.TypeId())
+	t2, err := Get[*TObject](db, t1.Metadata.TypeId())
 	if err != nil {
 		t.Fail()
 	}
@@ -112,7 +113,7 @@ func TestDB(t *testing.T) {
 		t.Fail()
 	}
 
-	prev, err := Delete[*TObject](t1.Metadata.TypeId())
+	prev, err := Delete[*TObject](db, t1.Metadata.TypeId())
 	if err != nil {
 		t.Fail()
 	}
@@ -120,7 +121,7 @@ func TestDB(t *testing.T) {
 		t.Fail()
 	}
 
-	_, err = Get[*TObject](t1.Metadata.TypeId())
+	_, err = Get[*TObject](db, t1.Metadata.TypeId())
 	if err == nil {
 		t.Fail()
 	}
@@ -131,10 +132,10 @@ func TestDB(t *testing.T) {
 }
 
 func TestList(t *testing.T) {
-	list, testDir := GenerateTestData(1000)
-	defer RemoveTestData(testDir)
+	db, list := GenerateTestData(1000)
+	defer RemoveTestData(db)
 	ctx := context.Background()
-	list2, nextToken, err := List[*TObject](ctx, TObj, 400000, "")
+	list2, nextToken, err := List[*TObject](ctx, db, TObj, 400000, "")
 	if err != nil {
 		t.Fail()
 	}
@@ -152,7 +153,7 @@ func TestList(t *testing.T) {
 	)
 	list4 := []*TObject{}
 	for {
-		list3, nextPageToken, err = List[*TObject](ctx, TObj, 1000, nextPageToken)
+		list3, nextPageToken, err = List[*TObject](ctx, db, TObj, 1000, nextPageToken)
 		if err != nil {
 			t.Fail()
 		}
@@ -165,12 +166,18 @@ func TestList(t *testing.T) {
 	if !CompareSame(list, list4) {
 		t.Fail()
 	}
+
+	for _, token := range []string{"nope", uuid.NewString()} {
+		if _, _, err := List[*TObject](ctx, db, TObj, 10, token); !errors.Is(err, ErrSessionInvalid) {
+			t.Fail()
+		}
+	}
 }
 
 func BenchmarkListLong(b *testing.B) {
 
-	list, testDir := GenerateTestData(1000)
-	defer RemoveTestData(testDir)
+	db, list := GenerateTestData(1000)
+	defer RemoveTestData(db)
 
 	var (
 		nextPageToken string = ""
@@ -180,7 +187,7 @@ func BenchmarkListLong(b *testing.B) {
 	ctx := context.Background()
 	list4 := []*TObject{}
 	for {
-		list3, nextPageToken, err = List[*TObject](ctx, TObj, 400000, nextPageToken)
+		list3, nextPageToken, err = List[*TObject](ctx, db, TObj, 400000, nextPageToken)
 		if err != nil {
 			b.Fail()
 		}
@@ -194,3 +201,50 @@ func BenchmarkListLong(b *testing.B) {
 		b.Fail()
 	}
 }
+
+func TestOpenMultiple(t *testing.T) {
+	db1 := CreateTestDb()
+	defer CloseTestDb(db1)
+	db2 := CreateTestDb()
+	defer CloseTestDb(db2)
+
+	t1 := MustNew[*TObject](db1, TObj)
+	if err := Put(db1, t1); err != nil {
+		t.FailNow()
+	}
+	if _, err := Get[*TObject](db1, t1.Metadata.TypeId()); err != nil {
+		t.Fail()
+	}
+	if _, err := Get[*TObject](db2, t1.Metadata.TypeId()); !errors.Is(err, ErrNotFound) {
+		t.Fail()
+	}
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "shdb_test")
+	if err != nil {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+	db, err := Open(path.Join(tmpDir, "test.db"), Options{})
+	if err != nil {
+		t.FailNow()
+	}
+	t1 := MustNew[*TObject](db, TObj)
+	if err := Put(db, t1); err != nil {
+		t.FailNow()
+	}
+	db.Close()
+
+	db, err = Open(path.Join(tmpDir, "test.db"), Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		t.FailNow()
+	}
+	defer db.Close()
+	if _, err := Get[*TObject](db, t1.Metadata.TypeId()); err != nil {
+		t.Fail()
+	}
+	if err := Put(db, MustNew[*TObject](db, TObj)); err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,360 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ErrTxReadOnly is returned when writing in a read-only transaction.
+var ErrTxReadOnly = errors.New("transaction is read-only")
+
+var revisionKey = []byte("revision")
+
+// Handle is what object operations such as Get, Put, Update and Delete
+// run against. It is either a *DB, where every operation runs in a
+// transaction of its own, or a *Tx, where the operation becomes part
+// of the enclosing transaction.
+type Handle interface {
+	read(fn func(tx *Tx) error) error
+	write(fn func(tx *Tx) error) error
+}
+
+// Tx is a transaction spanning any number of objects of any types.
+// Watch events caused by a read-write transaction are buffered, appended
+// to the changelog and sent to watchers, in order, once the transaction
+// has been committed.
+type Tx struct {
+	db     *DB
+	st     StorageTx
+	events []*EventInfo
+
+	rev       uint64
+	revLoaded bool
+	// restored is set by a full restore, which ends all watchers once
+	// it has been committed.
+	restored bool
+}
+
+// Txn runs fn in a read-write transaction. The transaction is committed
+// if fn returns nil and rolled back otherwise.
+func (db *DB) Txn(fn func(tx *Tx) error) error {
+	stx, err := db.st.Begin(true)
+	if err != nil {
+		return err
+	}
+	// Roll back if fn panics, so the writer lock is released.
+	committed := false
+	defer func() {
+		if !committed {
+			stx.Rollback()
+		}
+	}()
+	tx := &Tx{db: db, st: stx}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.logChanges(); err != nil {
+		return err
+	}
+
+	// Holding commitMux until the events are sent keeps the events of
+	// consecutive transactions in commit order.
+	db.commitMux.Lock()
+	defer db.commitMux.Unlock()
+	committed = true
+	if err := stx.Commit(); err != nil {
+		return err
+	}
+	for _, ev := range tx.events {
+		db.sendEvent(ev)
+	}
+	if tx.restored {
+		db.disconnectWatchers(ErrRestored)
+	}
+	return nil
+}
+
+// View runs fn in a read-only transaction.
+func (db *DB) View(fn func(tx *Tx) error) error {
+	stx, err := db.st.Begin(false)
+	if err != nil {
+		return err
+	}
+	defer stx.Rollback()
+	return fn(&Tx{db: db, st: stx})
+}
+
+func (db *DB) read(fn func(tx *Tx) error) error {
+	return db.View(fn)
+}
+
+func (db *DB) write(fn func(tx *Tx) error) error {
+	return db.Txn(fn)
+}
+
+func (tx *Tx) read(fn func(tx *Tx) error) error {
+	return fn(tx)
+}
+
+func (tx *Tx) write(fn func(tx *Tx) error) error {
+	if !tx.st.Writable() {
+		return ErrTxReadOnly
+	}
+	return fn(tx)
+}
+
+// DB returns the database the transaction belongs to.
+func (tx *Tx) DB() *DB {
+	return tx.db
+}
+
+// Writable returns true for read-write transactions.
+func (tx *Tx) Writable() bool {
+	return tx.st.Writable()
+}
+
+// Revision returns the revision of the database as seen by the transaction.
+func (tx *Tx) Revision() uint64 {
+	if !tx.revLoaded {
+		if data := tx.st.Get(bucket_meta, revisionKey); len(data) == 8 {
+			tx.rev = binary.BigEndian.Uint64(data)
+		}
+		tx.revLoaded = true
+	}
+	return tx.rev
+}
+
+// nextRevision increases the revision of the database. It is called
+// once for every object written or deleted.
+func (tx *Tx) nextRevision() (uint64, error) {
+	rev := tx.Revision() + 1
+	data := binary.BigEndian.AppendUint64(nil, rev)
+	if err := tx.st.Put(bucket_meta, revisionKey, data); err != nil {
+		return 0, err
+	}
+	tx.rev = rev
+	return rev, nil
+}
+
+// Revision returns the current revision of the database.
+func (db *DB) Revision() (rev uint64, err error) {
+	err = db.View(func(tx *Tx) error {
+		rev = tx.Revision()
+		return nil
+	})
+	return
+}
+
+// getKV returns the stored KeyVal of an object. The value is only valid
+// during the transaction. Expired objects are not returned.
+func (tx *Tx) getKV(tid TypeId) (KeyVal, error) {
+	kv, err := tx.rawKV(tid)
+	if err == nil && tx.expired(tid) {
+		return kv, ErrNotFound
+	}
+	return kv, err
+}
+
+// rawKV is like getKV but also returns expired objects.
+func (tx *Tx) rawKV(tid TypeId) (KeyVal, error) {
+	kv := KeyVal{TypeId: tid}
+	kv.Value = tx.st.Get(bucket_obj, kv.Key())
+	if kv.Value == nil {
+		return kv, ErrNotFound
+	}
+	return kv, nil
+}
+
+// load returns the stored object or nil if it does not exist. It is used
+// before writing an object, so an expired object is deleted right away.
+func (tx *Tx) load(tid TypeId) (IObject, error) {
+	kv, err := tx.rawKV(tid)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	obj, err := tx.db.reg.Unmarshal(kv.Key(), kv.Value)
+	if err != nil || !tx.expired(tid) {
+		return obj, err
+	}
+	if tx.Writable() {
+		return nil, tx.remove(obj)
+	}
+	return nil, nil
+}
+
+// stored returns the object tid as stored, without migrating it, or nil
+// if it does not exist. Index, reference and expiry entries are written
+// from stored objects, so they are removed based on them too.
+func (tx *Tx) stored(tid TypeId) (IObject, error) {
+	data := tx.st.Get(bucket_obj, tid.Key())
+	if data == nil {
+		return nil, nil
+	}
+	return tx.db.reg.decode(tid.Key(), data)
+}
+
+// forEach calls fn for all stored objects whose key starts with prefix.
+// The key and value are only valid during the call. Expired objects
+// are skipped.
+func (tx *Tx) forEach(prefix []byte, fn func(kv KeyVal) error) error {
+	return tx.forEachRaw(prefix, func(kv KeyVal) error {
+		if tx.expired(kv.TypeId) {
+			return nil
+		}
+		return fn(kv)
+	})
+}
+
+// forEachRaw is like forEach but includes expired objects.
+func (tx *Tx) forEachRaw(prefix []byte, fn func(kv KeyVal) error) error {
+	c := tx.st.Cursor(bucket_obj, prefix)
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if err := fn(KeyVal{TypeId: *MarshalTypeId(k), Value: v}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// put writes obj, overwriting any existing object with the same TypeId.
+func (tx *Tx) put(obj IObject) error {
+	prev, err := tx.load(obj.GetMetadata().TypeId())
+	if err != nil {
+		return err
+	}
+	return tx.store(obj, prev)
+}
+
+// store writes obj given the object it replaces, which is nil for
+// new objects. All object writes go through here. The revision and
+// update time in the metadata of obj are set.
+func (tx *Tx) store(obj, prev IObject) error {
+	if err := tx.checkRefs(obj); err != nil {
+		return err
+	}
+	return tx.storeUnchecked(obj, prev)
+}
+
+// storeUnchecked is store without checking the references of obj.
+func (tx *Tx) storeUnchecked(obj, prev IObject) error {
+	rev, err := tx.nextRevision()
+	if err != nil {
+		return err
+	}
+	now := timestamppb.Now()
+	md := obj.GetMetadata()
+	md.Revision = rev
+	md.UpdatedAt = now
+	if mi, err := tx.db.reg.GetMessageInfo(md.TypeId().TypeKey()); err == nil {
+		md.TypeVersion = mi.Version
+	}
+	md.SchemaVersion = tx.db.SchemaVersion()
+	if err := setMetadata(obj, md); err != nil {
+		return err
+	}
+	kvs, err := Marshal(obj)
+	if err != nil {
+		return err
+	}
+	old, err := tx.stored(md.TypeId())
+	if err != nil {
+		return err
+	}
+	if err := tx.st.Put(bucket_obj, kvs[0].Key(), kvs[0].Value); err != nil {
+		return err
+	}
+	if err := tx.updateIndexes(obj, old); err != nil {
+		return err
+	}
+	if err := tx.updateRefs(obj, old); err != nil {
+		return err
+	}
+	if err := tx.updateExpiry(obj, old); err != nil {
+		return err
+	}
+	if prev != nil {
+		if err := tx.recordHistory(prev, now, false); err != nil {
+			return err
+		}
+	}
+	if prev == nil {
+		tx.notifyCreate(obj)
+	} else {
+		tx.notifyUpdate(obj, prev)
+	}
+	return nil
+}
+
+// remove deletes a stored object. All object deletes go through here.
+func (tx *Tx) remove(obj IObject) error {
+	tid := obj.GetMetadata().TypeId()
+	if _, err := tx.nextRevision(); err != nil {
+		return err
+	}
+	old, err := tx.stored(tid)
+	if err != nil {
+		return err
+	}
+	if old == nil {
+		old = obj
+	}
+	if err := tx.st.Delete(bucket_obj, tid.Key()); err != nil {
+		return err
+	}
+	if err := tx.updateIndexes(nil, old); err != nil {
+		return err
+	}
+	if err := tx.updateRefs(nil, old); err != nil {
+		return err
+	}
+	if err := tx.updateExpiry(nil, old); err != nil {
+		return err
+	}
+	if err := tx.recordHistory(obj, timestamppb.Now(), true); err != nil {
+		return err
+	}
+	tx.notifyDelete(obj)
+	return nil
+}
+
+// DeleteAll deletes all objects of a specific type.
+func (tx *Tx) DeleteAll(tk TypeKey) error {
+	if !tx.st.Writable() {
+		return ErrTxReadOnly
+	}
+	objs := []IObject{}
+	err := tx.forEachRaw(tk[:], func(kv KeyVal) error {
+		obj, err := tx.db.reg.Unmarshal(kv.Key(), kv.Value)
+		if err != nil {
+			return err
+		}
+		objs = append(objs, obj)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		if err := tx.delete(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
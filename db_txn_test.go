@@ -0,0 +1,173 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTxn(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+	if err := db.TypeRegistry().AddFileFromProtoFileDescriptor(CreateTestFileDescriptor()); err != nil {
+		t.FailNow()
+	}
+	TObj2 := TypeKeyOf("shdb.test.volatile.TObj2")
+
+	ch := make(chan *EventInfo, 10)
+	watchId, err := db.WatchType("", ch, TObj, TObj2)
+	if err != nil {
+		t.FailNow()
+	}
+	defer db.RemoveWatcher(watchId)
+
+	parent := MustNew[*TObject](db, TObj)
+	child := MustNew[IObject](db, TObj2)
+	err = db.Txn(func(tx *Tx) error {
+		if err := Put(tx, parent); err != nil {
+			return err
+		}
+		if err := Put(tx, child); err != nil {
+			return err
+		}
+		// Objects written in the transaction are visible within it
+		_, err := Update(tx, parent.Metadata.TypeId(), func(obj *TObject) (*TObject, error) {
+			obj.MyInt = 42
+			return obj, nil
+		})
+		return err
+	})
+	if err != nil {
+		t.FailNow()
+	}
+
+	kinds := []int{EventCreated, EventCreated, EventUpdated}
+	for _, kind := range kinds {
+		if ev := <-ch; ev.Kind != kind {
+			t.Fail()
+		}
+	}
+	if obj, err := Get[*TObject](db, parent.Metadata.TypeId()); err != nil || obj.MyInt != 42 {
+		t.Fail()
+	}
+	if _, err := Get[IObject](db, child.GetMetadata().TypeId()); err != nil {
+		t.Fail()
+	}
+}
+
+func TestTxnRollback(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+
+	ch := make(chan *EventInfo, 10)
+	watchId, err := db.WatchType("", ch, TObj)
+	if err != nil {
+		t.FailNow()
+	}
+	defer db.RemoveWatcher(watchId)
+
+	obj := MustNew[*TObject](db, TObj)
+	errAbort := errors.New("abort")
+	err = db.Txn(func(tx *Tx) error {
+		if err := Put(tx, obj); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fail()
+	}
+	if _, err := Get[*TObject](db, obj.Metadata.TypeId()); !errors.Is(err, ErrNotFound) {
+		t.Fail()
+	}
+
+	// The first event seen must be from the next committed transaction
+	if err := Put(db, MustNew[*TObject](db, TObj)); err != nil {
+		t.FailNow()
+	}
+	if ev := <-ch; ev.Object.GetMetadata().TypeId() == obj.Metadata.TypeId() {
+		t.Fail()
+	}
+}
+
+func TestTxnPanic(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+
+	obj := MustNew[*TObject](db, TObj)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fail()
+			}
+		}()
+		db.Txn(func(tx *Tx) error {
+			if err := Put(tx, obj); err != nil {
+				return err
+			}
+			panic("abort")
+		})
+	}()
+
+	// The panicking transaction was rolled back and released the writer
+	if _, err := Get[*TObject](db, obj.Metadata.TypeId()); !errors.Is(err, ErrNotFound) {
+		t.Fail()
+	}
+	if err := Put(db, MustNew[*TObject](db, TObj)); err != nil {
+		t.Fail()
+	}
+}
+
+func TestView(t *testing.T) {
+	db, list := GenerateTestData(10)
+	defer RemoveTestData(db)
+
+	err := db.View(func(tx *Tx) error {
+		all, err := GetAll[*TObject](tx, TObj)
+		if err != nil {
+			return err
+		}
+		if !CompareSame(list, all) {
+			t.Fail()
+		}
+		even, err := Select(tx, TObj, func(obj *TObject) (bool, error) {
+			return obj.MyInt%2 == 0, nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(even) != 5 {
+			t.Fail()
+		}
+		return Put(tx, MustNew[*TObject](db, TObj))
+	})
+	if !errors.Is(err, ErrTxReadOnly) {
+		t.Fail()
+	}
+}
+
+func TestDeleteAll(t *testing.T) {
+	db, _ := GenerateTestData(10)
+	defer RemoveTestData(db)
+
+	if err := db.DeleteAll(TObj); err != nil {
+		t.FailNow()
+	}
+	all, err := GetAll[*TObject](db, TObj)
+	if err != nil || len(all) != 0 {
+		t.Fail()
+	}
+}
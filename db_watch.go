@@ -16,9 +16,9 @@ package shdb
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
-	"google.golang.org/protobuf/proto"
 )
 
 // Events reflecting life-cycle changes to an object
@@ -27,6 +27,9 @@ const (
 	EventCreated      = 1
 	EventUpdated      = 2
 	EventDeleted      = 3
+	// EventError is the last event of a watcher that has been removed
+	// because of an error, see EventInfo.Err.
+	EventError = 4
 )
 
 // EventInfo contains the information about a specific life cycle event
@@ -35,16 +38,92 @@ type EventInfo struct {
 	Tid      TypeId
 	Object   IObject
 	Previous IObject
+	// Seq is the sequence number of the change in the changelog.
+	Seq uint64
+	// Revision is the revision of the database made by the change.
+	Revision uint64
+	// Time is when the change was committed.
+	Time time.Time
+	// Err is the error of an EventError event.
+	Err error
 }
 
 type watchInstance struct {
-	Types   []TypeKey
-	TypeIds []TypeId
-	Ch      chan *EventInfo
+	Types     []TypeKey
+	TypeIds   []TypeId
+	Selectors []typeSelector
+	Filters   []typeFilter
+	// All watches every change, as used by subscriptions.
+	All bool
+	// Disconnected watchers get no more events, but are kept until they
+	// are removed so that their queue is closed.
+	Disconnected bool
+	q            *watchQueue
+}
+
+// matches returns true if the watcher watches the object of ev.
+func (wi *watchInstance) matches(ev *EventInfo) bool {
+	if wi.All || containsTypeKey(wi.Types, ev.Tid.TypeKey()) {
+		return true
+	}
+	for _, tid := range wi.TypeIds {
+		if tid == ev.Tid {
+			return true
+		}
+	}
+	for _, ts := range wi.Selectors {
+		if ts.matches(ev) {
+			return true
+		}
+	}
+	for _, tf := range wi.Filters {
+		if tf.matches(ev) {
+			return true
+		}
+	}
+	return false
+}
+
+// typeSelector watches the objects of a type whose labels match a selector.
+type typeSelector struct {
+	Type TypeKey
+	Sel  Selector
+}
+
+// matches returns true if the object, or the object it replaced, has
+// the type and labels of ts. Watchers thereby see objects both entering
+// and leaving the selection.
+func (ts typeSelector) matches(ev *EventInfo) bool {
+	if ts.Type != ev.Tid.TypeKey() {
+		return false
+	}
+	return ts.Sel.MatchesObject(ev.Object) || ts.Sel.MatchesObject(ev.Previous)
+}
+
+// typeFilter watches the objects of a type that match an expression,
+// and when fields are given, only updates that change one of them.
+type typeFilter struct {
+	Type   TypeKey
+	Expr   *Expr
+	Fields []string
+}
+
+// matches returns true if the object, or the object it replaced, has
+// the type of tf and matches its expression, and one of the fields of
+// tf has changed.
+func (tf typeFilter) matches(ev *EventInfo) bool {
+	if tf.Type != ev.Tid.TypeKey() {
+		return false
+	}
+	if len(tf.Fields) > 0 && ev.Kind == EventUpdated && !fieldsChanged(ev.Previous, ev.Object, tf.Fields) {
+		return false
+	}
+	return tf.Expr == nil || tf.Expr.matches(ev.Object) || tf.Expr.matches(ev.Previous)
 }
 
 type watchCtrlRsp struct {
 	watcherId string
+	stats     map[string]WatcherStats
 	err       error
 }
 
@@ -52,33 +131,95 @@ type watchCtrlReq struct {
 	watcherId  string
 	addTypes   []TypeKey
 	addTypeIds []TypeId
+	addSels    []typeSelector
+	addFilters []typeFilter
+	addAll     bool
 	rmTypes    []TypeKey
 	rmTypeIds  []TypeId
 	rmWatcher  bool
+	getStats   bool
+	disconnect error
 	evCh       chan *EventInfo
+	opts       WatcherOptions
 	rsp        chan watchCtrlRsp
 }
 
-var (
-	cmdCh chan watchCtrlReq
-	evCh  chan *EventInfo
-)
+func (db *DB) startWatch() {
+	// Watchers have queues of their own, this only lets writers go on
+	// while the dispatcher handles a control request
+	db.watchEvCh = make(chan *EventInfo, 64)
+	db.watchCmdCh = make(chan watchCtrlReq, 1)
+	db.watchCtx, db.watchStop = context.WithCancel(context.Background())
+	db.watchQueues = map[string]*watchQueue{}
+	go db.watchRun(db.watchCtx)
+}
+
+// watchQueue returns the queue of a watcher, or nil.
+func (db *DB) watchQueue(watcherId string) *watchQueue {
+	db.watchQueuesMux.Lock()
+	defer db.watchQueuesMux.Unlock()
+	return db.watchQueues[watcherId]
+}
 
-func watchRun(ctx context.Context) {
+func (db *DB) setWatchQueue(watcherId string, q *watchQueue) {
+	db.watchQueuesMux.Lock()
+	defer db.watchQueuesMux.Unlock()
+	if q == nil {
+		delete(db.watchQueues, watcherId)
+	} else {
+		db.watchQueues[watcherId] = q
+	}
+}
+
+// closeWatchQueues closes the queues of all watchers.
+func (db *DB) closeWatchQueues() {
+	db.watchQueuesMux.Lock()
+	queues := db.watchQueues
+	db.watchQueues = map[string]*watchQueue{}
+	db.watchQueuesMux.Unlock()
+	for _, q := range queues {
+		q.close()
+	}
+}
+
+func (db *DB) watchRun(ctx context.Context) {
 
 	watchInstances := map[string]*watchInstance{}
 
 	handleCmd := func(cmd watchCtrlReq) {
 		rsp := watchCtrlRsp{watcherId: cmd.watcherId}
-		if cmd.addTypeIds != nil || cmd.addTypes != nil {
-			if cmd.watcherId == "" {
-				rsp.watcherId = uuid.NewString()
-				watchInstances[rsp.watcherId] = &watchInstance{Ch: cmd.evCh}
+		if cmd.getStats {
+			rsp.stats = map[string]WatcherStats{}
+			for id, v := range watchInstances {
+				rsp.stats[id] = v.q.getStats()
 			}
+			cmd.rsp <- rsp
+			return
+		}
+		if cmd.disconnect != nil {
+			for _, v := range watchInstances {
+				if !v.Disconnected {
+					v.q.disconnect(cmd.disconnect)
+					v.Disconnected = true
+				}
+			}
+			cmd.rsp <- rsp
+			return
+		}
+		if cmd.watcherId == "" {
+			rsp.watcherId = uuid.NewString()
+			q := newWatchQueue(cmd.evCh, cmd.opts)
+			watchInstances[rsp.watcherId] = &watchInstance{All: cmd.addAll, q: q}
+			db.setWatchQueue(rsp.watcherId, q)
+		} else if _, ok := watchInstances[cmd.watcherId]; !ok {
+			rsp.err = ErrSessionInvalid
+			cmd.rsp <- rsp
+			return
 		}
 		if cmd.rmWatcher {
-			close(watchInstances[cmd.watcherId].Ch)
+			watchInstances[cmd.watcherId].q.close()
 			delete(watchInstances, rsp.watcherId)
+			db.setWatchQueue(rsp.watcherId, nil)
 			cmd.rsp <- rsp
 			return
 		}
@@ -88,6 +229,12 @@ func watchRun(ctx context.Context) {
 		if cmd.addTypes != nil {
 			watchInstances[rsp.watcherId].Types = append(watchInstances[rsp.watcherId].Types, cmd.addTypes...)
 		}
+		if cmd.addSels != nil {
+			watchInstances[rsp.watcherId].Selectors = append(watchInstances[rsp.watcherId].Selectors, cmd.addSels...)
+		}
+		if cmd.addFilters != nil {
+			watchInstances[rsp.watcherId].Filters = append(watchInstances[rsp.watcherId].Filters, cmd.addFilters...)
+		}
 		if cmd.rmTypeIds != nil {
 			res := []TypeId{}
 		tidLoop:
@@ -113,29 +260,35 @@ func watchRun(ctx context.Context) {
 				res = append(res, v)
 			}
 			watchInstances[rsp.watcherId].Types = res
+			sels := []typeSelector{}
+			for _, v := range watchInstances[rsp.watcherId].Selectors {
+				if !containsTypeKey(cmd.rmTypes, v.Type) {
+					sels = append(sels, v)
+				}
+			}
+			watchInstances[rsp.watcherId].Selectors = sels
+			filters := []typeFilter{}
+			for _, v := range watchInstances[rsp.watcherId].Filters {
+				if !containsTypeKey(cmd.rmTypes, v.Type) {
+					filters = append(filters, v)
+				}
+			}
+			watchInstances[rsp.watcherId].Filters = filters
 		}
 		cmd.rsp <- rsp
 	}
 
 	handleEvent := func(ev *EventInfo) {
-		typ := ev.Tid.TypeKey()
 		for _, v := range watchInstances {
-			for _, wv := range v.Types {
-				if wv == typ {
-					v.Ch <- ev
-				}
-			}
-			for _, wv := range v.TypeIds {
-				if wv == ev.Tid {
-					v.Ch <- ev
-				}
+			if !v.Disconnected && v.matches(ev) && !v.q.push(ev) {
+				v.Disconnected = true
 			}
 		}
 	}
 
 	closeAll := func() {
 		for _, v := range watchInstances {
-			close(v.Ch)
+			v.q.close()
 		}
 	}
 
@@ -143,80 +296,137 @@ func watchRun(ctx context.Context) {
 
 	for {
 		select {
-		case cmd := <-cmdCh:
+		case cmd := <-db.watchCmdCh:
+			// Events of writes that finished before the command was
+			// sent go first
+		drain:
+			for {
+				select {
+				case ev := <-db.watchEvCh:
+					handleEvent(ev)
+				default:
+					break drain
+				}
+			}
 			handleCmd(cmd)
 		case <-ctx.Done():
 			return
-		case ev := <-evCh:
+		case ev := <-db.watchEvCh:
 			handleEvent(ev)
 		}
 	}
 }
 
-func notifyCreate(obj IObject) {
-	m := obj.(proto.Message)
+// sendEvent hands an event to the dispatcher. Events are dropped once the
+// database has been closed.
+func (db *DB) sendEvent(ev *EventInfo) {
+	select {
+	case db.watchEvCh <- ev:
+	case <-db.watchCtx.Done():
+	}
+}
+
+// sendCmd hands a control request to the dispatcher and waits for the reply.
+func (db *DB) sendCmd(req watchCtrlReq) watchCtrlRsp {
+	closed := watchCtrlRsp{watcherId: req.watcherId, err: ErrSessionInvalid}
+	select {
+	case db.watchCmdCh <- req:
+	case <-db.watchCtx.Done():
+		return closed
+	}
+	select {
+	case rsp := <-req.rsp:
+		return rsp
+	case <-db.watchCtx.Done():
+		return closed
+	}
+}
+
+func (tx *Tx) notifyCreate(obj IObject) {
 	ev := &EventInfo{
 		Kind:     EventCreated,
-		Object:   proto.Clone(m).(IObject),
+		Object:   cloneObject(obj),
 		Previous: nil,
 		Tid:      obj.GetMetadata().TypeId(),
+		Revision: tx.Revision(),
 	}
-	evCh <- ev
+	tx.events = append(tx.events, ev)
 }
 
-func notifyUpdate(obj, prev IObject) {
-	mObj := obj.(proto.Message)
-	mPrev := prev.(proto.Message)
+func (tx *Tx) notifyUpdate(obj, prev IObject) {
 	ev := &EventInfo{
 		Kind:     EventUpdated,
-		Object:   proto.Clone(mObj).(IObject),
-		Previous: proto.Clone(mPrev).(IObject),
+		Object:   cloneObject(obj),
+		Previous: cloneObject(prev),
 		Tid:      obj.GetMetadata().TypeId(),
+		Revision: tx.Revision(),
 	}
-	evCh <- ev
+	tx.events = append(tx.events, ev)
 }
 
-func notifyDelete(obj IObject) {
-	mObj := obj.(proto.Message)
+func (tx *Tx) notifyDelete(obj IObject) {
 	ev := &EventInfo{
 		Kind:     EventDeleted,
-		Object:   proto.Clone(mObj).(IObject),
+		Object:   cloneObject(obj),
 		Previous: nil,
 		Tid:      obj.GetMetadata().TypeId(),
+		Revision: tx.Revision(),
+	}
+	tx.events = append(tx.events, ev)
+}
+
+// NewWatcher creates a watcher without any watches, to be added with
+// WatchType, WatchTypeId and WatchSelector. Events are queued for the
+// watcher as set by opts, so a slow receiver does not hold up other
+// watchers or writers unless it uses OverflowBlock. Watchers created by
+// the Watch functions use OverflowDisconnect with the default queue size.
+func (db *DB) NewWatcher(eventCh chan *EventInfo, opts WatcherOptions) (string, error) {
+	req := watchCtrlReq{
+		evCh: eventCh,
+		opts: opts,
+		rsp:  make(chan watchCtrlRsp, 1),
+	}
+	rsp := db.sendCmd(req)
+	return rsp.watcherId, rsp.err
+}
+
+// WatchStats returns the metrics of all watchers by watcherId.
+func (db *DB) WatchStats() (map[string]WatcherStats, error) {
+	req := watchCtrlReq{
+		getStats: true,
+		rsp:      make(chan watchCtrlRsp, 1),
 	}
-	evCh <- ev
+	rsp := db.sendCmd(req)
+	return rsp.stats, rsp.err
 }
 
 // WatchType creates or updates a watcher by adding watches to new TypeKeys
 // If the provided watcherId is the empty string, a new watcher is created and the
 // eventCh must be specified. If watcherId is non-empty, then the eventCh can be set to nil
 // The watcherId is returned.
-func WatchType(watcherId string, eventCh chan *EventInfo, typeKeys ...TypeKey) (string, error) {
+func (db *DB) WatchType(watcherId string, eventCh chan *EventInfo, typeKeys ...TypeKey) (string, error) {
 	req := watchCtrlReq{
 		watcherId: watcherId,
 		addTypes:  typeKeys,
 		evCh:      eventCh,
-		rsp:       make(chan watchCtrlRsp),
+		rsp:       make(chan watchCtrlRsp, 1),
 	}
-	cmdCh <- req
-	rsp := <-req.rsp
-	close(req.rsp)
+	rsp := db.sendCmd(req)
 	return rsp.watcherId, rsp.err
 }
 
-// UnwatchType removes a list of TypeKeys from a watcher
-func UnwatchType(watcherId string, typeKeys ...TypeKey) error {
+// UnwatchType removes a list of TypeKeys, and any selectors and
+// expressions on them, from a watcher
+func (db *DB) UnwatchType(watcherId string, typeKeys ...TypeKey) error {
 	if watcherId == "" {
 		return ErrSessionInvalid
 	}
 	req := watchCtrlReq{
 		watcherId: watcherId,
 		rmTypes:   typeKeys,
-		rsp:       make(chan watchCtrlRsp),
+		rsp:       make(chan watchCtrlRsp, 1),
 	}
-	cmdCh <- req
-	rsp := <-req.rsp
-	close(req.rsp)
+	rsp := db.sendCmd(req)
 	return rsp.err
 }
 
@@ -224,55 +434,117 @@ func UnwatchType(watcherId string, typeKeys ...TypeKey) error {
 // If the provided watcherId is the empty string, a new watcher is created and the
 // eventCh must be specified. If watcherId is non-empty, then the eventCh can be set to nil
 // The watcherId is returned.
-func WatchTypeId(watcherId string, eventCh chan *EventInfo, tids ...TypeId) (string, error) {
+func (db *DB) WatchTypeId(watcherId string, eventCh chan *EventInfo, tids ...TypeId) (string, error) {
 	req := watchCtrlReq{
 		watcherId:  watcherId,
 		addTypeIds: tids,
 		evCh:       eventCh,
-		rsp:        make(chan watchCtrlRsp),
+		rsp:        make(chan watchCtrlRsp, 1),
 	}
-	cmdCh <- req
-	rsp := <-req.rsp
-	close(req.rsp)
+	rsp := db.sendCmd(req)
+	return rsp.watcherId, rsp.err
+}
+
+// WatchSelector creates or updates a watcher by adding a watch on the
+// objects of a type whose labels match sel. Events are sent both for
+// objects that match and for objects that matched before the change.
+// See WatchType for watcherId and eventCh.
+func (db *DB) WatchSelector(watcherId string, eventCh chan *EventInfo, typeKey TypeKey, sel Selector) (string, error) {
+	req := watchCtrlReq{
+		watcherId: watcherId,
+		addSels:   []typeSelector{{Type: typeKey, Sel: sel}},
+		evCh:      eventCh,
+		rsp:       make(chan watchCtrlRsp, 1),
+	}
+	rsp := db.sendCmd(req)
+	return rsp.watcherId, rsp.err
+}
+
+// WatchExpr creates or updates a watcher by adding a watch on the
+// objects of a type that match expr, before or after the change. When
+// fields are given, as dotted field paths, updates are only sent if one
+// of those fields has changed. expr may be nil to only watch the fields.
+// See WatchType for watcherId and eventCh.
+func (db *DB) WatchExpr(watcherId string, eventCh chan *EventInfo, typeKey TypeKey, expr *Expr, fields ...string) (string, error) {
+	mi, err := db.reg.GetMessageInfo(typeKey)
+	if err != nil {
+		return watcherId, err
+	}
+	md := mi.MessageType.Descriptor()
+	if expr != nil {
+		if err := expr.Check(md); err != nil {
+			return watcherId, err
+		}
+	}
+	if err := checkFieldPaths(md, fields); err != nil {
+		return watcherId, err
+	}
+	req := watchCtrlReq{
+		watcherId:  watcherId,
+		addFilters: []typeFilter{{Type: typeKey, Expr: expr, Fields: fields}},
+		evCh:       eventCh,
+		rsp:        make(chan watchCtrlRsp, 1),
+	}
+	rsp := db.sendCmd(req)
 	return rsp.watcherId, rsp.err
 }
 
 // UnwatchTypeId removes a list of TypeIds from a watcher
-func UnwatchTypeId(watcherId string, tids ...TypeId) error {
+func (db *DB) UnwatchTypeId(watcherId string, tids ...TypeId) error {
 	if watcherId == "" {
 		return ErrSessionInvalid
 	}
 	req := watchCtrlReq{
 		watcherId: watcherId,
 		rmTypeIds: tids,
-		rsp:       make(chan watchCtrlRsp),
+		rsp:       make(chan watchCtrlRsp, 1),
 	}
-	cmdCh <- req
-	rsp := <-req.rsp
-	close(req.rsp)
+	rsp := db.sendCmd(req)
 	return rsp.err
 }
 
+// watchAll creates a watcher that is sent every change.
+func (db *DB) watchAll(eventCh chan *EventInfo, opts WatcherOptions) (string, error) {
+	req := watchCtrlReq{
+		addAll: true,
+		evCh:   eventCh,
+		opts:   opts,
+		rsp:    make(chan watchCtrlRsp, 1),
+	}
+	rsp := db.sendCmd(req)
+	return rsp.watcherId, rsp.err
+}
+
+// disconnectWatchers ends all watchers with an EventError event with
+// err. They still have to be removed.
+func (db *DB) disconnectWatchers(err error) {
+	db.sendCmd(watchCtrlReq{disconnect: err, rsp: make(chan watchCtrlRsp, 1)})
+}
+
 // RemoveWatcher closes the eventCh for the watcher and removes
-// the watcher.
-func RemoveWatcher(watcherId string) error {
+// the watcher. Events still queued for the watcher are dropped.
+func (db *DB) RemoveWatcher(watcherId string) error {
 	if watcherId == "" {
 		return ErrSessionInvalid
 	}
+	// Closing the queue first frees the dispatcher if it is blocked on it
+	if q := db.watchQueue(watcherId); q != nil {
+		q.close()
+	}
 	req := watchCtrlReq{
 		watcherId: watcherId,
 		rmWatcher: true,
-		rsp:       make(chan watchCtrlRsp),
+		rsp:       make(chan watchCtrlRsp, 1),
 	}
-	cmdCh <- req
-	rsp := <-req.rsp
-	close(req.rsp)
+	rsp := db.sendCmd(req)
 	return rsp.err
 }
 
-func init() {
-	evCh = make(chan *EventInfo, 1)
-	cmdCh = make(chan watchCtrlReq, 1)
-	go watchRun(context.Background())
-	// fmt.Println("init")
+func containsTypeKey(list []TypeKey, tk TypeKey) bool {
+	for _, v := range list {
+		if v == tk {
+			return true
+		}
+	}
+	return false
 }
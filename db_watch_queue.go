@@ -0,0 +1,246 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"sync"
+)
+
+// OverflowPolicy decides what happens when the queue of a watcher is full.
+type OverflowPolicy int
+
+const (
+	// OverflowDisconnect stops sending events to the watcher. It is sent
+	// the queued events followed by an EventError event, and then its
+	// channel is closed. The watcher must still be removed with
+	// RemoveWatcher, which also closes the channel if the events are
+	// never received. It is the default.
+	OverflowDisconnect OverflowPolicy = iota
+	// OverflowDropOldest drops the oldest queued event.
+	OverflowDropOldest
+	// OverflowCoalesce merges the events of the same object while they
+	// are queued, and drops the oldest event when the queue is full of
+	// events of different objects.
+	OverflowCoalesce
+	// OverflowBlock makes the dispatcher, and thereby all writers, wait
+	// until the watcher has made room in its queue. RemoveWatcher and
+	// Close do not wait for it.
+	OverflowBlock
+)
+
+// defaultWatchQueueSize is the queue size of watchers created without
+// WatcherOptions.
+const defaultWatchQueueSize = 1000
+
+// WatcherOptions controls the queue of a watcher. Events are queued
+// until the receiver of the event channel has taken them.
+type WatcherOptions struct {
+	// QueueSize is the maximum number of queued events. Defaults to 1000.
+	QueueSize int
+	// Overflow is applied when the queue is full.
+	Overflow OverflowPolicy
+}
+
+// WatcherStats are the metrics of a watcher.
+type WatcherStats struct {
+	// Queued is the number of events waiting to be received.
+	Queued int
+	// Lag is the number of changes between the last received event and
+	// the last queued event.
+	Lag uint64
+	// Delivered, Dropped and Coalesced count the events that have been
+	// received, dropped on overflow and merged with a queued event.
+	Delivered uint64
+	Dropped   uint64
+	Coalesced uint64
+}
+
+// watchQueue holds the events of a watcher until they are received from
+// its channel. It is filled by the dispatcher and emptied by pump.
+type watchQueue struct {
+	mux    sync.Mutex
+	cond   *sync.Cond
+	events []*EventInfo
+	opts   WatcherOptions
+	ch     chan *EventInfo
+	closed bool
+	// done stops pump, which closes stopped when it returns.
+	done     chan struct{}
+	doneOnce sync.Once
+	stopped  chan struct{}
+	// lastSeq is the Seq of the last queued event and deliveredSeq
+	// that of the last delivered one.
+	lastSeq      uint64
+	deliveredSeq uint64
+	stats        WatcherStats
+}
+
+func newWatchQueue(ch chan *EventInfo, opts WatcherOptions) *watchQueue {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultWatchQueueSize
+	}
+	q := &watchQueue{opts: opts, ch: ch, done: make(chan struct{}), stopped: make(chan struct{})}
+	q.cond = sync.NewCond(&q.mux)
+	go q.pump()
+	return q
+}
+
+// push queues ev. It returns false if the watcher has overflowed with
+// OverflowDisconnect and is to be removed.
+func (q *watchQueue) push(ev *EventInfo) bool {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	if q.closed {
+		return false
+	}
+	defer q.cond.Broadcast()
+	q.lastSeq = ev.Seq
+	if q.opts.Overflow == OverflowCoalesce && q.coalesce(ev) {
+		return true
+	}
+	if len(q.events) >= q.opts.QueueSize {
+		switch q.opts.Overflow {
+		case OverflowBlock:
+			for len(q.events) >= q.opts.QueueSize && !q.closed {
+				q.cond.Wait()
+			}
+			if q.closed {
+				return false
+			}
+		case OverflowDropOldest, OverflowCoalesce:
+			q.events = q.events[1:]
+			q.stats.Dropped++
+		case OverflowDisconnect:
+			q.stats.Dropped++
+			q.end(ErrWatcherOverflow)
+			return false
+		}
+	}
+	q.events = append(q.events, ev)
+	return true
+}
+
+// coalesce merges ev with a queued event of the same object, if any,
+// and queues the result last. It returns false if there was none.
+func (q *watchQueue) coalesce(ev *EventInfo) bool {
+	for i, queued := range q.events {
+		if queued.Tid != ev.Tid {
+			continue
+		}
+		q.events = append(q.events[:i], q.events[i+1:]...)
+		q.stats.Coalesced++
+		if merged := mergeEvents(queued, ev); merged != nil {
+			q.events = append(q.events, merged)
+		}
+		return true
+	}
+	return false
+}
+
+// mergeEvents returns the event equivalent to the event a followed by
+// the event b of the same object, or nil if they cancel out.
+func mergeEvents(a, b *EventInfo) *EventInfo {
+	merged := *b
+	switch {
+	case a.Kind == EventCreated && b.Kind == EventDeleted:
+		return nil
+	case a.Kind == EventCreated:
+		merged.Kind = EventCreated
+		merged.Previous = nil
+	case a.Kind == EventUpdated && b.Kind == EventUpdated:
+		merged.Previous = a.Previous
+	case a.Kind == EventDeleted && b.Kind == EventCreated:
+		merged.Kind = EventUpdated
+		merged.Previous = a.Object
+	}
+	return &merged
+}
+
+// disconnect stops queueing events. The queued events are sent followed
+// by an EventError event with err, and then the channel of the watcher
+// is closed.
+func (q *watchQueue) disconnect(err error) {
+	q.mux.Lock()
+	q.end(err)
+	q.mux.Unlock()
+	q.cond.Broadcast()
+}
+
+// end queues the EventError event of disconnect. q.mux must be held.
+func (q *watchQueue) end(err error) {
+	if !q.closed {
+		q.events = append(q.events, &EventInfo{Kind: EventError, Err: err})
+		q.closed = true
+	}
+}
+
+// close stops the queue, drops the queued events and closes the channel
+// of the watcher. A push blocked on the queue returns. It may be called
+// more than once and from any goroutine.
+func (q *watchQueue) close() {
+	q.mux.Lock()
+	if !q.closed {
+		q.closed = true
+		q.events = nil
+	}
+	q.mux.Unlock()
+	q.cond.Broadcast()
+	q.doneOnce.Do(func() { close(q.done) })
+	<-q.stopped
+}
+
+// pump sends the queued events to the channel of the watcher.
+func (q *watchQueue) pump() {
+	defer close(q.stopped)
+	defer close(q.ch)
+	for {
+		q.mux.Lock()
+		for len(q.events) == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if len(q.events) == 0 {
+			q.mux.Unlock()
+			return
+		}
+		ev := q.events[0]
+		q.events = q.events[1:]
+		q.mux.Unlock()
+		q.cond.Broadcast()
+
+		select {
+		case q.ch <- ev:
+		case <-q.done:
+			return
+		}
+
+		q.mux.Lock()
+		q.stats.Delivered++
+		if ev.Seq > 0 {
+			q.deliveredSeq = ev.Seq
+		}
+		q.mux.Unlock()
+	}
+}
+
+func (q *watchQueue) getStats() WatcherStats {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	stats := q.stats
+	stats.Queued = len(q.events)
+	if q.lastSeq > q.deliveredSeq {
+		stats.Lag = q.lastSeq - q.deliveredSeq
+	}
+	return stats
+}
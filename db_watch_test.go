@@ -15,20 +15,25 @@
 package shdb
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"testing"
+	"time"
 )
 
 func TestWatch(t *testing.T) {
 	count := 10
+	db := CreateTestDb()
+	defer RemoveTestData(db)
 	ch := make(chan *EventInfo, 1)
-	watchId, err := WatchType("", ch, TObj)
+	watchId, err := db.WatchType("", ch, TObj)
 	if err != nil {
 		t.FailNow()
 	}
+	done := make(chan struct{})
 	go func() {
-		defer UnwatchType(watchId, TObj)
+		defer close(done)
 		for i := 0; i < 10; i++ {
 			ev := <-ch
 			if ev.Kind != EventCreated {
@@ -40,17 +45,19 @@ func TestWatch(t *testing.T) {
 			}
 		}
 	}()
-	_, testDir := GenerateTestData(count)
-	defer RemoveTestData(testDir)
-	if err = RemoveWatcher(watchId); err != nil {
+	PutTestData(db, count)
+	<-done
+	if err = db.RemoveWatcher(watchId); err != nil {
 		t.Fail()
 	}
 }
 
 func TestWatch2(t *testing.T) {
 	count := 10
+	db := CreateTestDb()
+	defer RemoveTestData(db)
 	ch := make(chan *EventInfo, 1)
-	watchId, err := WatchType("", ch, TObj)
+	watchId, err := db.WatchType("", ch, TObj)
 	if err != nil {
 		t.FailNow()
 	}
@@ -101,18 +108,17 @@ func TestWatch2(t *testing.T) {
 		chDone <- nil
 	}()
 
-	_, testDir := GenerateTestData(count)
-	defer RemoveTestData(testDir)
+	PutTestData(db, count)
 
 	// Update
 	for i := count - 1; i >= 0; i-- {
-		obj, err := GetFirst(TObj, func(obj *TObject) bool {
+		obj, err := GetFirst(db, TObj, func(obj *TObject) bool {
 			return obj.MyInt == uint64(i)
 		})
 		if err != nil {
 			t.FailNow()
 		}
-		_, err = Update(obj.Metadata.TypeId(), func(obj *TObject) (*TObject, error) {
+		_, err = Update(db, obj.Metadata.TypeId(), func(obj *TObject) (*TObject, error) {
 			obj.MyInt++
 			return obj, nil
 		})
@@ -123,13 +129,13 @@ func TestWatch2(t *testing.T) {
 
 	// Delete
 	for i := 1; i <= count; i++ {
-		obj, err := GetFirst(TObj, func(obj *TObject) bool {
+		obj, err := GetFirst(db, TObj, func(obj *TObject) bool {
 			return obj.MyInt == uint64(i)
 		})
 		if err != nil {
 			t.FailNow()
 		}
-		_, err = Delete[*TObject](obj.Metadata.TypeId())
+		_, err = Delete[*TObject](db, obj.Metadata.TypeId())
 		if err != nil {
 			t.FailNow()
 		}
@@ -138,5 +144,264 @@ func TestWatch2(t *testing.T) {
 	if err != nil {
 		log.Printf("check failed %v\n", err)
 	}
-	UnwatchType(watchId, TObj)
+	db.UnwatchType(watchId, TObj)
+}
+
+// waitWatchStats waits until fn returns true for the stats of a watcher,
+// which are nil once the watcher has been removed.
+func waitWatchStats(t *testing.T, db *DB, watchId string, fn func(stats *WatcherStats) bool) {
+	for k := 0; k < 500; k++ {
+		all, err := db.WatchStats()
+		if err != nil {
+			t.FailNow()
+		}
+		var stats *WatcherStats
+		if s, ok := all[watchId]; ok {
+			stats = &s
+		}
+		if fn(stats) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("timeout waiting for watcher stats")
+}
+
+// drainWatch returns the events sent to ch until no more arrive.
+func drainWatch(ch chan *EventInfo) []*EventInfo {
+	evs := []*EventInfo{}
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return evs
+			}
+			evs = append(evs, ev)
+		case <-time.After(100 * time.Millisecond):
+			return evs
+		}
+	}
+}
+
+func TestWatchDropOldest(t *testing.T) {
+	db := CreateTestDb()
+	defer RemoveTestData(db)
+	ch := make(chan *EventInfo)
+	watchId, err := db.NewWatcher(ch, WatcherOptions{QueueSize: 2, Overflow: OverflowDropOldest})
+	if err != nil {
+		t.FailNow()
+	}
+	if _, err := db.WatchType(watchId, nil, TObj); err != nil {
+		t.FailNow()
+	}
+	// Writers are not held up by the watcher
+	PutTestData(db, 10)
+	waitWatchStats(t, db, watchId, func(stats *WatcherStats) bool {
+		return stats.Lag == 10
+	})
+	evs := drainWatch(ch)
+	if len(evs) == 0 || evs[len(evs)-1].Object.(*TObject).MyInt != 9 {
+		t.FailNow()
+	}
+	all, _ := db.WatchStats()
+	if stats := all[watchId]; stats.Dropped+uint64(len(evs)) != 10 || stats.Delivered != uint64(len(evs)) || stats.Lag != 0 {
+		t.Fail()
+	}
+	if err := db.RemoveWatcher(watchId); err != nil {
+		t.Fail()
+	}
+}
+
+func TestWatchCoalesce(t *testing.T) {
+	db := CreateTestDb()
+	defer RemoveTestData(db)
+	ch := make(chan *EventInfo)
+	watchId, err := db.NewWatcher(ch, WatcherOptions{Overflow: OverflowCoalesce})
+	if err != nil {
+		t.FailNow()
+	}
+	if _, err := db.WatchType(watchId, nil, TObj); err != nil {
+		t.FailNow()
+	}
+	obj := PutTestData(db, 1)[0]
+	for k := 0; k < 5; k++ {
+		if _, err := Update(db, obj.GetMetadata().TypeId(), func(obj *TObject) (*TObject, error) {
+			obj.MyInt++
+			return obj, nil
+		}); err != nil {
+			t.FailNow()
+		}
+	}
+	waitWatchStats(t, db, watchId, func(stats *WatcherStats) bool {
+		return stats.Lag == 6
+	})
+	evs := drainWatch(ch)
+	all, _ := db.WatchStats()
+	if stats := all[watchId]; stats.Coalesced+uint64(len(evs)) != 6 || stats.Dropped != 0 {
+		t.Fail()
+	}
+	last := evs[len(evs)-1]
+	if last.Object.(*TObject).MyInt != 5 || last.Seq != 6 {
+		t.Fail()
+	}
+	if len(evs) == 2 && (evs[1].Kind != EventUpdated || evs[1].Previous.(*TObject).MyInt != 0) {
+		t.Fail()
+	}
+	db.RemoveWatcher(watchId)
+}
+
+func TestWatchDisconnect(t *testing.T) {
+	db := CreateTestDb()
+	defer RemoveTestData(db)
+	ch := make(chan *EventInfo)
+	watchId, err := db.NewWatcher(ch, WatcherOptions{QueueSize: 1, Overflow: OverflowDisconnect})
+	if err != nil {
+		t.FailNow()
+	}
+	if _, err := db.WatchType(watchId, nil, TObj); err != nil {
+		t.FailNow()
+	}
+	PutTestData(db, 5)
+	waitWatchStats(t, db, watchId, func(stats *WatcherStats) bool {
+		return stats != nil && stats.Dropped > 0
+	})
+	evs := drainWatch(ch)
+	if len(evs) < 2 || len(evs) > 3 {
+		t.FailNow()
+	}
+	if last := evs[len(evs)-1]; last.Kind != EventError || !errors.Is(last.Err, ErrWatcherOverflow) {
+		t.Fail()
+	}
+	if _, ok := <-ch; ok {
+		t.Fail()
+	}
+	if err := db.RemoveWatcher(watchId); err != nil {
+		t.Fail()
+	}
+	if err := db.RemoveWatcher(watchId); !errors.Is(err, ErrSessionInvalid) {
+		t.Fail()
+	}
+
+	// Removing a disconnected watcher whose events are never received
+	// closes its channel
+	ch = make(chan *EventInfo)
+	if watchId, err = db.NewWatcher(ch, WatcherOptions{QueueSize: 1, Overflow: OverflowDisconnect}); err != nil {
+		t.FailNow()
+	}
+	if _, err := db.WatchType(watchId, nil, TObj); err != nil {
+		t.FailNow()
+	}
+	PutTestData(db, 5)
+	waitWatchStats(t, db, watchId, func(stats *WatcherStats) bool {
+		return stats != nil && stats.Dropped > 0
+	})
+	if err := db.RemoveWatcher(watchId); err != nil {
+		t.Fail()
+	}
+	if _, ok := <-ch; ok {
+		t.Fail()
+	}
+}
+
+func TestWatchBlock(t *testing.T) {
+	for _, remove := range []bool{true, false} {
+		db := CreateTestDb()
+		ch := make(chan *EventInfo)
+		watchId, err := db.NewWatcher(ch, WatcherOptions{QueueSize: 1, Overflow: OverflowBlock})
+		if err != nil {
+			t.FailNow()
+		}
+		if _, err := db.WatchType(watchId, nil, TObj); err != nil {
+			t.FailNow()
+		}
+		// The writers are held up by the watcher until it is removed or
+		// the database is closed
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			// More than the dispatcher buffers
+			for i := 0; i < 100; i++ {
+				Put(db, MustNew[*TObject](db, TObj))
+			}
+		}()
+		select {
+		case <-done:
+			t.FailNow()
+		case <-time.After(100 * time.Millisecond):
+		}
+		if remove {
+			if err := db.RemoveWatcher(watchId); err != nil {
+				t.Fail()
+			}
+		} else {
+			CloseTestDb(db)
+		}
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.FailNow()
+		}
+		drainWatch(ch)
+		if remove {
+			CloseTestDb(db)
+		}
+	}
+}
+
+func TestRemoveUnknownWatcher(t *testing.T) {
+	db := CreateTestDb()
+	defer RemoveTestData(db)
+	if err := db.RemoveWatcher("nope"); !errors.Is(err, ErrSessionInvalid) {
+		t.Fail()
+	}
+	if _, err := db.WatchType("nope", nil, TObj); !errors.Is(err, ErrSessionInvalid) {
+		t.Fail()
+	}
+}
+
+func TestWatchExpr(t *testing.T) {
+	db := CreateTestDb()
+	defer RemoveTestData(db)
+	ch := make(chan *EventInfo, 10)
+	watchId, err := db.WatchExpr("", ch, TObj, MustParseExpr("my_int > 10"))
+	if err != nil {
+		t.FailNow()
+	}
+	if _, err := db.WatchExpr(watchId, nil, TObj, MustParseExpr("nope > 1")); !errors.Is(err, ErrInvalidExpr) {
+		t.Fail()
+	}
+	list := PutTestData(db, 12)
+	// Creates and deletes always change the fields
+	if _, err := db.WatchExpr(watchId, nil, TObj, nil, "my_string"); err != nil {
+		t.FailNow()
+	}
+	update := func(obj *TObject, fn func(obj *TObject)) {
+		if _, err := Update(db, obj.GetMetadata().TypeId(), func(obj *TObject) (*TObject, error) {
+			fn(obj)
+			return obj, nil
+		}); err != nil {
+			t.FailNow()
+		}
+	}
+	// Neither matching nor changing my_string
+	update(list[0], func(obj *TObject) { obj.MyInt = 5 })
+	// Changing my_string
+	update(list[1], func(obj *TObject) { obj.MyString = "x" })
+	// Leaving the expression
+	update(list[11], func(obj *TObject) { obj.MyInt = 1 })
+
+	evs := drainWatch(ch)
+	if len(evs) != 3 {
+		t.FailNow()
+	}
+	if evs[0].Kind != EventCreated || evs[0].Object.(*TObject).MyInt != 11 {
+		t.Fail()
+	}
+	if evs[1].Kind != EventUpdated || evs[1].Object.(*TObject).MyString != "x" {
+		t.Fail()
+	}
+	if evs[2].Kind != EventUpdated || evs[2].Previous.(*TObject).MyInt != 11 {
+		t.Fail()
+	}
+	db.RemoveWatcher(watchId)
 }
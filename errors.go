@@ -16,6 +16,7 @@ package shdb
 
 import (
 	"errors"
+	"fmt"
 )
 
 // Todo: Make it possible to add arguments to the errors.
@@ -23,10 +24,74 @@ import (
 // also a new IsErrNotFound(err).
 
 var (
-	ErrNotAnObject      = errors.New("not an object type")
-	ErrInvalidType      = errors.New("invalid type")
-	ErrNotFound         = errors.New("not found")
-	ErrSessionInvalid   = errors.New("session invalid")
-	ErrContextCancelled = errors.New("context cancelled")
-	ErrDatabaseCorrupt  = errors.New("database corrupt")
+	ErrNotAnObject        = errors.New("not an object type")
+	ErrInvalidType        = errors.New("invalid type")
+	ErrNotFound           = errors.New("not found")
+	ErrSessionInvalid     = errors.New("session invalid")
+	ErrContextCancelled   = errors.New("context cancelled")
+	ErrDatabaseCorrupt    = errors.New("database corrupt")
+	ErrConflict           = errors.New("conflict")
+	ErrInvalidIndex       = errors.New("invalid index")
+	ErrInvalidSelector    = errors.New("invalid selector")
+	ErrDanglingRef        = errors.New("dangling reference")
+	ErrReferenced         = errors.New("object is referenced")
+	ErrIncompatibleSchema = errors.New("incompatible schema")
+	ErrTypeKeyCollision   = errors.New("type key collision")
+	ErrNotSupported       = errors.New("not supported")
+	ErrChangelogTruncated = errors.New("changelog truncated")
+	ErrWatcherOverflow    = errors.New("watcher queue overflow")
+	ErrInvalidExpr        = errors.New("invalid expression")
+	ErrRestored           = errors.New("database restored")
+	ErrInvalidSeq         = errors.New("invalid sequence number")
 )
+
+// ConflictError is returned by conditional writes when the stored object
+// is not in the expected state. It matches ErrConflict with errors.Is.
+type ConflictError struct {
+	TypeId TypeId
+	// Expected is the revision the caller expected. Zero means that the
+	// object was expected not to exist.
+	Expected uint64
+	// Actual is the stored revision, zero if the object does not exist.
+	Actual uint64
+}
+
+func (e *ConflictError) Error() string {
+	if e.Expected == 0 {
+		return fmt.Sprintf("conflict: object %s already exists", e.TypeId.Uuid())
+	}
+	if e.Actual == 0 {
+		return fmt.Sprintf("conflict: object %s does not exist, expected revision %d", e.TypeId.Uuid(), e.Expected)
+	}
+	return fmt.Sprintf("conflict: object %s has revision %d, expected %d", e.TypeId.Uuid(), e.Actual, e.Expected)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrConflict
+}
+
+// RefError is returned when a write would break a reference between two
+// objects. It matches ErrDanglingRef or ErrReferenced with errors.Is.
+type RefError struct {
+	// TypeId of the referencing object.
+	TypeId TypeId
+	// Field is the path of the ObjRef field.
+	Field string
+	// Ref is the referenced object, zero if a required field is not set.
+	Ref TypeId
+	err error
+}
+
+func (e *RefError) Error() string {
+	if e.err == ErrReferenced {
+		return fmt.Sprintf("object %s is referenced by %s in field %s", e.Ref.Uuid(), e.TypeId.Uuid(), e.Field)
+	}
+	if e.Ref == (TypeId{}) {
+		return fmt.Sprintf("dangling reference: required field %s of %s is not set", e.Field, e.TypeId.Uuid())
+	}
+	return fmt.Sprintf("dangling reference: field %s of %s references %s", e.Field, e.TypeId.Uuid(), e.Ref.Uuid())
+}
+
+func (e *RefError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,812 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Expr is a boolean expression over the fields of an object, for
+// instance
+//
+//	my_int > 10 && (my_string == "duck" || !my_bool)
+//
+// Fields are referred to by their dotted proto field path. A field is
+// compared to a literal with ==, !=, <, <=, > or >=, and conditions are
+// combined with &&, || and !. A field on its own is true if it is set.
+// Literals are numbers, double quoted strings, true, false and enum value
+// names. Integers are decimal, even with leading zeros, unless prefixed by
+// 0x, 0o or 0b for hexadecimal, octal or binary, also when compared to
+// float fields. Timestamps are compared to RFC 3339 strings.
+//
+// String fields are matched against a regular expression with =~, as in
+// my_string =~ "^du". Repeated fields are tested for an element with has,
+// as in metadata.labels has "env=prod", and maps for a key. A path
+// through a repeated message is true if it is true for any element.
+type Expr struct {
+	src  string
+	root exprNode
+}
+
+type exprNode interface {
+	eval(m protoreflect.Message) (bool, error)
+	check(md protoreflect.MessageDescriptor) error
+}
+
+// ParseExpr parses an expression, see Expr.
+func ParseExpr(src string) (*Expr, error) {
+	p := &exprParser{src: src}
+	if err := p.lex(); err != nil {
+		return nil, err
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, p.errorf("unexpected %q", p.toks[p.pos].text)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// MustParseExpr is like ParseExpr but panics on errors.
+func MustParseExpr(src string) *Expr {
+	e, err := ParseExpr(src)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Check returns an error if the expression refers to fields that md
+// does not have, or compares fields to literals of another type.
+func (e *Expr) Check(md protoreflect.MessageDescriptor) error {
+	if err := e.root.check(md); err != nil {
+		return fmt.Errorf("%w: %v in %q", ErrInvalidExpr, err, e.src)
+	}
+	return nil
+}
+
+// Match evaluates the expression for msg.
+func (e *Expr) Match(msg proto.Message) (bool, error) {
+	ok, err := e.root.eval(msg.ProtoReflect())
+	if err != nil {
+		return false, fmt.Errorf("%w: %v in %q", ErrInvalidExpr, err, e.src)
+	}
+	return ok, nil
+}
+
+// matches is Match for messages that may be nil, with errors as no match.
+func (e *Expr) matches(msg proto.Message) bool {
+	if msg == nil {
+		return false
+	}
+	ok, err := e.Match(msg)
+	return err == nil && ok
+}
+
+type tokenKind int
+
+const (
+	tokIdent tokenKind = iota
+	tokNumber
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+type exprParser struct {
+	src  string
+	toks []token
+	pos  int
+}
+
+func (p *exprParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s in %q", ErrInvalidExpr, fmt.Sprintf(format, args...), p.src)
+}
+
+var exprOps = []string{"==", "!=", "=~", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")"}
+
+func isIdentRune(c rune) bool {
+	return c == '_' || c == '.' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+func (p *exprParser) lex() error {
+	s := p.src
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"':
+			j := i + 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				if s[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(s) {
+				return p.errorf("unterminated string")
+			}
+			text, err := strconv.Unquote(s[i : j+1])
+			if err != nil {
+				return p.errorf("invalid string %s", s[i:j+1])
+			}
+			p.toks = append(p.toks, token{kind: tokString, text: text})
+			i = j + 1
+		case unicode.IsDigit(c) || (c == '-' && i+1 < len(s) && unicode.IsDigit(rune(s[i+1]))):
+			j := i + 1
+			for j < len(s) && (isIdentRune(rune(s[j])) || (s[j] == '-' || s[j] == '+') && (s[j-1] == 'e' || s[j-1] == 'E')) {
+				j++
+			}
+			p.toks = append(p.toks, token{kind: tokNumber, text: s[i:j]})
+			i = j
+		case isIdentRune(c):
+			j := i
+			for j < len(s) && isIdentRune(rune(s[j])) {
+				j++
+			}
+			p.toks = append(p.toks, token{kind: tokIdent, text: s[i:j]})
+			i = j
+		default:
+			op := ""
+			for _, o := range exprOps {
+				if strings.HasPrefix(s[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return p.errorf("unexpected %q", s[i:i+1])
+			}
+			p.toks = append(p.toks, token{kind: tokOp, text: op})
+			i += len(op)
+		}
+	}
+	return nil
+}
+
+func (p *exprParser) peekOp(op string) bool {
+	return p.pos < len(p.toks) && p.toks[p.pos].kind == tokOp && p.toks[p.pos].text == op
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	x, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOp("||") {
+		p.pos++
+		y, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		x = orNode{x, y}
+	}
+	return x, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOp("&&") {
+		p.pos++
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		x = andNode{x, y}
+	}
+	return x, nil
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.peekOp("!") {
+		p.pos++
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{x}, nil
+	}
+	if p.peekOp("(") {
+		p.pos++
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peekOp(")") {
+			return nil, p.errorf("missing )")
+		}
+		p.pos++
+		return x, nil
+	}
+	if p.pos >= len(p.toks) {
+		return nil, p.errorf("unexpected end")
+	}
+	tok := p.toks[p.pos]
+	if tok.kind != tokIdent {
+		return nil, p.errorf("expected a field, got %q", tok.text)
+	}
+	p.pos++
+	path := strings.Split(tok.text, ".")
+	if p.pos < len(p.toks) && p.toks[p.pos].kind == tokIdent && p.toks[p.pos].text == "has" {
+		p.pos++
+		lit, err := p.literal("has")
+		if err != nil {
+			return nil, err
+		}
+		return hasNode{path: path, lit: lit}, nil
+	}
+	if p.peekOp("=~") {
+		p.pos++
+		lit, err := p.literal("=~")
+		if err != nil {
+			return nil, err
+		}
+		if lit.kind != tokString {
+			return nil, p.errorf("expected a string after =~, got %q", lit.text)
+		}
+		re, err := regexp.Compile(lit.text)
+		if err != nil {
+			return nil, p.errorf("invalid regular expression %q: %v", lit.text, err)
+		}
+		return regexNode{path: path, re: re}, nil
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if !p.peekOp(op) {
+			continue
+		}
+		p.pos++
+		lit, err := p.literal(op)
+		if err != nil {
+			return nil, err
+		}
+		return cmpNode{path: path, op: op, lit: lit}, nil
+	}
+	return fieldNode{path: path}, nil
+}
+
+// literal returns the literal after op.
+func (p *exprParser) literal(op string) (token, error) {
+	if p.pos >= len(p.toks) || p.toks[p.pos].kind == tokOp {
+		return token{}, p.errorf("expected a value after %s", op)
+	}
+	p.pos++
+	return p.toks[p.pos-1], nil
+}
+
+type andNode struct{ x, y exprNode }
+
+func (n andNode) eval(m protoreflect.Message) (bool, error) {
+	ok, err := n.x.eval(m)
+	if err != nil || !ok {
+		return false, err
+	}
+	return n.y.eval(m)
+}
+
+func (n andNode) check(md protoreflect.MessageDescriptor) error {
+	if err := n.x.check(md); err != nil {
+		return err
+	}
+	return n.y.check(md)
+}
+
+type orNode struct{ x, y exprNode }
+
+func (n orNode) eval(m protoreflect.Message) (bool, error) {
+	ok, err := n.x.eval(m)
+	if err != nil || ok {
+		return ok, err
+	}
+	return n.y.eval(m)
+}
+
+func (n orNode) check(md protoreflect.MessageDescriptor) error {
+	if err := n.x.check(md); err != nil {
+		return err
+	}
+	return n.y.check(md)
+}
+
+type notNode struct{ x exprNode }
+
+func (n notNode) eval(m protoreflect.Message) (bool, error) {
+	ok, err := n.x.eval(m)
+	return !ok, err
+}
+
+func (n notNode) check(md protoreflect.MessageDescriptor) error {
+	return n.x.check(md)
+}
+
+// fieldNode is true if the field is set.
+type fieldNode struct{ path []string }
+
+func (n fieldNode) eval(m protoreflect.Message) (bool, error) {
+	fds, err := fieldPath(m.Descriptor(), n.path)
+	if err != nil {
+		return false, err
+	}
+	fd := fds[len(fds)-1]
+	for _, h := range holders(m, fds) {
+		if h.Has(fd) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (n fieldNode) check(md protoreflect.MessageDescriptor) error {
+	_, err := fieldPath(md, n.path)
+	return err
+}
+
+// cmpNode compares a singular field to a literal.
+type cmpNode struct {
+	path []string
+	op   string
+	lit  token
+}
+
+func (n cmpNode) check(md protoreflect.MessageDescriptor) error {
+	fds, err := fieldPath(md, n.path)
+	if err != nil {
+		return err
+	}
+	fd := fds[len(fds)-1]
+	if fd.IsList() || fd.IsMap() {
+		return fmt.Errorf("%s is repeated, use has", strings.Join(n.path, "."))
+	}
+	if err := checkLiteral(fd, n.lit); err != nil {
+		return fmt.Errorf("%s: %v", strings.Join(n.path, "."), err)
+	}
+	return nil
+}
+
+func (n cmpNode) eval(m protoreflect.Message) (bool, error) {
+	fds, err := fieldPath(m.Descriptor(), n.path)
+	if err != nil {
+		return false, err
+	}
+	fd := fds[len(fds)-1]
+	if fd.IsList() || fd.IsMap() {
+		return false, fmt.Errorf("%s is repeated, use has", strings.Join(n.path, "."))
+	}
+	for _, h := range holders(m, fds) {
+		c, err := compareField(fd, h.Get(fd), n.lit)
+		if err != nil {
+			return false, fmt.Errorf("%s: %v", strings.Join(n.path, "."), err)
+		}
+		if compareOp(n.op, c) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func compareOp(op string, c int) bool {
+	switch op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+// regexNode matches a singular string or bytes field with a regular
+// expression.
+type regexNode struct {
+	path []string
+	re   *regexp.Regexp
+}
+
+func (n regexNode) check(md protoreflect.MessageDescriptor) error {
+	fds, err := fieldPath(md, n.path)
+	if err != nil {
+		return err
+	}
+	fd := fds[len(fds)-1]
+	if fd.IsList() || fd.IsMap() {
+		return fmt.Errorf("%s is repeated", strings.Join(n.path, "."))
+	}
+	if fd.Kind() != protoreflect.StringKind && fd.Kind() != protoreflect.BytesKind {
+		return fmt.Errorf("cannot match %s %s with =~", fd.Kind(), strings.Join(n.path, "."))
+	}
+	return nil
+}
+
+func (n regexNode) eval(m protoreflect.Message) (bool, error) {
+	if err := n.check(m.Descriptor()); err != nil {
+		return false, err
+	}
+	fds, _ := fieldPath(m.Descriptor(), n.path)
+	fd := fds[len(fds)-1]
+	for _, h := range holders(m, fds) {
+		v := h.Get(fd)
+		if fd.Kind() == protoreflect.BytesKind && n.re.Match(v.Bytes()) || fd.Kind() == protoreflect.StringKind && n.re.MatchString(v.String()) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hasNode is true if a repeated field has an element, or a map has a key,
+// equal to a literal.
+type hasNode struct {
+	path []string
+	lit  token
+}
+
+func (n hasNode) check(md protoreflect.MessageDescriptor) error {
+	fds, err := fieldPath(md, n.path)
+	if err != nil {
+		return err
+	}
+	fd := fds[len(fds)-1]
+	switch {
+	case fd.IsMap():
+		err = checkLiteral(fd.MapKey(), n.lit)
+	case fd.IsList():
+		err = checkLiteral(fd, n.lit)
+	default:
+		return fmt.Errorf("%s is not repeated", strings.Join(n.path, "."))
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", strings.Join(n.path, "."), err)
+	}
+	return nil
+}
+
+func (n hasNode) eval(m protoreflect.Message) (bool, error) {
+	if err := n.check(m.Descriptor()); err != nil {
+		return false, err
+	}
+	fds, _ := fieldPath(m.Descriptor(), n.path)
+	fd := fds[len(fds)-1]
+	for _, h := range holders(m, fds) {
+		if fd.IsMap() {
+			found := false
+			h.Get(fd).Map().Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+				c, err := compareField(fd.MapKey(), k.Value(), n.lit)
+				found = err == nil && c == 0
+				return !found
+			})
+			if found {
+				return true, nil
+			}
+			continue
+		}
+		l := h.Get(fd).List()
+		for i := 0; i < l.Len(); i++ {
+			if c, err := compareField(fd, l.Get(i), n.lit); err == nil && c == 0 {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// fieldPath resolves a field path in md. All but the last field must be
+// messages, which may be repeated.
+func fieldPath(md protoreflect.MessageDescriptor, path []string) ([]protoreflect.FieldDescriptor, error) {
+	fds := []protoreflect.FieldDescriptor{}
+	for i, name := range path {
+		if md == nil {
+			return nil, fmt.Errorf("%s is not a message", strings.Join(path[:i], "."))
+		}
+		fd := md.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			return nil, fmt.Errorf("no field %s in %s", strings.Join(path[:i+1], "."), md.FullName())
+		}
+		if i < len(path)-1 && fd.IsMap() {
+			return nil, fmt.Errorf("%s is a map", strings.Join(path[:i+1], "."))
+		}
+		fds = append(fds, fd)
+		md = fd.Message()
+	}
+	return fds, nil
+}
+
+// singularFieldPath is fieldPath without repeated messages on the way.
+func singularFieldPath(md protoreflect.MessageDescriptor, path []string) ([]protoreflect.FieldDescriptor, error) {
+	fds, err := fieldPath(md, path)
+	if err != nil {
+		return nil, err
+	}
+	for i, fd := range fds[:len(fds)-1] {
+		if fd.IsList() {
+			return nil, fmt.Errorf("%s is repeated", strings.Join(path[:i+1], "."))
+		}
+	}
+	return fds, nil
+}
+
+// resolveField returns the last field of path and the message holding
+// it. Unset messages on the way are read as empty messages.
+func resolveField(m protoreflect.Message, path []string) (protoreflect.Message, protoreflect.FieldDescriptor, error) {
+	fds, err := singularFieldPath(m.Descriptor(), path)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, fd := range fds[:len(fds)-1] {
+		m = m.Get(fd).Message()
+	}
+	return m, fds[len(fds)-1], nil
+}
+
+// holders returns the messages holding the last field of fds, one for
+// every element of the repeated messages on the way. Unset messages are
+// read as empty messages.
+func holders(m protoreflect.Message, fds []protoreflect.FieldDescriptor) []protoreflect.Message {
+	ms := []protoreflect.Message{m}
+	for _, fd := range fds[:len(fds)-1] {
+		next := []protoreflect.Message{}
+		for _, m := range ms {
+			if !fd.IsList() {
+				next = append(next, m.Get(fd).Message())
+				continue
+			}
+			l := m.Get(fd).List()
+			for i := 0; i < l.Len(); i++ {
+				next = append(next, l.Get(i).Message())
+			}
+		}
+		ms = next
+	}
+	return ms
+}
+
+// checkLiteral returns an error if values of fd cannot be compared to lit.
+func checkLiteral(fd protoreflect.FieldDescriptor, lit token) error {
+	var zero protoreflect.Value
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		zero = protoreflect.ValueOfBool(false)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		zero = protoreflect.ValueOfInt64(0)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		zero = protoreflect.ValueOfUint64(0)
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		zero = protoreflect.ValueOfFloat64(0)
+	case protoreflect.StringKind:
+		zero = protoreflect.ValueOfString("")
+	case protoreflect.BytesKind:
+		zero = protoreflect.ValueOfBytes(nil)
+	case protoreflect.EnumKind:
+		zero = protoreflect.ValueOfEnum(0)
+	case protoreflect.MessageKind:
+		zero = protoreflect.ValueOfMessage((&timestamppb.Timestamp{}).ProtoReflect())
+	}
+	_, err := compareField(fd, zero, lit)
+	return err
+}
+
+// intBase strips the 0x, 0o or 0b prefix of an integer literal and
+// returns the remaining text and its base, 10 if there is no prefix.
+func intBase(s string) (string, int) {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	if len(s) > 2 && s[0] == '0' {
+		switch s[1] {
+		case 'x', 'X':
+			return sign + s[2:], 16
+		case 'o', 'O':
+			return sign + s[2:], 8
+		case 'b', 'B':
+			return sign + s[2:], 2
+		}
+	}
+	return sign + s, 10
+}
+
+func parseInt(s string, bitSize int) (int64, error) {
+	s, base := intBase(s)
+	return strconv.ParseInt(s, base, bitSize)
+}
+
+func parseUint(s string) (uint64, error) {
+	s, base := intBase(s)
+	return strconv.ParseUint(s, base, 64)
+}
+
+// parseFloat parses a decimal number, or an integer with the prefixes of
+// intBase.
+func parseFloat(s string) (float64, error) {
+	if _, base := intBase(s); base != 10 {
+		i, err := parseInt(s, 64)
+		return float64(i), err
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// compareField compares a field value to a literal and returns -1, 0 or
+// 1 as for strings.Compare.
+func compareField(fd protoreflect.FieldDescriptor, v protoreflect.Value, lit token) (int, error) {
+	mismatch := fmt.Errorf("cannot compare %s to %q", fd.Kind(), lit.text)
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		if lit.kind != tokIdent || (lit.text != "true" && lit.text != "false") {
+			return 0, mismatch
+		}
+		return compareOrdered(boolInt(v.Bool()), boolInt(lit.text == "true")), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		if lit.kind != tokNumber {
+			return 0, mismatch
+		}
+		if i, err := parseInt(lit.text, 64); err == nil {
+			return compareOrdered(v.Int(), i), nil
+		}
+		f, err := parseFloat(lit.text)
+		if err != nil {
+			return 0, mismatch
+		}
+		return compareOrdered(float64(v.Int()), f), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if lit.kind != tokNumber {
+			return 0, mismatch
+		}
+		if u, err := parseUint(lit.text); err == nil {
+			return compareOrdered(v.Uint(), u), nil
+		}
+		f, err := parseFloat(lit.text)
+		if err != nil {
+			return 0, mismatch
+		}
+		return compareOrdered(float64(v.Uint()), f), nil
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		if lit.kind != tokNumber {
+			return 0, mismatch
+		}
+		f, err := parseFloat(lit.text)
+		if err != nil {
+			return 0, mismatch
+		}
+		return compareOrdered(v.Float(), f), nil
+	case protoreflect.StringKind:
+		if lit.kind != tokString {
+			return 0, mismatch
+		}
+		return strings.Compare(v.String(), lit.text), nil
+	case protoreflect.BytesKind:
+		if lit.kind != tokString {
+			return 0, mismatch
+		}
+		return strings.Compare(string(v.Bytes()), lit.text), nil
+	case protoreflect.EnumKind:
+		num := protoreflect.EnumNumber(0)
+		switch lit.kind {
+		case tokIdent:
+			ev := fd.Enum().Values().ByName(protoreflect.Name(lit.text))
+			if ev == nil {
+				return 0, fmt.Errorf("no value %s in %s", lit.text, fd.Enum().FullName())
+			}
+			num = ev.Number()
+		case tokNumber:
+			i, err := parseInt(lit.text, 32)
+			if err != nil {
+				return 0, mismatch
+			}
+			num = protoreflect.EnumNumber(i)
+		default:
+			return 0, mismatch
+		}
+		return compareOrdered(v.Enum(), num), nil
+	case protoreflect.MessageKind:
+		if fd.Message().FullName() != timestampFullname || lit.kind != tokString {
+			return 0, mismatch
+		}
+		t, err := time.Parse(time.RFC3339Nano, lit.text)
+		if err != nil {
+			return 0, mismatch
+		}
+		m := v.Message()
+		fields := m.Descriptor().Fields()
+		ts := time.Unix(m.Get(fields.ByName("seconds")).Int(), m.Get(fields.ByName("nanos")).Int())
+		return ts.Compare(t), nil
+	}
+	return 0, mismatch
+}
+
+type ordered interface {
+	~int | ~int32 | ~int64 | ~uint64 | ~float64
+}
+
+func compareOrdered[T ordered](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// fieldsChanged returns true if any of the fields at the dotted paths
+// differ between a and b, which are of the same type.
+func fieldsChanged(a, b proto.Message, paths []string) bool {
+	for _, path := range paths {
+		ma, fd, err := resolveField(a.ProtoReflect(), strings.Split(path, "."))
+		if err != nil {
+			continue
+		}
+		mb, _, err := resolveField(b.ProtoReflect(), strings.Split(path, "."))
+		if err != nil {
+			continue
+		}
+		// Compare messages holding only the field
+		ca, cb := ma.Type().New(), mb.Type().New()
+		if ma.Has(fd) {
+			ca.Set(fd, ma.Get(fd))
+		}
+		if mb.Has(fd) {
+			cb.Set(fd, mb.Get(fd))
+		}
+		if !proto.Equal(ca.Interface(), cb.Interface()) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFieldPaths returns an error if md does not have all the fields at
+// the dotted paths.
+func checkFieldPaths(md protoreflect.MessageDescriptor, paths []string) error {
+	for _, path := range paths {
+		if _, err := singularFieldPath(md, strings.Split(path, ".")); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidExpr, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestExpr(t *testing.T) {
+	obj := &TObject{
+		Metadata:  &Metadata{Description: "duck"},
+		MyInt:     12,
+		MyString:  "donald",
+		Timestamp: timestamppb.New(time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)),
+	}
+	tests := []struct {
+		src   string
+		match bool
+	}{
+		{"my_int > 10", true},
+		{"my_int >= 12 && my_int <= 12", true},
+		{"my_int == 0x0c", true},
+		{"my_int == 0o14", true},
+		{"my_int == 0b1100", true},
+		{"my_int == 012", true},
+		{"my_int < 0X0D", true},
+		{"my_int < 10 || my_string == \"donald\"", true},
+		{"!(my_int > 10)", false},
+		{"my_string != \"donald\"", false},
+		{"my_string > \"daisy\"", true},
+		{"metadata.description == \"duck\"", true},
+		{"timestamp < \"2023-05-02T00:00:00Z\"", true},
+		{"timestamp", true},
+		{"any", false},
+		{"metadata.created_at", false},
+		{"metadata.created_at.seconds == 0", true},
+		{"my_int > 11.5", true},
+	}
+	for _, test := range tests {
+		e, err := ParseExpr(test.src)
+		if err != nil {
+			t.Fatalf("%s: %v", test.src, err)
+		}
+		if err := e.Check(obj.ProtoReflect().Descriptor()); err != nil {
+			t.Errorf("%s: %v", test.src, err)
+		}
+		if ok, err := e.Match(obj); err != nil || ok != test.match {
+			t.Errorf("%s: got %v, %v", test.src, ok, err)
+		}
+	}
+
+	fd := &descriptorpb.FieldDescriptorProto{Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()}
+	if ok, err := MustParseExpr("type == TYPE_STRING && type > 1").Match(fd); err != nil || !ok {
+		t.Fail()
+	}
+
+	for _, src := range []string{"", "my_int >", "my_int > 1 &&", "(my_int > 1", "my_int ~ 1", "\"x\" == my_string"} {
+		if _, err := ParseExpr(src); !errors.Is(err, ErrInvalidExpr) {
+			t.Errorf("%q: expected a parse error", src)
+		}
+	}
+	if _, err := MustParseExpr("my_string > 1").Match(obj); !errors.Is(err, ErrInvalidExpr) {
+		t.Fail()
+	}
+	unknown := MustParseExpr("metadata.nope == 1")
+	if _, err := unknown.Match(obj); !errors.Is(err, ErrInvalidExpr) {
+		t.Fail()
+	}
+	if err := unknown.Check(obj.ProtoReflect().Descriptor()); !errors.Is(err, ErrInvalidExpr) {
+		t.Fail()
+	}
+}
+
+func TestExprOperators(t *testing.T) {
+	obj := &TObject{
+		Metadata: &Metadata{Labels: []string{"env=prod", "prod"}},
+		MyInt:    7,
+		MyString: "rubber duck",
+	}
+	msg := &descriptorpb.DescriptorProto{Field: []*descriptorpb.FieldDescriptorProto{
+		{Name: proto.String("a"), Number: proto.Int32(1)},
+		{Name: proto.String("b"), Number: proto.Int32(2), Label: descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()},
+	}}
+	st, err := structpb.NewStruct(map[string]any{"color": "yellow"})
+	if err != nil {
+		t.FailNow()
+	}
+	tests := []struct {
+		msg   proto.Message
+		src   string
+		match bool
+	}{
+		{obj, "my_string =~ \"duck\"", true},
+		{obj, "my_string =~ \"^duck\"", false},
+		{obj, "my_int >= 5 && my_string =~ \"d.ck$\" && metadata.labels has \"prod\"", true},
+		{obj, "metadata.labels has \"env=dev\"", false},
+		{obj, "!(metadata.labels has \"env=dev\")", true},
+		{obj, "metadata.labels", true},
+		{msg, "reserved_name", false},
+		{msg, "field.name == \"b\"", true},
+		{msg, "field.name == \"c\"", false},
+		{msg, "field.number > 1 && field.label == LABEL_REPEATED", true},
+		{msg, "field.type_name", false},
+		{st, "fields has \"color\"", true},
+		{st, "fields has \"size\"", false},
+		{structpb.NewNumberValue(12), "number_value == 0x0c", true},
+		{structpb.NewNumberValue(12), "number_value == 0o14 && number_value == 0b1100", true},
+		{structpb.NewNumberValue(12), "number_value == 012", true},
+		{structpb.NewNumberValue(-12), "number_value == -0xc", true},
+		{structpb.NewNumberValue(12.5), "number_value > 0xc && number_value < 13", true},
+		{wrapperspb.Float(12), "value == 0xc", true},
+	}
+	for _, test := range tests {
+		e, err := ParseExpr(test.src)
+		if err != nil {
+			t.Fatalf("%s: %v", test.src, err)
+		}
+		if err := e.Check(test.msg.ProtoReflect().Descriptor()); err != nil {
+			t.Errorf("%s: %v", test.src, err)
+		}
+		if ok, err := e.Match(test.msg); err != nil || ok != test.match {
+			t.Errorf("%s: got %v, %v", test.src, ok, err)
+		}
+	}
+
+	// Dynamic messages
+	dyn := dynamicpb.NewMessage(obj.ProtoReflect().Descriptor())
+	proto.Merge(dyn, obj)
+	if ok, err := MustParseExpr("my_int == 7 && metadata.labels has \"prod\"").Match(dyn); err != nil || !ok {
+		t.Fail()
+	}
+
+	// Type checking
+	md := obj.ProtoReflect().Descriptor()
+	for _, src := range []string{
+		"my_int == \"7\"",
+		"my_string == 7",
+		"my_int =~ \"7\"",
+		"my_string has \"x\"",
+		"metadata.labels == \"prod\"",
+		"metadata.labels has 1",
+		"timestamp == 7",
+		"any == \"x\"",
+	} {
+		if err := MustParseExpr(src).Check(md); !errors.Is(err, ErrInvalidExpr) {
+			t.Errorf("%q: expected a check error", src)
+		}
+	}
+	for _, src := range []string{"my_string =~ 1", "my_string =~ \"(\"", "metadata.labels has"} {
+		if _, err := ParseExpr(src); !errors.Is(err, ErrInvalidExpr) {
+			t.Errorf("%q: expected a parse error", src)
+		}
+	}
+}
+
+func TestFieldsChanged(t *testing.T) {
+	a := &TObject{Metadata: &Metadata{Labels: []string{"a=1"}}, MyInt: 1}
+	b := &TObject{Metadata: &Metadata{Labels: []string{"a=2"}}, MyInt: 1, MyString: "x"}
+	if fieldsChanged(a, b, []string{"my_int"}) {
+		t.Fail()
+	}
+	if !fieldsChanged(a, b, []string{"my_int", "my_string"}) {
+		t.Fail()
+	}
+	if !fieldsChanged(a, b, []string{"metadata.labels"}) {
+		t.Fail()
+	}
+}
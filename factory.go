@@ -46,9 +46,9 @@ var TypeKeyAll = TypeKey{0xff, 0xff, 0xff, 0xff}
 
 // New creates a new IObject based on the type key and initializes
 // the Metadata fields.
-func New[T IObject](typeKey TypeKey) (obj T, err error) {
+func New[T IObject](db *DB, typeKey TypeKey) (obj T, err error) {
 	var id []byte
-	obj, err = Create[T](typeKey)
+	obj, err = Alloc[T](db, typeKey)
 	if err != nil {
 		return
 	}
@@ -66,47 +66,47 @@ func New[T IObject](typeKey TypeKey) (obj T, err error) {
 }
 
 // MustNew is like `New` but panics if there is an error
-func MustNew[T IObject](typeKey TypeKey) T {
-	obj, err := New[T](typeKey)
+func MustNew[T IObject](db *DB, typeKey TypeKey) T {
+	obj, err := New[T](db, typeKey)
 	if err != nil {
 		panic(err)
 	}
 	return obj
 }
 
-func create(typeKey TypeKey) (proto.Message, error) {
-	obj, err := typeRegistry.CreateObject(typeKey)
+func (db *DB) create(typeKey TypeKey) (proto.Message, error) {
+	obj, err := db.reg.CreateObject(typeKey)
 	if err != nil {
 		return nil, ErrNotAnObject
 	}
 	return obj, err
 }
 
-// Create just creates the memory for an IObject without
+// Alloc just creates the memory for an IObject without
 // initializing the Metadata
-func Create[T IObject](typeKey TypeKey) (t T, err error) {
+func Alloc[T IObject](db *DB, typeKey TypeKey) (t T, err error) {
 
-	obj, err := typeRegistry.CreateObject(typeKey)
+	obj, err := db.reg.CreateObject(typeKey)
 	if err != nil {
 		return t, ErrNotAnObject
 	}
 	return obj.(T), err
 }
 
-func unmarshal(kv KeyVal) (proto.Message, error) {
-	obj, err := create(kv.TypeKey())
+func (db *DB) unmarshal(kv KeyVal) (proto.Message, error) {
+	obj, err := db.create(kv.TypeKey())
 	if err != nil {
 		return nil, err
 	}
 	if err = proto.Unmarshal(kv.Value, obj); err != nil {
 		return nil, err
 	}
-	return obj, err
+	return obj, db.reg.migrate(obj.(IObject))
 }
 
 // Unmarshal returns the IObject from a KeyVal binary representation
-func Unmarshal[T IObject](kv KeyVal) (T, error) {
-	obj, err := Create[T](kv.TypeKey())
+func Unmarshal[T IObject](db *DB, kv KeyVal) (T, error) {
+	obj, err := Alloc[T](db, kv.TypeKey())
 	if err != nil {
 		var t T
 		return t, err
@@ -115,14 +115,18 @@ func Unmarshal[T IObject](kv KeyVal) (T, error) {
 		var t T
 		return t, err
 	}
-	return obj, err
+	if err = db.reg.migrate(obj); err != nil {
+		var t T
+		return t, err
+	}
+	return obj, nil
 }
 
 // UnmarshalMany unmarshals a list of KeyVal binary representations
-func UnmarshalMany[T IObject](kvs []KeyVal) ([]T, error) {
+func UnmarshalMany[T IObject](db *DB, kvs []KeyVal) ([]T, error) {
 	res := []T{}
 	for _, v := range kvs {
-		obj, err := Unmarshal[T](v)
+		obj, err := Unmarshal[T](db, v)
 		if err != nil {
 			return nil, err
 		}
@@ -137,12 +141,9 @@ func Marshal[T IObject](objs ...T) (ret []KeyVal, err error) {
 	if len(objs) == 0 {
 		return nil, nil
 	}
-	tk := objs[0].GetMetadata().TypeId().TypeKey()
 	ret = []KeyVal{}
 	for _, o := range objs {
-		kv := KeyVal{}
-		kv.SetType(tk)
-		kv.SetUuidBytes(o.GetMetadata().Uuid)
+		kv := KeyVal{TypeId: o.GetMetadata().TypeId()}
 		kv.Value, err = proto.Marshal(o)
 		if err != nil {
 			return nil, err
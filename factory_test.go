@@ -20,14 +20,14 @@ import (
 )
 
 func TestGet(t *testing.T) {
-	tmpDir := CreateTestDb()
-	defer CloseTestDb(tmpDir)
+	db := CreateTestDb()
+	defer CloseTestDb(db)
 
 	TObj := TypeKeyOf("shdb.v1.TObject")
-	a := MustNew[*TObject](TObj)
+	a := MustNew[*TObject](db, TObj)
 	data, _ := Marshal(a)
 
-	b, _ := Unmarshal[*TObject](data[0])
+	b, _ := Unmarshal[*TObject](db, data[0])
 	if !proto.Equal(a, b) {
 		t.Fail()
 	}
@@ -17,21 +17,26 @@ import (
 	"context"
 	"io"
 	"log"
+	"sync"
 
 	grpc "google.golang.org/grpc"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type Client struct {
-	cc      *grpc.ClientConn
-	cli     BinaryObjectServiceClient
-	ctx     context.Context
-	typeReg *TypeRegistry
+	cc  *grpc.ClientConn
+	cli BinaryObjectServiceClient
+	ctx context.Context
+
+	// typeReg is loaded from the server when first used, see TypeRegistry.
+	typeRegMux sync.Mutex
+	typeReg    *TypeRegistry
 }
 
 // NewClient returns a new client for use with the API
 func NewClient(ctx context.Context, cc *grpc.ClientConn) *Client {
-	return &Client{ctx: ctx, cc: cc, cli: NewBinaryObjectServiceClient(cc), typeReg: nil}
+	return &Client{ctx: ctx, cc: cc, cli: NewBinaryObjectServiceClient(cc)}
 }
 
 func (c *Client) Get(tid TypeId) (IObject, error) {
@@ -43,15 +48,29 @@ func (c *Client) Get(tid TypeId) (IObject, error) {
 	if err != nil {
 		return nil, err
 	}
-	return c.TypeRegistry().Unmarshal(o.Key, o.Value)
+	return c.unmarshal(o.Key, o.Value)
 }
 
 func (c *Client) List(tk TypeKey) ([]IObject, error) {
+	return c.ListSelector(tk, "")
+}
+
+// ListSelector lists the objects of a type whose labels match the
+// selector. See ParseSelector for the syntax.
+func (c *Client) ListSelector(tk TypeKey, selector string) ([]IObject, error) {
+	return c.ListWith(tk, selector, false)
+}
+
+// ListWith lists the objects of a type whose labels match the selector,
+// followed by the soft deleted ones if includeDeleted is set.
+func (c *Client) ListWith(tk TypeKey, selector string, includeDeleted bool) ([]IObject, error) {
 	// Let's not complicate things. Get only first 100000 objects...
 	req := &ListReq{
-		Type:      tk[:],
-		PageSize:  100000,
-		PageToken: "",
+		Type:           tk[:],
+		PageSize:       100000,
+		PageToken:      "",
+		LabelSelector:  selector,
+		IncludeDeleted: includeDeleted,
 	}
 	rsp, err := c.cli.List(c.ctx, req)
 	if err != nil {
@@ -59,7 +78,7 @@ func (c *Client) List(tk TypeKey) ([]IObject, error) {
 	}
 	res := []IObject{}
 	for _, v := range rsp.Items {
-		obj, err := c.TypeRegistry().Unmarshal(v.Key, v.Value)
+		obj, err := c.unmarshal(v.Key, v.Value)
 		if err != nil {
 			return nil, err
 		}
@@ -68,6 +87,67 @@ func (c *Client) List(tk TypeKey) ([]IObject, error) {
 	return res, nil
 }
 
+// ListSeq lists all objects of a type whose labels match the selector,
+// page by page, and returns the sequence number of the last change they
+// include. Watching from the next sequence number misses no change.
+func (c *Client) ListSeq(tk TypeKey, selector string) ([]IObject, uint64, error) {
+	req := &ListReq{
+		Type:          tk[:],
+		PageSize:      1000,
+		LabelSelector: selector,
+	}
+	res := []IObject{}
+	for {
+		rsp, err := c.cli.List(c.ctx, req)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, v := range rsp.Items {
+			obj, err := c.unmarshal(v.Key, v.Value)
+			if err != nil {
+				return nil, 0, err
+			}
+			res = append(res, obj)
+		}
+		if rsp.NextPageToken == "" {
+			return res, rsp.Seq, nil
+		}
+		req.PageToken = rsp.NextPageToken
+	}
+}
+
+// Query lists all objects of a type matching the expression where, see
+// ParseExpr, and whose labels match the selector. The expression is
+// evaluated by the server. Soft deleted objects follow the others if
+// includeDeleted is set.
+func (c *Client) Query(tk TypeKey, where string, selector string, includeDeleted bool) ([]IObject, error) {
+	req := &QueryReq{
+		Type:           tk[:],
+		Where:          where,
+		PageSize:       1000,
+		LabelSelector:  selector,
+		IncludeDeleted: includeDeleted,
+	}
+	res := []IObject{}
+	for {
+		rsp, err := c.cli.Query(c.ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range rsp.Items {
+			obj, err := c.unmarshal(v.Key, v.Value)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, obj)
+		}
+		if rsp.NextPageToken == "" {
+			return res, nil
+		}
+		req.PageToken = rsp.NextPageToken
+	}
+}
+
 func (c *Client) Delete(tid TypeId) (IObject, error) {
 	ref, err := UnmarshalObjRef(tid.Key())
 	if err != nil {
@@ -77,7 +157,7 @@ func (c *Client) Delete(tid TypeId) (IObject, error) {
 	if err != nil {
 		return nil, err
 	}
-	return c.TypeRegistry().Unmarshal(rsp.Key, rsp.Value)
+	return c.unmarshal(rsp.Key, rsp.Value)
 }
 
 func (c *Client) Create(typ TypeKey) (IObject, error) {
@@ -85,9 +165,26 @@ func (c *Client) Create(typ TypeKey) (IObject, error) {
 	if err != nil {
 		return nil, err
 	}
-	return c.TypeRegistry().Unmarshal(rsp.Key, rsp.Value)
+	return c.unmarshal(rsp.Key, rsp.Value)
+}
+
+// Insert stores a new object. It fails with codes.FailedPrecondition
+// if the object already exists.
+func (c *Client) Insert(obj IObject) (IObject, error) {
+	kvs, err := Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	o := &BinaryObject{Key: kvs[0].Key(), Value: kvs[0].Value}
+	rsp, err := c.cli.Create(c.ctx, &CreateReq{Type: obj.GetMetadata().Type, Item: o})
+	if err != nil {
+		return nil, err
+	}
+	return c.unmarshal(rsp.Key, rsp.Value)
 }
 
+// Update replaces a stored object. If the object has a revision it must
+// match the stored one, otherwise it fails with codes.Aborted.
 func (c *Client) Update(obj IObject) (IObject, error) {
 	kvs, err := Marshal(obj)
 	if err != nil {
@@ -98,21 +195,392 @@ func (c *Client) Update(obj IObject) (IObject, error) {
 	if err != nil {
 		return nil, err
 	}
-	return c.TypeRegistry().Unmarshal(rsp.Key, rsp.Value)
+	return c.unmarshal(rsp.Key, rsp.Value)
 }
 
-func (c *Client) TypeRegistry() *TypeRegistry {
-	if c.typeReg == nil {
-		schema, err := c.cli.GetSchema(c.ctx, &emptypb.Empty{})
+// Undelete restores a soft deleted object.
+func (c *Client) Undelete(tid TypeId) (IObject, error) {
+	ref, err := UnmarshalObjRef(tid.Key())
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := c.cli.Undelete(c.ctx, &UndeleteReq{Ref: ref})
+	if err != nil {
+		return nil, err
+	}
+	return c.unmarshal(rsp.Key, rsp.Value)
+}
+
+// Purge permanently removes a soft deleted object.
+func (c *Client) Purge(tid TypeId) error {
+	ref, err := UnmarshalObjRef(tid.Key())
+	if err != nil {
+		return err
+	}
+	_, err = c.cli.Purge(c.ctx, &PurgeReq{Ref: ref})
+	return err
+}
+
+// PurgeAll permanently removes all soft deleted objects of a type and
+// returns their number.
+func (c *Client) PurgeAll(tk TypeKey) (int, error) {
+	rsp, err := c.cli.Purge(c.ctx, &PurgeReq{Type: tk[:]})
+	if err != nil {
+		return 0, err
+	}
+	return int(rsp.Count), nil
+}
+
+// History returns the kept revisions of an object, oldest first, followed
+// by the current revision if the object exists.
+func (c *Client) History(tid TypeId) ([]Version, error) {
+	ref, err := UnmarshalObjRef(tid.Key())
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := c.cli.History(c.ctx, &HistoryReq{Ref: ref})
+	if err != nil {
+		return nil, err
+	}
+	res := []Version{}
+	for _, v := range rsp.Entries {
+		obj, err := c.unmarshal(v.Item.Key, v.Item.Value)
+		if err != nil {
+			return nil, err
+		}
+		version := Version{Revision: v.Revision, Object: obj, Deleted: v.Deleted}
+		if v.ReplacedAt != nil {
+			version.ReplacedAt = v.ReplacedAt.AsTime()
+		}
+		res = append(res, version)
+	}
+	return res, nil
+}
+
+// Expand returns an object and the objects it references. See Expand.
+func (c *Client) Expand(tid TypeId, depth int, fields []string) (*Graph, error) {
+	ref, err := UnmarshalObjRef(tid.Key())
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := c.cli.Expand(c.ctx, &ExpandReq{Ref: ref, Depth: int32(depth), Fields: fields})
+	if err != nil {
+		return nil, err
+	}
+	g := &Graph{Objects: map[TypeId]IObject{}, Edges: []Edge{}}
+	if g.Root, err = c.unmarshal(rsp.Root.Key, rsp.Root.Value); err != nil {
+		return nil, err
+	}
+	for _, v := range rsp.Items {
+		obj, err := c.unmarshal(v.Key, v.Value)
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
-		c.typeReg = NewTypeRegistry()
-		if err := c.typeReg.UseFileDescriptorSet(schema); err != nil {
-			panic(err)
+		g.Objects[obj.GetMetadata().TypeId()] = obj
+	}
+	for _, e := range rsp.Edges {
+		g.Edges = append(g.Edges, Edge{From: *e.From.TypeId(), Field: e.Field, To: *e.To.TypeId()})
+	}
+	return g, nil
+}
+
+// SchemaVersions returns the versions of the schema, oldest first,
+// without the schemas.
+func (c *Client) SchemaVersions() ([]*SchemaVersion, error) {
+	rsp, err := c.cli.ListSchemaVersions(c.ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	return rsp.Versions, nil
+}
+
+// SchemaVersion returns a version of the schema. Its TypeRegistry decodes
+// objects written with it.
+func (c *Client) SchemaVersion(version uint32) (*SchemaVersion, error) {
+	return c.cli.GetSchemaVersion(c.ctx, &GetSchemaVersionReq{Version: version})
+}
+
+// RenameType moves the objects of a renamed type to its new fullname and
+// returns their number. See DB.RenameType.
+func (c *Client) RenameType(oldFullname, newFullname string, batchSize int) (int, error) {
+	rsp, err := c.cli.RenameType(c.ctx, &RenameTypeReq{
+		OldFullname: oldFullname,
+		NewFullname: newFullname,
+		BatchSize:   uint32(batchSize),
+	})
+	if err != nil {
+		return 0, err
+	}
+	// The type names have changed
+	c.resetTypeRegistry()
+	return int(rsp.Count), nil
+}
+
+// Backup writes a backup made by the server to w and returns its size.
+// See DB.Backup.
+func (c *Client) Backup(w io.Writer, opts BackupOptions) (int64, error) {
+	req := &BackupReq{Gzip: opts.Gzip, SinceRevision: opts.SinceRevision}
+	if !opts.Since.IsZero() {
+		req.Since = timestamppb.New(opts.Since)
+	}
+	stream, err := c.cli.Backup(c.ctx, req)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return n, nil
 		}
+		if err != nil {
+			return n, err
+		}
+		m, err := w.Write(chunk.Data)
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+// Restore sends a backup to the server to be restored and returns the
+// number of restored objects. See DB.Restore.
+func (c *Client) Restore(r io.Reader, opts RestoreOptions) (int, error) {
+	stream, err := c.cli.Restore(c.ctx)
+	if err != nil {
+		return 0, err
+	}
+	chunk := &RestoreChunk{}
+	if !opts.Until.IsZero() {
+		chunk.Until = timestamppb.New(opts.Until)
+	}
+	buf := make([]byte, backupChunkSize)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 || chunk.Until != nil {
+			chunk.Data = buf[:n]
+			if err := stream.Send(chunk); err != nil {
+				return 0, err
+			}
+			chunk = &RestoreChunk{}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	rsp, err := stream.CloseAndRecv()
+	if err != nil {
+		return 0, err
+	}
+	// The restored database may have other type keys
+	c.resetTypeRegistry()
+	return int(rsp.Count), nil
+}
+
+// Export writes the objects of the given types, or of all types if none
+// are given, whose labels match the selector to w. See DB.Export.
+func (c *Client) Export(w io.Writer, format ExportFormat, selector string, typeKeys ...TypeKey) (int64, error) {
+	req := &ExportReq{Selector: selector, Format: string(format)}
+	for _, tk := range typeKeys {
+		mi, err := c.GetMessageInfo(tk)
+		if err != nil {
+			return 0, err
+		}
+		req.Types = append(req.Types, mi.Fullname)
+	}
+	stream, err := c.cli.Export(c.ctx, req)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		m, err := w.Write(chunk.Data)
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+// Import sends an export to the server and returns the number of
+// imported objects. See DB.Import.
+func (c *Client) Import(r io.Reader, opts ImportOptions) (int, error) {
+	stream, err := c.cli.Import(c.ctx)
+	if err != nil {
+		return 0, err
+	}
+	chunk := &ImportChunk{Format: string(opts.Format), DryRun: opts.DryRun}
+	buf := make([]byte, backupChunkSize)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			chunk.Data = buf[:n]
+			if err := stream.Send(chunk); err != nil {
+				return 0, err
+			}
+			chunk = &ImportChunk{}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if chunk.Format != "" || chunk.DryRun {
+		// Nothing was read, still pass the options on
+		if err := stream.Send(chunk); err != nil {
+			return 0, err
+		}
+	}
+	rsp, err := stream.CloseAndRecv()
+	if err != nil {
+		return 0, err
+	}
+	return int(rsp.Count), nil
+}
+
+// Watch streams changes from the server with their objects decoded.
+// The changes are delivered on the C of the returned subscription until
+// it is closed or the stream fails.
+func (c *Client) Watch(opts WatchOptions) (*Subscription, error) {
+	req := &WatchReq{
+		LabelSelector: opts.Selector,
+		FromSeq:       opts.FromSeq,
+		FromRevision:  opts.FromRevision,
+		Predicate:     opts.Predicate,
+		Fields:        opts.Fields,
+	}
+	for _, tk := range opts.Types {
+		req.Types = append(req.Types, tk[:])
+	}
+	for _, tid := range opts.TypeIds {
+		ref, err := UnmarshalObjRef(tid.Key())
+		if err != nil {
+			return nil, err
+		}
+		req.Refs = append(req.Refs, ref)
+	}
+	reg, err := c.TypeRegistry()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	stream, err := c.cli.Watch(ctx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	out := make(chan *EventInfo)
+	s := &Subscription{C: out, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		defer close(out)
+		defer cancel()
+		for {
+			change, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF && ctx.Err() == nil {
+					s.err = err
+				}
+				return
+			}
+			ev, err := decodeChange(reg, change)
+			if err != nil {
+				s.err = err
+				return
+			}
+			select {
+			case out <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return s, nil
+}
+
+// TypeRegistry returns the types of the server. They are loaded from the
+// server when first used, and again after a failure.
+func (c *Client) TypeRegistry() (*TypeRegistry, error) {
+	c.typeRegMux.Lock()
+	defer c.typeRegMux.Unlock()
+	if c.typeReg != nil {
+		return c.typeReg, nil
+	}
+	schema, err := c.cli.GetSchema(c.ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	reg, err := NewTypeRegistry()
+	if err != nil {
+		return nil, err
+	}
+	if err := reg.UseFileDescriptorSet(schema); err != nil {
+		return nil, err
+	}
+	// Use the same TypeKeys as the server
+	names, err := c.cli.GetTypeNames(c.ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	assigned := map[string]TypeKey{}
+	for _, v := range names.TypeAliases {
+		if len(v.TypeKey) == 4 {
+			assigned[v.Fullname] = TypeKey(v.TypeKey)
+		}
+	}
+	if err := reg.useTypeKeys(assigned, nil); err != nil {
+		return nil, err
+	}
+	c.typeReg = reg
+	return reg, nil
+}
+
+// resetTypeRegistry makes TypeRegistry load the types again.
+func (c *Client) resetTypeRegistry() {
+	c.typeRegMux.Lock()
+	defer c.typeRegMux.Unlock()
+	c.typeReg = nil
+}
+
+// GetMessageInfo returns the MessageInfo of the type tk of the server.
+func (c *Client) GetMessageInfo(tk TypeKey) (MessageInfo, error) {
+	reg, err := c.TypeRegistry()
+	if err != nil {
+		return MessageInfo{}, err
+	}
+	return reg.GetMessageInfo(tk)
+}
+
+// GetTypeKeyFromToA returns the TypeKey of the type of the server with
+// the fullname or alias toa.
+func (c *Client) GetTypeKeyFromToA(toa string) (TypeKey, error) {
+	reg, err := c.TypeRegistry()
+	if err != nil {
+		return TypeKey{}, err
+	}
+	return reg.GetTypeKeyFromToA(toa)
+}
+
+// unmarshal decodes an object sent by the server.
+func (c *Client) unmarshal(key, value []byte) (IObject, error) {
+	reg, err := c.TypeRegistry()
+	if err != nil {
+		return nil, err
 	}
-	return c.typeReg
+	return reg.Unmarshal(key, value)
 }
 
 func (c *Client) GetTypeNames() (map[string][]string, error) {
@@ -15,45 +15,75 @@
 package shdb
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"errors"
+	"io"
+	"time"
 
 	"google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
-	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/descriptorpb"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 )
 
 type Server struct {
 	UnimplementedBinaryObjectServiceServer
-	ctx     context.Context
-	gs      *grpc.Server
-	typeReg *TypeRegistry
+	ctx context.Context
+	gs  *grpc.Server
+	db  *DB
 }
 
-func NewServer(ctx context.Context, grpcServer *grpc.Server, typeReg *TypeRegistry) *Server {
+// NewServer registers a BinaryObjectService serving the objects in db.
+func NewServer(ctx context.Context, grpcServer *grpc.Server, db *DB) *Server {
 	s := &Server{
-		ctx:     ctx,
-		gs:      grpcServer,
-		typeReg: typeReg,
+		ctx: ctx,
+		gs:  grpcServer,
+		db:  db,
 	}
 	RegisterBinaryObjectServiceServer(grpcServer, s)
 	return s
 }
 
 func (s *Server) List(ctx context.Context, req *ListReq) (*ListRsp, error) {
-	list, nextPageToken, err := List[IObject](ctx, [4]byte(req.Type), req.PageSize, req.PageToken)
+	sel, err := ParseSelector(req.LabelSelector)
 	if err != nil {
-		return nil, status.Error(codes.Internal, "failed listing objects")
+		return nil, statusError(err, "invalid label selector")
+	}
+	opts := QueryOptions{Selector: sel, IncludeDeleted: req.IncludeDeleted}
+	return s.list(ctx, req.Type, opts, req.PageSize, req.PageToken)
+}
+
+func (s *Server) Query(ctx context.Context, req *QueryReq) (*ListRsp, error) {
+	sel, err := ParseSelector(req.LabelSelector)
+	if err != nil {
+		return nil, statusError(err, "invalid label selector")
+	}
+	opts := QueryOptions{Selector: sel, IncludeDeleted: req.IncludeDeleted}
+	if req.Where != "" {
+		if opts.Where, err = ParseExpr(req.Where); err != nil {
+			return nil, statusError(err, "invalid where expression")
+		}
+	}
+	return s.list(ctx, req.Type, opts, req.PageSize, req.PageToken)
+}
+
+func (s *Server) list(ctx context.Context, typ []byte, opts QueryOptions, pageSize int32, pageToken string) (*ListRsp, error) {
+	if len(typ) != 4 {
+		return nil, status.Error(codes.InvalidArgument, "invalid type")
+	}
+	list, nextPageToken, seq, err := QuerySeq(ctx, s.db, TypeKey(typ), opts, func(IObject) (bool, error) { return true, nil }, pageSize, pageToken)
+	if err != nil {
+		return nil, statusError(err, "failed listing objects")
 	}
 
 	kv, err := Marshal(list...)
 	if err != nil {
 		return nil, status.Error(codes.Internal, "failed listing objects")
 	}
-	rsp := &ListRsp{Items: make([]*BinaryObject, 0), NextPageToken: nextPageToken}
+	rsp := &ListRsp{Items: make([]*BinaryObject, 0), NextPageToken: nextPageToken, Seq: seq}
 	for _, v := range kv {
 		rsp.Items = append(rsp.Items, &BinaryObject{Key: v.Key(), Value: v.Value})
 	}
@@ -61,18 +91,34 @@ func (s *Server) List(ctx context.Context, req *ListReq) (*ListRsp, error) {
 }
 
 func (s *Server) Get(ctx context.Context, req *GetReq) (*BinaryObject, error) {
-	kv, err := get(*req.Ref.TypeId())
+	tid, err := reqTypeId(req.Ref)
+	if err != nil {
+		return nil, err
+	}
+	kv, err := s.db.get(tid)
 	if err != nil {
-		return nil, status.Error(codes.Internal, "failed retrieve an object")
+		return nil, statusError(err, "failed retrieve an object")
 	}
 	return &BinaryObject{Key: kv.Key(), Value: kv.Value}, nil
 
 }
 
 func (s *Server) Create(ctx context.Context, req *CreateReq) (*BinaryObject, error) {
-	o, err := New[IObject]([4]byte(req.Type))
+	var o IObject
+	var err error
+	if req.Item != nil {
+		o, err = s.unmarshalItem(req.Item)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "failed to create object %v", err)
+		}
+		err = Create(s.db, o)
+	} else if len(req.Type) != len(TypeKey{}) {
+		return nil, status.Error(codes.InvalidArgument, "either item or type must be set")
+	} else {
+		o, err = New[IObject](s.db, TypeKey(req.Type))
+	}
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to create object %v", err)
+		return nil, statusError(err, "failed to create object")
 	}
 	kv, err := Marshal(o)
 	if err != nil {
@@ -82,19 +128,20 @@ func (s *Server) Create(ctx context.Context, req *CreateReq) (*BinaryObject, err
 }
 
 func (s *Server) Update(ctx context.Context, req *UpdateReq) (*BinaryObject, error) {
-	kv, err := get(*MarshalTypeId(req.Item.Key))
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to update object %v", err)
-	}
-	obj, err := Unmarshal[IObject](*kv)
+	obj, err := s.unmarshalItem(req.Item)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to update object %v", err)
+		return nil, status.Errorf(codes.InvalidArgument, "failed to update object %v", err)
 	}
-	ret, err := Update(obj.GetMetadata().TypeId(), func(obj IObject) (IObject, error) {
-		return proto.Clone(obj).(IObject), nil
+	tid := obj.GetMetadata().TypeId()
+	rev := obj.GetMetadata().Revision
+	ret, err := Update(s.db, tid, func(prev IObject) (IObject, error) {
+		if err := checkRevision(tid, rev, prev); rev != 0 && err != nil {
+			return nil, err
+		}
+		return obj, nil
 	})
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to update object %v", err)
+		return nil, statusError(err, "failed to update object")
 	}
 	kvs, err := Marshal(ret)
 	if err != nil {
@@ -104,9 +151,13 @@ func (s *Server) Update(ctx context.Context, req *UpdateReq) (*BinaryObject, err
 
 }
 func (s *Server) Delete(ctx context.Context, req *DeleteReq) (*BinaryObject, error) {
-	obj, err := Delete[IObject](*req.Ref.TypeId())
+	tid, err := reqTypeId(req.Ref)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to delete object %v", err)
+		return nil, err
+	}
+	obj, err := Delete[IObject](s.db, tid)
+	if err != nil {
+		return nil, statusError(err, "failed to delete object")
 	}
 	kvs, err := Marshal(obj)
 	if err != nil {
@@ -115,17 +166,130 @@ func (s *Server) Delete(ctx context.Context, req *DeleteReq) (*BinaryObject, err
 	return &BinaryObject{Key: kvs[0].Key(), Value: kvs[0].Value}, nil
 }
 
+func (s *Server) Undelete(ctx context.Context, req *UndeleteReq) (*BinaryObject, error) {
+	tid, err := reqTypeId(req.Ref)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := Undelete[IObject](s.db, tid)
+	if err != nil {
+		return nil, statusError(err, "failed to undelete object")
+	}
+	kvs, err := Marshal(obj)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to undelete object %v", err)
+	}
+	return &BinaryObject{Key: kvs[0].Key(), Value: kvs[0].Value}, nil
+}
+
+func (s *Server) Purge(ctx context.Context, req *PurgeReq) (*PurgeRsp, error) {
+	if req.Ref != nil {
+		tid, err := reqTypeId(req.Ref)
+		if err != nil {
+			return nil, err
+		}
+		if err := Purge(s.db, tid); err != nil {
+			return nil, statusError(err, "failed to purge object")
+		}
+		return &PurgeRsp{Count: 1}, nil
+	}
+	if len(req.Type) != len(TypeKey{}) {
+		return nil, status.Error(codes.InvalidArgument, "either ref or type must be set")
+	}
+	n, err := s.db.PurgeDeleted(TypeKey(req.Type), time.Time{})
+	if err != nil {
+		return nil, statusError(err, "failed to purge objects")
+	}
+	return &PurgeRsp{Count: uint32(n)}, nil
+}
+
+func (s *Server) History(ctx context.Context, req *HistoryReq) (*HistoryRsp, error) {
+	tid, err := reqTypeId(req.Ref)
+	if err != nil {
+		return nil, err
+	}
+	rsp := &HistoryRsp{}
+	err = s.db.View(func(tx *Tx) (err error) {
+		rsp.Entries, err = tx.history(tid)
+		return err
+	})
+	if err != nil {
+		return nil, statusError(err, "failed to get history")
+	}
+	return rsp, nil
+}
+
+// Expand returns an object and the objects it references.
+func (s *Server) Expand(ctx context.Context, req *ExpandReq) (*ExpandRsp, error) {
+	tid, err := reqTypeId(req.Ref)
+	if err != nil {
+		return nil, err
+	}
+	g, err := Expand(s.db, tid, int(req.Depth), req.Fields)
+	if err != nil {
+		return nil, statusError(err, "failed to expand object")
+	}
+	objs := []IObject{g.Root}
+	rsp := &ExpandRsp{Items: []*BinaryObject{}, Edges: []*ExpandRsp_Edge{}}
+	added := map[TypeId]bool{}
+	for _, e := range g.Edges {
+		from, _ := UnmarshalObjRef(e.From.Key())
+		to, _ := UnmarshalObjRef(e.To.Key())
+		rsp.Edges = append(rsp.Edges, &ExpandRsp_Edge{From: from, Field: e.Field, To: to})
+		if obj, ok := g.Objects[e.To]; ok && !added[e.To] {
+			added[e.To] = true
+			objs = append(objs, obj)
+		}
+	}
+	kvs, err := Marshal(objs...)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to expand object %v", err)
+	}
+	rsp.Root = &BinaryObject{Key: kvs[0].Key(), Value: kvs[0].Value}
+	for _, kv := range kvs[1:] {
+		rsp.Items = append(rsp.Items, &BinaryObject{Key: kv.Key(), Value: kv.Value})
+	}
+	return rsp, nil
+}
+
 func (s *Server) GetSchema(ctx context.Context, req *emptypb.Empty) (*descriptorpb.FileDescriptorSet, error) {
-	return s.typeReg.GetFileDescriptorSet(), nil
+	return s.db.TypeRegistry().GetFileDescriptorSet(), nil
+}
+
+func (s *Server) ListSchemaVersions(ctx context.Context, req *emptypb.Empty) (*ListSchemaVersionsRsp, error) {
+	versions, err := s.db.SchemaVersions()
+	if err != nil {
+		return nil, statusError(err, "failed to list schema versions")
+	}
+	return &ListSchemaVersionsRsp{Versions: versions}, nil
+}
+
+func (s *Server) GetSchemaVersion(ctx context.Context, req *GetSchemaVersionReq) (*SchemaVersion, error) {
+	sv, err := s.db.GetSchemaVersion(req.Version)
+	if err != nil {
+		return nil, statusError(err, "failed to get schema version")
+	}
+	return sv, nil
+}
+
+func (s *Server) RenameType(ctx context.Context, req *RenameTypeReq) (*RenameTypeRsp, error) {
+	n, err := s.db.RenameType(req.OldFullname, req.NewFullname, int(req.BatchSize))
+	if err != nil {
+		return nil, statusError(err, "failed to rename type")
+	}
+	return &RenameTypeRsp{Count: uint32(n)}, nil
 }
 
 func (s *Server) GetTypeNames(ctx context.Context, req *emptypb.Empty) (*GetTypeNamesRsp, error) {
 	rsp := &GetTypeNamesRsp{TypeAliases: []*GetTypeNamesRsp_TypeAliases{}}
-	tns := s.typeReg.GetTypeNames()
+	tns := s.db.TypeRegistry().GetTypeNames()
+	tks := s.db.TypeRegistry().TypeKeys()
 	for k, v := range tns {
+		tk := tks[k]
 		rsp.TypeAliases = append(rsp.TypeAliases, &GetTypeNamesRsp_TypeAliases{
 			Fullname: k,
 			Aliases:  v,
+			TypeKey:  tk[:],
 		})
 	}
 	return rsp, nil
@@ -138,7 +302,7 @@ func (s *Server) StreamRefs(req *StreamRefReq, stream BinaryObjectService_Stream
 		}
 		return bytes.Equal(req.TypeKey, obj.Type)
 	}
-	refs, _, err := SearchRef(stream.Context(), selector, 1000000, "")
+	refs, _, err := s.db.SearchRef(stream.Context(), selector, 1000000, "")
 	if err != nil {
 		return status.Errorf(codes.Internal, "query ref failed")
 	}
@@ -149,3 +313,213 @@ func (s *Server) StreamRefs(req *StreamRefReq, stream BinaryObjectService_Stream
 	}
 	return nil
 }
+
+// backupChunkSize is the size of the chunks a backup is streamed in.
+const backupChunkSize = 64 * 1024
+
+// chunkWriter streams the data written to it with send.
+type chunkWriter func(data []byte) error
+
+func (w chunkWriter) Write(p []byte) (int, error) {
+	if err := w(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *Server) Backup(req *BackupReq, stream BinaryObjectService_BackupServer) error {
+	opts := BackupOptions{Gzip: req.Gzip, SinceRevision: req.SinceRevision}
+	if req.Since != nil {
+		opts.Since = req.Since.AsTime()
+	}
+	w := bufio.NewWriterSize(chunkWriter(func(data []byte) error {
+		return stream.Send(&BackupChunk{Data: data})
+	}), backupChunkSize)
+	if _, err := s.db.Backup(w, opts); err != nil {
+		return statusError(err, "backup failed")
+	}
+	return w.Flush()
+}
+
+// chunkReader reads the data of the chunks streamed by a client, starting
+// with buf.
+type chunkReader struct {
+	recv func() ([]byte, error)
+	buf  []byte
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		data, err := r.recv()
+		if err != nil {
+			return 0, err
+		}
+		r.buf = data
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (s *Server) Restore(stream BinaryObjectService_RestoreServer) error {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return status.Error(codes.InvalidArgument, "no backup sent")
+	} else if err != nil {
+		return err
+	}
+	opts := RestoreOptions{}
+	if first.Until != nil {
+		opts.Until = first.Until.AsTime()
+	}
+	recv := func() ([]byte, error) {
+		chunk, err := stream.Recv()
+		return chunk.GetData(), err
+	}
+	n, err := s.db.Restore(&chunkReader{recv: recv, buf: first.Data}, opts)
+	if err != nil {
+		return statusError(err, "restore failed")
+	}
+	rev, err := s.db.Revision()
+	if err != nil {
+		return statusError(err, "restore failed")
+	}
+	return stream.SendAndClose(&RestoreRsp{Revision: rev, Count: uint32(n)})
+}
+
+func (s *Server) Export(req *ExportReq, stream BinaryObjectService_ExportServer) error {
+	sel, err := ParseSelector(req.Selector)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid selector: %v", err)
+	}
+	typeKeys := []TypeKey{}
+	for _, name := range req.Types {
+		tk, err := s.db.TypeRegistry().GetTypeKeyFromToA(name)
+		if err != nil {
+			return status.Errorf(codes.NotFound, "type %s not found", name)
+		}
+		typeKeys = append(typeKeys, tk)
+	}
+	w := bufio.NewWriterSize(chunkWriter(func(data []byte) error {
+		return stream.Send(&ExportChunk{Data: data})
+	}), backupChunkSize)
+	opts := ExportOptions{Format: ExportFormat(req.Format), Selector: sel}
+	if _, err := s.db.Export(w, opts, typeKeys...); err != nil {
+		return statusError(err, "export failed")
+	}
+	return w.Flush()
+}
+
+func (s *Server) Import(stream BinaryObjectService_ImportServer) error {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return stream.SendAndClose(&ImportRsp{})
+	} else if err != nil {
+		return err
+	}
+	recv := func() ([]byte, error) {
+		chunk, err := stream.Recv()
+		return chunk.GetData(), err
+	}
+	opts := ImportOptions{Format: ExportFormat(first.Format), DryRun: first.DryRun}
+	n, err := s.db.Import(&chunkReader{recv: recv, buf: first.Data}, opts)
+	if err != nil {
+		return statusError(err, "import failed")
+	}
+	return stream.SendAndClose(&ImportRsp{Count: uint32(n)})
+}
+
+// Watch streams the selected changes, replaying them from the changelog
+// when a sequence number or revision is given.
+func (s *Server) Watch(req *WatchReq, stream BinaryObjectService_WatchServer) error {
+	opts := WatchOptions{
+		Selector:     req.LabelSelector,
+		Predicate:    req.Predicate,
+		Fields:       req.Fields,
+		FromSeq:      req.FromSeq,
+		FromRevision: req.FromRevision,
+	}
+	for _, typ := range req.Types {
+		if len(typ) != 4 {
+			return status.Error(codes.InvalidArgument, "invalid type")
+		}
+		opts.Types = append(opts.Types, TypeKey(typ))
+	}
+	for _, ref := range req.Refs {
+		tid, err := reqTypeId(ref)
+		if err != nil {
+			return err
+		}
+		opts.TypeIds = append(opts.TypeIds, tid)
+	}
+	filter, err := opts.filter(s.db.TypeRegistry())
+	if err != nil {
+		return statusError(err, "invalid watch")
+	}
+	from, err := s.db.watchFrom(opts)
+	if err != nil {
+		return statusError(err, "watch failed")
+	}
+	sub, err := s.db.Subscribe(stream.Context(), from, filter)
+	if err != nil {
+		return statusError(err, "watch failed")
+	}
+	defer sub.Close()
+	for ev := range sub.C {
+		change, err := changeOf(ev)
+		if err != nil {
+			return status.Errorf(codes.Internal, "watch failed: %v", err)
+		}
+		if err := stream.Send(change); err != nil {
+			return err
+		}
+	}
+	if err := sub.Err(); err != nil {
+		return statusError(err, "watch failed")
+	}
+	return nil
+}
+
+// reqTypeId returns the TypeId of a ref sent by a client.
+func reqTypeId(ref *ObjRef) (TypeId, error) {
+	if ref == nil || len(ref.Type) != len(TypeKey{}) || len(ref.Uuid) != 16 {
+		return TypeId{}, status.Error(codes.InvalidArgument, "invalid ref")
+	}
+	return *ref.TypeId(), nil
+}
+
+// unmarshalItem decodes an object sent by a client.
+func (s *Server) unmarshalItem(item *BinaryObject) (IObject, error) {
+	if item == nil || len(item.Key) != len(TypeId{}.data) {
+		return nil, ErrInvalidType
+	}
+	return Unmarshal[IObject](s.db, KeyVal{TypeId: *MarshalTypeId(item.Key), Value: item.Value})
+}
+
+// statusError maps a database error to a gRPC status error.
+func statusError(err error, msg string) error {
+	var ce *ConflictError
+	var re *RefError
+	switch {
+	case errors.As(err, &re):
+		return status.Errorf(codes.FailedPrecondition, "%s: %v", msg, err)
+	case errors.As(err, &ce) && ce.Expected == 0:
+		return status.Errorf(codes.FailedPrecondition, "%s: %v", msg, err)
+	case errors.As(err, &ce):
+		return status.Errorf(codes.Aborted, "%s: %v", msg, err)
+	case errors.Is(err, ErrNotFound):
+		return status.Errorf(codes.NotFound, "%s: %v", msg, err)
+	case errors.Is(err, ErrIncompatibleSchema):
+		return status.Errorf(codes.FailedPrecondition, "%s: %v", msg, err)
+	case errors.Is(err, ErrNotSupported):
+		return status.Errorf(codes.Unimplemented, "%s: %v", msg, err)
+	case errors.Is(err, ErrChangelogTruncated):
+		return status.Errorf(codes.OutOfRange, "%s: %v", msg, err)
+	case errors.Is(err, ErrRestored):
+		return status.Errorf(codes.Unavailable, "%s: %v", msg, err)
+	case errors.Is(err, ErrInvalidSelector), errors.Is(err, ErrInvalidExpr), errors.Is(err, ErrInvalidSeq),
+		errors.Is(err, ErrSessionInvalid):
+		return status.Errorf(codes.InvalidArgument, "%s: %v", msg, err)
+	}
+	return status.Errorf(codes.Internal, "%s: %v", msg, err)
+}
@@ -0,0 +1,451 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// An informer keeps a local cache of the objects of one type. It lists
+// the objects and then watches the changelog from the sequence number
+// of the listing, so no change is missed in between. When the watch
+// fails it is resumed from the last change seen. Only when the changes
+// have been truncated from the changelog is the type listed again.
+
+const defaultInformerRetryPeriod = time.Second
+
+// Source is what an Informer lists and watches. Both DB and Client are
+// sources.
+type Source interface {
+	ListSeq(tk TypeKey, selector string) ([]IObject, uint64, error)
+	Watch(opts WatchOptions) (*Subscription, error)
+	GetMessageInfo(tk TypeKey) (MessageInfo, error)
+}
+
+// InformerOptions configures an Informer.
+type InformerOptions struct {
+	// Selector is a label selector, see ParseSelector. Objects that stop
+	// matching it are deleted from the cache.
+	Selector string
+	// ResyncPeriod is how often OnUpdate is called for all cached
+	// objects. Zero disables resyncs.
+	ResyncPeriod time.Duration
+	// Indexes are dotted field paths the cache is indexed on, see
+	// ByIndex. The same fields as for declared indexes are allowed.
+	Indexes []string
+	// RetryPeriod is the time between attempts to list or watch after
+	// a failure. Defaults to a second.
+	RetryPeriod time.Duration
+}
+
+// InformerHandler is notified of changes to the cache of an Informer.
+// Nil functions are skipped.
+type InformerHandler struct {
+	OnAdd    func(obj IObject)
+	OnUpdate func(old, obj IObject)
+	OnDelete func(obj IObject)
+}
+
+// Informer is a cache of the objects of one type, kept up to date by Run.
+// The objects it returns are shared and must not be modified.
+type Informer struct {
+	src     Source
+	tk      TypeKey
+	opts    InformerOptions
+	sel     Selector
+	indexes map[string][]protoreflect.FieldDescriptor
+
+	// handlerMux is held while the cache is changed and the handlers are
+	// notified, so handlers see the changes in order.
+	handlerMux sync.Mutex
+	handlers   []InformerHandler
+
+	mux      sync.RWMutex
+	objs     map[TypeId]IObject
+	index    map[string]map[string]map[TypeId]struct{}
+	seq      uint64
+	synced   bool
+	syncedCh chan struct{}
+}
+
+// NewInformer returns an informer for the objects of type tk in src.
+// Nothing is listed until Run is called.
+func NewInformer(src Source, tk TypeKey, opts InformerOptions) (*Informer, error) {
+	sel, err := ParseSelector(opts.Selector)
+	if err != nil {
+		return nil, err
+	}
+	mi, err := src.GetMessageInfo(tk)
+	if err != nil {
+		return nil, fmt.Errorf("type %s: %w", tk, err)
+	}
+	if opts.RetryPeriod <= 0 {
+		opts.RetryPeriod = defaultInformerRetryPeriod
+	}
+	inf := &Informer{
+		src:      src,
+		tk:       tk,
+		opts:     opts,
+		sel:      sel,
+		indexes:  map[string][]protoreflect.FieldDescriptor{},
+		objs:     map[TypeId]IObject{},
+		index:    map[string]map[string]map[TypeId]struct{}{},
+		syncedCh: make(chan struct{}),
+	}
+	for _, path := range opts.Indexes {
+		fds, err := indexFields(mi.MessageType.Descriptor(), path)
+		if err != nil {
+			return nil, err
+		}
+		inf.indexes[path] = fds
+		inf.index[path] = map[string]map[TypeId]struct{}{}
+	}
+	return inf, nil
+}
+
+// AddHandler adds a handler. OnAdd is called at once for the objects
+// already in the cache. Handlers must not call AddHandler.
+func (inf *Informer) AddHandler(h InformerHandler) {
+	inf.handlerMux.Lock()
+	defer inf.handlerMux.Unlock()
+	inf.handlers = append(inf.handlers, h)
+	if h.OnAdd != nil {
+		for _, obj := range inf.List() {
+			h.OnAdd(obj)
+		}
+	}
+}
+
+// Run lists and watches the objects until ctx is done. Failures are
+// logged and retried after RetryPeriod.
+func (inf *Informer) Run(ctx context.Context) {
+	listed := false
+	for ctx.Err() == nil {
+		if !listed {
+			if err := inf.list(); err != nil {
+				log.Printf("informer list failed, err=[%v]\n", err)
+				inf.wait(ctx)
+				continue
+			}
+			listed = true
+		}
+		err := inf.watch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, ErrChangelogTruncated) || status.Code(err) == codes.OutOfRange {
+			// The changes since the last one seen are gone
+			listed = false
+			continue
+		}
+		log.Printf("informer watch failed, err=[%v]\n", err)
+		inf.wait(ctx)
+	}
+}
+
+func (inf *Informer) wait(ctx context.Context) {
+	t := time.NewTimer(inf.opts.RetryPeriod)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
+
+// list replaces the cache with the listed objects and notifies the
+// handlers of the differences.
+func (inf *Informer) list() error {
+	objs, seq, err := inf.src.ListSeq(inf.tk, inf.opts.Selector)
+	if err != nil {
+		return err
+	}
+	inf.handlerMux.Lock()
+	defer inf.handlerMux.Unlock()
+	type update struct{ old, obj IObject }
+	added, updated, deleted := []IObject{}, []update{}, []IObject{}
+	listed := map[TypeId]bool{}
+	inf.mux.Lock()
+	for _, obj := range objs {
+		tid := *GetTypeId(obj)
+		listed[tid] = true
+		old, ok := inf.objs[tid]
+		switch {
+		case !ok:
+			added = append(added, obj)
+		case !proto.Equal(old, obj):
+			updated = append(updated, update{old, obj})
+		default:
+			continue
+		}
+		inf.set(tid, old, obj)
+	}
+	for tid, old := range inf.objs {
+		if !listed[tid] {
+			deleted = append(deleted, old)
+			inf.set(tid, old, nil)
+		}
+	}
+	inf.seq = seq
+	if !inf.synced {
+		inf.synced = true
+		close(inf.syncedCh)
+	}
+	inf.mux.Unlock()
+
+	for _, h := range inf.handlers {
+		for _, obj := range added {
+			if h.OnAdd != nil {
+				h.OnAdd(obj)
+			}
+		}
+		for _, u := range updated {
+			if h.OnUpdate != nil {
+				h.OnUpdate(u.old, u.obj)
+			}
+		}
+		for _, obj := range deleted {
+			if h.OnDelete != nil {
+				h.OnDelete(obj)
+			}
+		}
+	}
+	return nil
+}
+
+// watch applies the changes after the last one seen until the watch
+// fails or ctx is done.
+func (inf *Informer) watch(ctx context.Context) error {
+	sub, err := inf.src.Watch(WatchOptions{
+		Types:    []TypeKey{inf.tk},
+		Selector: inf.opts.Selector,
+		FromSeq:  inf.LastSeq() + 1,
+	})
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+	var resync <-chan time.Time
+	if inf.opts.ResyncPeriod > 0 {
+		ticker := time.NewTicker(inf.opts.ResyncPeriod)
+		defer ticker.Stop()
+		resync = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev, ok := <-sub.C:
+			if !ok {
+				if err := sub.Close(); err != nil {
+					return err
+				}
+				return io.EOF
+			}
+			if ev.Kind == EventError {
+				return ev.Err
+			}
+			inf.apply(ev)
+		case <-resync:
+			inf.resync()
+		}
+	}
+}
+
+// apply updates the cache with a change and notifies the handlers.
+func (inf *Informer) apply(ev *EventInfo) {
+	inf.handlerMux.Lock()
+	defer inf.handlerMux.Unlock()
+	inf.mux.Lock()
+	if ev.Seq <= inf.seq {
+		// Already seen before the watch was resumed
+		inf.mux.Unlock()
+		return
+	}
+	inf.seq = ev.Seq
+	old, cached := inf.objs[ev.Tid]
+	var obj IObject
+	if ev.Kind != EventDeleted && inf.sel.MatchesObject(ev.Object) {
+		obj = ev.Object
+	}
+	if obj != nil || cached {
+		inf.set(ev.Tid, old, obj)
+	}
+	inf.mux.Unlock()
+
+	for _, h := range inf.handlers {
+		switch {
+		case obj != nil && !cached:
+			if h.OnAdd != nil {
+				h.OnAdd(obj)
+			}
+		case obj != nil:
+			if h.OnUpdate != nil {
+				h.OnUpdate(old, obj)
+			}
+		case cached:
+			if h.OnDelete != nil {
+				h.OnDelete(old)
+			}
+		}
+	}
+}
+
+// resync calls OnUpdate for all cached objects.
+func (inf *Informer) resync() {
+	inf.handlerMux.Lock()
+	defer inf.handlerMux.Unlock()
+	objs := inf.List()
+	for _, h := range inf.handlers {
+		if h.OnUpdate == nil {
+			continue
+		}
+		for _, obj := range objs {
+			h.OnUpdate(obj, obj)
+		}
+	}
+}
+
+// set replaces old with obj in the cache and the indexes. A nil obj
+// deletes old. The caller holds mux.
+func (inf *Informer) set(tid TypeId, old, obj IObject) {
+	for path, fds := range inf.indexes {
+		values := inf.index[path]
+		if old != nil {
+			for _, v := range indexValues(old.ProtoReflect(), fds) {
+				delete(values[string(v)], tid)
+				if len(values[string(v)]) == 0 {
+					delete(values, string(v))
+				}
+			}
+		}
+		if obj != nil {
+			for _, v := range indexValues(obj.ProtoReflect(), fds) {
+				if values[string(v)] == nil {
+					values[string(v)] = map[TypeId]struct{}{}
+				}
+				values[string(v)][tid] = struct{}{}
+			}
+		}
+	}
+	if obj == nil {
+		delete(inf.objs, tid)
+	} else {
+		inf.objs[tid] = obj
+	}
+}
+
+// HasSynced reports whether the objects have been listed.
+func (inf *Informer) HasSynced() bool {
+	inf.mux.RLock()
+	defer inf.mux.RUnlock()
+	return inf.synced
+}
+
+// WaitForSync waits until the objects have been listed or ctx is done.
+func (inf *Informer) WaitForSync(ctx context.Context) error {
+	select {
+	case <-inf.syncedCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// LastSeq returns the sequence number of the last change in the cache.
+func (inf *Informer) LastSeq() uint64 {
+	inf.mux.RLock()
+	defer inf.mux.RUnlock()
+	return inf.seq
+}
+
+// Get returns a cached object.
+func (inf *Informer) Get(tid TypeId) (IObject, error) {
+	inf.mux.RLock()
+	defer inf.mux.RUnlock()
+	obj, ok := inf.objs[tid]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return obj, nil
+}
+
+// List returns all cached objects ordered by id.
+func (inf *Informer) List() []IObject {
+	inf.mux.RLock()
+	defer inf.mux.RUnlock()
+	res := make([]IObject, 0, len(inf.objs))
+	for _, obj := range inf.objs {
+		res = append(res, obj)
+	}
+	sortObjects(res)
+	return res
+}
+
+// ListSelector returns the cached objects whose labels match the
+// selector, ordered by id.
+func (inf *Informer) ListSelector(selector string) ([]IObject, error) {
+	sel, err := ParseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	res := []IObject{}
+	for _, obj := range inf.List() {
+		if sel.MatchesObject(obj) {
+			res = append(res, obj)
+		}
+	}
+	return res, nil
+}
+
+// ByIndex returns the cached objects whose indexed field equals value,
+// ordered by id. The values are given as for Lookup.
+func (inf *Informer) ByIndex(path string, value any) ([]IObject, error) {
+	fds, ok := inf.indexes[path]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is not an informer index", ErrInvalidIndex, path)
+	}
+	fd := fds[len(fds)-1]
+	v, err := indexValueOf(fd, value)
+	if err != nil {
+		return nil, err
+	}
+	inf.mux.RLock()
+	defer inf.mux.RUnlock()
+	res := []IObject{}
+	for tid := range inf.index[path][string(encodeIndexValue(fd, v))] {
+		res = append(res, inf.objs[tid])
+	}
+	sortObjects(res)
+	return res, nil
+}
+
+func sortObjects(objs []IObject) {
+	sort.Slice(objs, func(i, j int) bool {
+		return bytes.Compare(GetTypeId(objs[i]).Key(), GetTypeId(objs[j]).Key()) < 0
+	})
+}
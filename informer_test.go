@@ -0,0 +1,284 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type informerEvent struct {
+	kind     string
+	old, obj *TObject
+}
+
+func recordInformer(inf *Informer) chan informerEvent {
+	ch := make(chan informerEvent, 100)
+	inf.AddHandler(InformerHandler{
+		OnAdd: func(obj IObject) {
+			ch <- informerEvent{kind: "add", obj: obj.(*TObject)}
+		},
+		OnUpdate: func(old, obj IObject) {
+			ch <- informerEvent{kind: "update", old: old.(*TObject), obj: obj.(*TObject)}
+		},
+		OnDelete: func(obj IObject) {
+			ch <- informerEvent{kind: "delete", obj: obj.(*TObject)}
+		},
+	})
+	return ch
+}
+
+func recvInformer(t *testing.T, ch chan informerEvent, kind string) informerEvent {
+	select {
+	case ev := <-ch:
+		if ev.kind != kind {
+			t.Fatalf("got %s, expected %s", ev.kind, kind)
+		}
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for %s", kind)
+	}
+	return informerEvent{}
+}
+
+func startInformer(t *testing.T, inf *Informer) context.CancelFunc {
+	ctx, cancel := context.WithCancel(context.Background())
+	go inf.Run(ctx)
+	wctx, wcancel := context.WithTimeout(ctx, 5*time.Second)
+	defer wcancel()
+	if err := inf.WaitForSync(wctx); err != nil || !inf.HasSynced() {
+		t.FailNow()
+	}
+	return cancel
+}
+
+func TestInformer(t *testing.T) {
+	db, list := GenerateTestData(5)
+	defer RemoveTestData(db)
+	inf, err := NewInformer(db, TObj, InformerOptions{Indexes: []string{"my_string"}})
+	if err != nil {
+		t.FailNow()
+	}
+	ch := recordInformer(inf)
+	defer startInformer(t, inf)()
+	for k := 0; k < 5; k++ {
+		recvInformer(t, ch, "add")
+	}
+	if len(inf.List()) != 5 || inf.LastSeq() != 5 {
+		t.Fail()
+	}
+
+	tid := list[0].GetMetadata().TypeId()
+	if _, err := Update(db, tid, func(obj *TObject) (*TObject, error) {
+		obj.MyString = "duck"
+		return obj, nil
+	}); err != nil {
+		t.FailNow()
+	}
+	if ev := recvInformer(t, ch, "update"); ev.old.MyString != "" || ev.obj.MyString != "duck" {
+		t.Fail()
+	}
+	if res, err := inf.ByIndex("my_string", "duck"); err != nil || len(res) != 1 || *GetTypeId(res[0]) != tid {
+		t.Fail()
+	}
+	if res, err := inf.ByIndex("my_string", ""); err != nil || len(res) != 4 {
+		t.Fail()
+	}
+	if _, err := inf.ByIndex("my_int", 1); !errors.Is(err, ErrInvalidIndex) {
+		t.Fail()
+	}
+
+	if _, err := Delete[*TObject](db, list[1].GetMetadata().TypeId()); err != nil {
+		t.FailNow()
+	}
+	recvInformer(t, ch, "delete")
+	if _, err := inf.Get(list[1].GetMetadata().TypeId()); !errors.Is(err, ErrNotFound) {
+		t.Fail()
+	}
+	if obj, err := inf.Get(tid); err != nil || obj.(*TObject).MyString != "duck" {
+		t.Fail()
+	}
+
+	// Late handlers get the cached objects
+	late := recordInformer(inf)
+	for k := 0; k < 4; k++ {
+		recvInformer(t, late, "add")
+	}
+}
+
+func TestInformerSelector(t *testing.T) {
+	db := CreateTestDb()
+	defer RemoveTestData(db)
+	obj := MustNew[*TObject](db, TObj)
+	obj.Metadata.Labels = []string{"env=prod"}
+	other := MustNew[*TObject](db, TObj)
+	if err := Put(db, obj, other); err != nil {
+		t.FailNow()
+	}
+	inf, err := NewInformer(db, TObj, InformerOptions{Selector: "env=prod"})
+	if err != nil {
+		t.FailNow()
+	}
+	ch := recordInformer(inf)
+	defer startInformer(t, inf)()
+	if ev := recvInformer(t, ch, "add"); ev.obj.GetMetadata().TypeId() != obj.GetMetadata().TypeId() {
+		t.Fail()
+	}
+	if res, err := inf.ListSelector("env=prod"); err != nil || len(res) != 1 {
+		t.Fail()
+	}
+
+	// Leaving the selection deletes from the cache
+	if _, err := Update(db, obj.GetMetadata().TypeId(), func(obj *TObject) (*TObject, error) {
+		obj.Metadata.Labels = nil
+		return obj, nil
+	}); err != nil {
+		t.FailNow()
+	}
+	recvInformer(t, ch, "delete")
+	if _, err := Update(db, other.GetMetadata().TypeId(), func(obj *TObject) (*TObject, error) {
+		obj.Metadata.Labels = []string{"env=prod"}
+		return obj, nil
+	}); err != nil {
+		t.FailNow()
+	}
+	if ev := recvInformer(t, ch, "add"); ev.obj.GetMetadata().TypeId() != other.GetMetadata().TypeId() {
+		t.Fail()
+	}
+	if len(inf.List()) != 1 {
+		t.Fail()
+	}
+}
+
+func TestInformerResync(t *testing.T) {
+	db, _ := GenerateTestData(2)
+	defer RemoveTestData(db)
+	inf, err := NewInformer(db, TObj, InformerOptions{ResyncPeriod: 10 * time.Millisecond})
+	if err != nil {
+		t.FailNow()
+	}
+	ch := recordInformer(inf)
+	defer startInformer(t, inf)()
+	recvInformer(t, ch, "add")
+	recvInformer(t, ch, "add")
+	if ev := recvInformer(t, ch, "update"); ev.old != ev.obj {
+		t.Fail()
+	}
+}
+
+// gatedSource lets a test break the watch of an informer and decide
+// when it may watch again.
+type gatedSource struct {
+	*DB
+	gate chan struct{}
+
+	mux   sync.Mutex
+	sub   *Subscription
+	froms []uint64
+}
+
+func (s *gatedSource) Watch(opts WatchOptions) (*Subscription, error) {
+	<-s.gate
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.froms = append(s.froms, opts.FromSeq)
+	sub, err := s.DB.Watch(opts)
+	s.sub = sub
+	return sub, err
+}
+
+func (s *gatedSource) breakWatch() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.sub.Close()
+}
+
+func TestInformerReconnect(t *testing.T) {
+	db, list := GenerateTestData(3)
+	defer RemoveTestData(db)
+	src := &gatedSource{DB: db, gate: make(chan struct{}, 10)}
+	defer close(src.gate)
+	inf, err := NewInformer(src, TObj, InformerOptions{RetryPeriod: time.Millisecond})
+	if err != nil {
+		t.FailNow()
+	}
+	ch := recordInformer(inf)
+	src.gate <- struct{}{}
+	defer startInformer(t, inf)()
+	for k := 0; k < 3; k++ {
+		recvInformer(t, ch, "add")
+	}
+	update := func(tid TypeId, s string) {
+		if _, err := Update(db, tid, func(obj *TObject) (*TObject, error) {
+			obj.MyString = s
+			return obj, nil
+		}); err != nil {
+			t.FailNow()
+		}
+	}
+
+	// Changes made while disconnected are caught up from the changelog
+	update(list[0].GetMetadata().TypeId(), "first")
+	recvInformer(t, ch, "update")
+	src.breakWatch()
+	update(list[1].GetMetadata().TypeId(), "second")
+	src.gate <- struct{}{}
+	if ev := recvInformer(t, ch, "update"); ev.obj.MyString != "second" {
+		t.Fail()
+	}
+
+	// Truncated changes make it list again
+	src.breakWatch()
+	update(list[2].GetMetadata().TypeId(), "third")
+	if _, err := db.TruncateChangelog(inf.LastSeq() + 2); err != nil {
+		t.FailNow()
+	}
+	src.gate <- struct{}{}
+	src.gate <- struct{}{}
+	if ev := recvInformer(t, ch, "update"); ev.obj.MyString != "third" {
+		t.Fail()
+	}
+	update(list[0].GetMetadata().TypeId(), "fourth")
+	if ev := recvInformer(t, ch, "update"); ev.obj.MyString != "fourth" {
+		t.Fail()
+	}
+
+	src.mux.Lock()
+	defer src.mux.Unlock()
+	if len(src.froms) != 4 || src.froms[0] != 4 || src.froms[1] != 5 || src.froms[2] != 6 || src.froms[3] != 7 {
+		t.Errorf("watched from %v", src.froms)
+	}
+}
+
+// brokenSource fails to return the types, as a client that cannot
+// reach its server.
+type brokenSource struct {
+	*DB
+}
+
+func (s brokenSource) GetMessageInfo(tk TypeKey) (MessageInfo, error) {
+	return MessageInfo{}, errors.New("unavailable")
+}
+
+func TestInformerSourceError(t *testing.T) {
+	db := CreateTestDb()
+	defer RemoveTestData(db)
+	if _, err := NewInformer(brokenSource{db}, TObj, InformerOptions{}); err == nil {
+		t.Fail()
+	}
+}
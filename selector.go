@@ -0,0 +1,272 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Labels are stored in Metadata.Labels as "key=value" strings. A label
+// without '=' is a key with an empty value.
+
+// parseLabel splits a label into key and value.
+func parseLabel(label string) (key, value string) {
+	key, value, _ = strings.Cut(label, "=")
+	return
+}
+
+// Label returns the value of the label key and whether it is set.
+func (m *Metadata) Label(key string) (string, bool) {
+	for _, l := range m.Labels {
+		if k, v := parseLabel(l); k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// SetLabel sets the label key to value, replacing any previous value.
+func (m *Metadata) SetLabel(key, value string) {
+	m.RemoveLabel(key)
+	m.Labels = append(m.Labels, key+"="+value)
+}
+
+// RemoveLabel removes the label key.
+func (m *Metadata) RemoveLabel(key string) {
+	res := make([]string, 0, len(m.Labels))
+	for _, l := range m.Labels {
+		if k, _ := parseLabel(l); k != key {
+			res = append(res, l)
+		}
+	}
+	m.Labels = res
+}
+
+type selectorOp int
+
+const (
+	opEquals selectorOp = iota
+	opNotEquals
+	opIn
+	opNotIn
+	opExists
+	opNotExists
+)
+
+type requirement struct {
+	key    string
+	op     selectorOp
+	values []string
+}
+
+// Selector selects objects by their labels. The zero Selector selects
+// all objects.
+type Selector struct {
+	reqs []requirement
+}
+
+// ParseSelector parses a comma separated list of requirements that all
+// have to be met, for instance
+//
+//	env=prod,tier!=db,team in (a,b),team notin (c),owner,!deprecated
+//
+// "==" is the same as "=". A bare key requires the label to be set and a
+// key prefixed with '!' requires it not to be set.
+func ParseSelector(s string) (Selector, error) {
+	sel := Selector{}
+	for _, term := range splitSelector(s) {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			if strings.TrimSpace(s) == "" {
+				continue
+			}
+			return Selector{}, fmt.Errorf("%w: empty requirement in %q", ErrInvalidSelector, s)
+		}
+		req, err := parseRequirement(term)
+		if err != nil {
+			return Selector{}, err
+		}
+		sel.reqs = append(sel.reqs, req)
+	}
+	return sel, nil
+}
+
+// MustParseSelector is like ParseSelector but panics on errors.
+func MustParseSelector(s string) Selector {
+	sel, err := ParseSelector(s)
+	if err != nil {
+		panic(err)
+	}
+	return sel
+}
+
+// splitSelector splits s on commas outside of parentheses.
+func splitSelector(s string) []string {
+	res := []string{}
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				res = append(res, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(res, s[start:])
+}
+
+func parseRequirement(term string) (requirement, error) {
+	invalid := fmt.Errorf("%w: %q", ErrInvalidSelector, term)
+	if strings.HasPrefix(term, "!") && !strings.Contains(term, "=") {
+		key := strings.TrimSpace(term[1:])
+		if !validLabelKey(key) {
+			return requirement{}, invalid
+		}
+		return requirement{key: key, op: opNotExists}, nil
+	}
+	for _, sep := range []struct {
+		token string
+		op    selectorOp
+	}{{"!=", opNotEquals}, {"==", opEquals}, {"=", opEquals}} {
+		if key, value, ok := strings.Cut(term, sep.token); ok {
+			key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+			if !validLabelKey(key) || strings.ContainsAny(value, "=!(), ") {
+				return requirement{}, invalid
+			}
+			return requirement{key: key, op: sep.op, values: []string{value}}, nil
+		}
+	}
+	fields := strings.Fields(term)
+	if len(fields) == 1 {
+		if !validLabelKey(fields[0]) {
+			return requirement{}, invalid
+		}
+		return requirement{key: fields[0], op: opExists}, nil
+	}
+	if len(fields) < 3 || (fields[1] != "in" && fields[1] != "notin") || !validLabelKey(fields[0]) {
+		return requirement{}, invalid
+	}
+	set := strings.TrimSpace(term[len(fields[0]):])
+	set = strings.TrimSpace(set[len(fields[1]):])
+	if !strings.HasPrefix(set, "(") || !strings.HasSuffix(set, ")") {
+		return requirement{}, invalid
+	}
+	req := requirement{key: fields[0], op: opIn}
+	if fields[1] == "notin" {
+		req.op = opNotIn
+	}
+	for _, v := range strings.Split(set[1:len(set)-1], ",") {
+		v = strings.TrimSpace(v)
+		if strings.ContainsAny(v, "=!() ") {
+			return requirement{}, invalid
+		}
+		req.values = append(req.values, v)
+	}
+	sort.Strings(req.values)
+	return req, nil
+}
+
+func validLabelKey(key string) bool {
+	return key != "" && !strings.ContainsAny(key, "=!(), \t")
+}
+
+// Empty returns true if the selector selects all objects.
+func (s Selector) Empty() bool {
+	return len(s.reqs) == 0
+}
+
+// Matches returns true if labels meet all requirements of the selector.
+func (s Selector) Matches(labels []string) bool {
+	set := make(map[string]string, len(labels))
+	for _, l := range labels {
+		k, v := parseLabel(l)
+		set[k] = v
+	}
+	for _, req := range s.reqs {
+		v, ok := set[req.key]
+		switch req.op {
+		case opEquals:
+			if !ok || v != req.values[0] {
+				return false
+			}
+		case opNotEquals:
+			if ok && v == req.values[0] {
+				return false
+			}
+		case opIn:
+			if !ok || !containsString(req.values, v) {
+				return false
+			}
+		case opNotIn:
+			if ok && containsString(req.values, v) {
+				return false
+			}
+		case opExists:
+			if !ok {
+				return false
+			}
+		case opNotExists:
+			if ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// MatchesObject returns true if the labels of obj meet all requirements.
+func (s Selector) MatchesObject(obj IObject) bool {
+	if obj == nil {
+		return false
+	}
+	return s.Matches(obj.GetMetadata().GetLabels())
+}
+
+func (s Selector) String() string {
+	terms := []string{}
+	for _, req := range s.reqs {
+		switch req.op {
+		case opEquals:
+			terms = append(terms, req.key+"="+req.values[0])
+		case opNotEquals:
+			terms = append(terms, req.key+"!="+req.values[0])
+		case opIn:
+			terms = append(terms, req.key+" in ("+strings.Join(req.values, ",")+")")
+		case opNotIn:
+			terms = append(terms, req.key+" notin ("+strings.Join(req.values, ",")+")")
+		case opExists:
+			terms = append(terms, req.key)
+		case opNotExists:
+			terms = append(terms, "!"+req.key)
+		}
+	}
+	return strings.Join(terms, ",")
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseSelector(t *testing.T) {
+	labels := []string{"env=prod", "tier=web", "team=a", "owner"}
+	tests := []struct {
+		sel   string
+		match bool
+	}{
+		{"", true},
+		{"env=prod", true},
+		{"env==prod", true},
+		{"env=dev", false},
+		{"tier!=db", true},
+		{"tier!=web", false},
+		{"missing!=x", true},
+		{"team in (a,b)", true},
+		{"team in ( b, c )", false},
+		{"team notin (b)", true},
+		{"team notin (a)", false},
+		{"owner", true},
+		{"owner=", true},
+		{"!owner", false},
+		{"!deprecated", true},
+		{"env=prod,tier!=db,team in (a,b),!deprecated", true},
+		{"env=prod, tier=db", false},
+	}
+	for _, v := range tests {
+		sel, err := ParseSelector(v.sel)
+		if err != nil {
+			t.Errorf("%q: %v", v.sel, err)
+			continue
+		}
+		if sel.Matches(labels) != v.match {
+			t.Errorf("%q: expected %v", v.sel, v.match)
+		}
+		// The string form parses to the same selector
+		if again, err := ParseSelector(sel.String()); err != nil || again.String() != sel.String() {
+			t.Errorf("%q: round trip %q", v.sel, sel.String())
+		}
+	}
+	for _, v := range []string{"=prod", "env=prod,", "team in a,b", "team in (a", "!env=prod", "a b"} {
+		if _, err := ParseSelector(v); !errors.Is(err, ErrInvalidSelector) {
+			t.Errorf("%q: expected an error", v)
+		}
+	}
+}
+
+func TestLabels(t *testing.T) {
+	md := &Metadata{}
+	md.SetLabel("env", "dev")
+	md.SetLabel("env", "prod")
+	md.SetLabel("tier", "web")
+	if v, ok := md.Label("env"); !ok || v != "prod" || len(md.Labels) != 2 {
+		t.Fail()
+	}
+	md.RemoveLabel("env")
+	if _, ok := md.Label("env"); ok || len(md.Labels) != 1 {
+		t.Fail()
+	}
+}
@@ -34,6 +34,7 @@ import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
@@ -47,6 +48,63 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// What happens to an object when an object it references is deleted.
+type Shdb_Ref_Options_OnDelete int32
+
+const (
+	// The reference is left dangling.
+	Shdb_Ref_Options_NONE Shdb_Ref_Options_OnDelete = 0
+	// The referenced object cannot be deleted.
+	Shdb_Ref_Options_RESTRICT Shdb_Ref_Options_OnDelete = 1
+	// The object is deleted as well.
+	Shdb_Ref_Options_CASCADE Shdb_Ref_Options_OnDelete = 2
+	// The reference is cleared, or removed from a repeated field.
+	Shdb_Ref_Options_NULLIFY Shdb_Ref_Options_OnDelete = 3
+)
+
+// Enum value maps for Shdb_Ref_Options_OnDelete.
+var (
+	Shdb_Ref_Options_OnDelete_name = map[int32]string{
+		0: "NONE",
+		1: "RESTRICT",
+		2: "CASCADE",
+		3: "NULLIFY",
+	}
+	Shdb_Ref_Options_OnDelete_value = map[string]int32{
+		"NONE":     0,
+		"RESTRICT": 1,
+		"CASCADE":  2,
+		"NULLIFY":  3,
+	}
+)
+
+func (x Shdb_Ref_Options_OnDelete) Enum() *Shdb_Ref_Options_OnDelete {
+	p := new(Shdb_Ref_Options_OnDelete)
+	*p = x
+	return p
+}
+
+func (x Shdb_Ref_Options_OnDelete) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Shdb_Ref_Options_OnDelete) Descriptor() protoreflect.EnumDescriptor {
+	return file_pb_shdb_v1_shdb_proto_enumTypes[0].Descriptor()
+}
+
+func (Shdb_Ref_Options_OnDelete) Type() protoreflect.EnumType {
+	return &file_pb_shdb_v1_shdb_proto_enumTypes[0]
+}
+
+func (x Shdb_Ref_Options_OnDelete) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Shdb_Ref_Options_OnDelete.Descriptor instead.
+func (Shdb_Ref_Options_OnDelete) EnumDescriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{24, 0}
+}
+
 type Metadata struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -58,6 +116,18 @@ type Metadata struct {
 	Description string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
 	CreatedAt   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	UpdatedAt   *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Revision of the database when the object was last written. Every
+	// write in the database increases it.
+	Revision uint64 `protobuf:"varint,7,opt,name=revision,proto3" json:"revision,omitempty"`
+	// The object is deleted by the reaper after this time and is not
+	// visible to reads once it has passed.
+	ExpiresAt *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// Set on soft deleted objects.
+	DeletedAt *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+	// The version option of the type when the object was written.
+	TypeVersion uint32 `protobuf:"varint,10,opt,name=type_version,json=typeVersion,proto3" json:"type_version,omitempty"`
+	// The version of the database schema when the object was written.
+	SchemaVersion uint32 `protobuf:"varint,11,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`
 }
 
 func (x *Metadata) Reset() {
@@ -134,6 +204,41 @@ func (x *Metadata) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *Metadata) GetRevision() uint64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *Metadata) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *Metadata) GetDeletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return nil
+}
+
+func (x *Metadata) GetTypeVersion() uint32 {
+	if x != nil {
+		return x.TypeVersion
+	}
+	return 0
+}
+
+func (x *Metadata) GetSchemaVersion() uint32 {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return 0
+}
+
 type ObjRef struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -354,6 +459,11 @@ type ListReq struct {
 	Type      []byte `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
 	PageSize  int32  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
 	PageToken string `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// Only list objects whose labels match the selector, for instance
+	// "env=prod,tier!=db". See ParseSelector.
+	LabelSelector string `protobuf:"bytes,4,opt,name=label_selector,json=labelSelector,proto3" json:"label_selector,omitempty"`
+	// Also list soft deleted objects, after the others.
+	IncludeDeleted bool `protobuf:"varint,5,opt,name=include_deleted,json=includeDeleted,proto3" json:"include_deleted,omitempty"`
 }
 
 func (x *ListReq) Reset() {
@@ -409,6 +519,111 @@ func (x *ListReq) GetPageToken() string {
 	return ""
 }
 
+func (x *ListReq) GetLabelSelector() string {
+	if x != nil {
+		return x.LabelSelector
+	}
+	return ""
+}
+
+func (x *ListReq) GetIncludeDeleted() bool {
+	if x != nil {
+		return x.IncludeDeleted
+	}
+	return false
+}
+
+type QueryReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type []byte `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	// Only list objects matching the expression, for instance
+	// "my_int >= 5 && metadata.labels has \"env=prod\"". See ParseExpr.
+	Where     string `protobuf:"bytes,2,opt,name=where,proto3" json:"where,omitempty"`
+	PageSize  int32  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken string `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// Only list objects whose labels match the selector. See ParseSelector.
+	LabelSelector string `protobuf:"bytes,5,opt,name=label_selector,json=labelSelector,proto3" json:"label_selector,omitempty"`
+	// Also list soft deleted objects, after the others.
+	IncludeDeleted bool `protobuf:"varint,6,opt,name=include_deleted,json=includeDeleted,proto3" json:"include_deleted,omitempty"`
+}
+
+func (x *QueryReq) Reset() {
+	*x = QueryReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *QueryReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryReq) ProtoMessage() {}
+
+func (x *QueryReq) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryReq.ProtoReflect.Descriptor instead.
+func (*QueryReq) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *QueryReq) GetType() []byte {
+	if x != nil {
+		return x.Type
+	}
+	return nil
+}
+
+func (x *QueryReq) GetWhere() string {
+	if x != nil {
+		return x.Where
+	}
+	return ""
+}
+
+func (x *QueryReq) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *QueryReq) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *QueryReq) GetLabelSelector() string {
+	if x != nil {
+		return x.LabelSelector
+	}
+	return ""
+}
+
+func (x *QueryReq) GetIncludeDeleted() bool {
+	if x != nil {
+		return x.IncludeDeleted
+	}
+	return false
+}
+
 type ListRsp struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -416,12 +631,15 @@ type ListRsp struct {
 
 	Items         []*BinaryObject `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
 	NextPageToken string          `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	// Sequence number of the last change in the changelog when the listing
+	// was started. All pages see the database as of that change.
+	Seq uint64 `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`
 }
 
 func (x *ListRsp) Reset() {
 	*x = ListRsp{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[6]
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -434,7 +652,7 @@ func (x *ListRsp) String() string {
 func (*ListRsp) ProtoMessage() {}
 
 func (x *ListRsp) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[6]
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -447,7 +665,7 @@ func (x *ListRsp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListRsp.ProtoReflect.Descriptor instead.
 func (*ListRsp) Descriptor() ([]byte, []int) {
-	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{6}
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *ListRsp) GetItems() []*BinaryObject {
@@ -464,6 +682,13 @@ func (x *ListRsp) GetNextPageToken() string {
 	return ""
 }
 
+func (x *ListRsp) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
 type GetReq struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -475,7 +700,7 @@ type GetReq struct {
 func (x *GetReq) Reset() {
 	*x = GetReq{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[7]
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -488,7 +713,7 @@ func (x *GetReq) String() string {
 func (*GetReq) ProtoMessage() {}
 
 func (x *GetReq) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[7]
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -501,7 +726,7 @@ func (x *GetReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetReq.ProtoReflect.Descriptor instead.
 func (*GetReq) Descriptor() ([]byte, []int) {
-	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{7}
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *GetReq) GetRef() *ObjRef {
@@ -517,12 +742,14 @@ type CreateReq struct {
 	unknownFields protoimpl.UnknownFields
 
 	Type []byte `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	// If set, the item is stored. It fails if the object already exists.
+	Item *BinaryObject `protobuf:"bytes,2,opt,name=item,proto3" json:"item,omitempty"`
 }
 
 func (x *CreateReq) Reset() {
 	*x = CreateReq{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[8]
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -535,7 +762,7 @@ func (x *CreateReq) String() string {
 func (*CreateReq) ProtoMessage() {}
 
 func (x *CreateReq) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[8]
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -548,7 +775,7 @@ func (x *CreateReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateReq.ProtoReflect.Descriptor instead.
 func (*CreateReq) Descriptor() ([]byte, []int) {
-	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{8}
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *CreateReq) GetType() []byte {
@@ -558,6 +785,15 @@ func (x *CreateReq) GetType() []byte {
 	return nil
 }
 
+func (x *CreateReq) GetItem() *BinaryObject {
+	if x != nil {
+		return x.Item
+	}
+	return nil
+}
+
+// The item replaces the stored object. If the revision in the item's
+// metadata is non-zero it must match the stored revision.
 type UpdateReq struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -569,7 +805,7 @@ type UpdateReq struct {
 func (x *UpdateReq) Reset() {
 	*x = UpdateReq{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[9]
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -582,7 +818,7 @@ func (x *UpdateReq) String() string {
 func (*UpdateReq) ProtoMessage() {}
 
 func (x *UpdateReq) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[9]
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -595,7 +831,7 @@ func (x *UpdateReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateReq.ProtoReflect.Descriptor instead.
 func (*UpdateReq) Descriptor() ([]byte, []int) {
-	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{9}
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *UpdateReq) GetItem() *BinaryObject {
@@ -616,7 +852,7 @@ type DeleteReq struct {
 func (x *DeleteReq) Reset() {
 	*x = DeleteReq{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[10]
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -629,7 +865,7 @@ func (x *DeleteReq) String() string {
 func (*DeleteReq) ProtoMessage() {}
 
 func (x *DeleteReq) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[10]
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -642,7 +878,7 @@ func (x *DeleteReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteReq.ProtoReflect.Descriptor instead.
 func (*DeleteReq) Descriptor() ([]byte, []int) {
-	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{10}
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *DeleteReq) GetRef() *ObjRef {
@@ -652,34 +888,31 @@ func (x *DeleteReq) GetRef() *ObjRef {
 	return nil
 }
 
-type Shdb_Message_Options struct {
+type UndeleteReq struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Type           string            `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
-	Aliases        []string          `protobuf:"bytes,2,rep,name=aliases,proto3" json:"aliases,omitempty"`
-	TypeKey        []byte            `protobuf:"bytes,3,opt,name=type_key,json=typeKey,proto3" json:"type_key,omitempty"`
-	PrintTemplates map[string]string `protobuf:"bytes,4,rep,name=print_templates,json=printTemplates,proto3" json:"print_templates,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Ref *ObjRef `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
 }
 
-func (x *Shdb_Message_Options) Reset() {
-	*x = Shdb_Message_Options{}
+func (x *UndeleteReq) Reset() {
+	*x = UndeleteReq{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[11]
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[12]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Shdb_Message_Options) String() string {
+func (x *UndeleteReq) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Shdb_Message_Options) ProtoMessage() {}
+func (*UndeleteReq) ProtoMessage() {}
 
-func (x *Shdb_Message_Options) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[11]
+func (x *UndeleteReq) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -690,64 +923,46 @@ func (x *Shdb_Message_Options) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Shdb_Message_Options.ProtoReflect.Descriptor instead.
-func (*Shdb_Message_Options) Descriptor() ([]byte, []int) {
-	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{11}
-}
-
-func (x *Shdb_Message_Options) GetType() string {
-	if x != nil {
-		return x.Type
-	}
-	return ""
-}
-
-func (x *Shdb_Message_Options) GetAliases() []string {
-	if x != nil {
-		return x.Aliases
-	}
-	return nil
-}
-
-func (x *Shdb_Message_Options) GetTypeKey() []byte {
-	if x != nil {
-		return x.TypeKey
-	}
-	return nil
+// Deprecated: Use UndeleteReq.ProtoReflect.Descriptor instead.
+func (*UndeleteReq) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *Shdb_Message_Options) GetPrintTemplates() map[string]string {
+func (x *UndeleteReq) GetRef() *ObjRef {
 	if x != nil {
-		return x.PrintTemplates
+		return x.Ref
 	}
 	return nil
 }
 
-type GetTypeNamesRsp struct {
+type PurgeReq struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	TypeAliases []*GetTypeNamesRsp_TypeAliases `protobuf:"bytes,1,rep,name=type_aliases,json=typeAliases,proto3" json:"type_aliases,omitempty"`
+	// The soft deleted object to purge. If not set, all soft deleted
+	// objects of the type are purged.
+	Ref  *ObjRef `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+	Type []byte  `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
 }
 
-func (x *GetTypeNamesRsp) Reset() {
-	*x = GetTypeNamesRsp{}
+func (x *PurgeReq) Reset() {
+	*x = PurgeReq{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[12]
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *GetTypeNamesRsp) String() string {
+func (x *PurgeReq) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetTypeNamesRsp) ProtoMessage() {}
+func (*PurgeReq) ProtoMessage() {}
 
-func (x *GetTypeNamesRsp) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[12]
+func (x *PurgeReq) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -758,43 +973,50 @@ func (x *GetTypeNamesRsp) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetTypeNamesRsp.ProtoReflect.Descriptor instead.
-func (*GetTypeNamesRsp) Descriptor() ([]byte, []int) {
-	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use PurgeReq.ProtoReflect.Descriptor instead.
+func (*PurgeReq) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *GetTypeNamesRsp) GetTypeAliases() []*GetTypeNamesRsp_TypeAliases {
+func (x *PurgeReq) GetRef() *ObjRef {
 	if x != nil {
-		return x.TypeAliases
+		return x.Ref
 	}
 	return nil
 }
 
-type StreamRefReq struct {
+func (x *PurgeReq) GetType() []byte {
+	if x != nil {
+		return x.Type
+	}
+	return nil
+}
+
+type PurgeRsp struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	TypeKey []byte `protobuf:"bytes,1,opt,name=type_key,json=typeKey,proto3" json:"type_key,omitempty"`
+	Count uint32 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
 }
 
-func (x *StreamRefReq) Reset() {
-	*x = StreamRefReq{}
+func (x *PurgeRsp) Reset() {
+	*x = PurgeRsp{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[13]
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[14]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *StreamRefReq) String() string {
+func (x *PurgeRsp) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StreamRefReq) ProtoMessage() {}
+func (*PurgeRsp) ProtoMessage() {}
 
-func (x *StreamRefReq) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[13]
+func (x *PurgeRsp) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[14]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -805,29 +1027,33 @@ func (x *StreamRefReq) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StreamRefReq.ProtoReflect.Descriptor instead.
-func (*StreamRefReq) Descriptor() ([]byte, []int) {
-	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{13}
+// Deprecated: Use PurgeRsp.ProtoReflect.Descriptor instead.
+func (*PurgeRsp) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *StreamRefReq) GetTypeKey() []byte {
+func (x *PurgeRsp) GetCount() uint32 {
 	if x != nil {
-		return x.TypeKey
+		return x.Count
 	}
-	return nil
+	return 0
 }
 
-type GetTypeNamesRsp_TypeAliases struct {
+type ExpandReq struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Fullname string   `protobuf:"bytes,1,opt,name=fullname,proto3" json:"fullname,omitempty"`
-	Aliases  []string `protobuf:"bytes,2,rep,name=aliases,proto3" json:"aliases,omitempty"`
+	Ref *ObjRef `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+	// How many references away from ref objects are expanded. Negative
+	// for no limit.
+	Depth int32 `protobuf:"varint,2,opt,name=depth,proto3" json:"depth,omitempty"`
+	// Only follow the ObjRef fields with these paths. All if empty.
+	Fields []string `protobuf:"bytes,3,rep,name=fields,proto3" json:"fields,omitempty"`
 }
 
-func (x *GetTypeNamesRsp_TypeAliases) Reset() {
-	*x = GetTypeNamesRsp_TypeAliases{}
+func (x *ExpandReq) Reset() {
+	*x = ExpandReq{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[15]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -835,13 +1061,13 @@ func (x *GetTypeNamesRsp_TypeAliases) Reset() {
 	}
 }
 
-func (x *GetTypeNamesRsp_TypeAliases) String() string {
+func (x *ExpandReq) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetTypeNamesRsp_TypeAliases) ProtoMessage() {}
+func (*ExpandReq) ProtoMessage() {}
 
-func (x *GetTypeNamesRsp_TypeAliases) ProtoReflect() protoreflect.Message {
+func (x *ExpandReq) ProtoReflect() protoreflect.Message {
 	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[15]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -853,16 +1079,2018 @@ func (x *GetTypeNamesRsp_TypeAliases) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetTypeNamesRsp_TypeAliases.ProtoReflect.Descriptor instead.
-func (*GetTypeNamesRsp_TypeAliases) Descriptor() ([]byte, []int) {
-	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{12, 0}
+// Deprecated: Use ExpandReq.ProtoReflect.Descriptor instead.
+func (*ExpandReq) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{15}
 }
 
-func (x *GetTypeNamesRsp_TypeAliases) GetFullname() string {
+func (x *ExpandReq) GetRef() *ObjRef {
 	if x != nil {
-		return x.Fullname
+		return x.Ref
 	}
-	return ""
+	return nil
+}
+
+func (x *ExpandReq) GetDepth() int32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *ExpandReq) GetFields() []string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+// An object and the objects it references, directly or indirectly.
+type ExpandRsp struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Root *BinaryObject `protobuf:"bytes,1,opt,name=root,proto3" json:"root,omitempty"`
+	// The referenced objects, each once.
+	Items []*BinaryObject `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	// The references followed. Objects that do not exist are not in items.
+	Edges []*ExpandRsp_Edge `protobuf:"bytes,3,rep,name=edges,proto3" json:"edges,omitempty"`
+}
+
+func (x *ExpandRsp) Reset() {
+	*x = ExpandRsp{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExpandRsp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpandRsp) ProtoMessage() {}
+
+func (x *ExpandRsp) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpandRsp.ProtoReflect.Descriptor instead.
+func (*ExpandRsp) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ExpandRsp) GetRoot() *BinaryObject {
+	if x != nil {
+		return x.Root
+	}
+	return nil
+}
+
+func (x *ExpandRsp) GetItems() []*BinaryObject {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *ExpandRsp) GetEdges() []*ExpandRsp_Edge {
+	if x != nil {
+		return x.Edges
+	}
+	return nil
+}
+
+type HistoryReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ref *ObjRef `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+}
+
+func (x *HistoryReq) Reset() {
+	*x = HistoryReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HistoryReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryReq) ProtoMessage() {}
+
+func (x *HistoryReq) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryReq.ProtoReflect.Descriptor instead.
+func (*HistoryReq) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *HistoryReq) GetRef() *ObjRef {
+	if x != nil {
+		return x.Ref
+	}
+	return nil
+}
+
+// The revisions of an object, oldest first. The last entry is the current
+// object unless it has been deleted.
+type HistoryRsp struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Entries []*HistoryEntry `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+}
+
+func (x *HistoryRsp) Reset() {
+	*x = HistoryRsp{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HistoryRsp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryRsp) ProtoMessage() {}
+
+func (x *HistoryRsp) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryRsp.ProtoReflect.Descriptor instead.
+func (*HistoryRsp) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *HistoryRsp) GetEntries() []*HistoryEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+// A revision of an object kept in the history bucket.
+type HistoryEntry struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Revision uint64        `protobuf:"varint,1,opt,name=revision,proto3" json:"revision,omitempty"`
+	Item     *BinaryObject `protobuf:"bytes,2,opt,name=item,proto3" json:"item,omitempty"`
+	// When the revision was replaced or deleted. Not set for the current
+	// revision.
+	ReplacedAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=replaced_at,json=replacedAt,proto3" json:"replaced_at,omitempty"`
+	// The object was deleted at replaced_at.
+	Deleted bool `protobuf:"varint,4,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	// Revision of the database when the revision was replaced or deleted.
+	ReplacedRevision uint64 `protobuf:"varint,5,opt,name=replaced_revision,json=replacedRevision,proto3" json:"replaced_revision,omitempty"`
+}
+
+func (x *HistoryEntry) Reset() {
+	*x = HistoryEntry{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HistoryEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryEntry) ProtoMessage() {}
+
+func (x *HistoryEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryEntry.ProtoReflect.Descriptor instead.
+func (*HistoryEntry) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *HistoryEntry) GetRevision() uint64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *HistoryEntry) GetItem() *BinaryObject {
+	if x != nil {
+		return x.Item
+	}
+	return nil
+}
+
+func (x *HistoryEntry) GetReplacedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReplacedAt
+	}
+	return nil
+}
+
+func (x *HistoryEntry) GetDeleted() bool {
+	if x != nil {
+		return x.Deleted
+	}
+	return false
+}
+
+func (x *HistoryEntry) GetReplacedRevision() uint64 {
+	if x != nil {
+		return x.ReplacedRevision
+	}
+	return 0
+}
+
+// Retention rules of a type, applied by the reaper.
+type Shdb_Retention_Options struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Objects expire this long after they were created.
+	MaxAge *durationpb.Duration `protobuf:"bytes,1,opt,name=max_age,json=maxAge,proto3" json:"max_age,omitempty"`
+	// Only the newest objects, by creation time, are kept. 0 for no limit.
+	MaxCount uint32 `protobuf:"varint,2,opt,name=max_count,json=maxCount,proto3" json:"max_count,omitempty"`
+}
+
+func (x *Shdb_Retention_Options) Reset() {
+	*x = Shdb_Retention_Options{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Shdb_Retention_Options) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Shdb_Retention_Options) ProtoMessage() {}
+
+func (x *Shdb_Retention_Options) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Shdb_Retention_Options.ProtoReflect.Descriptor instead.
+func (*Shdb_Retention_Options) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *Shdb_Retention_Options) GetMaxAge() *durationpb.Duration {
+	if x != nil {
+		return x.MaxAge
+	}
+	return nil
+}
+
+func (x *Shdb_Retention_Options) GetMaxCount() uint32 {
+	if x != nil {
+		return x.MaxCount
+	}
+	return 0
+}
+
+// Enables soft delete for a type. Deleted objects are kept as tombstones
+// and can be undeleted until they are purged.
+type Shdb_Soft_Delete_Options struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Tombstones are purged by the reaper this long after the delete. Not
+	// set to keep them until purged explicitly.
+	PurgeAfter *durationpb.Duration `protobuf:"bytes,1,opt,name=purge_after,json=purgeAfter,proto3" json:"purge_after,omitempty"`
+}
+
+func (x *Shdb_Soft_Delete_Options) Reset() {
+	*x = Shdb_Soft_Delete_Options{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Shdb_Soft_Delete_Options) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Shdb_Soft_Delete_Options) ProtoMessage() {}
+
+func (x *Shdb_Soft_Delete_Options) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Shdb_Soft_Delete_Options.ProtoReflect.Descriptor instead.
+func (*Shdb_Soft_Delete_Options) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *Shdb_Soft_Delete_Options) GetPurgeAfter() *durationpb.Duration {
+	if x != nil {
+		return x.PurgeAfter
+	}
+	return nil
+}
+
+// Enables the history of a type. Previous revisions of its objects are
+// kept until one of the limits is reached.
+type Shdb_History_Options struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Maximum number of previous revisions kept per object, 0 for no limit.
+	MaxRevisions uint32 `protobuf:"varint,1,opt,name=max_revisions,json=maxRevisions,proto3" json:"max_revisions,omitempty"`
+	// Maximum age of a previous revision, counted from when it was
+	// replaced. Not set for no limit.
+	MaxAge *durationpb.Duration `protobuf:"bytes,2,opt,name=max_age,json=maxAge,proto3" json:"max_age,omitempty"`
+}
+
+func (x *Shdb_History_Options) Reset() {
+	*x = Shdb_History_Options{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Shdb_History_Options) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Shdb_History_Options) ProtoMessage() {}
+
+func (x *Shdb_History_Options) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Shdb_History_Options.ProtoReflect.Descriptor instead.
+func (*Shdb_History_Options) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *Shdb_History_Options) GetMaxRevisions() uint32 {
+	if x != nil {
+		return x.MaxRevisions
+	}
+	return 0
+}
+
+func (x *Shdb_History_Options) GetMaxAge() *durationpb.Duration {
+	if x != nil {
+		return x.MaxAge
+	}
+	return nil
+}
+
+type Shdb_Message_Options struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type    string   `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Aliases []string `protobuf:"bytes,2,rep,name=aliases,proto3" json:"aliases,omitempty"`
+	// Four bytes used as the TypeKey of the type instead of the hash of its
+	// fullname. It cannot be changed once the type is known to a database.
+	TypeKey        []byte            `protobuf:"bytes,3,opt,name=type_key,json=typeKey,proto3" json:"type_key,omitempty"`
+	PrintTemplates map[string]string `protobuf:"bytes,4,rep,name=print_templates,json=printTemplates,proto3" json:"print_templates,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Field paths of secondary indexes, for instance "my_string" or
+	// "metadata.created_at". The fields must be scalars, enums or
+	// google.protobuf.Timestamp and may be repeated.
+	Indexes []string `protobuf:"bytes,5,rep,name=indexes,proto3" json:"indexes,omitempty"`
+	// Keeps previous revisions of the objects if set.
+	History    *Shdb_History_Options     `protobuf:"bytes,6,opt,name=history,proto3" json:"history,omitempty"`
+	Retention  *Shdb_Retention_Options   `protobuf:"bytes,7,opt,name=retention,proto3" json:"retention,omitempty"`
+	SoftDelete *Shdb_Soft_Delete_Options `protobuf:"bytes,8,opt,name=soft_delete,json=softDelete,proto3" json:"soft_delete,omitempty"`
+	// Version of the schema of the type. Objects written with an older
+	// version are migrated by the migrations registered for the type.
+	Version uint32 `protobuf:"varint,9,opt,name=version,proto3" json:"version,omitempty"`
+}
+
+func (x *Shdb_Message_Options) Reset() {
+	*x = Shdb_Message_Options{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Shdb_Message_Options) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Shdb_Message_Options) ProtoMessage() {}
+
+func (x *Shdb_Message_Options) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Shdb_Message_Options.ProtoReflect.Descriptor instead.
+func (*Shdb_Message_Options) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *Shdb_Message_Options) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Shdb_Message_Options) GetAliases() []string {
+	if x != nil {
+		return x.Aliases
+	}
+	return nil
+}
+
+func (x *Shdb_Message_Options) GetTypeKey() []byte {
+	if x != nil {
+		return x.TypeKey
+	}
+	return nil
+}
+
+func (x *Shdb_Message_Options) GetPrintTemplates() map[string]string {
+	if x != nil {
+		return x.PrintTemplates
+	}
+	return nil
+}
+
+func (x *Shdb_Message_Options) GetIndexes() []string {
+	if x != nil {
+		return x.Indexes
+	}
+	return nil
+}
+
+func (x *Shdb_Message_Options) GetHistory() *Shdb_History_Options {
+	if x != nil {
+		return x.History
+	}
+	return nil
+}
+
+func (x *Shdb_Message_Options) GetRetention() *Shdb_Retention_Options {
+	if x != nil {
+		return x.Retention
+	}
+	return nil
+}
+
+func (x *Shdb_Message_Options) GetSoftDelete() *Shdb_Soft_Delete_Options {
+	if x != nil {
+		return x.SoftDelete
+	}
+	return nil
+}
+
+func (x *Shdb_Message_Options) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// Options of an ObjRef field.
+type Shdb_Ref_Options struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	OnDelete Shdb_Ref_Options_OnDelete `protobuf:"varint,1,opt,name=on_delete,json=onDelete,proto3,enum=shdb.v1.Shdb_Ref_Options_OnDelete" json:"on_delete,omitempty"`
+	// The field must be set and the referenced objects must exist when
+	// the object is written.
+	Required bool `protobuf:"varint,2,opt,name=required,proto3" json:"required,omitempty"`
+}
+
+func (x *Shdb_Ref_Options) Reset() {
+	*x = Shdb_Ref_Options{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[24]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Shdb_Ref_Options) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Shdb_Ref_Options) ProtoMessage() {}
+
+func (x *Shdb_Ref_Options) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[24]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Shdb_Ref_Options.ProtoReflect.Descriptor instead.
+func (*Shdb_Ref_Options) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *Shdb_Ref_Options) GetOnDelete() Shdb_Ref_Options_OnDelete {
+	if x != nil {
+		return x.OnDelete
+	}
+	return Shdb_Ref_Options_NONE
+}
+
+func (x *Shdb_Ref_Options) GetRequired() bool {
+	if x != nil {
+		return x.Required
+	}
+	return false
+}
+
+type Shdb_Field_Options struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ref *Shdb_Ref_Options `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+}
+
+func (x *Shdb_Field_Options) Reset() {
+	*x = Shdb_Field_Options{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[25]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Shdb_Field_Options) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Shdb_Field_Options) ProtoMessage() {}
+
+func (x *Shdb_Field_Options) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[25]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Shdb_Field_Options.ProtoReflect.Descriptor instead.
+func (*Shdb_Field_Options) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *Shdb_Field_Options) GetRef() *Shdb_Ref_Options {
+	if x != nil {
+		return x.Ref
+	}
+	return nil
+}
+
+// A version of the schema stored in the database. A new version is
+// stored whenever the schema changes.
+type SchemaVersion struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Version   uint32                 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// SHA-256 of the schema with its files sorted by name.
+	Hash []byte `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	// Not set when the versions are listed.
+	Schema *descriptorpb.FileDescriptorSet `protobuf:"bytes,4,opt,name=schema,proto3" json:"schema,omitempty"`
+}
+
+func (x *SchemaVersion) Reset() {
+	*x = SchemaVersion{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[26]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SchemaVersion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SchemaVersion) ProtoMessage() {}
+
+func (x *SchemaVersion) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[26]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SchemaVersion.ProtoReflect.Descriptor instead.
+func (*SchemaVersion) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *SchemaVersion) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *SchemaVersion) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *SchemaVersion) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
+func (x *SchemaVersion) GetSchema() *descriptorpb.FileDescriptorSet {
+	if x != nil {
+		return x.Schema
+	}
+	return nil
+}
+
+type ListSchemaVersionsRsp struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Versions []*SchemaVersion `protobuf:"bytes,1,rep,name=versions,proto3" json:"versions,omitempty"`
+}
+
+func (x *ListSchemaVersionsRsp) Reset() {
+	*x = ListSchemaVersionsRsp{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[27]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListSchemaVersionsRsp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSchemaVersionsRsp) ProtoMessage() {}
+
+func (x *ListSchemaVersionsRsp) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[27]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSchemaVersionsRsp.ProtoReflect.Descriptor instead.
+func (*ListSchemaVersionsRsp) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ListSchemaVersionsRsp) GetVersions() []*SchemaVersion {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+type GetSchemaVersionReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Version uint32 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+}
+
+func (x *GetSchemaVersionReq) Reset() {
+	*x = GetSchemaVersionReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[28]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetSchemaVersionReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSchemaVersionReq) ProtoMessage() {}
+
+func (x *GetSchemaVersionReq) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[28]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSchemaVersionReq.ProtoReflect.Descriptor instead.
+func (*GetSchemaVersionReq) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *GetSchemaVersionReq) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type RenameTypeReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	OldFullname string `protobuf:"bytes,1,opt,name=old_fullname,json=oldFullname,proto3" json:"old_fullname,omitempty"`
+	NewFullname string `protobuf:"bytes,2,opt,name=new_fullname,json=newFullname,proto3" json:"new_fullname,omitempty"`
+	// Objects moved per transaction, all in one transaction when 0.
+	BatchSize uint32 `protobuf:"varint,3,opt,name=batch_size,json=batchSize,proto3" json:"batch_size,omitempty"`
+}
+
+func (x *RenameTypeReq) Reset() {
+	*x = RenameTypeReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[29]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RenameTypeReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenameTypeReq) ProtoMessage() {}
+
+func (x *RenameTypeReq) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[29]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenameTypeReq.ProtoReflect.Descriptor instead.
+func (*RenameTypeReq) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *RenameTypeReq) GetOldFullname() string {
+	if x != nil {
+		return x.OldFullname
+	}
+	return ""
+}
+
+func (x *RenameTypeReq) GetNewFullname() string {
+	if x != nil {
+		return x.NewFullname
+	}
+	return ""
+}
+
+func (x *RenameTypeReq) GetBatchSize() uint32 {
+	if x != nil {
+		return x.BatchSize
+	}
+	return 0
+}
+
+type RenameTypeRsp struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Count uint32 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+}
+
+func (x *RenameTypeRsp) Reset() {
+	*x = RenameTypeRsp{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[30]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RenameTypeRsp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenameTypeRsp) ProtoMessage() {}
+
+func (x *RenameTypeRsp) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[30]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenameTypeRsp.ProtoReflect.Descriptor instead.
+func (*RenameTypeRsp) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *RenameTypeRsp) GetCount() uint32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type GetTypeNamesRsp struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TypeAliases []*GetTypeNamesRsp_TypeAliases `protobuf:"bytes,1,rep,name=type_aliases,json=typeAliases,proto3" json:"type_aliases,omitempty"`
+}
+
+func (x *GetTypeNamesRsp) Reset() {
+	*x = GetTypeNamesRsp{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[31]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetTypeNamesRsp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTypeNamesRsp) ProtoMessage() {}
+
+func (x *GetTypeNamesRsp) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[31]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTypeNamesRsp.ProtoReflect.Descriptor instead.
+func (*GetTypeNamesRsp) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *GetTypeNamesRsp) GetTypeAliases() []*GetTypeNamesRsp_TypeAliases {
+	if x != nil {
+		return x.TypeAliases
+	}
+	return nil
+}
+
+type StreamRefReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TypeKey []byte `protobuf:"bytes,1,opt,name=type_key,json=typeKey,proto3" json:"type_key,omitempty"`
+}
+
+func (x *StreamRefReq) Reset() {
+	*x = StreamRefReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[32]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamRefReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRefReq) ProtoMessage() {}
+
+func (x *StreamRefReq) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[32]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRefReq.ProtoReflect.Descriptor instead.
+func (*StreamRefReq) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *StreamRefReq) GetTypeKey() []byte {
+	if x != nil {
+		return x.TypeKey
+	}
+	return nil
+}
+
+type BackupReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Gzip bool `protobuf:"varint,1,opt,name=gzip,proto3" json:"gzip,omitempty"`
+	// Only the changes made after since_revision, or after since if
+	// since_revision is 0, are included, as found in the changelog. A full
+	// backup is made if neither is set.
+	SinceRevision uint64                 `protobuf:"varint,2,opt,name=since_revision,json=sinceRevision,proto3" json:"since_revision,omitempty"`
+	Since         *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=since,proto3" json:"since,omitempty"`
+}
+
+func (x *BackupReq) Reset() {
+	*x = BackupReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[33]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BackupReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackupReq) ProtoMessage() {}
+
+func (x *BackupReq) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[33]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackupReq.ProtoReflect.Descriptor instead.
+func (*BackupReq) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *BackupReq) GetGzip() bool {
+	if x != nil {
+		return x.Gzip
+	}
+	return false
+}
+
+func (x *BackupReq) GetSinceRevision() uint64 {
+	if x != nil {
+		return x.SinceRevision
+	}
+	return 0
+}
+
+func (x *BackupReq) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+type BackupChunk struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *BackupChunk) Reset() {
+	*x = BackupChunk{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[34]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BackupChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackupChunk) ProtoMessage() {}
+
+func (x *BackupChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[34]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackupChunk.ProtoReflect.Descriptor instead.
+func (*BackupChunk) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *BackupChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// First record of an incremental backup.
+type BackupHeader struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Revision of the database when the backup was made.
+	Revision      uint64                          `protobuf:"varint,1,opt,name=revision,proto3" json:"revision,omitempty"`
+	CreatedAt     *timestamppb.Timestamp          `protobuf:"bytes,2,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	SinceRevision uint64                          `protobuf:"varint,3,opt,name=since_revision,json=sinceRevision,proto3" json:"since_revision,omitempty"`
+	Since         *timestamppb.Timestamp          `protobuf:"bytes,4,opt,name=since,proto3" json:"since,omitempty"`
+	SchemaVersion uint32                          `protobuf:"varint,5,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`
+	Schema        *descriptorpb.FileDescriptorSet `protobuf:"bytes,6,opt,name=schema,proto3" json:"schema,omitempty"`
+}
+
+func (x *BackupHeader) Reset() {
+	*x = BackupHeader{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[35]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BackupHeader) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackupHeader) ProtoMessage() {}
+
+func (x *BackupHeader) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[35]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackupHeader.ProtoReflect.Descriptor instead.
+func (*BackupHeader) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *BackupHeader) GetRevision() uint64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *BackupHeader) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *BackupHeader) GetSinceRevision() uint64 {
+	if x != nil {
+		return x.SinceRevision
+	}
+	return 0
+}
+
+func (x *BackupHeader) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *BackupHeader) GetSchemaVersion() uint32 {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return 0
+}
+
+func (x *BackupHeader) GetSchema() *descriptorpb.FileDescriptorSet {
+	if x != nil {
+		return x.Schema
+	}
+	return nil
+}
+
+// An incremental backup is a stream of length-delimited BackupRecords
+// starting with a header, followed by a record for every change in the
+// order they were made.
+type BackupRecord struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Record:
+	//	*BackupRecord_Header
+	//	*BackupRecord_Object
+	//	*BackupRecord_Tombstone
+	//	*BackupRecord_Deleted
+	Record isBackupRecord_Record `protobuf_oneof:"record"`
+	// When the change of the record was made.
+	Time *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=time,proto3" json:"time,omitempty"`
+}
+
+func (x *BackupRecord) Reset() {
+	*x = BackupRecord{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[36]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BackupRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackupRecord) ProtoMessage() {}
+
+func (x *BackupRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[36]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackupRecord.ProtoReflect.Descriptor instead.
+func (*BackupRecord) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{36}
+}
+
+func (m *BackupRecord) GetRecord() isBackupRecord_Record {
+	if m != nil {
+		return m.Record
+	}
+	return nil
+}
+
+func (x *BackupRecord) GetHeader() *BackupHeader {
+	if x, ok := x.GetRecord().(*BackupRecord_Header); ok {
+		return x.Header
+	}
+	return nil
+}
+
+func (x *BackupRecord) GetObject() *BinaryObject {
+	if x, ok := x.GetRecord().(*BackupRecord_Object); ok {
+		return x.Object
+	}
+	return nil
+}
+
+func (x *BackupRecord) GetTombstone() *BinaryObject {
+	if x, ok := x.GetRecord().(*BackupRecord_Tombstone); ok {
+		return x.Tombstone
+	}
+	return nil
+}
+
+func (x *BackupRecord) GetDeleted() *BackupRecord_Deletion {
+	if x, ok := x.GetRecord().(*BackupRecord_Deleted); ok {
+		return x.Deleted
+	}
+	return nil
+}
+
+func (x *BackupRecord) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+type isBackupRecord_Record interface {
+	isBackupRecord_Record()
+}
+
+type BackupRecord_Header struct {
+	Header *BackupHeader `protobuf:"bytes,1,opt,name=header,proto3,oneof"`
+}
+
+type BackupRecord_Object struct {
+	// An object written since the previous backup.
+	Object *BinaryObject `protobuf:"bytes,2,opt,name=object,proto3,oneof"`
+}
+
+type BackupRecord_Tombstone struct {
+	// A soft deleted object, deleted since the previous backup.
+	Tombstone *BinaryObject `protobuf:"bytes,3,opt,name=tombstone,proto3,oneof"`
+}
+
+type BackupRecord_Deleted struct {
+	Deleted *BackupRecord_Deletion `protobuf:"bytes,4,opt,name=deleted,proto3,oneof"`
+}
+
+func (*BackupRecord_Header) isBackupRecord_Record() {}
+
+func (*BackupRecord_Object) isBackupRecord_Record() {}
+
+func (*BackupRecord_Tombstone) isBackupRecord_Record() {}
+
+func (*BackupRecord_Deleted) isBackupRecord_Record() {}
+
+// A backup sent to the Restore RPC.
+type RestoreChunk struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Only incremental backup records up to this time are applied. Only
+	// read from the first chunk.
+	Until *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=until,proto3" json:"until,omitempty"`
+	Data  []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *RestoreChunk) Reset() {
+	*x = RestoreChunk{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[37]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RestoreChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreChunk) ProtoMessage() {}
+
+func (x *RestoreChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[37]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreChunk.ProtoReflect.Descriptor instead.
+func (*RestoreChunk) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *RestoreChunk) GetUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Until
+	}
+	return nil
+}
+
+func (x *RestoreChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type RestoreRsp struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Revision of the database after the restore.
+	Revision uint64 `protobuf:"varint,1,opt,name=revision,proto3" json:"revision,omitempty"`
+	// Number of objects restored.
+	Count uint32 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+}
+
+func (x *RestoreRsp) Reset() {
+	*x = RestoreRsp{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[38]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RestoreRsp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreRsp) ProtoMessage() {}
+
+func (x *RestoreRsp) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[38]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreRsp.ProtoReflect.Descriptor instead.
+func (*RestoreRsp) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *RestoreRsp) GetRevision() uint64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *RestoreRsp) GetCount() uint32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type ExportReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Fullnames or aliases of the types to export, all types if empty.
+	Types []string `protobuf:"bytes,1,rep,name=types,proto3" json:"types,omitempty"`
+	// Label selector, for instance "env=prod,tier!=db".
+	Selector string `protobuf:"bytes,2,opt,name=selector,proto3" json:"selector,omitempty"`
+	// "ndjson" or "yaml", defaults to "ndjson".
+	Format string `protobuf:"bytes,3,opt,name=format,proto3" json:"format,omitempty"`
+}
+
+func (x *ExportReq) Reset() {
+	*x = ExportReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[39]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExportReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportReq) ProtoMessage() {}
+
+func (x *ExportReq) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[39]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportReq.ProtoReflect.Descriptor instead.
+func (*ExportReq) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *ExportReq) GetTypes() []string {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+func (x *ExportReq) GetSelector() string {
+	if x != nil {
+		return x.Selector
+	}
+	return ""
+}
+
+func (x *ExportReq) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+type ExportChunk struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *ExportChunk) Reset() {
+	*x = ExportChunk{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[40]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExportChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportChunk) ProtoMessage() {}
+
+func (x *ExportChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[40]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportChunk.ProtoReflect.Descriptor instead.
+func (*ExportChunk) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *ExportChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// An export sent to the Import RPC. Format and dry_run are only read
+// from the first chunk.
+type ImportChunk struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Format string `protobuf:"bytes,1,opt,name=format,proto3" json:"format,omitempty"`
+	DryRun bool   `protobuf:"varint,2,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	Data   []byte `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *ImportChunk) Reset() {
+	*x = ImportChunk{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[41]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ImportChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportChunk) ProtoMessage() {}
+
+func (x *ImportChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[41]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportChunk.ProtoReflect.Descriptor instead.
+func (*ImportChunk) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *ImportChunk) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *ImportChunk) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *ImportChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type ImportRsp struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Count uint32 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+}
+
+func (x *ImportRsp) Reset() {
+	*x = ImportRsp{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[42]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ImportRsp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportRsp) ProtoMessage() {}
+
+func (x *ImportRsp) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[42]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportRsp.ProtoReflect.Descriptor instead.
+func (*ImportRsp) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *ImportRsp) GetCount() uint32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+// A committed change kept in the changelog bucket.
+type Change struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Seq uint64 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	// EventCreated, EventUpdated or EventDeleted.
+	Kind int32 `protobuf:"varint,2,opt,name=kind,proto3" json:"kind,omitempty"`
+	// The written object, or the deleted object for deletes.
+	Item *BinaryObject `protobuf:"bytes,3,opt,name=item,proto3" json:"item,omitempty"`
+	// The object replaced by an update.
+	Previous *BinaryObject          `protobuf:"bytes,4,opt,name=previous,proto3" json:"previous,omitempty"`
+	Time     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=time,proto3" json:"time,omitempty"`
+	// Revision of the database made by the change.
+	Revision uint64 `protobuf:"varint,6,opt,name=revision,proto3" json:"revision,omitempty"`
+}
+
+func (x *Change) Reset() {
+	*x = Change{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[43]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Change) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Change) ProtoMessage() {}
+
+func (x *Change) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[43]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Change.ProtoReflect.Descriptor instead.
+func (*Change) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *Change) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Change) GetKind() int32 {
+	if x != nil {
+		return x.Kind
+	}
+	return 0
+}
+
+func (x *Change) GetItem() *BinaryObject {
+	if x != nil {
+		return x.Item
+	}
+	return nil
+}
+
+func (x *Change) GetPrevious() *BinaryObject {
+	if x != nil {
+		return x.Previous
+	}
+	return nil
+}
+
+func (x *Change) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Change) GetRevision() uint64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+// Watches the changes of objects of the given types or with the given
+// refs, all changes if both are empty.
+type WatchReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Types [][]byte  `protobuf:"bytes,1,rep,name=types,proto3" json:"types,omitempty"`
+	Refs  []*ObjRef `protobuf:"bytes,2,rep,name=refs,proto3" json:"refs,omitempty"`
+	// Only changes of objects whose labels match the selector, before or
+	// after the change. See ParseSelector.
+	LabelSelector string `protobuf:"bytes,3,opt,name=label_selector,json=labelSelector,proto3" json:"label_selector,omitempty"`
+	// Sequence number in the changelog of the first change to send, as in
+	// Change.seq and ListRsp.seq. This is not an object revision. When zero
+	// only new changes are sent, unless from_revision is set.
+	FromSeq uint64 `protobuf:"varint,4,opt,name=from_seq,json=fromSeq,proto3" json:"from_seq,omitempty"`
+	// Only changes of objects matching the expression, before or after the
+	// change, for instance "my_int > 10". See ParseExpr.
+	Predicate string `protobuf:"bytes,5,opt,name=predicate,proto3" json:"predicate,omitempty"`
+	// Only updates that change one of these dotted field paths.
+	Fields []string `protobuf:"bytes,6,rep,name=fields,proto3" json:"fields,omitempty"`
+	// Send the changes made after this revision of the database, as in
+	// Metadata.revision and Change.revision. Used when from_seq is zero.
+	FromRevision uint64 `protobuf:"varint,7,opt,name=from_revision,json=fromRevision,proto3" json:"from_revision,omitempty"`
+}
+
+func (x *WatchReq) Reset() {
+	*x = WatchReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[44]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchReq) ProtoMessage() {}
+
+func (x *WatchReq) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[44]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchReq.ProtoReflect.Descriptor instead.
+func (*WatchReq) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *WatchReq) GetTypes() [][]byte {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+func (x *WatchReq) GetRefs() []*ObjRef {
+	if x != nil {
+		return x.Refs
+	}
+	return nil
+}
+
+func (x *WatchReq) GetLabelSelector() string {
+	if x != nil {
+		return x.LabelSelector
+	}
+	return ""
+}
+
+func (x *WatchReq) GetFromSeq() uint64 {
+	if x != nil {
+		return x.FromSeq
+	}
+	return 0
+}
+
+func (x *WatchReq) GetPredicate() string {
+	if x != nil {
+		return x.Predicate
+	}
+	return ""
+}
+
+func (x *WatchReq) GetFields() []string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+func (x *WatchReq) GetFromRevision() uint64 {
+	if x != nil {
+		return x.FromRevision
+	}
+	return 0
+}
+
+type ExpandRsp_Edge struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	From  *ObjRef `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	Field string  `protobuf:"bytes,2,opt,name=field,proto3" json:"field,omitempty"`
+	To    *ObjRef `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+}
+
+func (x *ExpandRsp_Edge) Reset() {
+	*x = ExpandRsp_Edge{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[45]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExpandRsp_Edge) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpandRsp_Edge) ProtoMessage() {}
+
+func (x *ExpandRsp_Edge) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[45]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpandRsp_Edge.ProtoReflect.Descriptor instead.
+func (*ExpandRsp_Edge) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{16, 0}
+}
+
+func (x *ExpandRsp_Edge) GetFrom() *ObjRef {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *ExpandRsp_Edge) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *ExpandRsp_Edge) GetTo() *ObjRef {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+type GetTypeNamesRsp_TypeAliases struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Fullname string   `protobuf:"bytes,1,opt,name=fullname,proto3" json:"fullname,omitempty"`
+	Aliases  []string `protobuf:"bytes,2,rep,name=aliases,proto3" json:"aliases,omitempty"`
+	TypeKey  []byte   `protobuf:"bytes,3,opt,name=type_key,json=typeKey,proto3" json:"type_key,omitempty"`
+}
+
+func (x *GetTypeNamesRsp_TypeAliases) Reset() {
+	*x = GetTypeNamesRsp_TypeAliases{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[47]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetTypeNamesRsp_TypeAliases) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTypeNamesRsp_TypeAliases) ProtoMessage() {}
+
+func (x *GetTypeNamesRsp_TypeAliases) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[47]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTypeNamesRsp_TypeAliases.ProtoReflect.Descriptor instead.
+func (*GetTypeNamesRsp_TypeAliases) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{31, 0}
+}
+
+func (x *GetTypeNamesRsp_TypeAliases) GetFullname() string {
+	if x != nil {
+		return x.Fullname
+	}
+	return ""
 }
 
 func (x *GetTypeNamesRsp_TypeAliases) GetAliases() []string {
@@ -872,6 +3100,78 @@ func (x *GetTypeNamesRsp_TypeAliases) GetAliases() []string {
 	return nil
 }
 
+func (x *GetTypeNamesRsp_TypeAliases) GetTypeKey() []byte {
+	if x != nil {
+		return x.TypeKey
+	}
+	return nil
+}
+
+// An object deleted since the previous backup.
+type BackupRecord_Deletion struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key       []byte                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	DeletedAt *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+	// Revision of the database when the object was deleted.
+	Revision uint64 `protobuf:"varint,3,opt,name=revision,proto3" json:"revision,omitempty"`
+}
+
+func (x *BackupRecord_Deletion) Reset() {
+	*x = BackupRecord_Deletion{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_shdb_proto_msgTypes[48]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BackupRecord_Deletion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackupRecord_Deletion) ProtoMessage() {}
+
+func (x *BackupRecord_Deletion) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_shdb_proto_msgTypes[48]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackupRecord_Deletion.ProtoReflect.Descriptor instead.
+func (*BackupRecord_Deletion) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_shdb_proto_rawDescGZIP(), []int{36, 0}
+}
+
+func (x *BackupRecord_Deletion) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *BackupRecord_Deletion) GetDeletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return nil
+}
+
+func (x *BackupRecord_Deletion) GetRevision() uint64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
 var file_pb_shdb_v1_shdb_proto_extTypes = []protoimpl.ExtensionInfo{
 	{
 		ExtendedType:  (*descriptorpb.MessageOptions)(nil),
@@ -881,6 +3181,14 @@ var file_pb_shdb_v1_shdb_proto_extTypes = []protoimpl.ExtensionInfo{
 		Tag:           "bytes,52000,opt,name=shdb_options",
 		Filename:      "pb/shdb/v1/shdb.proto",
 	},
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*Shdb_Field_Options)(nil),
+		Field:         52000,
+		Name:          "shdb.v1.shdb_field_options",
+		Tag:           "bytes,52000,opt,name=shdb_field_options",
+		Filename:      "pb/shdb/v1/shdb.proto",
+	},
 	{
 		ExtendedType:  (*descriptorpb.MessageOptions)(nil),
 		ExtensionType: (*string)(nil),
@@ -912,11 +3220,17 @@ var (
 	// optional shdb.v1.Shdb_Message_Options shdb_options = 52000;
 	E_ShdbOptions = &file_pb_shdb_v1_shdb_proto_extTypes[0]
 	// optional string shdb_type = 51234;
-	E_ShdbType = &file_pb_shdb_v1_shdb_proto_extTypes[1]
+	E_ShdbType = &file_pb_shdb_v1_shdb_proto_extTypes[2]
 	// optional string shdb_aliases = 51235;
-	E_ShdbAliases = &file_pb_shdb_v1_shdb_proto_extTypes[2]
+	E_ShdbAliases = &file_pb_shdb_v1_shdb_proto_extTypes[3]
 	// optional bytes shdb_type_key = 51236;
-	E_ShdbTypeKey = &file_pb_shdb_v1_shdb_proto_extTypes[3]
+	E_ShdbTypeKey = &file_pb_shdb_v1_shdb_proto_extTypes[4]
+)
+
+// Extension fields to descriptorpb.FieldOptions.
+var (
+	// optional shdb.v1.Shdb_Field_Options shdb_field_options = 52000;
+	E_ShdbFieldOptions = &file_pb_shdb_v1_shdb_proto_extTypes[1]
 )
 
 var File_pb_shdb_v1_shdb_proto protoreflect.FileDescriptor
@@ -926,11 +3240,13 @@ var file_pb_shdb_v1_shdb_proto_rawDesc = []byte{
 	0x62, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31,
 	0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
 	0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74,
 	0x6f, 0x1a, 0x20, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
 	0x75, 0x66, 0x2f, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x6f, 0x72, 0x2e, 0x70, 0x72,
 	0x6f, 0x74, 0x6f, 0x1a, 0x1b, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
 	0x6f, 0x62, 0x75, 0x66, 0x2f, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x22, 0xe2, 0x01, 0x0a, 0x08, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x12, 0x0a,
+	0x22, 0xbe, 0x03, 0x0a, 0x08, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x12, 0x0a,
 	0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x74, 0x79, 0x70,
 	0x65, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x75, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52,
 	0x04, 0x75, 0x75, 0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x18,
@@ -944,125 +3260,451 @@ var file_pb_shdb_v1_shdb_proto_rawDesc = []byte{
 	0x64, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a,
 	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
 	0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x75, 0x70, 0x64, 0x61,
-	0x74, 0x65, 0x64, 0x41, 0x74, 0x22, 0x30, 0x0a, 0x06, 0x4f, 0x62, 0x6a, 0x52, 0x65, 0x66, 0x12,
+	0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f,
+	0x6e, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x39, 0x0a, 0x0a, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x5f, 0x61, 0x74, 0x18,
+	0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x52, 0x09, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x41, 0x74, 0x12, 0x39, 0x0a, 0x0a,
+	0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x64, 0x65,
+	0x6c, 0x65, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x74, 0x79, 0x70, 0x65, 0x5f,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x74,
+	0x79, 0x70, 0x65, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x0b, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x0d, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x22, 0x30, 0x0a, 0x06, 0x4f, 0x62, 0x6a, 0x52, 0x65, 0x66, 0x12, 0x12, 0x0a, 0x04, 0x74,
+	0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12,
+	0x12, 0x0a, 0x04, 0x75, 0x75, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x75,
+	0x75, 0x69, 0x64, 0x22, 0x4e, 0x0a, 0x09, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x48, 0x69, 0x74,
+	0x12, 0x2d, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x11, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x65, 0x74,
+	0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12,
+	0x12, 0x0a, 0x04, 0x68, 0x69, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x68,
+	0x69, 0x74, 0x73, 0x22, 0x36, 0x0a, 0x0c, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x12, 0x26, 0x0a, 0x04, 0x68, 0x69, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x12, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65, 0x61, 0x72,
+	0x63, 0x68, 0x48, 0x69, 0x74, 0x52, 0x04, 0x68, 0x69, 0x74, 0x73, 0x22, 0x36, 0x0a, 0x0c, 0x42,
+	0x69, 0x6e, 0x61, 0x72, 0x79, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x6b,
+	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x22, 0xa9, 0x01, 0x0a, 0x07, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x71, 0x12,
 	0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x74,
-	0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x75, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x0c, 0x52, 0x04, 0x75, 0x75, 0x69, 0x64, 0x22, 0x4e, 0x0a, 0x09, 0x53, 0x65, 0x61, 0x72, 0x63,
-	0x68, 0x48, 0x69, 0x74, 0x12, 0x2d, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31,
-	0x2e, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64,
-	0x61, 0x74, 0x61, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x69, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
-	0x09, 0x52, 0x04, 0x68, 0x69, 0x74, 0x73, 0x22, 0x36, 0x0a, 0x0c, 0x53, 0x65, 0x61, 0x72, 0x63,
-	0x68, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x26, 0x0a, 0x04, 0x68, 0x69, 0x74, 0x73, 0x18,
-	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e,
-	0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x48, 0x69, 0x74, 0x52, 0x04, 0x68, 0x69, 0x74, 0x73, 0x22,
-	0x36, 0x0a, 0x0c, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x12,
-	0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65,
-	0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c,
-	0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x59, 0x0a, 0x07, 0x4c, 0x69, 0x73, 0x74, 0x52,
-	0x65, 0x71, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c,
-	0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x73,
-	0x69, 0x7a, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x70, 0x61, 0x67, 0x65, 0x53,
+	0x79, 0x70, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x73, 0x69, 0x7a, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x70, 0x61, 0x67, 0x65, 0x53, 0x69, 0x7a, 0x65,
+	0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12,
+	0x25, 0x0a, 0x0e, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x5f, 0x73, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f,
+	0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x53, 0x65,
+	0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x27, 0x0a, 0x0f, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64,
+	0x65, 0x5f, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0e, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x22,
+	0xc0, 0x01, 0x0a, 0x08, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x65, 0x71, 0x12, 0x12, 0x0a, 0x04,
+	0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65,
+	0x12, 0x14, 0x0a, 0x05, 0x77, 0x68, 0x65, 0x72, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x77, 0x68, 0x65, 0x72, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x73,
+	0x69, 0x7a, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x70, 0x61, 0x67, 0x65, 0x53,
 	0x69, 0x7a, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65,
-	0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b,
-	0x65, 0x6e, 0x22, 0x5e, 0x0a, 0x07, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x73, 0x70, 0x12, 0x2b, 0x0a,
+	0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b,
+	0x65, 0x6e, 0x12, 0x25, 0x0a, 0x0e, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x5f, 0x73, 0x65, 0x6c, 0x65,
+	0x63, 0x74, 0x6f, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x6c, 0x61, 0x62, 0x65,
+	0x6c, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x27, 0x0a, 0x0f, 0x69, 0x6e, 0x63,
+	0x6c, 0x75, 0x64, 0x65, 0x5f, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x0e, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x44, 0x65, 0x6c, 0x65, 0x74,
+	0x65, 0x64, 0x22, 0x70, 0x0a, 0x07, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x73, 0x70, 0x12, 0x2b, 0x0a,
 	0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x73,
 	0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x4f, 0x62, 0x6a,
 	0x65, 0x63, 0x74, 0x52, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x12, 0x26, 0x0a, 0x0f, 0x6e, 0x65,
 	0x78, 0x74, 0x5f, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20,
 	0x01, 0x28, 0x09, 0x52, 0x0d, 0x6e, 0x65, 0x78, 0x74, 0x50, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b,
-	0x65, 0x6e, 0x22, 0x2b, 0x0a, 0x06, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x12, 0x21, 0x0a, 0x03,
-	0x72, 0x65, 0x66, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x73, 0x68, 0x64, 0x62,
-	0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x62, 0x6a, 0x52, 0x65, 0x66, 0x52, 0x03, 0x72, 0x65, 0x66, 0x22,
-	0x1f, 0x0a, 0x09, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x12, 0x12, 0x0a, 0x04,
-	0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65,
-	0x22, 0x36, 0x0a, 0x09, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x12, 0x29, 0x0a,
-	0x04, 0x69, 0x74, 0x65, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x73, 0x68,
-	0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x4f, 0x62, 0x6a, 0x65,
-	0x63, 0x74, 0x52, 0x04, 0x69, 0x74, 0x65, 0x6d, 0x22, 0x2e, 0x0a, 0x09, 0x44, 0x65, 0x6c, 0x65,
-	0x74, 0x65, 0x52, 0x65, 0x71, 0x12, 0x21, 0x0a, 0x03, 0x72, 0x65, 0x66, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x62, 0x6a,
-	0x52, 0x65, 0x66, 0x52, 0x03, 0x72, 0x65, 0x66, 0x22, 0xfe, 0x01, 0x0a, 0x14, 0x53, 0x68, 0x64,
-	0x62, 0x5f, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e,
-	0x73, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x65, 0x73,
-	0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x65, 0x73, 0x12,
-	0x19, 0x0a, 0x08, 0x74, 0x79, 0x70, 0x65, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28,
-	0x0c, 0x52, 0x07, 0x74, 0x79, 0x70, 0x65, 0x4b, 0x65, 0x79, 0x12, 0x5a, 0x0a, 0x0f, 0x70, 0x72,
-	0x69, 0x6e, 0x74, 0x5f, 0x74, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x18, 0x04, 0x20,
-	0x03, 0x28, 0x0b, 0x32, 0x31, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x68,
-	0x64, 0x62, 0x5f, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x4f, 0x70, 0x74, 0x69, 0x6f,
-	0x6e, 0x73, 0x2e, 0x50, 0x72, 0x69, 0x6e, 0x74, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65,
-	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0e, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x54, 0x65, 0x6d,
-	0x70, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x1a, 0x41, 0x0a, 0x13, 0x50, 0x72, 0x69, 0x6e, 0x74, 0x54,
-	0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
-	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
-	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
-	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x9f, 0x01, 0x0a, 0x0f, 0x47, 0x65,
+	0x65, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x71, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x03, 0x73, 0x65, 0x71, 0x22, 0x2b, 0x0a, 0x06, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x12, 0x21,
+	0x0a, 0x03, 0x72, 0x65, 0x66, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x73, 0x68,
+	0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x62, 0x6a, 0x52, 0x65, 0x66, 0x52, 0x03, 0x72, 0x65,
+	0x66, 0x22, 0x4a, 0x0a, 0x09, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x12, 0x12,
+	0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x74, 0x79,
+	0x70, 0x65, 0x12, 0x29, 0x0a, 0x04, 0x69, 0x74, 0x65, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x15, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x69, 0x6e, 0x61, 0x72,
+	0x79, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x52, 0x04, 0x69, 0x74, 0x65, 0x6d, 0x22, 0x36, 0x0a,
+	0x09, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x12, 0x29, 0x0a, 0x04, 0x69, 0x74,
+	0x65, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e,
+	0x76, 0x31, 0x2e, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x52,
+	0x04, 0x69, 0x74, 0x65, 0x6d, 0x22, 0x2e, 0x0a, 0x09, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52,
+	0x65, 0x71, 0x12, 0x21, 0x0a, 0x03, 0x72, 0x65, 0x66, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0f, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x62, 0x6a, 0x52, 0x65, 0x66,
+	0x52, 0x03, 0x72, 0x65, 0x66, 0x22, 0x30, 0x0a, 0x0b, 0x55, 0x6e, 0x64, 0x65, 0x6c, 0x65, 0x74,
+	0x65, 0x52, 0x65, 0x71, 0x12, 0x21, 0x0a, 0x03, 0x72, 0x65, 0x66, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x0f, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x62, 0x6a, 0x52,
+	0x65, 0x66, 0x52, 0x03, 0x72, 0x65, 0x66, 0x22, 0x41, 0x0a, 0x08, 0x50, 0x75, 0x72, 0x67, 0x65,
+	0x52, 0x65, 0x71, 0x12, 0x21, 0x0a, 0x03, 0x72, 0x65, 0x66, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x0f, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x62, 0x6a, 0x52, 0x65,
+	0x66, 0x52, 0x03, 0x72, 0x65, 0x66, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x22, 0x20, 0x0a, 0x08, 0x50, 0x75,
+	0x72, 0x67, 0x65, 0x52, 0x73, 0x70, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x5c, 0x0a, 0x09,
+	0x45, 0x78, 0x70, 0x61, 0x6e, 0x64, 0x52, 0x65, 0x71, 0x12, 0x21, 0x0a, 0x03, 0x72, 0x65, 0x66,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31,
+	0x2e, 0x4f, 0x62, 0x6a, 0x52, 0x65, 0x66, 0x52, 0x03, 0x72, 0x65, 0x66, 0x12, 0x14, 0x0a, 0x05,
+	0x64, 0x65, 0x70, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x64, 0x65, 0x70,
+	0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x18, 0x03, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x06, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x22, 0xf6, 0x01, 0x0a, 0x09, 0x45,
+	0x78, 0x70, 0x61, 0x6e, 0x64, 0x52, 0x73, 0x70, 0x12, 0x29, 0x0a, 0x04, 0x72, 0x6f, 0x6f, 0x74,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31,
+	0x2e, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x52, 0x04, 0x72,
+	0x6f, 0x6f, 0x74, 0x12, 0x2b, 0x0a, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x18, 0x02, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x15, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x69, 0x6e,
+	0x61, 0x72, 0x79, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x52, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73,
+	0x12, 0x2d, 0x0a, 0x05, 0x65, 0x64, 0x67, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x17, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x78, 0x70, 0x61, 0x6e, 0x64,
+	0x52, 0x73, 0x70, 0x2e, 0x45, 0x64, 0x67, 0x65, 0x52, 0x05, 0x65, 0x64, 0x67, 0x65, 0x73, 0x1a,
+	0x62, 0x0a, 0x04, 0x45, 0x64, 0x67, 0x65, 0x12, 0x23, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e,
+	0x4f, 0x62, 0x6a, 0x52, 0x65, 0x66, 0x52, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x12, 0x14, 0x0a, 0x05,
+	0x66, 0x69, 0x65, 0x6c, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x66, 0x69, 0x65,
+	0x6c, 0x64, 0x12, 0x1f, 0x0a, 0x02, 0x74, 0x6f, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f,
+	0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x62, 0x6a, 0x52, 0x65, 0x66, 0x52,
+	0x02, 0x74, 0x6f, 0x22, 0x2f, 0x0a, 0x0a, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x52, 0x65,
+	0x71, 0x12, 0x21, 0x0a, 0x03, 0x72, 0x65, 0x66, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f,
+	0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x62, 0x6a, 0x52, 0x65, 0x66, 0x52,
+	0x03, 0x72, 0x65, 0x66, 0x22, 0x3d, 0x0a, 0x0a, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x52,
+	0x73, 0x70, 0x12, 0x2f, 0x0a, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x48, 0x69,
+	0x73, 0x74, 0x6f, 0x72, 0x79, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x65, 0x6e, 0x74, 0x72,
+	0x69, 0x65, 0x73, 0x22, 0xd9, 0x01, 0x0a, 0x0c, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e,
+	0x12, 0x29, 0x0a, 0x04, 0x69, 0x74, 0x65, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15,
+	0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x4f,
+	0x62, 0x6a, 0x65, 0x63, 0x74, 0x52, 0x04, 0x69, 0x74, 0x65, 0x6d, 0x12, 0x3b, 0x0a, 0x0b, 0x72,
+	0x65, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x72, 0x65,
+	0x70, 0x6c, 0x61, 0x63, 0x65, 0x64, 0x41, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x64, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x64, 0x65, 0x6c, 0x65, 0x74,
+	0x65, 0x64, 0x12, 0x2b, 0x0a, 0x11, 0x72, 0x65, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x64, 0x5f, 0x72,
+	0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x10, 0x72,
+	0x65, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x64, 0x52, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x22,
+	0x69, 0x0a, 0x16, 0x53, 0x68, 0x64, 0x62, 0x5f, 0x52, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f,
+	0x6e, 0x5f, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x32, 0x0a, 0x07, 0x6d, 0x61, 0x78,
+	0x5f, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x06, 0x6d, 0x61, 0x78, 0x41, 0x67, 0x65, 0x12, 0x1b, 0x0a,
+	0x09, 0x6d, 0x61, 0x78, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x08, 0x6d, 0x61, 0x78, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x56, 0x0a, 0x18, 0x53, 0x68,
+	0x64, 0x62, 0x5f, 0x53, 0x6f, 0x66, 0x74, 0x5f, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x5f, 0x4f,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x3a, 0x0a, 0x0b, 0x70, 0x75, 0x72, 0x67, 0x65, 0x5f,
+	0x61, 0x66, 0x74, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a, 0x70, 0x75, 0x72, 0x67, 0x65, 0x41, 0x66, 0x74,
+	0x65, 0x72, 0x22, 0x6f, 0x0a, 0x14, 0x53, 0x68, 0x64, 0x62, 0x5f, 0x48, 0x69, 0x73, 0x74, 0x6f,
+	0x72, 0x79, 0x5f, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x6d, 0x61,
+	0x78, 0x5f, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x0c, 0x6d, 0x61, 0x78, 0x52, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12,
+	0x32, 0x0a, 0x07, 0x6d, 0x61, 0x78, 0x5f, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x06, 0x6d, 0x61, 0x78,
+	0x41, 0x67, 0x65, 0x22, 0xee, 0x03, 0x0a, 0x14, 0x53, 0x68, 0x64, 0x62, 0x5f, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x5f, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x12, 0x0a, 0x04,
+	0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65,
+	0x12, 0x18, 0x0a, 0x07, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x07, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x65, 0x73, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x79,
+	0x70, 0x65, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x74, 0x79,
+	0x70, 0x65, 0x4b, 0x65, 0x79, 0x12, 0x5a, 0x0a, 0x0f, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x5f, 0x74,
+	0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x31,
+	0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x68, 0x64, 0x62, 0x5f, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x50, 0x72,
+	0x69, 0x6e, 0x74, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x52, 0x0e, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65,
+	0x73, 0x12, 0x18, 0x0a, 0x07, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x07, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x65, 0x73, 0x12, 0x37, 0x0a, 0x07, 0x68,
+	0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x73,
+	0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x68, 0x64, 0x62, 0x5f, 0x48, 0x69, 0x73, 0x74,
+	0x6f, 0x72, 0x79, 0x5f, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x07, 0x68, 0x69, 0x73,
+	0x74, 0x6f, 0x72, 0x79, 0x12, 0x3d, 0x0a, 0x09, 0x72, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f,
+	0x6e, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76,
+	0x31, 0x2e, 0x53, 0x68, 0x64, 0x62, 0x5f, 0x52, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e,
+	0x5f, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x09, 0x72, 0x65, 0x74, 0x65, 0x6e, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x42, 0x0a, 0x0b, 0x73, 0x6f, 0x66, 0x74, 0x5f, 0x64, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e,
+	0x76, 0x31, 0x2e, 0x53, 0x68, 0x64, 0x62, 0x5f, 0x53, 0x6f, 0x66, 0x74, 0x5f, 0x44, 0x65, 0x6c,
+	0x65, 0x74, 0x65, 0x5f, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x0a, 0x73, 0x6f, 0x66,
+	0x74, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x1a, 0x41, 0x0a, 0x13, 0x50, 0x72, 0x69, 0x6e, 0x74, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61,
+	0x74, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x3a, 0x02, 0x38, 0x01, 0x22, 0xad, 0x01, 0x0a, 0x10, 0x53, 0x68, 0x64, 0x62, 0x5f, 0x52, 0x65,
+	0x66, 0x5f, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x3f, 0x0a, 0x09, 0x6f, 0x6e, 0x5f,
+	0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x22, 0x2e, 0x73,
+	0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x68, 0x64, 0x62, 0x5f, 0x52, 0x65, 0x66, 0x5f,
+	0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x4f, 0x6e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65,
+	0x52, 0x08, 0x6f, 0x6e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65,
+	0x71, 0x75, 0x69, 0x72, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x72, 0x65,
+	0x71, 0x75, 0x69, 0x72, 0x65, 0x64, 0x22, 0x3c, 0x0a, 0x08, 0x4f, 0x6e, 0x44, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x12, 0x08, 0x0a, 0x04, 0x4e, 0x4f, 0x4e, 0x45, 0x10, 0x00, 0x12, 0x0c, 0x0a, 0x08,
+	0x52, 0x45, 0x53, 0x54, 0x52, 0x49, 0x43, 0x54, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x43, 0x41,
+	0x53, 0x43, 0x41, 0x44, 0x45, 0x10, 0x02, 0x12, 0x0b, 0x0a, 0x07, 0x4e, 0x55, 0x4c, 0x4c, 0x49,
+	0x46, 0x59, 0x10, 0x03, 0x22, 0x41, 0x0a, 0x12, 0x53, 0x68, 0x64, 0x62, 0x5f, 0x46, 0x69, 0x65,
+	0x6c, 0x64, 0x5f, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x2b, 0x0a, 0x03, 0x72, 0x65,
+	0x66, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76,
+	0x31, 0x2e, 0x53, 0x68, 0x64, 0x62, 0x5f, 0x52, 0x65, 0x66, 0x5f, 0x4f, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x52, 0x03, 0x72, 0x65, 0x66, 0x22, 0xb4, 0x01, 0x0a, 0x0d, 0x53, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x12, 0x39, 0x0a, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x52, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x12,
+	0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x68, 0x61,
+	0x73, 0x68, 0x12, 0x3a, 0x0a, 0x06, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x22, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70,
+	0x74, 0x6f, 0x72, 0x53, 0x65, 0x74, 0x52, 0x06, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x22, 0x4b,
+	0x0a, 0x15, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x56, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x73, 0x52, 0x73, 0x70, 0x12, 0x32, 0x0a, 0x08, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x73, 0x68, 0x64, 0x62,
+	0x2e, 0x76, 0x31, 0x2e, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x52, 0x08, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x2f, 0x0a, 0x13, 0x47,
+	0x65, 0x74, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52,
+	0x65, 0x71, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x74, 0x0a, 0x0d,
+	0x52, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x54, 0x79, 0x70, 0x65, 0x52, 0x65, 0x71, 0x12, 0x21, 0x0a,
+	0x0c, 0x6f, 0x6c, 0x64, 0x5f, 0x66, 0x75, 0x6c, 0x6c, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0b, 0x6f, 0x6c, 0x64, 0x46, 0x75, 0x6c, 0x6c, 0x6e, 0x61, 0x6d, 0x65,
+	0x12, 0x21, 0x0a, 0x0c, 0x6e, 0x65, 0x77, 0x5f, 0x66, 0x75, 0x6c, 0x6c, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x6e, 0x65, 0x77, 0x46, 0x75, 0x6c, 0x6c, 0x6e,
+	0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x62, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x73, 0x69, 0x7a,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x62, 0x61, 0x74, 0x63, 0x68, 0x53, 0x69,
+	0x7a, 0x65, 0x22, 0x25, 0x0a, 0x0d, 0x52, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x54, 0x79, 0x70, 0x65,
+	0x52, 0x73, 0x70, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0xba, 0x01, 0x0a, 0x0f, 0x47, 0x65,
 	0x74, 0x54, 0x79, 0x70, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x52, 0x73, 0x70, 0x12, 0x47, 0x0a,
 	0x0c, 0x74, 0x79, 0x70, 0x65, 0x5f, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x65, 0x73, 0x18, 0x01, 0x20,
 	0x03, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65,
 	0x74, 0x54, 0x79, 0x70, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x52, 0x73, 0x70, 0x2e, 0x54, 0x79,
 	0x70, 0x65, 0x41, 0x6c, 0x69, 0x61, 0x73, 0x65, 0x73, 0x52, 0x0b, 0x74, 0x79, 0x70, 0x65, 0x41,
-	0x6c, 0x69, 0x61, 0x73, 0x65, 0x73, 0x1a, 0x43, 0x0a, 0x0b, 0x54, 0x79, 0x70, 0x65, 0x41, 0x6c,
+	0x6c, 0x69, 0x61, 0x73, 0x65, 0x73, 0x1a, 0x5e, 0x0a, 0x0b, 0x54, 0x79, 0x70, 0x65, 0x41, 0x6c,
 	0x69, 0x61, 0x73, 0x65, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x75, 0x6c, 0x6c, 0x6e, 0x61, 0x6d,
 	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x66, 0x75, 0x6c, 0x6c, 0x6e, 0x61, 0x6d,
 	0x65, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03,
-	0x28, 0x09, 0x52, 0x07, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x65, 0x73, 0x22, 0x29, 0x0a, 0x0c, 0x53,
-	0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x66, 0x52, 0x65, 0x71, 0x12, 0x19, 0x0a, 0x08, 0x74,
-	0x79, 0x70, 0x65, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x74,
-	0x79, 0x70, 0x65, 0x4b, 0x65, 0x79, 0x32, 0xd2, 0x03, 0x0a, 0x13, 0x42, 0x69, 0x6e, 0x61, 0x72,
-	0x79, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x2a,
-	0x0a, 0x04, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x10, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31,
-	0x2e, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x71, 0x1a, 0x10, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e,
-	0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x73, 0x70, 0x12, 0x2d, 0x0a, 0x03, 0x47, 0x65,
-	0x74, 0x12, 0x0f, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x52,
+	0x28, 0x09, 0x52, 0x07, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x65, 0x73, 0x12, 0x19, 0x0a, 0x08, 0x74,
+	0x79, 0x70, 0x65, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x74,
+	0x79, 0x70, 0x65, 0x4b, 0x65, 0x79, 0x22, 0x29, 0x0a, 0x0c, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d,
+	0x52, 0x65, 0x66, 0x52, 0x65, 0x71, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x79, 0x70, 0x65, 0x5f, 0x6b,
+	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x74, 0x79, 0x70, 0x65, 0x4b, 0x65,
+	0x79, 0x22, 0x78, 0x0a, 0x09, 0x42, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x52, 0x65, 0x71, 0x12, 0x12,
+	0x0a, 0x04, 0x67, 0x7a, 0x69, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x67, 0x7a,
+	0x69, 0x70, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x5f, 0x72, 0x65, 0x76, 0x69,
+	0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0d, 0x73, 0x69, 0x6e, 0x63,
+	0x65, 0x52, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x30, 0x0a, 0x05, 0x73, 0x69, 0x6e,
+	0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x52, 0x05, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x22, 0x21, 0x0a, 0x0b, 0x42,
+	0x61, 0x63, 0x6b, 0x75, 0x70, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61,
+	0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0xa1,
+	0x02, 0x0a, 0x0c, 0x42, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12,
+	0x1a, 0x0a, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x39, 0x0a, 0x0a, 0x63,
+	0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x63, 0x72, 0x65,
+	0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x5f,
+	0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0d,
+	0x73, 0x69, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x30, 0x0a,
+	0x05, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x05, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x12,
+	0x25, 0x0a, 0x0e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x56,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x3a, 0x0a, 0x06, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x44, 0x65, 0x73,
+	0x63, 0x72, 0x69, 0x70, 0x74, 0x6f, 0x72, 0x53, 0x65, 0x74, 0x52, 0x06, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x22, 0x92, 0x03, 0x0a, 0x0c, 0x42, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x52, 0x65, 0x63,
+	0x6f, 0x72, 0x64, 0x12, 0x2f, 0x0a, 0x06, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61,
+	0x63, 0x6b, 0x75, 0x70, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x48, 0x00, 0x52, 0x06, 0x68, 0x65,
+	0x61, 0x64, 0x65, 0x72, 0x12, 0x2f, 0x0a, 0x06, 0x6f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x42,
+	0x69, 0x6e, 0x61, 0x72, 0x79, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x48, 0x00, 0x52, 0x06, 0x6f,
+	0x62, 0x6a, 0x65, 0x63, 0x74, 0x12, 0x35, 0x0a, 0x09, 0x74, 0x6f, 0x6d, 0x62, 0x73, 0x74, 0x6f,
+	0x6e, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e,
+	0x76, 0x31, 0x2e, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x48,
+	0x00, 0x52, 0x09, 0x74, 0x6f, 0x6d, 0x62, 0x73, 0x74, 0x6f, 0x6e, 0x65, 0x12, 0x3a, 0x0a, 0x07,
+	0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e,
+	0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x52, 0x65,
+	0x63, 0x6f, 0x72, 0x64, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52,
+	0x07, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x12, 0x2e, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x1a, 0x73, 0x0a, 0x08, 0x44, 0x65, 0x6c, 0x65,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x39, 0x0a, 0x0a, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65,
+	0x64, 0x5f, 0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x41,
+	0x74, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x42, 0x08, 0x0a,
+	0x06, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x22, 0x54, 0x0a, 0x0c, 0x52, 0x65, 0x73, 0x74, 0x6f,
+	0x72, 0x65, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x12, 0x30, 0x0a, 0x05, 0x75, 0x6e, 0x74, 0x69, 0x6c,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x52, 0x05, 0x75, 0x6e, 0x74, 0x69, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74,
+	0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x3e, 0x0a,
+	0x0a, 0x52, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x52, 0x73, 0x70, 0x12, 0x1a, 0x0a, 0x08, 0x72,
+	0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x72,
+	0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x55, 0x0a,
+	0x09, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x71, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x79,
+	0x70, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x74, 0x79, 0x70, 0x65, 0x73,
+	0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x73, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x16, 0x0a, 0x06,
+	0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x66, 0x6f,
+	0x72, 0x6d, 0x61, 0x74, 0x22, 0x21, 0x0a, 0x0b, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x43, 0x68,
+	0x75, 0x6e, 0x6b, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x52, 0x0a, 0x0b, 0x49, 0x6d, 0x70, 0x6f, 0x72,
+	0x74, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x17,
+	0x0a, 0x07, 0x64, 0x72, 0x79, 0x5f, 0x72, 0x75, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x06, 0x64, 0x72, 0x79, 0x52, 0x75, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x21, 0x0a, 0x09, 0x49,
+	0x6d, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x73, 0x70, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0xd8,
+	0x01, 0x0a, 0x06, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x71,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x73, 0x65, 0x71, 0x12, 0x12, 0x0a, 0x04, 0x6b,
+	0x69, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12,
+	0x29, 0x0a, 0x04, 0x69, 0x74, 0x65, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e,
+	0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x4f, 0x62,
+	0x6a, 0x65, 0x63, 0x74, 0x52, 0x04, 0x69, 0x74, 0x65, 0x6d, 0x12, 0x31, 0x0a, 0x08, 0x70, 0x72,
+	0x65, 0x76, 0x69, 0x6f, 0x75, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x73,
+	0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x4f, 0x62, 0x6a,
+	0x65, 0x63, 0x74, 0x52, 0x08, 0x70, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75, 0x73, 0x12, 0x2e, 0x0a,
+	0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x1a, 0x0a,
+	0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0xe2, 0x01, 0x0a, 0x08, 0x57, 0x61,
+	0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x79, 0x70, 0x65, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x05, 0x74, 0x79, 0x70, 0x65, 0x73, 0x12, 0x23, 0x0a, 0x04,
+	0x72, 0x65, 0x66, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x73, 0x68, 0x64,
+	0x62, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x62, 0x6a, 0x52, 0x65, 0x66, 0x52, 0x04, 0x72, 0x65, 0x66,
+	0x73, 0x12, 0x25, 0x0a, 0x0e, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x5f, 0x73, 0x65, 0x6c, 0x65, 0x63,
+	0x74, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x6c, 0x61, 0x62, 0x65, 0x6c,
+	0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x19, 0x0a, 0x08, 0x66, 0x72, 0x6f, 0x6d,
+	0x5f, 0x73, 0x65, 0x71, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x66, 0x72, 0x6f, 0x6d,
+	0x53, 0x65, 0x71, 0x12, 0x1c, 0x0a, 0x09, 0x70, 0x72, 0x65, 0x64, 0x69, 0x63, 0x61, 0x74, 0x65,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70, 0x72, 0x65, 0x64, 0x69, 0x63, 0x61, 0x74,
+	0x65, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x06, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x66, 0x72, 0x6f,
+	0x6d, 0x5f, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x0c, 0x66, 0x72, 0x6f, 0x6d, 0x52, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x32, 0xaf,
+	0x09, 0x0a, 0x13, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x53,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x2a, 0x0a, 0x04, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x10,
+	0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x71,
+	0x1a, 0x10, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x52,
+	0x73, 0x70, 0x12, 0x2c, 0x0a, 0x05, 0x51, 0x75, 0x65, 0x72, 0x79, 0x12, 0x11, 0x2e, 0x73, 0x68,
+	0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x65, 0x71, 0x1a, 0x10,
+	0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x73, 0x70,
+	0x12, 0x2d, 0x0a, 0x03, 0x47, 0x65, 0x74, 0x12, 0x0f, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76,
+	0x31, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x1a, 0x15, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e,
+	0x76, 0x31, 0x2e, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x12,
+	0x33, 0x0a, 0x06, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x12, 0x12, 0x2e, 0x73, 0x68, 0x64, 0x62,
+	0x2e, 0x76, 0x31, 0x2e, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x15, 0x2e,
+	0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x4f, 0x62,
+	0x6a, 0x65, 0x63, 0x74, 0x12, 0x33, 0x0a, 0x06, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12, 0x12,
+	0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52,
 	0x65, 0x71, 0x1a, 0x15, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x69, 0x6e,
-	0x61, 0x72, 0x79, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x12, 0x33, 0x0a, 0x06, 0x43, 0x72, 0x65,
-	0x61, 0x74, 0x65, 0x12, 0x12, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x72,
-	0x65, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x15, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76,
+	0x61, 0x72, 0x79, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x12, 0x33, 0x0a, 0x06, 0x44, 0x65, 0x6c,
+	0x65, 0x74, 0x65, 0x12, 0x12, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65,
+	0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x15, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76,
 	0x31, 0x2e, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x12, 0x33,
-	0x0a, 0x06, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12, 0x12, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e,
-	0x76, 0x31, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x15, 0x2e, 0x73,
-	0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x4f, 0x62, 0x6a,
-	0x65, 0x63, 0x74, 0x12, 0x33, 0x0a, 0x06, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x12, 0x2e,
-	0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65,
-	0x71, 0x1a, 0x15, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x69, 0x6e, 0x61,
-	0x72, 0x79, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x12, 0x36, 0x0a, 0x0a, 0x53, 0x74, 0x72, 0x65,
-	0x61, 0x6d, 0x52, 0x65, 0x66, 0x73, 0x12, 0x15, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31,
-	0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x66, 0x52, 0x65, 0x71, 0x1a, 0x0f, 0x2e,
-	0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x62, 0x6a, 0x52, 0x65, 0x66, 0x30, 0x01,
-	0x12, 0x47, 0x0a, 0x09, 0x47, 0x65, 0x74, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x12, 0x16, 0x2e,
-	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
-	0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x22, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x44, 0x65, 0x73, 0x63,
-	0x72, 0x69, 0x70, 0x74, 0x6f, 0x72, 0x53, 0x65, 0x74, 0x12, 0x40, 0x0a, 0x0c, 0x47, 0x65, 0x74,
-	0x54, 0x79, 0x70, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
-	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74,
-	0x79, 0x1a, 0x18, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x54,
-	0x79, 0x70, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x52, 0x73, 0x70, 0x3a, 0x66, 0x0a, 0x0c, 0x73,
-	0x68, 0x64, 0x62, 0x5f, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1f, 0x2e, 0x67, 0x6f,
-	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65,
-	0x73, 0x73, 0x61, 0x67, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xa0, 0x96, 0x03,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x53,
-	0x68, 0x64, 0x62, 0x5f, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x4f, 0x70, 0x74, 0x69,
-	0x6f, 0x6e, 0x73, 0x52, 0x0b, 0x73, 0x68, 0x64, 0x62, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73,
-	0x88, 0x01, 0x01, 0x3a, 0x41, 0x0a, 0x09, 0x73, 0x68, 0x64, 0x62, 0x5f, 0x74, 0x79, 0x70, 0x65,
-	0x12, 0x1f, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
-	0x75, 0x66, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e,
-	0x73, 0x18, 0xa2, 0x90, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x68, 0x64, 0x62, 0x54,
-	0x79, 0x70, 0x65, 0x88, 0x01, 0x01, 0x3a, 0x47, 0x0a, 0x0c, 0x73, 0x68, 0x64, 0x62, 0x5f, 0x61,
-	0x6c, 0x69, 0x61, 0x73, 0x65, 0x73, 0x12, 0x1f, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
-	0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xa3, 0x90, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x0b, 0x73, 0x68, 0x64, 0x62, 0x41, 0x6c, 0x69, 0x61, 0x73, 0x65, 0x73, 0x88, 0x01, 0x01, 0x3a,
-	0x48, 0x0a, 0x0d, 0x73, 0x68, 0x64, 0x62, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x5f, 0x6b, 0x65, 0x79,
+	0x0a, 0x07, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x12, 0x13, 0x2e, 0x73, 0x68, 0x64, 0x62,
+	0x2e, 0x76, 0x31, 0x2e, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x52, 0x65, 0x71, 0x1a, 0x13,
+	0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79,
+	0x52, 0x73, 0x70, 0x12, 0x37, 0x0a, 0x08, 0x55, 0x6e, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12,
+	0x14, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x6e, 0x64, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x15, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e,
+	0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x12, 0x2d, 0x0a, 0x05,
+	0x50, 0x75, 0x72, 0x67, 0x65, 0x12, 0x11, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e,
+	0x50, 0x75, 0x72, 0x67, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x11, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e,
+	0x76, 0x31, 0x2e, 0x50, 0x75, 0x72, 0x67, 0x65, 0x52, 0x73, 0x70, 0x12, 0x30, 0x0a, 0x06, 0x45,
+	0x78, 0x70, 0x61, 0x6e, 0x64, 0x12, 0x12, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e,
+	0x45, 0x78, 0x70, 0x61, 0x6e, 0x64, 0x52, 0x65, 0x71, 0x1a, 0x12, 0x2e, 0x73, 0x68, 0x64, 0x62,
+	0x2e, 0x76, 0x31, 0x2e, 0x45, 0x78, 0x70, 0x61, 0x6e, 0x64, 0x52, 0x73, 0x70, 0x12, 0x36, 0x0a,
+	0x0a, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x66, 0x73, 0x12, 0x15, 0x2e, 0x73, 0x68,
+	0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x66, 0x52,
+	0x65, 0x71, 0x1a, 0x0f, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x62, 0x6a,
+	0x52, 0x65, 0x66, 0x30, 0x01, 0x12, 0x47, 0x0a, 0x09, 0x47, 0x65, 0x74, 0x53, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x22, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x6c,
+	0x65, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x6f, 0x72, 0x53, 0x65, 0x74, 0x12, 0x40,
+	0x0a, 0x0c, 0x47, 0x65, 0x74, 0x54, 0x79, 0x70, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x12, 0x16,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x18, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31,
+	0x2e, 0x47, 0x65, 0x74, 0x54, 0x79, 0x70, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x52, 0x73, 0x70,
+	0x12, 0x4c, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x56, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x1e,
+	0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x73, 0x70, 0x12, 0x48,
+	0x0a, 0x10, 0x47, 0x65, 0x74, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x56, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x12, 0x1c, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74,
+	0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71,
+	0x1a, 0x16, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x3c, 0x0a, 0x0a, 0x52, 0x65, 0x6e, 0x61,
+	0x6d, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x16, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31,
+	0x2e, 0x52, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x54, 0x79, 0x70, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x16,
+	0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x54,
+	0x79, 0x70, 0x65, 0x52, 0x73, 0x70, 0x12, 0x34, 0x0a, 0x06, 0x42, 0x61, 0x63, 0x6b, 0x75, 0x70,
+	0x12, 0x12, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61, 0x63, 0x6b, 0x75,
+	0x70, 0x52, 0x65, 0x71, 0x1a, 0x14, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x42,
+	0x61, 0x63, 0x6b, 0x75, 0x70, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x30, 0x01, 0x12, 0x37, 0x0a, 0x07,
+	0x52, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x12, 0x15, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76,
+	0x31, 0x2e, 0x52, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x1a, 0x13,
+	0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65,
+	0x52, 0x73, 0x70, 0x28, 0x01, 0x12, 0x34, 0x0a, 0x06, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x12,
+	0x12, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74,
+	0x52, 0x65, 0x71, 0x1a, 0x14, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x78,
+	0x70, 0x6f, 0x72, 0x74, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x30, 0x01, 0x12, 0x34, 0x0a, 0x06, 0x49,
+	0x6d, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x14, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e,
+	0x49, 0x6d, 0x70, 0x6f, 0x72, 0x74, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x1a, 0x12, 0x2e, 0x73, 0x68,
+	0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6d, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x73, 0x70, 0x28,
+	0x01, 0x12, 0x2d, 0x0a, 0x05, 0x57, 0x61, 0x74, 0x63, 0x68, 0x12, 0x11, 0x2e, 0x73, 0x68, 0x64,
+	0x62, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x1a, 0x0f, 0x2e,
+	0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x30, 0x01,
+	0x3a, 0x66, 0x0a, 0x0c, 0x73, 0x68, 0x64, 0x62, 0x5f, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73,
 	0x12, 0x1f, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
 	0x75, 0x66, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e,
-	0x73, 0x18, 0xa4, 0x90, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x73, 0x68, 0x64, 0x62, 0x54,
-	0x79, 0x70, 0x65, 0x4b, 0x65, 0x79, 0x88, 0x01, 0x01, 0x42, 0x1c, 0x5a, 0x1a, 0x67, 0x69, 0x74,
-	0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x73, 0x68, 0x65, 0x6e, 0x72, 0x79, 0x74, 0x65,
-	0x63, 0x68, 0x2f, 0x73, 0x68, 0x64, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x73, 0x18, 0xa0, 0x96, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x73, 0x68, 0x64, 0x62,
+	0x2e, 0x76, 0x31, 0x2e, 0x53, 0x68, 0x64, 0x62, 0x5f, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x5f, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x0b, 0x73, 0x68, 0x64, 0x62, 0x4f, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x88, 0x01, 0x01, 0x3a, 0x6d, 0x0a, 0x12, 0x73, 0x68, 0x64, 0x62,
+	0x5f, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x5f, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1d,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xa0, 0x96,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e,
+	0x53, 0x68, 0x64, 0x62, 0x5f, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x5f, 0x4f, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x52, 0x10, 0x73, 0x68, 0x64, 0x62, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x88, 0x01, 0x01, 0x3a, 0x41, 0x0a, 0x09, 0x73, 0x68, 0x64, 0x62, 0x5f,
+	0x74, 0x79, 0x70, 0x65, 0x12, 0x1f, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x4f, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xa2, 0x90, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73,
+	0x68, 0x64, 0x62, 0x54, 0x79, 0x70, 0x65, 0x88, 0x01, 0x01, 0x3a, 0x47, 0x0a, 0x0c, 0x73, 0x68,
+	0x64, 0x62, 0x5f, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x65, 0x73, 0x12, 0x1f, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xa3, 0x90, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0b, 0x73, 0x68, 0x64, 0x62, 0x41, 0x6c, 0x69, 0x61, 0x73, 0x65, 0x73,
+	0x88, 0x01, 0x01, 0x3a, 0x48, 0x0a, 0x0d, 0x73, 0x68, 0x64, 0x62, 0x5f, 0x74, 0x79, 0x70, 0x65,
+	0x5f, 0x6b, 0x65, 0x79, 0x12, 0x1f, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x4f, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xa4, 0x90, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x73,
+	0x68, 0x64, 0x62, 0x54, 0x79, 0x70, 0x65, 0x4b, 0x65, 0x79, 0x88, 0x01, 0x01, 0x42, 0x1c, 0x5a,
+	0x1a, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x73, 0x68, 0x65, 0x6e,
+	0x72, 0x79, 0x74, 0x65, 0x63, 0x68, 0x2f, 0x73, 0x68, 0x64, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1077,66 +3719,172 @@ func file_pb_shdb_v1_shdb_proto_rawDescGZIP() []byte {
 	return file_pb_shdb_v1_shdb_proto_rawDescData
 }
 
-var file_pb_shdb_v1_shdb_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_pb_shdb_v1_shdb_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_pb_shdb_v1_shdb_proto_msgTypes = make([]protoimpl.MessageInfo, 49)
 var file_pb_shdb_v1_shdb_proto_goTypes = []interface{}{
-	(*Metadata)(nil),                       // 0: shdb.v1.Metadata
-	(*ObjRef)(nil),                         // 1: shdb.v1.ObjRef
-	(*SearchHit)(nil),                      // 2: shdb.v1.SearchHit
-	(*SearchResult)(nil),                   // 3: shdb.v1.SearchResult
-	(*BinaryObject)(nil),                   // 4: shdb.v1.BinaryObject
-	(*ListReq)(nil),                        // 5: shdb.v1.ListReq
-	(*ListRsp)(nil),                        // 6: shdb.v1.ListRsp
-	(*GetReq)(nil),                         // 7: shdb.v1.GetReq
-	(*CreateReq)(nil),                      // 8: shdb.v1.CreateReq
-	(*UpdateReq)(nil),                      // 9: shdb.v1.UpdateReq
-	(*DeleteReq)(nil),                      // 10: shdb.v1.DeleteReq
-	(*Shdb_Message_Options)(nil),           // 11: shdb.v1.Shdb_Message_Options
-	(*GetTypeNamesRsp)(nil),                // 12: shdb.v1.GetTypeNamesRsp
-	(*StreamRefReq)(nil),                   // 13: shdb.v1.StreamRefReq
-	nil,                                    // 14: shdb.v1.Shdb_Message_Options.PrintTemplatesEntry
-	(*GetTypeNamesRsp_TypeAliases)(nil),    // 15: shdb.v1.GetTypeNamesRsp.TypeAliases
-	(*timestamppb.Timestamp)(nil),          // 16: google.protobuf.Timestamp
-	(*descriptorpb.MessageOptions)(nil),    // 17: google.protobuf.MessageOptions
-	(*emptypb.Empty)(nil),                  // 18: google.protobuf.Empty
-	(*descriptorpb.FileDescriptorSet)(nil), // 19: google.protobuf.FileDescriptorSet
+	(Shdb_Ref_Options_OnDelete)(0),         // 0: shdb.v1.Shdb_Ref_Options.OnDelete
+	(*Metadata)(nil),                       // 1: shdb.v1.Metadata
+	(*ObjRef)(nil),                         // 2: shdb.v1.ObjRef
+	(*SearchHit)(nil),                      // 3: shdb.v1.SearchHit
+	(*SearchResult)(nil),                   // 4: shdb.v1.SearchResult
+	(*BinaryObject)(nil),                   // 5: shdb.v1.BinaryObject
+	(*ListReq)(nil),                        // 6: shdb.v1.ListReq
+	(*QueryReq)(nil),                       // 7: shdb.v1.QueryReq
+	(*ListRsp)(nil),                        // 8: shdb.v1.ListRsp
+	(*GetReq)(nil),                         // 9: shdb.v1.GetReq
+	(*CreateReq)(nil),                      // 10: shdb.v1.CreateReq
+	(*UpdateReq)(nil),                      // 11: shdb.v1.UpdateReq
+	(*DeleteReq)(nil),                      // 12: shdb.v1.DeleteReq
+	(*UndeleteReq)(nil),                    // 13: shdb.v1.UndeleteReq
+	(*PurgeReq)(nil),                       // 14: shdb.v1.PurgeReq
+	(*PurgeRsp)(nil),                       // 15: shdb.v1.PurgeRsp
+	(*ExpandReq)(nil),                      // 16: shdb.v1.ExpandReq
+	(*ExpandRsp)(nil),                      // 17: shdb.v1.ExpandRsp
+	(*HistoryReq)(nil),                     // 18: shdb.v1.HistoryReq
+	(*HistoryRsp)(nil),                     // 19: shdb.v1.HistoryRsp
+	(*HistoryEntry)(nil),                   // 20: shdb.v1.HistoryEntry
+	(*Shdb_Retention_Options)(nil),         // 21: shdb.v1.Shdb_Retention_Options
+	(*Shdb_Soft_Delete_Options)(nil),       // 22: shdb.v1.Shdb_Soft_Delete_Options
+	(*Shdb_History_Options)(nil),           // 23: shdb.v1.Shdb_History_Options
+	(*Shdb_Message_Options)(nil),           // 24: shdb.v1.Shdb_Message_Options
+	(*Shdb_Ref_Options)(nil),               // 25: shdb.v1.Shdb_Ref_Options
+	(*Shdb_Field_Options)(nil),             // 26: shdb.v1.Shdb_Field_Options
+	(*SchemaVersion)(nil),                  // 27: shdb.v1.SchemaVersion
+	(*ListSchemaVersionsRsp)(nil),          // 28: shdb.v1.ListSchemaVersionsRsp
+	(*GetSchemaVersionReq)(nil),            // 29: shdb.v1.GetSchemaVersionReq
+	(*RenameTypeReq)(nil),                  // 30: shdb.v1.RenameTypeReq
+	(*RenameTypeRsp)(nil),                  // 31: shdb.v1.RenameTypeRsp
+	(*GetTypeNamesRsp)(nil),                // 32: shdb.v1.GetTypeNamesRsp
+	(*StreamRefReq)(nil),                   // 33: shdb.v1.StreamRefReq
+	(*BackupReq)(nil),                      // 34: shdb.v1.BackupReq
+	(*BackupChunk)(nil),                    // 35: shdb.v1.BackupChunk
+	(*BackupHeader)(nil),                   // 36: shdb.v1.BackupHeader
+	(*BackupRecord)(nil),                   // 37: shdb.v1.BackupRecord
+	(*RestoreChunk)(nil),                   // 38: shdb.v1.RestoreChunk
+	(*RestoreRsp)(nil),                     // 39: shdb.v1.RestoreRsp
+	(*ExportReq)(nil),                      // 40: shdb.v1.ExportReq
+	(*ExportChunk)(nil),                    // 41: shdb.v1.ExportChunk
+	(*ImportChunk)(nil),                    // 42: shdb.v1.ImportChunk
+	(*ImportRsp)(nil),                      // 43: shdb.v1.ImportRsp
+	(*Change)(nil),                         // 44: shdb.v1.Change
+	(*WatchReq)(nil),                       // 45: shdb.v1.WatchReq
+	(*ExpandRsp_Edge)(nil),                 // 46: shdb.v1.ExpandRsp.Edge
+	nil,                                    // 47: shdb.v1.Shdb_Message_Options.PrintTemplatesEntry
+	(*GetTypeNamesRsp_TypeAliases)(nil),    // 48: shdb.v1.GetTypeNamesRsp.TypeAliases
+	(*BackupRecord_Deletion)(nil),          // 49: shdb.v1.BackupRecord.Deletion
+	(*timestamppb.Timestamp)(nil),          // 50: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),            // 51: google.protobuf.Duration
+	(*descriptorpb.FileDescriptorSet)(nil), // 52: google.protobuf.FileDescriptorSet
+	(*descriptorpb.MessageOptions)(nil),    // 53: google.protobuf.MessageOptions
+	(*descriptorpb.FieldOptions)(nil),      // 54: google.protobuf.FieldOptions
+	(*emptypb.Empty)(nil),                  // 55: google.protobuf.Empty
 }
 var file_pb_shdb_v1_shdb_proto_depIdxs = []int32{
-	16, // 0: shdb.v1.Metadata.created_at:type_name -> google.protobuf.Timestamp
-	16, // 1: shdb.v1.Metadata.updated_at:type_name -> google.protobuf.Timestamp
-	0,  // 2: shdb.v1.SearchHit.metadata:type_name -> shdb.v1.Metadata
-	2,  // 3: shdb.v1.SearchResult.hits:type_name -> shdb.v1.SearchHit
-	4,  // 4: shdb.v1.ListRsp.items:type_name -> shdb.v1.BinaryObject
-	1,  // 5: shdb.v1.GetReq.ref:type_name -> shdb.v1.ObjRef
-	4,  // 6: shdb.v1.UpdateReq.item:type_name -> shdb.v1.BinaryObject
-	1,  // 7: shdb.v1.DeleteReq.ref:type_name -> shdb.v1.ObjRef
-	14, // 8: shdb.v1.Shdb_Message_Options.print_templates:type_name -> shdb.v1.Shdb_Message_Options.PrintTemplatesEntry
-	15, // 9: shdb.v1.GetTypeNamesRsp.type_aliases:type_name -> shdb.v1.GetTypeNamesRsp.TypeAliases
-	17, // 10: shdb.v1.shdb_options:extendee -> google.protobuf.MessageOptions
-	17, // 11: shdb.v1.shdb_type:extendee -> google.protobuf.MessageOptions
-	17, // 12: shdb.v1.shdb_aliases:extendee -> google.protobuf.MessageOptions
-	17, // 13: shdb.v1.shdb_type_key:extendee -> google.protobuf.MessageOptions
-	11, // 14: shdb.v1.shdb_options:type_name -> shdb.v1.Shdb_Message_Options
-	5,  // 15: shdb.v1.BinaryObjectService.List:input_type -> shdb.v1.ListReq
-	7,  // 16: shdb.v1.BinaryObjectService.Get:input_type -> shdb.v1.GetReq
-	8,  // 17: shdb.v1.BinaryObjectService.Create:input_type -> shdb.v1.CreateReq
-	9,  // 18: shdb.v1.BinaryObjectService.Update:input_type -> shdb.v1.UpdateReq
-	10, // 19: shdb.v1.BinaryObjectService.Delete:input_type -> shdb.v1.DeleteReq
-	13, // 20: shdb.v1.BinaryObjectService.StreamRefs:input_type -> shdb.v1.StreamRefReq
-	18, // 21: shdb.v1.BinaryObjectService.GetSchema:input_type -> google.protobuf.Empty
-	18, // 22: shdb.v1.BinaryObjectService.GetTypeNames:input_type -> google.protobuf.Empty
-	6,  // 23: shdb.v1.BinaryObjectService.List:output_type -> shdb.v1.ListRsp
-	4,  // 24: shdb.v1.BinaryObjectService.Get:output_type -> shdb.v1.BinaryObject
-	4,  // 25: shdb.v1.BinaryObjectService.Create:output_type -> shdb.v1.BinaryObject
-	4,  // 26: shdb.v1.BinaryObjectService.Update:output_type -> shdb.v1.BinaryObject
-	4,  // 27: shdb.v1.BinaryObjectService.Delete:output_type -> shdb.v1.BinaryObject
-	1,  // 28: shdb.v1.BinaryObjectService.StreamRefs:output_type -> shdb.v1.ObjRef
-	19, // 29: shdb.v1.BinaryObjectService.GetSchema:output_type -> google.protobuf.FileDescriptorSet
-	12, // 30: shdb.v1.BinaryObjectService.GetTypeNames:output_type -> shdb.v1.GetTypeNamesRsp
-	23, // [23:31] is the sub-list for method output_type
-	15, // [15:23] is the sub-list for method input_type
-	14, // [14:15] is the sub-list for extension type_name
-	10, // [10:14] is the sub-list for extension extendee
-	0,  // [0:10] is the sub-list for field type_name
+	50, // 0: shdb.v1.Metadata.created_at:type_name -> google.protobuf.Timestamp
+	50, // 1: shdb.v1.Metadata.updated_at:type_name -> google.protobuf.Timestamp
+	50, // 2: shdb.v1.Metadata.expires_at:type_name -> google.protobuf.Timestamp
+	50, // 3: shdb.v1.Metadata.deleted_at:type_name -> google.protobuf.Timestamp
+	1,  // 4: shdb.v1.SearchHit.metadata:type_name -> shdb.v1.Metadata
+	3,  // 5: shdb.v1.SearchResult.hits:type_name -> shdb.v1.SearchHit
+	5,  // 6: shdb.v1.ListRsp.items:type_name -> shdb.v1.BinaryObject
+	2,  // 7: shdb.v1.GetReq.ref:type_name -> shdb.v1.ObjRef
+	5,  // 8: shdb.v1.CreateReq.item:type_name -> shdb.v1.BinaryObject
+	5,  // 9: shdb.v1.UpdateReq.item:type_name -> shdb.v1.BinaryObject
+	2,  // 10: shdb.v1.DeleteReq.ref:type_name -> shdb.v1.ObjRef
+	2,  // 11: shdb.v1.UndeleteReq.ref:type_name -> shdb.v1.ObjRef
+	2,  // 12: shdb.v1.PurgeReq.ref:type_name -> shdb.v1.ObjRef
+	2,  // 13: shdb.v1.ExpandReq.ref:type_name -> shdb.v1.ObjRef
+	5,  // 14: shdb.v1.ExpandRsp.root:type_name -> shdb.v1.BinaryObject
+	5,  // 15: shdb.v1.ExpandRsp.items:type_name -> shdb.v1.BinaryObject
+	46, // 16: shdb.v1.ExpandRsp.edges:type_name -> shdb.v1.ExpandRsp.Edge
+	2,  // 17: shdb.v1.HistoryReq.ref:type_name -> shdb.v1.ObjRef
+	20, // 18: shdb.v1.HistoryRsp.entries:type_name -> shdb.v1.HistoryEntry
+	5,  // 19: shdb.v1.HistoryEntry.item:type_name -> shdb.v1.BinaryObject
+	50, // 20: shdb.v1.HistoryEntry.replaced_at:type_name -> google.protobuf.Timestamp
+	51, // 21: shdb.v1.Shdb_Retention_Options.max_age:type_name -> google.protobuf.Duration
+	51, // 22: shdb.v1.Shdb_Soft_Delete_Options.purge_after:type_name -> google.protobuf.Duration
+	51, // 23: shdb.v1.Shdb_History_Options.max_age:type_name -> google.protobuf.Duration
+	47, // 24: shdb.v1.Shdb_Message_Options.print_templates:type_name -> shdb.v1.Shdb_Message_Options.PrintTemplatesEntry
+	23, // 25: shdb.v1.Shdb_Message_Options.history:type_name -> shdb.v1.Shdb_History_Options
+	21, // 26: shdb.v1.Shdb_Message_Options.retention:type_name -> shdb.v1.Shdb_Retention_Options
+	22, // 27: shdb.v1.Shdb_Message_Options.soft_delete:type_name -> shdb.v1.Shdb_Soft_Delete_Options
+	0,  // 28: shdb.v1.Shdb_Ref_Options.on_delete:type_name -> shdb.v1.Shdb_Ref_Options.OnDelete
+	25, // 29: shdb.v1.Shdb_Field_Options.ref:type_name -> shdb.v1.Shdb_Ref_Options
+	50, // 30: shdb.v1.SchemaVersion.created_at:type_name -> google.protobuf.Timestamp
+	52, // 31: shdb.v1.SchemaVersion.schema:type_name -> google.protobuf.FileDescriptorSet
+	27, // 32: shdb.v1.ListSchemaVersionsRsp.versions:type_name -> shdb.v1.SchemaVersion
+	48, // 33: shdb.v1.GetTypeNamesRsp.type_aliases:type_name -> shdb.v1.GetTypeNamesRsp.TypeAliases
+	50, // 34: shdb.v1.BackupReq.since:type_name -> google.protobuf.Timestamp
+	50, // 35: shdb.v1.BackupHeader.created_at:type_name -> google.protobuf.Timestamp
+	50, // 36: shdb.v1.BackupHeader.since:type_name -> google.protobuf.Timestamp
+	52, // 37: shdb.v1.BackupHeader.schema:type_name -> google.protobuf.FileDescriptorSet
+	36, // 38: shdb.v1.BackupRecord.header:type_name -> shdb.v1.BackupHeader
+	5,  // 39: shdb.v1.BackupRecord.object:type_name -> shdb.v1.BinaryObject
+	5,  // 40: shdb.v1.BackupRecord.tombstone:type_name -> shdb.v1.BinaryObject
+	49, // 41: shdb.v1.BackupRecord.deleted:type_name -> shdb.v1.BackupRecord.Deletion
+	50, // 42: shdb.v1.BackupRecord.time:type_name -> google.protobuf.Timestamp
+	50, // 43: shdb.v1.RestoreChunk.until:type_name -> google.protobuf.Timestamp
+	5,  // 44: shdb.v1.Change.item:type_name -> shdb.v1.BinaryObject
+	5,  // 45: shdb.v1.Change.previous:type_name -> shdb.v1.BinaryObject
+	50, // 46: shdb.v1.Change.time:type_name -> google.protobuf.Timestamp
+	2,  // 47: shdb.v1.WatchReq.refs:type_name -> shdb.v1.ObjRef
+	2,  // 48: shdb.v1.ExpandRsp.Edge.from:type_name -> shdb.v1.ObjRef
+	2,  // 49: shdb.v1.ExpandRsp.Edge.to:type_name -> shdb.v1.ObjRef
+	50, // 50: shdb.v1.BackupRecord.Deletion.deleted_at:type_name -> google.protobuf.Timestamp
+	53, // 51: shdb.v1.shdb_options:extendee -> google.protobuf.MessageOptions
+	54, // 52: shdb.v1.shdb_field_options:extendee -> google.protobuf.FieldOptions
+	53, // 53: shdb.v1.shdb_type:extendee -> google.protobuf.MessageOptions
+	53, // 54: shdb.v1.shdb_aliases:extendee -> google.protobuf.MessageOptions
+	53, // 55: shdb.v1.shdb_type_key:extendee -> google.protobuf.MessageOptions
+	24, // 56: shdb.v1.shdb_options:type_name -> shdb.v1.Shdb_Message_Options
+	26, // 57: shdb.v1.shdb_field_options:type_name -> shdb.v1.Shdb_Field_Options
+	6,  // 58: shdb.v1.BinaryObjectService.List:input_type -> shdb.v1.ListReq
+	7,  // 59: shdb.v1.BinaryObjectService.Query:input_type -> shdb.v1.QueryReq
+	9,  // 60: shdb.v1.BinaryObjectService.Get:input_type -> shdb.v1.GetReq
+	10, // 61: shdb.v1.BinaryObjectService.Create:input_type -> shdb.v1.CreateReq
+	11, // 62: shdb.v1.BinaryObjectService.Update:input_type -> shdb.v1.UpdateReq
+	12, // 63: shdb.v1.BinaryObjectService.Delete:input_type -> shdb.v1.DeleteReq
+	18, // 64: shdb.v1.BinaryObjectService.History:input_type -> shdb.v1.HistoryReq
+	13, // 65: shdb.v1.BinaryObjectService.Undelete:input_type -> shdb.v1.UndeleteReq
+	14, // 66: shdb.v1.BinaryObjectService.Purge:input_type -> shdb.v1.PurgeReq
+	16, // 67: shdb.v1.BinaryObjectService.Expand:input_type -> shdb.v1.ExpandReq
+	33, // 68: shdb.v1.BinaryObjectService.StreamRefs:input_type -> shdb.v1.StreamRefReq
+	55, // 69: shdb.v1.BinaryObjectService.GetSchema:input_type -> google.protobuf.Empty
+	55, // 70: shdb.v1.BinaryObjectService.GetTypeNames:input_type -> google.protobuf.Empty
+	55, // 71: shdb.v1.BinaryObjectService.ListSchemaVersions:input_type -> google.protobuf.Empty
+	29, // 72: shdb.v1.BinaryObjectService.GetSchemaVersion:input_type -> shdb.v1.GetSchemaVersionReq
+	30, // 73: shdb.v1.BinaryObjectService.RenameType:input_type -> shdb.v1.RenameTypeReq
+	34, // 74: shdb.v1.BinaryObjectService.Backup:input_type -> shdb.v1.BackupReq
+	38, // 75: shdb.v1.BinaryObjectService.Restore:input_type -> shdb.v1.RestoreChunk
+	40, // 76: shdb.v1.BinaryObjectService.Export:input_type -> shdb.v1.ExportReq
+	42, // 77: shdb.v1.BinaryObjectService.Import:input_type -> shdb.v1.ImportChunk
+	45, // 78: shdb.v1.BinaryObjectService.Watch:input_type -> shdb.v1.WatchReq
+	8,  // 79: shdb.v1.BinaryObjectService.List:output_type -> shdb.v1.ListRsp
+	8,  // 80: shdb.v1.BinaryObjectService.Query:output_type -> shdb.v1.ListRsp
+	5,  // 81: shdb.v1.BinaryObjectService.Get:output_type -> shdb.v1.BinaryObject
+	5,  // 82: shdb.v1.BinaryObjectService.Create:output_type -> shdb.v1.BinaryObject
+	5,  // 83: shdb.v1.BinaryObjectService.Update:output_type -> shdb.v1.BinaryObject
+	5,  // 84: shdb.v1.BinaryObjectService.Delete:output_type -> shdb.v1.BinaryObject
+	19, // 85: shdb.v1.BinaryObjectService.History:output_type -> shdb.v1.HistoryRsp
+	5,  // 86: shdb.v1.BinaryObjectService.Undelete:output_type -> shdb.v1.BinaryObject
+	15, // 87: shdb.v1.BinaryObjectService.Purge:output_type -> shdb.v1.PurgeRsp
+	17, // 88: shdb.v1.BinaryObjectService.Expand:output_type -> shdb.v1.ExpandRsp
+	2,  // 89: shdb.v1.BinaryObjectService.StreamRefs:output_type -> shdb.v1.ObjRef
+	52, // 90: shdb.v1.BinaryObjectService.GetSchema:output_type -> google.protobuf.FileDescriptorSet
+	32, // 91: shdb.v1.BinaryObjectService.GetTypeNames:output_type -> shdb.v1.GetTypeNamesRsp
+	28, // 92: shdb.v1.BinaryObjectService.ListSchemaVersions:output_type -> shdb.v1.ListSchemaVersionsRsp
+	27, // 93: shdb.v1.BinaryObjectService.GetSchemaVersion:output_type -> shdb.v1.SchemaVersion
+	31, // 94: shdb.v1.BinaryObjectService.RenameType:output_type -> shdb.v1.RenameTypeRsp
+	35, // 95: shdb.v1.BinaryObjectService.Backup:output_type -> shdb.v1.BackupChunk
+	39, // 96: shdb.v1.BinaryObjectService.Restore:output_type -> shdb.v1.RestoreRsp
+	41, // 97: shdb.v1.BinaryObjectService.Export:output_type -> shdb.v1.ExportChunk
+	43, // 98: shdb.v1.BinaryObjectService.Import:output_type -> shdb.v1.ImportRsp
+	44, // 99: shdb.v1.BinaryObjectService.Watch:output_type -> shdb.v1.Change
+	79, // [79:100] is the sub-list for method output_type
+	58, // [58:79] is the sub-list for method input_type
+	56, // [56:58] is the sub-list for extension type_name
+	51, // [51:56] is the sub-list for extension extendee
+	0,  // [0:51] is the sub-list for field type_name
 }
 
 func init() { file_pb_shdb_v1_shdb_proto_init() }
@@ -1157,8 +3905,140 @@ func file_pb_shdb_v1_shdb_proto_init() {
 				return nil
 			}
 		}
-		file_pb_shdb_v1_shdb_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ObjRef); i {
+		file_pb_shdb_v1_shdb_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ObjRef); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SearchHit); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SearchResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BinaryObject); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*QueryReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListRsp); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CreateReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UpdateReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeleteReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UndeleteReq); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1169,8 +4049,8 @@ func file_pb_shdb_v1_shdb_proto_init() {
 				return nil
 			}
 		}
-		file_pb_shdb_v1_shdb_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SearchHit); i {
+		file_pb_shdb_v1_shdb_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PurgeReq); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1181,8 +4061,8 @@ func file_pb_shdb_v1_shdb_proto_init() {
 				return nil
 			}
 		}
-		file_pb_shdb_v1_shdb_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SearchResult); i {
+		file_pb_shdb_v1_shdb_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PurgeRsp); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1193,8 +4073,8 @@ func file_pb_shdb_v1_shdb_proto_init() {
 				return nil
 			}
 		}
-		file_pb_shdb_v1_shdb_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*BinaryObject); i {
+		file_pb_shdb_v1_shdb_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExpandReq); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1205,8 +4085,8 @@ func file_pb_shdb_v1_shdb_proto_init() {
 				return nil
 			}
 		}
-		file_pb_shdb_v1_shdb_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ListReq); i {
+		file_pb_shdb_v1_shdb_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExpandRsp); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1217,8 +4097,8 @@ func file_pb_shdb_v1_shdb_proto_init() {
 				return nil
 			}
 		}
-		file_pb_shdb_v1_shdb_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ListRsp); i {
+		file_pb_shdb_v1_shdb_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HistoryReq); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1229,8 +4109,8 @@ func file_pb_shdb_v1_shdb_proto_init() {
 				return nil
 			}
 		}
-		file_pb_shdb_v1_shdb_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GetReq); i {
+		file_pb_shdb_v1_shdb_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HistoryRsp); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1241,8 +4121,8 @@ func file_pb_shdb_v1_shdb_proto_init() {
 				return nil
 			}
 		}
-		file_pb_shdb_v1_shdb_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CreateReq); i {
+		file_pb_shdb_v1_shdb_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HistoryEntry); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1253,8 +4133,8 @@ func file_pb_shdb_v1_shdb_proto_init() {
 				return nil
 			}
 		}
-		file_pb_shdb_v1_shdb_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*UpdateReq); i {
+		file_pb_shdb_v1_shdb_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Shdb_Retention_Options); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1265,8 +4145,8 @@ func file_pb_shdb_v1_shdb_proto_init() {
 				return nil
 			}
 		}
-		file_pb_shdb_v1_shdb_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DeleteReq); i {
+		file_pb_shdb_v1_shdb_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Shdb_Soft_Delete_Options); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1277,7 +4157,19 @@ func file_pb_shdb_v1_shdb_proto_init() {
 				return nil
 			}
 		}
-		file_pb_shdb_v1_shdb_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+		file_pb_shdb_v1_shdb_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Shdb_History_Options); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Shdb_Message_Options); i {
 			case 0:
 				return &v.state
@@ -1289,7 +4181,91 @@ func file_pb_shdb_v1_shdb_proto_init() {
 				return nil
 			}
 		}
-		file_pb_shdb_v1_shdb_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+		file_pb_shdb_v1_shdb_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Shdb_Ref_Options); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Shdb_Field_Options); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SchemaVersion); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListSchemaVersionsRsp); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetSchemaVersionReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RenameTypeReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RenameTypeRsp); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetTypeNamesRsp); i {
 			case 0:
 				return &v.state
@@ -1301,7 +4277,7 @@ func file_pb_shdb_v1_shdb_proto_init() {
 				return nil
 			}
 		}
-		file_pb_shdb_v1_shdb_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+		file_pb_shdb_v1_shdb_proto_msgTypes[32].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*StreamRefReq); i {
 			case 0:
 				return &v.state
@@ -1313,7 +4289,163 @@ func file_pb_shdb_v1_shdb_proto_init() {
 				return nil
 			}
 		}
-		file_pb_shdb_v1_shdb_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+		file_pb_shdb_v1_shdb_proto_msgTypes[33].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BackupReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[34].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BackupChunk); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[35].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BackupHeader); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[36].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BackupRecord); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[37].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RestoreChunk); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[38].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RestoreRsp); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[39].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExportReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[40].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExportChunk); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[41].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ImportChunk); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[42].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ImportRsp); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[43].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Change); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[44].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[45].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExpandRsp_Edge); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[47].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetTypeNamesRsp_TypeAliases); i {
 			case 0:
 				return &v.state
@@ -1325,19 +4457,38 @@ func file_pb_shdb_v1_shdb_proto_init() {
 				return nil
 			}
 		}
+		file_pb_shdb_v1_shdb_proto_msgTypes[48].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BackupRecord_Deletion); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_pb_shdb_v1_shdb_proto_msgTypes[36].OneofWrappers = []interface{}{
+		(*BackupRecord_Header)(nil),
+		(*BackupRecord_Object)(nil),
+		(*BackupRecord_Tombstone)(nil),
+		(*BackupRecord_Deleted)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_pb_shdb_v1_shdb_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   16,
-			NumExtensions: 4,
+			NumEnums:      1,
+			NumMessages:   49,
+			NumExtensions: 5,
 			NumServices:   1,
 		},
 		GoTypes:           file_pb_shdb_v1_shdb_proto_goTypes,
 		DependencyIndexes: file_pb_shdb_v1_shdb_proto_depIdxs,
+		EnumInfos:         file_pb_shdb_v1_shdb_proto_enumTypes,
 		MessageInfos:      file_pb_shdb_v1_shdb_proto_msgTypes,
 		ExtensionInfos:    file_pb_shdb_v1_shdb_proto_extTypes,
 	}.Build()
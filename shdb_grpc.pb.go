@@ -25,13 +25,26 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type BinaryObjectServiceClient interface {
 	List(ctx context.Context, in *ListReq, opts ...grpc.CallOption) (*ListRsp, error)
+	Query(ctx context.Context, in *QueryReq, opts ...grpc.CallOption) (*ListRsp, error)
 	Get(ctx context.Context, in *GetReq, opts ...grpc.CallOption) (*BinaryObject, error)
 	Create(ctx context.Context, in *CreateReq, opts ...grpc.CallOption) (*BinaryObject, error)
 	Update(ctx context.Context, in *UpdateReq, opts ...grpc.CallOption) (*BinaryObject, error)
 	Delete(ctx context.Context, in *DeleteReq, opts ...grpc.CallOption) (*BinaryObject, error)
+	History(ctx context.Context, in *HistoryReq, opts ...grpc.CallOption) (*HistoryRsp, error)
+	Undelete(ctx context.Context, in *UndeleteReq, opts ...grpc.CallOption) (*BinaryObject, error)
+	Purge(ctx context.Context, in *PurgeReq, opts ...grpc.CallOption) (*PurgeRsp, error)
+	Expand(ctx context.Context, in *ExpandReq, opts ...grpc.CallOption) (*ExpandRsp, error)
 	StreamRefs(ctx context.Context, in *StreamRefReq, opts ...grpc.CallOption) (BinaryObjectService_StreamRefsClient, error)
 	GetSchema(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*descriptorpb.FileDescriptorSet, error)
 	GetTypeNames(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*GetTypeNamesRsp, error)
+	ListSchemaVersions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListSchemaVersionsRsp, error)
+	GetSchemaVersion(ctx context.Context, in *GetSchemaVersionReq, opts ...grpc.CallOption) (*SchemaVersion, error)
+	RenameType(ctx context.Context, in *RenameTypeReq, opts ...grpc.CallOption) (*RenameTypeRsp, error)
+	Backup(ctx context.Context, in *BackupReq, opts ...grpc.CallOption) (BinaryObjectService_BackupClient, error)
+	Restore(ctx context.Context, opts ...grpc.CallOption) (BinaryObjectService_RestoreClient, error)
+	Export(ctx context.Context, in *ExportReq, opts ...grpc.CallOption) (BinaryObjectService_ExportClient, error)
+	Import(ctx context.Context, opts ...grpc.CallOption) (BinaryObjectService_ImportClient, error)
+	Watch(ctx context.Context, in *WatchReq, opts ...grpc.CallOption) (BinaryObjectService_WatchClient, error)
 }
 
 type binaryObjectServiceClient struct {
@@ -51,6 +64,15 @@ func (c *binaryObjectServiceClient) List(ctx context.Context, in *ListReq, opts
 	return out, nil
 }
 
+func (c *binaryObjectServiceClient) Query(ctx context.Context, in *QueryReq, opts ...grpc.CallOption) (*ListRsp, error) {
+	out := new(ListRsp)
+	err := c.cc.Invoke(ctx, "/shdb.v1.BinaryObjectService/Query", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *binaryObjectServiceClient) Get(ctx context.Context, in *GetReq, opts ...grpc.CallOption) (*BinaryObject, error) {
 	out := new(BinaryObject)
 	err := c.cc.Invoke(ctx, "/shdb.v1.BinaryObjectService/Get", in, out, opts...)
@@ -87,6 +109,42 @@ func (c *binaryObjectServiceClient) Delete(ctx context.Context, in *DeleteReq, o
 	return out, nil
 }
 
+func (c *binaryObjectServiceClient) History(ctx context.Context, in *HistoryReq, opts ...grpc.CallOption) (*HistoryRsp, error) {
+	out := new(HistoryRsp)
+	err := c.cc.Invoke(ctx, "/shdb.v1.BinaryObjectService/History", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *binaryObjectServiceClient) Undelete(ctx context.Context, in *UndeleteReq, opts ...grpc.CallOption) (*BinaryObject, error) {
+	out := new(BinaryObject)
+	err := c.cc.Invoke(ctx, "/shdb.v1.BinaryObjectService/Undelete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *binaryObjectServiceClient) Purge(ctx context.Context, in *PurgeReq, opts ...grpc.CallOption) (*PurgeRsp, error) {
+	out := new(PurgeRsp)
+	err := c.cc.Invoke(ctx, "/shdb.v1.BinaryObjectService/Purge", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *binaryObjectServiceClient) Expand(ctx context.Context, in *ExpandReq, opts ...grpc.CallOption) (*ExpandRsp, error) {
+	out := new(ExpandRsp)
+	err := c.cc.Invoke(ctx, "/shdb.v1.BinaryObjectService/Expand", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *binaryObjectServiceClient) StreamRefs(ctx context.Context, in *StreamRefReq, opts ...grpc.CallOption) (BinaryObjectService_StreamRefsClient, error) {
 	stream, err := c.cc.NewStream(ctx, &BinaryObjectService_ServiceDesc.Streams[0], "/shdb.v1.BinaryObjectService/StreamRefs", opts...)
 	if err != nil {
@@ -137,18 +195,222 @@ func (c *binaryObjectServiceClient) GetTypeNames(ctx context.Context, in *emptyp
 	return out, nil
 }
 
+func (c *binaryObjectServiceClient) ListSchemaVersions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListSchemaVersionsRsp, error) {
+	out := new(ListSchemaVersionsRsp)
+	err := c.cc.Invoke(ctx, "/shdb.v1.BinaryObjectService/ListSchemaVersions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *binaryObjectServiceClient) GetSchemaVersion(ctx context.Context, in *GetSchemaVersionReq, opts ...grpc.CallOption) (*SchemaVersion, error) {
+	out := new(SchemaVersion)
+	err := c.cc.Invoke(ctx, "/shdb.v1.BinaryObjectService/GetSchemaVersion", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *binaryObjectServiceClient) RenameType(ctx context.Context, in *RenameTypeReq, opts ...grpc.CallOption) (*RenameTypeRsp, error) {
+	out := new(RenameTypeRsp)
+	err := c.cc.Invoke(ctx, "/shdb.v1.BinaryObjectService/RenameType", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *binaryObjectServiceClient) Backup(ctx context.Context, in *BackupReq, opts ...grpc.CallOption) (BinaryObjectService_BackupClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BinaryObjectService_ServiceDesc.Streams[1], "/shdb.v1.BinaryObjectService/Backup", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &binaryObjectServiceBackupClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BinaryObjectService_BackupClient interface {
+	Recv() (*BackupChunk, error)
+	grpc.ClientStream
+}
+
+type binaryObjectServiceBackupClient struct {
+	grpc.ClientStream
+}
+
+func (x *binaryObjectServiceBackupClient) Recv() (*BackupChunk, error) {
+	m := new(BackupChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *binaryObjectServiceClient) Restore(ctx context.Context, opts ...grpc.CallOption) (BinaryObjectService_RestoreClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BinaryObjectService_ServiceDesc.Streams[2], "/shdb.v1.BinaryObjectService/Restore", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &binaryObjectServiceRestoreClient{stream}
+	return x, nil
+}
+
+type BinaryObjectService_RestoreClient interface {
+	Send(*RestoreChunk) error
+	CloseAndRecv() (*RestoreRsp, error)
+	grpc.ClientStream
+}
+
+type binaryObjectServiceRestoreClient struct {
+	grpc.ClientStream
+}
+
+func (x *binaryObjectServiceRestoreClient) Send(m *RestoreChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *binaryObjectServiceRestoreClient) CloseAndRecv() (*RestoreRsp, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(RestoreRsp)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *binaryObjectServiceClient) Export(ctx context.Context, in *ExportReq, opts ...grpc.CallOption) (BinaryObjectService_ExportClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BinaryObjectService_ServiceDesc.Streams[3], "/shdb.v1.BinaryObjectService/Export", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &binaryObjectServiceExportClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BinaryObjectService_ExportClient interface {
+	Recv() (*ExportChunk, error)
+	grpc.ClientStream
+}
+
+type binaryObjectServiceExportClient struct {
+	grpc.ClientStream
+}
+
+func (x *binaryObjectServiceExportClient) Recv() (*ExportChunk, error) {
+	m := new(ExportChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *binaryObjectServiceClient) Import(ctx context.Context, opts ...grpc.CallOption) (BinaryObjectService_ImportClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BinaryObjectService_ServiceDesc.Streams[4], "/shdb.v1.BinaryObjectService/Import", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &binaryObjectServiceImportClient{stream}
+	return x, nil
+}
+
+type BinaryObjectService_ImportClient interface {
+	Send(*ImportChunk) error
+	CloseAndRecv() (*ImportRsp, error)
+	grpc.ClientStream
+}
+
+type binaryObjectServiceImportClient struct {
+	grpc.ClientStream
+}
+
+func (x *binaryObjectServiceImportClient) Send(m *ImportChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *binaryObjectServiceImportClient) CloseAndRecv() (*ImportRsp, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ImportRsp)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *binaryObjectServiceClient) Watch(ctx context.Context, in *WatchReq, opts ...grpc.CallOption) (BinaryObjectService_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BinaryObjectService_ServiceDesc.Streams[5], "/shdb.v1.BinaryObjectService/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &binaryObjectServiceWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BinaryObjectService_WatchClient interface {
+	Recv() (*Change, error)
+	grpc.ClientStream
+}
+
+type binaryObjectServiceWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *binaryObjectServiceWatchClient) Recv() (*Change, error) {
+	m := new(Change)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // BinaryObjectServiceServer is the server API for BinaryObjectService service.
 // All implementations must embed UnimplementedBinaryObjectServiceServer
 // for forward compatibility
 type BinaryObjectServiceServer interface {
 	List(context.Context, *ListReq) (*ListRsp, error)
+	Query(context.Context, *QueryReq) (*ListRsp, error)
 	Get(context.Context, *GetReq) (*BinaryObject, error)
 	Create(context.Context, *CreateReq) (*BinaryObject, error)
 	Update(context.Context, *UpdateReq) (*BinaryObject, error)
 	Delete(context.Context, *DeleteReq) (*BinaryObject, error)
+	History(context.Context, *HistoryReq) (*HistoryRsp, error)
+	Undelete(context.Context, *UndeleteReq) (*BinaryObject, error)
+	Purge(context.Context, *PurgeReq) (*PurgeRsp, error)
+	Expand(context.Context, *ExpandReq) (*ExpandRsp, error)
 	StreamRefs(*StreamRefReq, BinaryObjectService_StreamRefsServer) error
 	GetSchema(context.Context, *emptypb.Empty) (*descriptorpb.FileDescriptorSet, error)
 	GetTypeNames(context.Context, *emptypb.Empty) (*GetTypeNamesRsp, error)
+	ListSchemaVersions(context.Context, *emptypb.Empty) (*ListSchemaVersionsRsp, error)
+	GetSchemaVersion(context.Context, *GetSchemaVersionReq) (*SchemaVersion, error)
+	RenameType(context.Context, *RenameTypeReq) (*RenameTypeRsp, error)
+	Backup(*BackupReq, BinaryObjectService_BackupServer) error
+	Restore(BinaryObjectService_RestoreServer) error
+	Export(*ExportReq, BinaryObjectService_ExportServer) error
+	Import(BinaryObjectService_ImportServer) error
+	Watch(*WatchReq, BinaryObjectService_WatchServer) error
 	mustEmbedUnimplementedBinaryObjectServiceServer()
 }
 
@@ -159,6 +421,9 @@ type UnimplementedBinaryObjectServiceServer struct {
 func (UnimplementedBinaryObjectServiceServer) List(context.Context, *ListReq) (*ListRsp, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
 }
+func (UnimplementedBinaryObjectServiceServer) Query(context.Context, *QueryReq) (*ListRsp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Query not implemented")
+}
 func (UnimplementedBinaryObjectServiceServer) Get(context.Context, *GetReq) (*BinaryObject, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
 }
@@ -171,6 +436,18 @@ func (UnimplementedBinaryObjectServiceServer) Update(context.Context, *UpdateReq
 func (UnimplementedBinaryObjectServiceServer) Delete(context.Context, *DeleteReq) (*BinaryObject, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
 }
+func (UnimplementedBinaryObjectServiceServer) History(context.Context, *HistoryReq) (*HistoryRsp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method History not implemented")
+}
+func (UnimplementedBinaryObjectServiceServer) Undelete(context.Context, *UndeleteReq) (*BinaryObject, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Undelete not implemented")
+}
+func (UnimplementedBinaryObjectServiceServer) Purge(context.Context, *PurgeReq) (*PurgeRsp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Purge not implemented")
+}
+func (UnimplementedBinaryObjectServiceServer) Expand(context.Context, *ExpandReq) (*ExpandRsp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Expand not implemented")
+}
 func (UnimplementedBinaryObjectServiceServer) StreamRefs(*StreamRefReq, BinaryObjectService_StreamRefsServer) error {
 	return status.Errorf(codes.Unimplemented, "method StreamRefs not implemented")
 }
@@ -180,6 +457,30 @@ func (UnimplementedBinaryObjectServiceServer) GetSchema(context.Context, *emptyp
 func (UnimplementedBinaryObjectServiceServer) GetTypeNames(context.Context, *emptypb.Empty) (*GetTypeNamesRsp, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetTypeNames not implemented")
 }
+func (UnimplementedBinaryObjectServiceServer) ListSchemaVersions(context.Context, *emptypb.Empty) (*ListSchemaVersionsRsp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSchemaVersions not implemented")
+}
+func (UnimplementedBinaryObjectServiceServer) GetSchemaVersion(context.Context, *GetSchemaVersionReq) (*SchemaVersion, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSchemaVersion not implemented")
+}
+func (UnimplementedBinaryObjectServiceServer) RenameType(context.Context, *RenameTypeReq) (*RenameTypeRsp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenameType not implemented")
+}
+func (UnimplementedBinaryObjectServiceServer) Backup(*BackupReq, BinaryObjectService_BackupServer) error {
+	return status.Errorf(codes.Unimplemented, "method Backup not implemented")
+}
+func (UnimplementedBinaryObjectServiceServer) Restore(BinaryObjectService_RestoreServer) error {
+	return status.Errorf(codes.Unimplemented, "method Restore not implemented")
+}
+func (UnimplementedBinaryObjectServiceServer) Export(*ExportReq, BinaryObjectService_ExportServer) error {
+	return status.Errorf(codes.Unimplemented, "method Export not implemented")
+}
+func (UnimplementedBinaryObjectServiceServer) Import(BinaryObjectService_ImportServer) error {
+	return status.Errorf(codes.Unimplemented, "method Import not implemented")
+}
+func (UnimplementedBinaryObjectServiceServer) Watch(*WatchReq, BinaryObjectService_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
 func (UnimplementedBinaryObjectServiceServer) mustEmbedUnimplementedBinaryObjectServiceServer() {}
 
 // UnsafeBinaryObjectServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -211,6 +512,24 @@ func _BinaryObjectService_List_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BinaryObjectService_Query_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BinaryObjectServiceServer).Query(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/shdb.v1.BinaryObjectService/Query",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BinaryObjectServiceServer).Query(ctx, req.(*QueryReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _BinaryObjectService_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetReq)
 	if err := dec(in); err != nil {
@@ -283,6 +602,78 @@ func _BinaryObjectService_Delete_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BinaryObjectService_History_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HistoryReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BinaryObjectServiceServer).History(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/shdb.v1.BinaryObjectService/History",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BinaryObjectServiceServer).History(ctx, req.(*HistoryReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BinaryObjectService_Undelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UndeleteReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BinaryObjectServiceServer).Undelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/shdb.v1.BinaryObjectService/Undelete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BinaryObjectServiceServer).Undelete(ctx, req.(*UndeleteReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BinaryObjectService_Purge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgeReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BinaryObjectServiceServer).Purge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/shdb.v1.BinaryObjectService/Purge",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BinaryObjectServiceServer).Purge(ctx, req.(*PurgeReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BinaryObjectService_Expand_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExpandReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BinaryObjectServiceServer).Expand(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/shdb.v1.BinaryObjectService/Expand",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BinaryObjectServiceServer).Expand(ctx, req.(*ExpandReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _BinaryObjectService_StreamRefs_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(StreamRefReq)
 	if err := stream.RecvMsg(m); err != nil {
@@ -340,6 +731,175 @@ func _BinaryObjectService_GetTypeNames_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BinaryObjectService_ListSchemaVersions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BinaryObjectServiceServer).ListSchemaVersions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/shdb.v1.BinaryObjectService/ListSchemaVersions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BinaryObjectServiceServer).ListSchemaVersions(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BinaryObjectService_GetSchemaVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSchemaVersionReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BinaryObjectServiceServer).GetSchemaVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/shdb.v1.BinaryObjectService/GetSchemaVersion",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BinaryObjectServiceServer).GetSchemaVersion(ctx, req.(*GetSchemaVersionReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BinaryObjectService_RenameType_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenameTypeReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BinaryObjectServiceServer).RenameType(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/shdb.v1.BinaryObjectService/RenameType",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BinaryObjectServiceServer).RenameType(ctx, req.(*RenameTypeReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BinaryObjectService_Backup_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BackupReq)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BinaryObjectServiceServer).Backup(m, &binaryObjectServiceBackupServer{stream})
+}
+
+type BinaryObjectService_BackupServer interface {
+	Send(*BackupChunk) error
+	grpc.ServerStream
+}
+
+type binaryObjectServiceBackupServer struct {
+	grpc.ServerStream
+}
+
+func (x *binaryObjectServiceBackupServer) Send(m *BackupChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _BinaryObjectService_Restore_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BinaryObjectServiceServer).Restore(&binaryObjectServiceRestoreServer{stream})
+}
+
+type BinaryObjectService_RestoreServer interface {
+	SendAndClose(*RestoreRsp) error
+	Recv() (*RestoreChunk, error)
+	grpc.ServerStream
+}
+
+type binaryObjectServiceRestoreServer struct {
+	grpc.ServerStream
+}
+
+func (x *binaryObjectServiceRestoreServer) SendAndClose(m *RestoreRsp) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *binaryObjectServiceRestoreServer) Recv() (*RestoreChunk, error) {
+	m := new(RestoreChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _BinaryObjectService_Export_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportReq)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BinaryObjectServiceServer).Export(m, &binaryObjectServiceExportServer{stream})
+}
+
+type BinaryObjectService_ExportServer interface {
+	Send(*ExportChunk) error
+	grpc.ServerStream
+}
+
+type binaryObjectServiceExportServer struct {
+	grpc.ServerStream
+}
+
+func (x *binaryObjectServiceExportServer) Send(m *ExportChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _BinaryObjectService_Import_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BinaryObjectServiceServer).Import(&binaryObjectServiceImportServer{stream})
+}
+
+type BinaryObjectService_ImportServer interface {
+	SendAndClose(*ImportRsp) error
+	Recv() (*ImportChunk, error)
+	grpc.ServerStream
+}
+
+type binaryObjectServiceImportServer struct {
+	grpc.ServerStream
+}
+
+func (x *binaryObjectServiceImportServer) SendAndClose(m *ImportRsp) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *binaryObjectServiceImportServer) Recv() (*ImportChunk, error) {
+	m := new(ImportChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _BinaryObjectService_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchReq)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BinaryObjectServiceServer).Watch(m, &binaryObjectServiceWatchServer{stream})
+}
+
+type BinaryObjectService_WatchServer interface {
+	Send(*Change) error
+	grpc.ServerStream
+}
+
+type binaryObjectServiceWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *binaryObjectServiceWatchServer) Send(m *Change) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // BinaryObjectService_ServiceDesc is the grpc.ServiceDesc for BinaryObjectService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -351,6 +911,10 @@ var BinaryObjectService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "List",
 			Handler:    _BinaryObjectService_List_Handler,
 		},
+		{
+			MethodName: "Query",
+			Handler:    _BinaryObjectService_Query_Handler,
+		},
 		{
 			MethodName: "Get",
 			Handler:    _BinaryObjectService_Get_Handler,
@@ -367,6 +931,22 @@ var BinaryObjectService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Delete",
 			Handler:    _BinaryObjectService_Delete_Handler,
 		},
+		{
+			MethodName: "History",
+			Handler:    _BinaryObjectService_History_Handler,
+		},
+		{
+			MethodName: "Undelete",
+			Handler:    _BinaryObjectService_Undelete_Handler,
+		},
+		{
+			MethodName: "Purge",
+			Handler:    _BinaryObjectService_Purge_Handler,
+		},
+		{
+			MethodName: "Expand",
+			Handler:    _BinaryObjectService_Expand_Handler,
+		},
 		{
 			MethodName: "GetSchema",
 			Handler:    _BinaryObjectService_GetSchema_Handler,
@@ -375,6 +955,18 @@ var BinaryObjectService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetTypeNames",
 			Handler:    _BinaryObjectService_GetTypeNames_Handler,
 		},
+		{
+			MethodName: "ListSchemaVersions",
+			Handler:    _BinaryObjectService_ListSchemaVersions_Handler,
+		},
+		{
+			MethodName: "GetSchemaVersion",
+			Handler:    _BinaryObjectService_GetSchemaVersion_Handler,
+		},
+		{
+			MethodName: "RenameType",
+			Handler:    _BinaryObjectService_RenameType_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -382,6 +974,31 @@ var BinaryObjectService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _BinaryObjectService_StreamRefs_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "Backup",
+			Handler:       _BinaryObjectService_Backup_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Restore",
+			Handler:       _BinaryObjectService_Restore_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Export",
+			Handler:       _BinaryObjectService_Export_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Import",
+			Handler:       _BinaryObjectService_Import_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _BinaryObjectService_Watch_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "pb/shdb/v1/shdb.proto",
 }
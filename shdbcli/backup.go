@@ -0,0 +1,104 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdbcli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/shenrytech/shdb"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func backup(cmd *cobra.Command, args []string) error {
+	opts := shdb.BackupOptions{
+		Gzip:          viper.GetBool("gzip"),
+		SinceRevision: viper.GetUint64("since-revision"),
+	}
+	if since := viper.GetString("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return err
+		}
+		opts.Since = t
+	}
+	var w io.Writer = os.Stdout
+	if name := viper.GetString("file"); name != "-" {
+		f, err := os.Create(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	cli := shdb.NewClient(ccAccessor())
+	n, err := cli.Backup(w, opts)
+	if err != nil {
+		return err
+	}
+	if w != os.Stdout {
+		fmt.Fprintf(os.Stderr, "wrote %d bytes\n", n)
+	}
+	return nil
+}
+
+func restore(cmd *cobra.Command, args []string) error {
+	opts := shdb.RestoreOptions{}
+	if until := viper.GetString("until"); until != "" {
+		t, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return err
+		}
+		opts.Until = t
+	}
+	cli := shdb.NewClient(ccAccessor())
+	for _, name := range viper.GetStringSlice("restore-file") {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		n, err := cli.Restore(f, opts)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		fmt.Printf("restored %d objects from %s\n", n, name)
+	}
+	return nil
+}
+
+var backupCmd = &cobra.Command{
+	Use:   "backup -f <file>",
+	Short: "make a backup of a running server",
+	Long: `Make a backup of a running server. A full backup is a database file
+that shdbd can be started with. With --since or --since-revision only the
+changes made after that are included, as long as the changelog of the
+server still has them.`,
+	RunE: backup,
+	Args: cobra.NoArgs,
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore -f <file> [-f <file>...]",
+	Short: "restore backups on a running server",
+	Long: `Restore backups on a running server, in the order given. A full backup
+replaces all data on the server and incremental backups are applied on top
+of it. With --until the incremental backups are applied up to that time.`,
+	RunE: restore,
+	Args: cobra.NoArgs,
+}
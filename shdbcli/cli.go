@@ -15,6 +15,9 @@
 package shdbcli
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/google/uuid"
 	"github.com/shenrytech/shdb"
 	"github.com/spf13/cobra"
@@ -44,44 +47,99 @@ func ValidTypeArgFn(cmd *cobra.Command, args []string, toComplete string) ([]str
 	return completeType(cli, toComplete)
 }
 
+// parseTypeId returns the TypeId from a type fullname or alias and an id.
+func parseTypeId(cli *shdb.Client, typ string, id string) (shdb.TypeId, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return shdb.TypeId{}, err
+	}
+	bid, err := uid.MarshalBinary()
+	if err != nil {
+		return shdb.TypeId{}, err
+	}
+	tk, err := cli.GetTypeKeyFromToA(typ)
+	if err != nil {
+		return shdb.TypeId{}, err
+	}
+	ref := &shdb.ObjRef{
+		Type: tk[:],
+		Uuid: bid,
+	}
+	return *ref.TypeId(), nil
+}
+
 func get(cmd *cobra.Command, args []string) error {
 	cli := shdb.NewClient(ccAccessor())
-	id, err := uuid.Parse(args[1])
+	tid, err := parseTypeId(cli, args[0], args[1])
 	if err != nil {
 		return err
 	}
-	bid, err := id.MarshalBinary()
+	tr, err := cli.TypeRegistry()
 	if err != nil {
 		return err
 	}
-	tk, err := cli.TypeRegistry().GetTypeKeyFromToA(args[0])
+	format := viper.GetString("output")
+	depth := viper.GetInt("expand")
+	if depth == 0 && format != "dot" {
+		obj, err := cli.Get(tid)
+		if err != nil {
+			return err
+		}
+		return output(tr, obj, format)
+	}
+	g, err := cli.Expand(tid, depth, viper.GetStringSlice("fields"))
 	if err != nil {
 		return err
 	}
-	ref := &shdb.ObjRef{
-		Type: tk[:],
-		Uuid: bid,
+	if format == "dot" {
+		return g.WriteDot(os.Stdout)
 	}
-	obj, err := cli.Get(*ref.TypeId())
-	if err != nil {
+	return outputGraph(tr, g, format)
+}
+
+// outputGraph outputs the root of g followed by the objects it references.
+func outputGraph(tr *shdb.TypeRegistry, g *shdb.Graph, format string) error {
+	if err := output(tr, g.Root, format); err != nil {
 		return err
 	}
-	return output(cli.TypeRegistry(), obj, viper.GetString("output"))
+	done := map[shdb.TypeId]bool{g.Root.GetMetadata().TypeId(): true}
+	for _, e := range g.Edges {
+		obj, ok := g.Objects[e.To]
+		if !ok || done[e.To] {
+			continue
+		}
+		done[e.To] = true
+		if format == "yaml" {
+			fmt.Println("---")
+		}
+		if err := output(tr, obj, format); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func list(cmd *cobra.Command, args []string) error {
 	cli := shdb.NewClient(ccAccessor())
 
-	tk, err := cli.TypeRegistry().GetTypeKeyFromToA(args[0])
+	tk, err := cli.GetTypeKeyFromToA(args[0])
 	if err != nil {
 		return err
 	}
 
-	obj, err := cli.List(tk)
+	var obj []shdb.IObject
+	if where := viper.GetString("where"); where != "" {
+		obj, err = cli.Query(tk, where, viper.GetString("selector"), viper.GetBool("include-deleted"))
+	} else {
+		obj, err = cli.ListWith(tk, viper.GetString("selector"), viper.GetBool("include-deleted"))
+	}
+	if err != nil {
+		return err
+	}
+	tr, err := cli.TypeRegistry()
 	if err != nil {
 		return err
 	}
-	tr := cli.TypeRegistry()
 	for _, v := range obj {
 		if err := output(tr, v, "list"); err != nil {
 			return err
@@ -109,8 +167,67 @@ var listCmd = &cobra.Command{
 
 func AddCmds(parent *cobra.Command, ccAccess func() (outgoingCtxt context.Context, cc *grpc.ClientConn)) {
 	ccAccessor = ccAccess
-	getCmd.PersistentFlags().StringP("output", "o", "yaml", "output format [json|yaml|brief|list|detail|\"<go template>\"]")
+	getCmd.PersistentFlags().StringP("output", "o", "yaml", "output format [json|yaml|brief|list|detail|dot|\"<go template>\"]")
 	viper.BindPFlag("output", getCmd.PersistentFlags().Lookup("output"))
+	getCmd.Flags().Int("expand", 0, "also get the objects referenced this many links away, -1 for all")
+	viper.BindPFlag("expand", getCmd.Flags().Lookup("expand"))
+	getCmd.Flags().StringSlice("fields", nil, "only expand these ObjRef fields")
+	viper.BindPFlag("fields", getCmd.Flags().Lookup("fields"))
+	listCmd.Flags().StringP("selector", "l", "", "label selector, for instance \"env=prod,tier!=db\"")
+	viper.BindPFlag("selector", listCmd.Flags().Lookup("selector"))
+	listCmd.Flags().Bool("include-deleted", false, "also list soft deleted objects")
+	viper.BindPFlag("include-deleted", listCmd.Flags().Lookup("include-deleted"))
+	listCmd.Flags().String("where", "", "only list objects matching an expression, for instance \"my_int >= 5\"")
+	viper.BindPFlag("where", listCmd.Flags().Lookup("where"))
+	schemaRenameCmd.Flags().Int("batch-size", 0, "objects moved per transaction, 0 for a single transaction")
+	viper.BindPFlag("batch-size", schemaRenameCmd.Flags().Lookup("batch-size"))
+	schemaCmd.AddCommand(schemaRenameCmd)
+	backupCmd.Flags().StringP("file", "f", "-", "file to write the backup to, - for stdout")
+	viper.BindPFlag("file", backupCmd.Flags().Lookup("file"))
+	backupCmd.Flags().Bool("gzip", false, "compress the backup")
+	viper.BindPFlag("gzip", backupCmd.Flags().Lookup("gzip"))
+	backupCmd.Flags().String("since", "", "only changes made after this RFC 3339 time")
+	viper.BindPFlag("since", backupCmd.Flags().Lookup("since"))
+	backupCmd.Flags().Uint64("since-revision", 0, "only changes made after this revision")
+	viper.BindPFlag("since-revision", backupCmd.Flags().Lookup("since-revision"))
+	restoreCmd.Flags().StringSliceP("file", "f", nil, "backup files to restore, in order")
+	viper.BindPFlag("restore-file", restoreCmd.Flags().Lookup("file"))
+	restoreCmd.MarkFlagRequired("file")
+	restoreCmd.Flags().String("until", "", "only restore changes up to this RFC 3339 time")
+	viper.BindPFlag("until", restoreCmd.Flags().Lookup("until"))
+	exportCmd.Flags().StringP("file", "f", "-", "file to write the export to, - for stdout")
+	viper.BindPFlag("export-file", exportCmd.Flags().Lookup("file"))
+	exportCmd.Flags().StringP("selector", "l", "", "label selector, for instance \"env=prod,tier!=db\"")
+	viper.BindPFlag("export-selector", exportCmd.Flags().Lookup("selector"))
+	exportCmd.Flags().String("format", "ndjson", "export format [ndjson|yaml]")
+	viper.BindPFlag("export-format", exportCmd.Flags().Lookup("format"))
+	importCmd.Flags().StringP("file", "f", "-", "file to import, - for stdin")
+	viper.BindPFlag("import-file", importCmd.Flags().Lookup("file"))
+	importCmd.Flags().String("format", "ndjson", "import format [ndjson|yaml]")
+	viper.BindPFlag("import-format", importCmd.Flags().Lookup("format"))
+	importCmd.Flags().Bool("dry-run", false, "check the objects without importing them")
+	viper.BindPFlag("dry-run", importCmd.Flags().Lookup("dry-run"))
+	watchCmd.Flags().StringP("selector", "l", "", "label selector, for instance \"env=prod,tier!=db\"")
+	viper.BindPFlag("watch-selector", watchCmd.Flags().Lookup("selector"))
+	watchCmd.Flags().Uint64("from-seq", 0, "replay the changes from this sequence number")
+	viper.BindPFlag("from-seq", watchCmd.Flags().Lookup("from-seq"))
+	watchCmd.Flags().Uint64("from-revision", 0, "replay the changes made after this revision")
+	viper.BindPFlag("from-revision", watchCmd.Flags().Lookup("from-revision"))
+	watchCmd.Flags().String("where", "", "only changes of objects matching the expression, for instance \"my_int > 10\"")
+	viper.BindPFlag("watch-where", watchCmd.Flags().Lookup("where"))
+	watchCmd.Flags().StringSlice("fields", nil, "only updates changing one of these fields")
+	viper.BindPFlag("watch-fields", watchCmd.Flags().Lookup("fields"))
+	watchCmd.Flags().StringP("output", "o", "list", "output format [json|yaml|brief|list|detail|\"<go template>\"]")
+	viper.BindPFlag("watch-output", watchCmd.Flags().Lookup("output"))
 	parent.AddCommand(getCmd)
 	parent.AddCommand(listCmd)
+	parent.AddCommand(historyCmd)
+	parent.AddCommand(undeleteCmd)
+	parent.AddCommand(purgeCmd)
+	parent.AddCommand(schemaCmd)
+	parent.AddCommand(backupCmd)
+	parent.AddCommand(restoreCmd)
+	parent.AddCommand(exportCmd)
+	parent.AddCommand(importCmd)
+	parent.AddCommand(watchCmd)
 }
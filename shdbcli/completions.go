@@ -38,7 +38,7 @@ func completeType(cli *shdb.Client, toComplete string) ([]string, cobra.ShellCom
 }
 
 func completeId(cli *shdb.Client, toa string, toComplete string) ([]string, cobra.ShellCompDirective) {
-	tk, err := cli.TypeRegistry().GetTypeKeyFromToA(toa)
+	tk, err := cli.GetTypeKeyFromToA(toa)
 	if err != nil {
 		return nil, cobra.ShellCompDirectiveError
 	}
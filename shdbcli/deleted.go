@@ -0,0 +1,77 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdbcli
+
+import (
+	"fmt"
+
+	"github.com/shenrytech/shdb"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func undelete(cmd *cobra.Command, args []string) error {
+	cli := shdb.NewClient(ccAccessor())
+	tid, err := parseTypeId(cli, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	obj, err := cli.Undelete(tid)
+	if err != nil {
+		return err
+	}
+	tr, err := cli.TypeRegistry()
+	if err != nil {
+		return err
+	}
+	return output(tr, obj, viper.GetString("output"))
+}
+
+func purge(cmd *cobra.Command, args []string) error {
+	cli := shdb.NewClient(ccAccessor())
+	if len(args) == 2 {
+		tid, err := parseTypeId(cli, args[0], args[1])
+		if err != nil {
+			return err
+		}
+		return cli.Purge(tid)
+	}
+	tk, err := cli.GetTypeKeyFromToA(args[0])
+	if err != nil {
+		return err
+	}
+	n, err := cli.PurgeAll(tk)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("purged %d objects\n", n)
+	return nil
+}
+
+var undeleteCmd = &cobra.Command{
+	Use:               "undelete <fullname|alias> id",
+	Short:             "restore a soft deleted IObject",
+	RunE:              undelete,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: ValidTypeIdArgFn,
+}
+
+var purgeCmd = &cobra.Command{
+	Use:               "purge <fullname|alias> [id]",
+	Short:             "permanently remove a soft deleted IObject, or all of a type",
+	RunE:              purge,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: ValidTypeArgFn,
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdbcli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/shenrytech/shdb"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func export(cmd *cobra.Command, args []string) error {
+	cli := shdb.NewClient(ccAccessor())
+	typeKeys := []shdb.TypeKey{}
+	for _, arg := range args {
+		tk, err := cli.GetTypeKeyFromToA(arg)
+		if err != nil {
+			return err
+		}
+		typeKeys = append(typeKeys, tk)
+	}
+	var w io.Writer = os.Stdout
+	if name := viper.GetString("export-file"); name != "-" {
+		f, err := os.Create(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	format := shdb.ExportFormat(viper.GetString("export-format"))
+	_, err := cli.Export(w, format, viper.GetString("export-selector"), typeKeys...)
+	return err
+}
+
+func importObjects(cmd *cobra.Command, args []string) error {
+	var r io.Reader = os.Stdin
+	if name := viper.GetString("import-file"); name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	opts := shdb.ImportOptions{
+		Format: shdb.ExportFormat(viper.GetString("import-format")),
+		DryRun: viper.GetBool("dry-run"),
+	}
+	cli := shdb.NewClient(ccAccessor())
+	n, err := cli.Import(r, opts)
+	if err != nil {
+		return err
+	}
+	if opts.DryRun {
+		fmt.Printf("would import %d objects\n", n)
+	} else {
+		fmt.Printf("imported %d objects\n", n)
+	}
+	return nil
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export [<fullname|alias>...]",
+	Short: "export objects as NDJSON or YAML",
+	Long: `Export the objects of the given types, or of all types, one record per
+object with the type fullname and the object as protojson.`,
+	RunE:              export,
+	ValidArgsFunction: ValidTypeArgFn,
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import -f <file>",
+	Short: "import objects exported with export",
+	Long: `Import objects exported with export in a single transaction. Existing
+objects with the same ids are overwritten. With --dry-run the objects are
+checked but nothing is written.`,
+	RunE: importObjects,
+	Args: cobra.NoArgs,
+}
@@ -0,0 +1,121 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdbcli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shenrytech/shdb"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+	"sigs.k8s.io/yaml"
+)
+
+func history(cmd *cobra.Command, args []string) error {
+	cli := shdb.NewClient(ccAccessor())
+	tid, err := parseTypeId(cli, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	versions, err := cli.History(tid)
+	if err != nil {
+		return err
+	}
+	prev := []string{}
+	for _, v := range versions {
+		fmt.Printf("revision %d", v.Revision)
+		if md := v.Object.GetMetadata(); md.UpdatedAt != nil {
+			fmt.Printf(", written %s", md.UpdatedAt.AsTime().Format(time.RFC3339))
+		}
+		if v.Deleted {
+			fmt.Printf(", deleted %s", v.ReplacedAt.Format(time.RFC3339))
+		}
+		fmt.Println()
+		lines, err := yamlLines(v.Object)
+		if err != nil {
+			return err
+		}
+		for _, l := range diffLines(prev, lines) {
+			fmt.Println(l)
+		}
+		fmt.Println()
+		prev = lines
+	}
+	return nil
+}
+
+// yamlLines returns the YAML representation of obj split in lines.
+func yamlLines(obj shdb.IObject) ([]string, error) {
+	o := protojson.MarshalOptions{
+		UseProtoNames: true,
+	}
+	j, err := o.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	y, err := yaml.JSONToYAML(j)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimRight(string(y), "\n"), "\n"), nil
+}
+
+// diffLines returns the lines of b prefixed with "+ " if they are not in
+// a, "  " if they are, and the lines only in a prefixed with "- ".
+func diffLines(a, b []string) []string {
+	// Longest common subsequence
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	res := []string{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			res = append(res, "  "+a[i])
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			res = append(res, "+ "+b[j])
+			j++
+		default:
+			res = append(res, "- "+a[i])
+			i++
+		}
+	}
+	return res
+}
+
+var historyCmd = &cobra.Command{
+	Use:               "history <fullname|alias> id",
+	Short:             "show the revisions of an IObject and the changes between them",
+	RunE:              history,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: ValidTypeIdArgFn,
+}
@@ -0,0 +1,49 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdbcli
+
+import (
+	"fmt"
+
+	"github.com/shenrytech/shdb"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func renameType(cmd *cobra.Command, args []string) error {
+	cli := shdb.NewClient(ccAccessor())
+	n, err := cli.RenameType(args[0], args[1], viper.GetInt("batch-size"))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("renamed %s to %s, moved %d objects\n", args[0], args[1], n)
+	return nil
+}
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "manage the schema of the database",
+}
+
+var schemaRenameCmd = &cobra.Command{
+	Use:   "rename <old fullname> <new fullname>",
+	Short: "move the objects of a renamed type to its new fullname",
+	Long: `Move all objects of a renamed type to the TypeKey of its new fullname.
+The new type must be known to the server. The old fullname becomes an
+alias of the new type. An interrupted rename is resumed by running the
+command again.`,
+	RunE: renameType,
+	Args: cobra.ExactArgs(2),
+}
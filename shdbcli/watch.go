@@ -0,0 +1,78 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdbcli
+
+import (
+	"fmt"
+
+	"github.com/shenrytech/shdb"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var eventNames = map[int]string{
+	shdb.EventCreated: "created",
+	shdb.EventUpdated: "updated",
+	shdb.EventDeleted: "deleted",
+}
+
+func watch(cmd *cobra.Command, args []string) error {
+	cli := shdb.NewClient(ccAccessor())
+	opts := shdb.WatchOptions{
+		Selector:     viper.GetString("watch-selector"),
+		FromSeq:      viper.GetUint64("from-seq"),
+		FromRevision: viper.GetUint64("from-revision"),
+		Predicate:    viper.GetString("watch-where"),
+		Fields:       viper.GetStringSlice("watch-fields"),
+	}
+	for _, arg := range args {
+		tk, err := cli.GetTypeKeyFromToA(arg)
+		if err != nil {
+			return err
+		}
+		opts.Types = append(opts.Types, tk)
+	}
+	sub, err := cli.Watch(opts)
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+	tr, err := cli.TypeRegistry()
+	if err != nil {
+		return err
+	}
+	format := viper.GetString("watch-output")
+	for ev := range sub.C {
+		fmt.Printf("%d\t%s\t", ev.Seq, eventNames[ev.Kind])
+		if err := output(tr, ev.Object, format); err != nil {
+			return err
+		}
+	}
+	return sub.Err()
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [<fullname|alias>...]",
+	Short: "print changes as they are made",
+	Long: `Print the changes of objects of the given types, or of all types, as
+they are made. With --from-seq the changes are first replayed from the
+changelog of the server, starting with that changelog sequence number,
+and with --from-revision starting after that revision of the database.
+--where only prints changes of objects matching
+an expression such as 'my_int > 10', and --fields only prints updates
+that change one of the given fields.`,
+	RunE:              watch,
+	ValidArgsFunction: ValidTypeArgFn,
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/shenrytech/shdb"
@@ -65,19 +66,19 @@ func uuidb(idx int) []byte {
 	return uuidbs[idx]
 }
 
-func loadtd() {
+func loadtd(db *shdb.DB) {
 	TTObject := shdb.TypeKeyOf("shdb.v1.TObject")
-	if err := shdb.DeleteAll(TTObject); err != nil {
+	if err := db.DeleteAll(TTObject); err != nil {
 		panic(err)
 	}
 	for i := 0; i < len(uuids); i++ {
-		obj, err := shdb.New[*shdb.TObject](TTObject)
+		obj, err := shdb.New[*shdb.TObject](db, TTObject)
 		if err != nil {
 			panic(err)
 		}
 		obj.Metadata.Uuid = uuidb(i)
 		obj.MyInt = uint64(i)
-		if err := shdb.Put(obj); err != nil {
+		if err := shdb.Put(db, obj); err != nil {
 			panic(err)
 		}
 	}
@@ -88,18 +89,23 @@ func main() {
 	serverPort := flag.Int("grpc-port", 3335, "api server port to listen on")
 	dbFile := flag.String("dbfile", "/tmp/shdb.db", "database file")
 	loadTestData := flag.Bool("load-test-data", false, "load test data")
+	reapInterval := flag.Duration("reap-interval", 10*time.Second, "how often expired objects are deleted, 0 to disable")
 	flag.Parse()
 
 	listener, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", *serverPort))
 	if err != nil {
 		log.Fatalf("failed to listen %v", err)
 	}
-	shdb.Init(*dbFile)
+	db, err := shdb.Open(*dbFile, shdb.Options{Timeout: time.Second, ReapInterval: *reapInterval})
+	if err != nil {
+		log.Fatalf("failed to open database %v", err)
+	}
+	defer db.Close()
 	if *loadTestData {
-		loadtd()
+		loadtd(db)
 	}
 	var opts []grpc.ServerOption
 	grpcServer := grpc.NewServer(opts...)
-	shdb.NewServer(context.Background(), grpcServer, shdb.NewTypeRegistry())
+	shdb.NewServer(context.Background(), grpcServer, db)
 	grpcServer.Serve(listener)
 }
@@ -0,0 +1,96 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import "io"
+
+// Storage is the ordered key-value store backing a database. Keys are
+// grouped in named buckets and kept in byte order within a bucket.
+//
+// Implementations must allow many concurrent read transactions and at
+// most one write transaction at a time. A read transaction sees a
+// consistent snapshot for its whole lifetime.
+type Storage interface {
+	// Begin starts a new transaction. Write transactions block until
+	// any other write transaction has finished.
+	Begin(writable bool) (StorageTx, error)
+	// Close releases the storage. It waits for open transactions to finish.
+	Close() error
+}
+
+// StorageTx is a transaction against a Storage. Values returned by Get
+// and by cursors are only valid for the life of the transaction and
+// must not be modified.
+type StorageTx interface {
+	// CreateBucketIfNotExists creates a bucket if it does not exist.
+	CreateBucketIfNotExists(bucket []byte) error
+	// Get returns the value for key or nil if it does not exist.
+	Get(bucket, key []byte) []byte
+	// Put sets the value for key.
+	Put(bucket, key, value []byte) error
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(bucket, key []byte) error
+	// Cursor returns a cursor over the keys in bucket starting with prefix.
+	Cursor(bucket, prefix []byte) Cursor
+	// Writable returns true for write transactions.
+	Writable() bool
+	// Commit writes all changes of a write transaction.
+	Commit() error
+	// Rollback discards the transaction.
+	Rollback() error
+}
+
+// Snapshotter is implemented by storage transactions that can write a
+// consistent copy of the whole store as a bbolt database file.
+type Snapshotter interface {
+	WriteTo(w io.Writer) (int64, error)
+}
+
+// Cursor iterates the keys sharing a prefix in key order. A nil key
+// is returned when the cursor moves past the last matching key.
+// The bucket must not be modified while a cursor is in use; collect
+// the keys first and modify afterwards.
+type Cursor interface {
+	// First moves to the first key.
+	First() (key, value []byte)
+	// Seek moves to the first key that is equal to or greater than seek.
+	Seek(seek []byte) (key, value []byte)
+	// Next moves to the next key.
+	Next() (key, value []byte)
+}
+
+// view runs fn in a read transaction.
+func view(st Storage, fn func(tx StorageTx) error) error {
+	tx, err := st.Begin(false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	return fn(tx)
+}
+
+// update runs fn in a write transaction that is committed if fn returns
+// nil and rolled back otherwise.
+func update(st Storage, fn func(tx StorageTx) error) error {
+	tx, err := st.Begin(true)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
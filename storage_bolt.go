@@ -0,0 +1,152 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"bytes"
+	"io"
+
+	"go.etcd.io/bbolt"
+)
+
+// BoltStorage is a Storage kept in a bbolt database file.
+type BoltStorage struct {
+	db *bbolt.DB
+}
+
+// OpenBoltStorage opens or creates a bbolt database file.
+func OpenBoltStorage(path string, opts Options) (*BoltStorage, error) {
+	mode := opts.FileMode
+	if mode == 0 {
+		mode = 0600
+	}
+	db, err := bbolt.Open(path, mode, &bbolt.Options{
+		Timeout:  opts.Timeout,
+		ReadOnly: opts.ReadOnly,
+		NoSync:   opts.NoSync,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &BoltStorage{db: db}, nil
+}
+
+// Bolt returns the underlying bbolt database.
+func (s *BoltStorage) Bolt() *bbolt.DB {
+	return s.db
+}
+
+func (s *BoltStorage) Begin(writable bool) (StorageTx, error) {
+	tx, err := s.db.Begin(writable)
+	if err != nil {
+		return nil, err
+	}
+	return &boltTx{tx: tx}, nil
+}
+
+func (s *BoltStorage) Close() error {
+	return s.db.Close()
+}
+
+type boltTx struct {
+	tx *bbolt.Tx
+}
+
+func (t *boltTx) CreateBucketIfNotExists(bucket []byte) error {
+	_, err := t.tx.CreateBucketIfNotExists(bucket)
+	return err
+}
+
+func (t *boltTx) Get(bucket, key []byte) []byte {
+	b := t.tx.Bucket(bucket)
+	if b == nil {
+		return nil
+	}
+	return b.Get(key)
+}
+
+func (t *boltTx) Put(bucket, key, value []byte) error {
+	b := t.tx.Bucket(bucket)
+	if b == nil {
+		return bbolt.ErrBucketNotFound
+	}
+	return b.Put(key, value)
+}
+
+func (t *boltTx) Delete(bucket, key []byte) error {
+	b := t.tx.Bucket(bucket)
+	if b == nil {
+		return nil
+	}
+	return b.Delete(key)
+}
+
+func (t *boltTx) Cursor(bucket, prefix []byte) Cursor {
+	b := t.tx.Bucket(bucket)
+	if b == nil {
+		return emptyCursor{}
+	}
+	return &boltCursor{c: b.Cursor(), prefix: prefix}
+}
+
+// WriteTo writes the database file as seen by the transaction to w.
+func (t *boltTx) WriteTo(w io.Writer) (int64, error) {
+	return t.tx.WriteTo(w)
+}
+
+func (t *boltTx) Writable() bool {
+	return t.tx.Writable()
+}
+
+func (t *boltTx) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *boltTx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+type boltCursor struct {
+	c      *bbolt.Cursor
+	prefix []byte
+}
+
+func (c *boltCursor) match(k, v []byte) ([]byte, []byte) {
+	if k == nil || !bytes.HasPrefix(k, c.prefix) {
+		return nil, nil
+	}
+	return k, v
+}
+
+func (c *boltCursor) First() ([]byte, []byte) {
+	return c.match(c.c.Seek(c.prefix))
+}
+
+func (c *boltCursor) Seek(seek []byte) ([]byte, []byte) {
+	if bytes.Compare(seek, c.prefix) < 0 {
+		seek = c.prefix
+	}
+	return c.match(c.c.Seek(seek))
+}
+
+func (c *boltCursor) Next() ([]byte, []byte) {
+	return c.match(c.c.Next())
+}
+
+type emptyCursor struct{}
+
+func (emptyCursor) First() ([]byte, []byte)      { return nil, nil }
+func (emptyCursor) Seek([]byte) ([]byte, []byte) { return nil, nil }
+func (emptyCursor) Next() ([]byte, []byte)       { return nil, nil }
@@ -0,0 +1,299 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"go.etcd.io/bbolt"
+)
+
+var (
+	errTxClosed       = errors.New("transaction closed")
+	errTxNotWritable  = errors.New("transaction not writable")
+	errBucketNotFound = errors.New("bucket not found")
+	errStorageClosed  = errors.New("storage closed")
+)
+
+// MemoryStorage is a Storage kept entirely in memory. It is mainly
+// intended for tests. Buckets are copy-on-write sorted slices, so read
+// transactions keep a consistent snapshot while a writer commits.
+type MemoryStorage struct {
+	writer sync.Mutex
+	mux    sync.RWMutex
+	root   map[string]memBucket
+	// open counts the open transactions, so that Close can wait for
+	// them as bbolt does. closed is guarded by closeMux.
+	open     sync.WaitGroup
+	closeMux sync.Mutex
+	closed   bool
+}
+
+type memItem struct {
+	key, value []byte
+}
+
+type memBucket []memItem
+
+func (b memBucket) find(key []byte) (int, bool) {
+	idx := sort.Search(len(b), func(i int) bool {
+		return bytes.Compare(b[i].key, key) >= 0
+	})
+	return idx, idx < len(b) && bytes.Equal(b[idx].key, key)
+}
+
+// NewMemoryStorage returns an empty MemoryStorage.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{root: map[string]memBucket{}}
+}
+
+func (s *MemoryStorage) Begin(writable bool) (StorageTx, error) {
+	s.closeMux.Lock()
+	if s.closed {
+		s.closeMux.Unlock()
+		return nil, errStorageClosed
+	}
+	s.open.Add(1)
+	s.closeMux.Unlock()
+	if writable {
+		s.writer.Lock()
+	}
+	s.mux.RLock()
+	root := s.root
+	s.mux.RUnlock()
+	return &memTx{s: s, root: root, writable: writable, dirty: map[string]bool{}}, nil
+}
+
+// Close waits for the open transactions to finish. Transactions can no
+// longer be started once it has been called.
+func (s *MemoryStorage) Close() error {
+	s.closeMux.Lock()
+	s.closed = true
+	s.closeMux.Unlock()
+	s.open.Wait()
+	return nil
+}
+
+type memTx struct {
+	s        *MemoryStorage
+	root     map[string]memBucket
+	writable bool
+	closed   bool
+	// rootCopied is set when root is no longer shared with the storage
+	rootCopied bool
+	// dirty tracks the buckets that have been copied by this transaction
+	dirty map[string]bool
+}
+
+// bucket returns a bucket that may be modified by the transaction.
+func (t *memTx) bucket(name []byte) (memBucket, error) {
+	if t.closed {
+		return nil, errTxClosed
+	}
+	if !t.writable {
+		return nil, errTxNotWritable
+	}
+	b, ok := t.root[string(name)]
+	if !ok {
+		return nil, errBucketNotFound
+	}
+	if !t.dirty[string(name)] {
+		t.cloneRoot()
+		b = append(memBucket(nil), b...)
+		t.root[string(name)] = b
+		t.dirty[string(name)] = true
+	}
+	return b, nil
+}
+
+// cloneRoot makes sure the transaction has its own copy of the bucket map.
+func (t *memTx) cloneRoot() {
+	if t.rootCopied {
+		return
+	}
+	root := make(map[string]memBucket, len(t.root))
+	for k, v := range t.root {
+		root[k] = v
+	}
+	t.root = root
+	t.rootCopied = true
+}
+
+func (t *memTx) CreateBucketIfNotExists(bucket []byte) error {
+	if t.closed {
+		return errTxClosed
+	}
+	if !t.writable {
+		return errTxNotWritable
+	}
+	if _, ok := t.root[string(bucket)]; ok {
+		return nil
+	}
+	t.cloneRoot()
+	t.root[string(bucket)] = memBucket{}
+	t.dirty[string(bucket)] = true
+	return nil
+}
+
+func (t *memTx) Get(bucket, key []byte) []byte {
+	b := t.root[string(bucket)]
+	if idx, ok := b.find(key); ok {
+		return b[idx].value
+	}
+	return nil
+}
+
+func (t *memTx) Put(bucket, key, value []byte) error {
+	b, err := t.bucket(bucket)
+	if err != nil {
+		return err
+	}
+	item := memItem{key: bytes.Clone(key), value: bytes.Clone(value)}
+	if item.value == nil {
+		item.value = []byte{}
+	}
+	idx, ok := b.find(key)
+	if ok {
+		b[idx] = item
+	} else {
+		b = append(b, memItem{})
+		copy(b[idx+1:], b[idx:])
+		b[idx] = item
+	}
+	t.root[string(bucket)] = b
+	return nil
+}
+
+func (t *memTx) Delete(bucket, key []byte) error {
+	b, err := t.bucket(bucket)
+	if err != nil {
+		return err
+	}
+	if idx, ok := b.find(key); ok {
+		b = append(b[:idx], b[idx+1:]...)
+		t.root[string(bucket)] = b
+	}
+	return nil
+}
+
+func (t *memTx) Cursor(bucket, prefix []byte) Cursor {
+	return &memCursor{b: t.root[string(bucket)], prefix: prefix}
+}
+
+// WriteTo writes the buckets seen by the transaction to w as a bbolt
+// database file. The file is built in a temporary directory.
+func (t *memTx) WriteTo(w io.Writer) (int64, error) {
+	dir, err := os.MkdirTemp("", "shdb")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(dir)
+	db, err := bbolt.Open(dir+"/snapshot.db", 0600, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for name, items := range t.root {
+			b, err := tx.CreateBucket([]byte(name))
+			if err != nil {
+				return err
+			}
+			for _, item := range items {
+				if err := b.Put(item.key, item.value); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	err = db.View(func(tx *bbolt.Tx) error {
+		n, err = tx.WriteTo(w)
+		return err
+	})
+	return n, err
+}
+
+func (t *memTx) Writable() bool {
+	return t.writable
+}
+
+func (t *memTx) Commit() error {
+	if t.closed {
+		return errTxClosed
+	}
+	if !t.writable {
+		return errTxNotWritable
+	}
+	t.closed = true
+	t.s.mux.Lock()
+	t.s.root = t.root
+	t.s.mux.Unlock()
+	t.s.writer.Unlock()
+	t.s.open.Done()
+	return nil
+}
+
+func (t *memTx) Rollback() error {
+	if t.closed {
+		return errTxClosed
+	}
+	t.closed = true
+	if t.writable {
+		t.s.writer.Unlock()
+	}
+	t.s.open.Done()
+	return nil
+}
+
+type memCursor struct {
+	b      memBucket
+	prefix []byte
+	pos    int
+}
+
+func (c *memCursor) at() ([]byte, []byte) {
+	if c.pos >= len(c.b) || !bytes.HasPrefix(c.b[c.pos].key, c.prefix) {
+		return nil, nil
+	}
+	return c.b[c.pos].key, c.b[c.pos].value
+}
+
+func (c *memCursor) First() ([]byte, []byte) {
+	c.pos, _ = c.b.find(c.prefix)
+	return c.at()
+}
+
+func (c *memCursor) Seek(seek []byte) ([]byte, []byte) {
+	if bytes.Compare(seek, c.prefix) < 0 {
+		seek = c.prefix
+	}
+	c.pos, _ = c.b.find(seek)
+	return c.at()
+}
+
+func (c *memCursor) Next() ([]byte, []byte) {
+	c.pos++
+	return c.at()
+}
@@ -0,0 +1,159 @@
+// Copyright 2023 Shenry Tech AB
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shdb
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+var bucket_test = []byte("test")
+
+func testStorage(t *testing.T, st Storage) {
+	err := update(st, func(tx StorageTx) error {
+		if err := tx.CreateBucketIfNotExists(bucket_test); err != nil {
+			return err
+		}
+		for _, k := range []string{"a1", "a2", "a3", "b1", "b2"} {
+			if err := tx.Put(bucket_test, []byte(k), []byte("v"+k)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.FailNow()
+	}
+
+	// Prefix cursor
+	err = view(st, func(tx StorageTx) error {
+		if !bytes.Equal(tx.Get(bucket_test, []byte("a2")), []byte("va2")) {
+			t.Fail()
+		}
+		if tx.Get(bucket_test, []byte("c1")) != nil {
+			t.Fail()
+		}
+		keys := []string{}
+		c := tx.Cursor(bucket_test, []byte("a"))
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			keys = append(keys, string(k))
+		}
+		if len(keys) != 3 || keys[0] != "a1" || keys[2] != "a3" {
+			t.Fail()
+		}
+		if k, _ := c.Seek([]byte("a2")); string(k) != "a2" {
+			t.Fail()
+		}
+		if k, _ := c.Seek([]byte("a4")); k != nil {
+			t.Fail()
+		}
+		if k, _ := tx.Cursor([]byte("missing"), nil).First(); k != nil {
+			t.Fail()
+		}
+		return nil
+	})
+	if err != nil {
+		t.FailNow()
+	}
+
+	err = update(st, func(tx StorageTx) error {
+		return tx.Delete(bucket_test, []byte("a1"))
+	})
+	if err != nil {
+		t.FailNow()
+	}
+
+	// Rolled back writes are discarded
+	errAbort := errors.New("abort")
+	err = update(st, func(tx StorageTx) error {
+		if err := tx.Put(bucket_test, []byte("c1"), []byte("vc1")); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fail()
+	}
+	view(st, func(tx StorageTx) error {
+		if tx.Get(bucket_test, []byte("a1")) != nil {
+			t.Fail()
+		}
+		if tx.Get(bucket_test, []byte("c1")) != nil {
+			t.Fail()
+		}
+		return nil
+	})
+}
+
+func TestMemoryStorage(t *testing.T) {
+	st := NewMemoryStorage()
+	defer st.Close()
+	testStorage(t, st)
+
+	// A read transaction keeps its snapshot while a writer commits.
+	// Not tested for bbolt where a writer may wait for readers to remap.
+	rtx, err := st.Begin(false)
+	if err != nil {
+		t.FailNow()
+	}
+	err = update(st, func(tx StorageTx) error {
+		return tx.Delete(bucket_test, []byte("a2"))
+	})
+	if err != nil {
+		t.FailNow()
+	}
+	if rtx.Get(bucket_test, []byte("a2")) == nil {
+		t.Fail()
+	}
+
+	// Close waits for open transactions
+	closed := make(chan struct{})
+	go func() {
+		st.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fail()
+	case <-time.After(50 * time.Millisecond):
+	}
+	// A transaction begun while Close waits fails instead of blocking
+	if _, err := st.Begin(false); err == nil {
+		t.Fail()
+	}
+	rtx.Rollback()
+	<-closed
+	if _, err := st.Begin(false); err == nil {
+		t.Fail()
+	}
+}
+
+func TestBoltStorage(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "shdb_test")
+	if err != nil {
+		t.FailNow()
+	}
+	defer os.RemoveAll(tmpDir)
+	st, err := OpenBoltStorage(path.Join(tmpDir, "test.db"), Options{})
+	if err != nil {
+		t.FailNow()
+	}
+	defer st.Close()
+	testStorage(t, st)
+}
@@ -125,6 +125,86 @@ func (x *TObject) GetAny() *anypb.Any {
 	return nil
 }
 
+// TNode references other objects.
+type TNode struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Metadata *Metadata `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Name     string    `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Parent   *ObjRef   `protobuf:"bytes,3,opt,name=parent,proto3" json:"parent,omitempty"`
+	Links    []*ObjRef `protobuf:"bytes,4,rep,name=links,proto3" json:"links,omitempty"`
+	Owner    *ObjRef   `protobuf:"bytes,5,opt,name=owner,proto3" json:"owner,omitempty"`
+}
+
+func (x *TNode) Reset() {
+	*x = TNode{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_shdb_v1_test_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TNode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TNode) ProtoMessage() {}
+
+func (x *TNode) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_shdb_v1_test_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TNode.ProtoReflect.Descriptor instead.
+func (*TNode) Descriptor() ([]byte, []int) {
+	return file_pb_shdb_v1_test_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TNode) GetMetadata() *Metadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *TNode) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *TNode) GetParent() *ObjRef {
+	if x != nil {
+		return x.Parent
+	}
+	return nil
+}
+
+func (x *TNode) GetLinks() []*ObjRef {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+func (x *TNode) GetOwner() *ObjRef {
+	if x != nil {
+		return x.Owner
+	}
+	return nil
+}
+
 var File_pb_shdb_v1_test_proto protoreflect.FileDescriptor
 
 var file_pb_shdb_v1_test_proto_rawDesc = []byte{
@@ -135,7 +215,7 @@ var file_pb_shdb_v1_test_proto_rawDesc = []byte{
 	0x6f, 0x1a, 0x19, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
 	0x75, 0x66, 0x2f, 0x61, 0x6e, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x15, 0x70, 0x62,
 	0x2f, 0x73, 0x68, 0x64, 0x62, 0x2f, 0x76, 0x31, 0x2f, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x22, 0xcd, 0x02, 0x0a, 0x07, 0x54, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x12,
+	0x6f, 0x74, 0x6f, 0x22, 0xf1, 0x02, 0x0a, 0x07, 0x54, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x12,
 	0x2d, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28,
 	0x0b, 0x32, 0x11, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x65, 0x74, 0x61,
 	0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x15,
@@ -148,17 +228,35 @@ var file_pb_shdb_v1_test_proto_rawDesc = []byte{
 	0x70, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x26, 0x0a, 0x03,
 	0x61, 0x6e, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
 	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x41, 0x6e, 0x79, 0x52,
-	0x03, 0x61, 0x6e, 0x79, 0x3a, 0x7d, 0x82, 0xb2, 0x19, 0x79, 0x0a, 0x0f, 0x73, 0x68, 0x64, 0x62,
-	0x2e, 0x76, 0x31, 0x2e, 0x54, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x12, 0x04, 0x74, 0x6f, 0x62,
-	0x6a, 0x12, 0x05, 0x6e, 0x69, 0x73, 0x73, 0x65, 0x22, 0x1d, 0x0a, 0x05, 0x62, 0x72, 0x69, 0x65,
-	0x66, 0x12, 0x14, 0x54, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x3a, 0x20, 0x7b, 0x7b, 0x2e, 0x6d,
-	0x79, 0x5f, 0x69, 0x6e, 0x74, 0x7d, 0x7d, 0x22, 0x3a, 0x0a, 0x08, 0x64, 0x65, 0x74, 0x61, 0x69,
-	0x6c, 0x65, 0x64, 0x12, 0x2e, 0x54, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x20, 0x7b, 0x7b, 0x2e,
-	0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x75, 0x75, 0x69, 0x64, 0x7d, 0x7d, 0x20,
-	0x6d, 0x79, 0x5f, 0x69, 0x6e, 0x74, 0x3a, 0x20, 0x7b, 0x7b, 0x2e, 0x6d, 0x79, 0x5f, 0x69, 0x6e,
-	0x74, 0x7d, 0x7d, 0x42, 0x1c, 0x5a, 0x1a, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
-	0x6d, 0x2f, 0x73, 0x68, 0x65, 0x6e, 0x72, 0x79, 0x74, 0x65, 0x63, 0x68, 0x2f, 0x73, 0x68, 0x64,
-	0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x03, 0x61, 0x6e, 0x79, 0x3a, 0xa0, 0x01, 0x82, 0xb2, 0x19, 0x9b, 0x01, 0x0a, 0x0f, 0x73, 0x68,
+	0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x12, 0x04, 0x74,
+	0x6f, 0x62, 0x6a, 0x12, 0x05, 0x6e, 0x69, 0x73, 0x73, 0x65, 0x22, 0x1d, 0x0a, 0x05, 0x62, 0x72,
+	0x69, 0x65, 0x66, 0x12, 0x14, 0x54, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x3a, 0x20, 0x7b, 0x7b,
+	0x2e, 0x6d, 0x79, 0x5f, 0x69, 0x6e, 0x74, 0x7d, 0x7d, 0x22, 0x3a, 0x0a, 0x08, 0x64, 0x65, 0x74,
+	0x61, 0x69, 0x6c, 0x65, 0x64, 0x12, 0x2e, 0x54, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x20, 0x7b,
+	0x7b, 0x2e, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x75, 0x75, 0x69, 0x64, 0x7d,
+	0x7d, 0x20, 0x6d, 0x79, 0x5f, 0x69, 0x6e, 0x74, 0x3a, 0x20, 0x7b, 0x7b, 0x2e, 0x6d, 0x79, 0x5f,
+	0x69, 0x6e, 0x74, 0x7d, 0x7d, 0x2a, 0x06, 0x6d, 0x79, 0x5f, 0x69, 0x6e, 0x74, 0x2a, 0x09, 0x6d,
+	0x79, 0x5f, 0x73, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x2a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x32, 0x02, 0x08, 0x0a, 0x22, 0xfd, 0x01, 0x0a, 0x05, 0x54, 0x4e, 0x6f, 0x64,
+	0x65, 0x12, 0x2d, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x65,
+	0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
+	0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x12, 0x31, 0x0a, 0x06, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x4f,
+	0x62, 0x6a, 0x52, 0x65, 0x66, 0x42, 0x08, 0x82, 0xb2, 0x19, 0x04, 0x0a, 0x02, 0x08, 0x02, 0x52,
+	0x06, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x12, 0x2f, 0x0a, 0x05, 0x6c, 0x69, 0x6e, 0x6b, 0x73,
+	0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31,
+	0x2e, 0x4f, 0x62, 0x6a, 0x52, 0x65, 0x66, 0x42, 0x08, 0x82, 0xb2, 0x19, 0x04, 0x0a, 0x02, 0x08,
+	0x03, 0x52, 0x05, 0x6c, 0x69, 0x6e, 0x6b, 0x73, 0x12, 0x31, 0x0a, 0x05, 0x6f, 0x77, 0x6e, 0x65,
+	0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76,
+	0x31, 0x2e, 0x4f, 0x62, 0x6a, 0x52, 0x65, 0x66, 0x42, 0x0a, 0x82, 0xb2, 0x19, 0x06, 0x0a, 0x04,
+	0x08, 0x01, 0x10, 0x01, 0x52, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x3a, 0x1a, 0x82, 0xb2, 0x19,
+	0x16, 0x0a, 0x0d, 0x73, 0x68, 0x64, 0x62, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x4e, 0x6f, 0x64, 0x65,
+	0x12, 0x05, 0x74, 0x6e, 0x6f, 0x64, 0x65, 0x42, 0x1c, 0x5a, 0x1a, 0x67, 0x69, 0x74, 0x68, 0x75,
+	0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x73, 0x68, 0x65, 0x6e, 0x72, 0x79, 0x74, 0x65, 0x63, 0x68,
+	0x2f, 0x73, 0x68, 0x64, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -173,22 +271,28 @@ func file_pb_shdb_v1_test_proto_rawDescGZIP() []byte {
 	return file_pb_shdb_v1_test_proto_rawDescData
 }
 
-var file_pb_shdb_v1_test_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_pb_shdb_v1_test_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
 var file_pb_shdb_v1_test_proto_goTypes = []interface{}{
 	(*TObject)(nil),               // 0: shdb.v1.TObject
-	(*Metadata)(nil),              // 1: shdb.v1.Metadata
-	(*timestamppb.Timestamp)(nil), // 2: google.protobuf.Timestamp
-	(*anypb.Any)(nil),             // 3: google.protobuf.Any
+	(*TNode)(nil),                 // 1: shdb.v1.TNode
+	(*Metadata)(nil),              // 2: shdb.v1.Metadata
+	(*timestamppb.Timestamp)(nil), // 3: google.protobuf.Timestamp
+	(*anypb.Any)(nil),             // 4: google.protobuf.Any
+	(*ObjRef)(nil),                // 5: shdb.v1.ObjRef
 }
 var file_pb_shdb_v1_test_proto_depIdxs = []int32{
-	1, // 0: shdb.v1.TObject.metadata:type_name -> shdb.v1.Metadata
-	2, // 1: shdb.v1.TObject.timestamp:type_name -> google.protobuf.Timestamp
-	3, // 2: shdb.v1.TObject.any:type_name -> google.protobuf.Any
-	3, // [3:3] is the sub-list for method output_type
-	3, // [3:3] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	2, // 0: shdb.v1.TObject.metadata:type_name -> shdb.v1.Metadata
+	3, // 1: shdb.v1.TObject.timestamp:type_name -> google.protobuf.Timestamp
+	4, // 2: shdb.v1.TObject.any:type_name -> google.protobuf.Any
+	2, // 3: shdb.v1.TNode.metadata:type_name -> shdb.v1.Metadata
+	5, // 4: shdb.v1.TNode.parent:type_name -> shdb.v1.ObjRef
+	5, // 5: shdb.v1.TNode.links:type_name -> shdb.v1.ObjRef
+	5, // 6: shdb.v1.TNode.owner:type_name -> shdb.v1.ObjRef
+	7, // [7:7] is the sub-list for method output_type
+	7, // [7:7] is the sub-list for method input_type
+	7, // [7:7] is the sub-list for extension type_name
+	7, // [7:7] is the sub-list for extension extendee
+	0, // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_pb_shdb_v1_test_proto_init() }
@@ -210,6 +314,18 @@ func file_pb_shdb_v1_test_proto_init() {
 				return nil
 			}
 		}
+		file_pb_shdb_v1_test_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TNode); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -217,7 +333,7 @@ func file_pb_shdb_v1_test_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_pb_shdb_v1_test_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   1,
+			NumMessages:   2,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -44,29 +44,68 @@ type MessageInfo struct {
 	TypeKey        TypeKey
 	MessageType    protoreflect.MessageType
 	IsDynamic      bool
+	// Indexes are the field paths of the secondary indexes of the type.
+	Indexes []string
+	// History is set if previous revisions of the objects are kept.
+	History *Shdb_History_Options
+	// Retention is set if the objects are deleted by age or count.
+	Retention *Shdb_Retention_Options
+	// SoftDelete is set if deleted objects are kept as tombstones.
+	SoftDelete *Shdb_Soft_Delete_Options
+	// Refs are the ObjRef fields of the type.
+	Refs []RefField
+	// Version is the version of the schema of the type.
+	Version uint32
 }
 
 type TypeRegistry struct {
 	fromFullname map[string]*MessageInfo
 	fromTypeKey  map[TypeKey]*MessageInfo
+	// migrations by type fullname, ordered by version
+	migrations map[string][]Migration
+	// assigned are the TypeKeys persisted in a database by fullname
+	assigned map[string]TypeKey
+	// renamed are the new fullnames of renamed types by old fullname
+	renamed map[string]string
 
 	types *protoregistry.Types
 	files *protoregistry.Files
 
-	mux *sync.Mutex
+	// mux guards the fields above. The registry is rebuilt when the
+	// schema changes or a type is renamed while the database is in use.
+	mux *sync.RWMutex
 }
 
-func NewTypeRegistry() *TypeRegistry {
+// NewTypeRegistry returns a registry of the types in the global proto
+// registry. Types that cannot be registered, such as types with
+// colliding TypeKeys, are left out and returned as an error with the
+// registry.
+func NewTypeRegistry() (*TypeRegistry, error) {
 
 	r := &TypeRegistry{
-		mux:          new(sync.Mutex),
+		mux:          new(sync.RWMutex),
 		fromFullname: map[string]*MessageInfo{},
 		fromTypeKey:  map[TypeKey]*MessageInfo{},
+		migrations:   map[string][]Migration{},
+		assigned:     map[string]TypeKey{},
+		renamed:      map[string]string{},
 		types:        protoregistry.GlobalTypes,
-		files:        protoregistry.GlobalFiles,
+		files:        cloneFiles(protoregistry.GlobalFiles),
 	}
-	r.refresh()
-	return r
+	return r, r.refresh()
+}
+
+// cloneFiles copies the file descriptors of src into a new registry so that
+// files added to one TypeRegistry are not visible in others.
+func cloneFiles(src *protoregistry.Files) *protoregistry.Files {
+	files := &protoregistry.Files{}
+	src.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		if err := files.RegisterFile(fd); err != nil {
+			log.Printf("error registering proto file %s [%v]", fd.Path(), err)
+		}
+		return true
+	})
+	return files
 }
 
 func (r *TypeRegistry) clear() {
@@ -79,11 +118,16 @@ func (r *TypeRegistry) clear() {
 func (r *TypeRegistry) addMessage(md protoreflect.MessageDescriptor) error {
 	mi := &MessageInfo{
 		Fullname:       string(md.FullName()),
-		TypeKey:        TypeKeyOf(string(md.FullName())),
 		PrintTemplates: map[string]string{},
 		Aliases:        []string{},
 		IsDynamic:      false,
+		Indexes:        []string{},
 	}
+	refs, err := refFields(md)
+	if err != nil {
+		return err
+	}
+	mi.Refs = refs
 
 	// Create a protoreflect.MessageType that can be used to
 	// create instances of the object. If there is a Go type use
@@ -100,6 +144,7 @@ func (r *TypeRegistry) addMessage(md protoreflect.MessageDescriptor) error {
 	}
 	mi.MessageType = mt
 
+	var typeKey []byte
 	if proto.HasExtension(md.Options(), E_ShdbOptions) {
 		ext := proto.GetExtension(md.Options(), E_ShdbOptions).(*Shdb_Message_Options)
 		if ext == nil {
@@ -109,13 +154,79 @@ func (r *TypeRegistry) addMessage(md protoreflect.MessageDescriptor) error {
 			mi.PrintTemplates[k] = v
 		}
 		mi.Aliases = append(mi.Aliases, ext.Aliases...)
-		// mi.TypeKey = TypeKey(ext.TypeKey) - TypeKey is now from hashing the fullname
+		for _, path := range ext.Indexes {
+			if _, err := indexFields(md, path); err != nil {
+				return err
+			}
+			mi.Indexes = append(mi.Indexes, path)
+		}
+		mi.History = ext.History
+		mi.Retention = ext.Retention
+		mi.SoftDelete = ext.SoftDelete
+		mi.Version = ext.Version
+		typeKey = ext.TypeKey
+	}
+	for old, name := range r.renamed {
+		if name == mi.Fullname && !containsString(mi.Aliases, old) {
+			mi.Aliases = append(mi.Aliases, old)
+		}
+	}
+	if mi.TypeKey, err = r.typeKeyFor(mi.Fullname, mi.Aliases, typeKey); err != nil {
+		return err
+	}
+	if other, ok := r.fromTypeKey[mi.TypeKey]; ok && other.Fullname != mi.Fullname {
+		return fmt.Errorf("%w: %s and %s have type key %x", ErrTypeKeyCollision, other.Fullname, mi.Fullname, mi.TypeKey)
 	}
 	r.fromFullname[mi.Fullname] = mi
-	r.fromTypeKey[[4]byte(mi.TypeKey)] = mi
+	r.fromTypeKey[mi.TypeKey] = mi
 	return nil
 }
 
+// typeKeyFor returns the TypeKey of a type. It is the key persisted for
+// its fullname, or for one of its aliases if the type has been renamed.
+// New types get the type_key option or the hash of their fullname.
+func (r *TypeRegistry) typeKeyFor(fullname string, aliases []string, option []byte) (TypeKey, error) {
+	if len(option) != 0 && len(option) != 4 {
+		return TypeKey{}, fmt.Errorf("%w: type_key of %s must be 4 bytes", ErrInvalidType, fullname)
+	}
+	if tk, ok := r.assigned[fullname]; ok {
+		if len(option) == 4 && TypeKey(option) != tk {
+			return TypeKey{}, fmt.Errorf("%w: type_key of %s differs from the stored type key %x", ErrInvalidType, fullname, tk)
+		}
+		return tk, nil
+	}
+	for _, alias := range aliases {
+		if tk, ok := r.assigned[alias]; ok {
+			return tk, nil
+		}
+	}
+	tk := TypeKeyOf(fullname)
+	if len(option) == 4 {
+		tk = TypeKey(option)
+	}
+	for name, other := range r.assigned {
+		if other == tk && !containsString(aliases, name) {
+			return TypeKey{}, fmt.Errorf("%w: %s and %s have type key %x", ErrTypeKeyCollision, name, fullname, tk)
+		}
+	}
+	return tk, nil
+}
+
+// useTypeKeys sets the TypeKeys and renamed types persisted in a database
+// and rebuilds the registry with them. A renamed type is left out and its
+// old fullname becomes an alias of the new type.
+func (r *TypeRegistry) useTypeKeys(assigned map[string]TypeKey, renamed map[string]string) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.assigned = assigned
+	if renamed == nil {
+		renamed = map[string]string{}
+	}
+	r.renamed = renamed
+	r.clear()
+	return r.refresh()
+}
+
 func (r *TypeRegistry) addFile(fd protoreflect.FileDescriptor) error {
 	_, err := r.files.FindFileByPath(fd.Path())
 	if err != nil {
@@ -156,9 +267,11 @@ func (r *TypeRegistry) AddFileFromProtoFileDescriptor(fd *descriptorpb.FileDescr
 	return r.refresh()
 }
 
+// refresh adds the types of the files that are not yet registered. The
+// other types are still added when one of them fails.
 func (r *TypeRegistry) refresh() error {
 
-	var err error
+	errs := []error{}
 
 	r.files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
 		for idx := 0; idx < fd.Messages().Len(); idx++ {
@@ -176,31 +289,39 @@ func (r *TypeRegistry) refresh() error {
 			if fmd.FullName() != "shdb.v1.Metadata" {
 				continue
 			}
+			// Leave out types that have been renamed
+			if _, ok := r.renamed[string(md.FullName())]; ok {
+				continue
+			}
 			// Include message if it's not already there
 			if _, ok := r.fromFullname[string(md.FullName())]; !ok {
-				if err = r.addMessage(md); err != nil {
-					return false
+				if err := r.addMessage(md); err != nil {
+					errs = append(errs, err)
 				}
 			}
 		}
 		return true
 	})
-	return err
+	return errors.Join(errs...)
 }
 
 func (r *TypeRegistry) CreateEmptyObject(tk TypeKey) (IObject, error) {
+	r.mux.RLock()
 	mi, ok := r.fromTypeKey[tk]
+	r.mux.RUnlock()
 	if !ok {
 		return nil, ErrNotFound
 
 	}
-	var obj IObject
+	return mi.newObject(), nil
+}
+
+// newObject returns a new empty object of the type.
+func (mi *MessageInfo) newObject() IObject {
 	if mi.IsDynamic {
-		obj = &DynObject{Message: mi.MessageType.New().Interface().(*dynamicpb.Message)}
-	} else {
-		obj = mi.MessageType.New().Interface().(IObject)
+		return &DynObject{Message: mi.MessageType.New().Interface().(*dynamicpb.Message)}
 	}
-	return obj, nil
+	return mi.MessageType.New().Interface().(IObject)
 }
 
 func (r *TypeRegistry) CreateObject(spec interface{}) (IObject, error) {
@@ -209,8 +330,8 @@ func (r *TypeRegistry) CreateObject(spec interface{}) (IObject, error) {
 		ok bool
 	)
 
-	r.mux.Lock()
-	defer r.mux.Unlock()
+	r.mux.RLock()
+	defer r.mux.RUnlock()
 
 	switch s := spec.(type) {
 	case string:
@@ -253,62 +374,84 @@ type DynObject struct {
 	*dynamicpb.Message
 }
 
+// cloneObject returns a deep copy of obj. Unlike proto.Clone it keeps
+// dynamic objects wrapped in a DynObject.
+func cloneObject(obj IObject) IObject {
+	if d, ok := obj.(*DynObject); ok {
+		return &DynObject{Message: proto.Clone(d.Message).(*dynamicpb.Message)}
+	}
+	return proto.Clone(obj).(IObject)
+}
+
+// GetMetadata returns a copy of the metadata of a dynamic object.
+// Use SetMetadata to store changes.
 func (o *DynObject) GetMetadata() *Metadata {
-	m := &Metadata{}
 	fd := o.Descriptor().Fields().ByName("metadata")
-	metaMd := fd.Message()
-	if metaMd == nil {
+	if fd == nil || fd.Message() == nil {
 		return nil
 	}
-	metaVal := o.Get(fd).Message()
-	for i := 0; i < metaMd.Fields().Len(); i++ {
-		switch fd := metaMd.Fields().Get(i); fd.Number() {
-		case 1: // "type"
-			m.Type = metaVal.Get(fd).Bytes()
-		case 2: // "uuid"
-			m.Uuid = metaVal.Get(fd).Bytes()
-		case 3: // "labels"
-			m.Labels = []string{}
-			list := metaVal.Get(fd).List()
-			for j := 0; j < list.Len(); j++ {
-				m.Labels = append(m.Labels, list.Get(j).String())
-			}
-		case 4: // "description"
-			m.Description = metaVal.Get(fd).String()
-		case 5: // "created_at"
-			ts := &timestamppb.Timestamp{}
-			tsMsg := metaVal.Get(fd).Message()
-			secFd := tsMsg.Descriptor().Fields().ByNumber(1)
-			nanosFd := tsMsg.Descriptor().Fields().ByNumber(2)
-			ts.Seconds = tsMsg.Get(secFd).Int()
-			ts.Nanos = int32(tsMsg.Get(nanosFd).Int())
-			m.CreatedAt = ts
-		case 6: // "updated_at"
-			ts := &timestamppb.Timestamp{}
-			tsMsg := metaVal.Get(fd).Message()
-			secFd := tsMsg.Descriptor().Fields().ByNumber(1)
-			nanosFd := tsMsg.Descriptor().Fields().ByNumber(2)
-			ts.Seconds = tsMsg.Get(secFd).Int()
-			ts.Nanos = int32(tsMsg.Get(nanosFd).Int())
-			m.CreatedAt = ts
-		default:
-			log.Printf("unknown field in Metadata: %s", fd.Name())
-		}
+	data, err := proto.Marshal(o.Get(fd).Message().Interface())
+	if err != nil {
+		log.Printf("failed to marshal Metadata: %v", err)
+		return nil
+	}
+	m := &Metadata{}
+	if err := proto.Unmarshal(data, m); err != nil {
+		log.Printf("failed to unmarshal Metadata: %v", err)
+		return nil
+	}
+	if m.Labels == nil {
+		m.Labels = []string{}
 	}
-
 	return m
 }
 
-func (r *TypeRegistry) StoreSchema() error {
-	r.mux.Lock()
-	defer r.mux.Unlock()
-	return StoreSchema(*r.files)
+// SetMetadata replaces the metadata of a dynamic object.
+func (o *DynObject) SetMetadata(m *Metadata) error {
+	fd := o.Descriptor().Fields().ByName("metadata")
+	if fd == nil || fd.Message() == nil {
+		return ErrNotAnObject
+	}
+	data, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	val := o.NewField(fd)
+	if err := proto.Unmarshal(data, val.Message().Interface()); err != nil {
+		return err
+	}
+	o.Set(fd, val)
+	return nil
 }
 
-func (r *TypeRegistry) LoadSchema() (err error) {
+// setMetadata stores changes made to the metadata returned by
+// obj.GetMetadata(). Only dynamic objects return a copy that needs
+// to be stored back.
+func setMetadata(obj IObject, m *Metadata) error {
+	if d, ok := obj.(*DynObject); ok {
+		return d.SetMetadata(m)
+	}
+	return nil
+}
+
+// StoreSchema stores the files known to the registry in db.
+func (r *TypeRegistry) StoreSchema(db *DB) error {
+	return db.StoreSchema(r.copyFiles())
+}
+
+// copyFiles returns a copy of the files known to the registry.
+func (r *TypeRegistry) copyFiles() *protoregistry.Files {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	return cloneFiles(r.files)
+}
+
+// LoadSchema replaces the files known to the registry with the
+// schema stored in db.
+func (r *TypeRegistry) LoadSchema(db *DB) (err error) {
 	r.mux.Lock()
 	defer r.mux.Unlock()
-	fds, err := LoadSchema()
+	fds, err := db.LoadSchema()
 	if err != nil {
 		return err
 	}
@@ -322,6 +465,8 @@ func (r *TypeRegistry) LoadSchema() (err error) {
 }
 
 func (r *TypeRegistry) GetFileDescriptorSet() *descriptorpb.FileDescriptorSet {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
 	fileSet := &descriptorpb.FileDescriptorSet{}
 	r.files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
 		fileSet.File = append(fileSet.File, protodesc.ToFileDescriptorProto(fd))
@@ -331,6 +476,8 @@ func (r *TypeRegistry) GetFileDescriptorSet() *descriptorpb.FileDescriptorSet {
 }
 
 func (r *TypeRegistry) GetTypeNames() map[string][]string {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
 	res := map[string][]string{}
 	for _, v := range r.fromTypeKey {
 		res[v.Fullname] = v.Aliases
@@ -338,15 +485,32 @@ func (r *TypeRegistry) GetTypeNames() map[string][]string {
 	return res
 }
 
-func (r *TypeRegistry) UseFileDescriptorSet(fds *descriptorpb.FileDescriptorSet) (err error) {
-	r.files = nil
-	r.files, err = protodesc.NewFiles(fds)
+// TypeKeys returns the TypeKeys of all types by fullname.
+func (r *TypeRegistry) TypeKeys() map[string]TypeKey {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	res := map[string]TypeKey{}
+	for _, v := range r.fromTypeKey {
+		res[v.Fullname] = v.TypeKey
+	}
+	return res
+}
+
+func (r *TypeRegistry) UseFileDescriptorSet(fds *descriptorpb.FileDescriptorSet) error {
+	files, err := protodesc.NewFiles(fds)
+	if err != nil {
+		return err
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.files = files
 	r.clear()
-	r.refresh()
-	return
+	return r.refresh()
 }
 
 func (r *TypeRegistry) GetTypeKeyFromToA(toa string) (TypeKey, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
 	for k, v := range r.fromTypeKey {
 		if v.Fullname == toa {
 			return k, nil
@@ -360,7 +524,18 @@ func (r *TypeRegistry) GetTypeKeyFromToA(toa string) (TypeKey, error) {
 	return TypeKey{}, ErrNotFound
 }
 
+// Unmarshal returns the object stored under key. Objects written with
+// an older version of their type are migrated.
 func (r *TypeRegistry) Unmarshal(key []byte, value []byte) (IObject, error) {
+	obj, err := r.decode(key, value)
+	if err != nil {
+		return nil, err
+	}
+	return obj, r.migrate(obj)
+}
+
+// decode is like Unmarshal without migrating the object.
+func (r *TypeRegistry) decode(key []byte, value []byte) (IObject, error) {
 	obj, err := r.CreateEmptyObject(TypeKey(key))
 	if err != nil {
 		return nil, err
@@ -371,7 +546,31 @@ func (r *TypeRegistry) Unmarshal(key []byte, value []byte) (IObject, error) {
 	return obj, err
 }
 
+// messageInfos returns the MessageInfo of all types.
+func (r *TypeRegistry) messageInfos() []MessageInfo {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	res := []MessageInfo{}
+	for _, mi := range r.fromTypeKey {
+		res = append(res, *mi)
+	}
+	return res
+}
+
+// messageInfo returns the MessageInfo of the type with fullname.
+func (r *TypeRegistry) messageInfo(fullname string) (MessageInfo, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	mi, ok := r.fromFullname[fullname]
+	if !ok {
+		return MessageInfo{}, ErrNotFound
+	}
+	return *mi, nil
+}
+
 func (r *TypeRegistry) GetMessageInfo(tk TypeKey) (MessageInfo, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
 	mi, ok := r.fromTypeKey[tk]
 	if !ok {
 		return MessageInfo{}, ErrNotFound
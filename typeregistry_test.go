@@ -15,6 +15,7 @@
 package shdb
 
 import (
+	"errors"
 	"log"
 	"testing"
 
@@ -29,6 +30,7 @@ func CreateTestFileDescriptor() *descriptorpb.FileDescriptorProto {
 	b := proto.Clone(a).(*descriptorpb.FileDescriptorProto)
 
 	b.Package = sp("shdb.test.volatile")
+	b.MessageType = b.MessageType[:1]
 	b.MessageType[0].Name = sp("TObj2")
 	b.Name = sp("shdb/test/shdb_test_volatile.proto")
 	return b
@@ -36,8 +38,8 @@ func CreateTestFileDescriptor() *descriptorpb.FileDescriptorProto {
 
 func TestTR(t *testing.T) {
 	TObject := TypeKeyOf("shdb.v1.TObject")
-	r := NewTypeRegistry()
-	if err := r.refresh(); err != nil {
+	r, err := NewTypeRegistry()
+	if err != nil {
 		t.FailNow()
 	}
 
@@ -56,8 +58,8 @@ func TestTR(t *testing.T) {
 
 func TestTRDyn(t *testing.T) {
 	TObj2 := TypeKeyOf("shdb.test.volatile.TObj2")
-	r := NewTypeRegistry()
-	if err := r.refresh(); err != nil {
+	r, err := NewTypeRegistry()
+	if err != nil {
 		t.FailNow()
 	}
 
@@ -92,8 +94,8 @@ func TestTRDyn(t *testing.T) {
 }
 
 func TestGetNames(t *testing.T) {
-	r := NewTypeRegistry()
-	if err := r.refresh(); err != nil {
+	r, err := NewTypeRegistry()
+	if err != nil {
 		t.FailNow()
 	}
 
@@ -103,7 +105,7 @@ func TestGetNames(t *testing.T) {
 		t.FailNow()
 	}
 	nameAliases := r.GetTypeNames()
-	if len(nameAliases) != 3 {
+	if len(nameAliases) != 4 {
 		t.FailNow()
 	}
 	if aliases1, ok := nameAliases["shdb.v1.TObject"]; !ok {
@@ -122,3 +124,135 @@ func TestGetNames(t *testing.T) {
 
 	}
 }
+
+// testFileWithTypes returns the synthetic file with a copy of TObj2 for
+// each of the options.
+func testFileWithTypes(names []string, opts []*Shdb_Message_Options) *descriptorpb.FileDescriptorProto {
+	fd := CreateTestFileDescriptor()
+	msg := fd.MessageType[0]
+	fd.MessageType = nil
+	for i, name := range names {
+		m := proto.Clone(msg).(*descriptorpb.DescriptorProto)
+		m.Name = proto.String(name)
+		m.Options = &descriptorpb.MessageOptions{}
+		proto.SetExtension(m.Options, E_ShdbOptions, opts[i])
+		fd.MessageType = append(fd.MessageType, m)
+	}
+	return fd
+}
+
+func TestTypeKeyOption(t *testing.T) {
+	r, err := NewTypeRegistry()
+	if err != nil {
+		t.FailNow()
+	}
+	fd := testFileWithTypes([]string{"A", "B"}, []*Shdb_Message_Options{
+		{TypeKey: []byte{1, 2, 3, 4}},
+		{},
+	})
+	if err := r.AddFileFromProtoFileDescriptor(fd); err != nil {
+		t.FailNow()
+	}
+	if mi, err := r.GetMessageInfo(TypeKey{1, 2, 3, 4}); err != nil || mi.Fullname != "shdb.test.volatile.A" {
+		t.Fail()
+	}
+	if _, err := r.GetMessageInfo(TypeKeyOf("shdb.test.volatile.B")); err != nil {
+		t.Fail()
+	}
+
+	if r, err = NewTypeRegistry(); err != nil {
+		t.FailNow()
+	}
+	fd = testFileWithTypes([]string{"A", "B", "C"}, []*Shdb_Message_Options{
+		{TypeKey: []byte{1, 2, 3, 4}},
+		{TypeKey: []byte{1, 2, 3, 4}},
+		{},
+	})
+	if err := r.AddFileFromProtoFileDescriptor(fd); !errors.Is(err, ErrTypeKeyCollision) {
+		t.Fail()
+	}
+	// The other types are still registered
+	if _, err := r.GetMessageInfo(TypeKeyOf("shdb.test.volatile.C")); err != nil {
+		t.Fail()
+	}
+}
+
+func TestPersistedTypeKeys(t *testing.T) {
+	r, err := NewTypeRegistry()
+	if err != nil {
+		t.FailNow()
+	}
+	err = r.useTypeKeys(map[string]TypeKey{
+		"shdb.test.volatile.Old":  {9, 9, 9, 9},
+		"shdb.test.volatile.Gone": TypeKeyOf("shdb.test.volatile.C"),
+	}, nil)
+	if err != nil {
+		t.FailNow()
+	}
+	// A renamed type keeps the key of its old name
+	fd := testFileWithTypes([]string{"New"}, []*Shdb_Message_Options{
+		{Aliases: []string{"shdb.test.volatile.Old"}},
+	})
+	if err := r.AddFileFromProtoFileDescriptor(fd); err != nil {
+		t.FailNow()
+	}
+	if mi, err := r.GetMessageInfo(TypeKey{9, 9, 9, 9}); err != nil || mi.Fullname != "shdb.test.volatile.New" {
+		t.Fail()
+	}
+
+	// A new type cannot reuse the key of a type that is gone
+	fd = testFileWithTypes([]string{"C"}, []*Shdb_Message_Options{{}})
+	fd.Name = proto.String("shdb/test/shdb_test_volatile_c.proto")
+	if err := r.AddFileFromProtoFileDescriptor(fd); !errors.Is(err, ErrTypeKeyCollision) {
+		t.Fail()
+	}
+}
+
+func TestStoredTypeKeys(t *testing.T) {
+	db := CreateTestDb()
+	defer CloseTestDb(db)
+
+	addTestType(db, &Shdb_Message_Options{TypeKey: []byte{1, 2, 3, 4}})
+	if err := db.TypeRegistry().StoreSchema(db); err != nil {
+		t.FailNow()
+	}
+	assigned, err := db.loadTypeKeys()
+	if err != nil {
+		t.FailNow()
+	}
+	if assigned["shdb.v1.TObject"] != TObj || assigned["shdb.test.volatile.TObj2"] != (TypeKey{1, 2, 3, 4}) {
+		t.Fail()
+	}
+}
+
+func TestRebuildWhileReading(t *testing.T) {
+	r, err := NewTypeRegistry()
+	if err != nil {
+		t.FailNow()
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for k := 0; k < 100; k++ {
+			if err := r.useTypeKeys(map[string]TypeKey{}, nil); err != nil {
+				t.Fail()
+			}
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		if _, err := r.GetMessageInfo(TObj); err != nil {
+			t.FailNow()
+		}
+		if _, err := r.CreateEmptyObject(TObj); err != nil {
+			t.FailNow()
+		}
+		if _, ok := r.TypeKeys()["shdb.v1.TObject"]; !ok {
+			t.FailNow()
+		}
+	}
+}